@@ -0,0 +1,280 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/berkayuckac/tidydata/internal/ui"
+	"github.com/spf13/cobra"
+)
+
+const backupProgressEvery = 1000
+
+var (
+	backupDest      string
+	restoreFile     string
+	restoreClear    bool
+	restoreClearYes bool
+)
+
+var backupCmd = &cobra.Command{
+	Use:   "backup",
+	Short: "Export all documents and images to a backup file",
+	Long: `Export every document and image to --dest/tidydata-backup-YYYY-MM-DD.json
+as newline-delimited JSON. A SHA-256 checksum is written next to it with a
+.sha256 suffix, and restore refuses archives that do not match it.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := os.MkdirAll(backupDest, 0o755); err != nil {
+			return fmt.Errorf("error creating backup directory: %w", err)
+		}
+		path := filepath.Join(backupDest, "tidydata-backup-"+time.Now().Format("2006-01-02")+".json")
+
+		records, err := writeBackup(cmd.Context(), path, os.Stderr)
+		if err != nil {
+			return err
+		}
+
+		return printResult(map[string]any{"path": path, "records": records}, path,
+			fmt.Sprintf("Backed up %d records to %s", records, path))
+	},
+}
+
+var restoreCmd = &cobra.Command{
+	Use:   "restore",
+	Short: "Restore documents and images from a backup file",
+	Long: `Import a file written by tidydata backup after checking it against its
+.sha256 checksum. With --clear-first, all existing documents and images are
+deleted before importing, once every record in the file has been checked.
+If the import fails after that, the existing data has already been deleted
+and the restore should be run again.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := verifyBackup(restoreFile); err != nil {
+			return err
+		}
+
+		if restoreClear && !dryRun &&
+			!ui.Confirm("Delete ALL existing documents and images before restoring?", restoreClearYes) {
+			return fmt.Errorf("aborted")
+		}
+
+		imported, err := restoreBackup(cmd.Context(), restoreFile, restoreClear)
+		if err != nil {
+			return err
+		}
+		if dryRun {
+			return nil
+		}
+
+		return printResult(map[string]any{"path": restoreFile, "imported": imported}, fmt.Sprint(imported),
+			fmt.Sprintf("Restored %d records from %s", imported, restoreFile))
+	},
+}
+
+// writeBackup streams the export to path, reporting progress to log, along
+// with its checksum in path.sha256. Both are written to .tmp files first and
+// only renamed into place once the archive has been re-read successfully, so
+// a failed export leaves an earlier backup of the same day intact.
+func writeBackup(ctx context.Context, path string, log io.Writer) (int, error) {
+	stream, err := mlClient.ExportAll(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("error exporting data: %w", err)
+	}
+	defer stream.Close()
+
+	tmp := path + ".tmp"
+	defer os.Remove(tmp)
+	defer os.Remove(tmp + ".sha256")
+
+	f, err := os.Create(tmp)
+	if err != nil {
+		return 0, fmt.Errorf("error creating backup file: %w", err)
+	}
+	defer f.Close()
+
+	hash := sha256.New()
+	w := bufio.NewWriter(io.MultiWriter(f, hash))
+	records := 0
+	scanner := bufio.NewScanner(stream)
+	scanner.Buffer(make([]byte, 0, 64*1024), 64<<20)
+	for scanner.Scan() {
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+		w.Write(line)
+		w.WriteByte('\n')
+		records++
+		if records%backupProgressEvery == 0 {
+			fmt.Fprintf(log, "Exported %d records...\n", records)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return 0, fmt.Errorf("error reading export: %w", err)
+	}
+	if err := w.Flush(); err != nil {
+		return 0, fmt.Errorf("error writing backup file: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		return 0, fmt.Errorf("error writing backup file: %w", err)
+	}
+
+	sum := hex.EncodeToString(hash.Sum(nil))
+	if err := os.WriteFile(tmp+".sha256", []byte(sum+"  "+filepath.Base(path)+"\n"), 0o644); err != nil {
+		return 0, fmt.Errorf("error writing checksum: %w", err)
+	}
+	if err := verifyBackup(tmp); err != nil {
+		return 0, err
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return 0, fmt.Errorf("error writing backup file: %w", err)
+	}
+	if err := os.Rename(tmp+".sha256", path+".sha256"); err != nil {
+		return 0, fmt.Errorf("error writing checksum: %w", err)
+	}
+	return records, nil
+}
+
+// verifyBackup checks path against the checksum in path.sha256.
+func verifyBackup(path string) error {
+	data, err := os.ReadFile(path + ".sha256")
+	if err != nil {
+		return fmt.Errorf("error reading checksum: %w", err)
+	}
+	fields := strings.Fields(string(data))
+	if len(fields) == 0 {
+		return fmt.Errorf("checksum file %s.sha256 is empty", path)
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("error opening backup file: %w", err)
+	}
+	defer f.Close()
+
+	hash := sha256.New()
+	if _, err := io.Copy(hash, f); err != nil {
+		return fmt.Errorf("error reading backup file: %w", err)
+	}
+	if sum := hex.EncodeToString(hash.Sum(nil)); sum != fields[0] {
+		return fmt.Errorf("checksum mismatch for %s: the backup is corrupted or was modified", path)
+	}
+	return nil
+}
+
+// restoreBackup imports the records in path. When clearFirst is set, the
+// records are checked before existing data is cleared, so a malformed backup
+// does not leave the service empty.
+func restoreBackup(ctx context.Context, path string, clearFirst bool) (int, error) {
+	if clearFirst {
+		if err := validateBackup(path); err != nil {
+			return 0, err
+		}
+	}
+	if dryRun {
+		if clearFirst {
+			printDryRun(http.MethodDelete, "/data")
+		}
+		printDryRun(http.MethodPost, "/import", "file: "+path)
+		return 0, nil
+	}
+
+	if !clearFirst {
+		return importRecords(ctx, path)
+	}
+	if err := mlClient.ClearAll(ctx); err != nil {
+		return 0, fmt.Errorf("error clearing existing data: %w", err)
+	}
+	imported, err := importRecords(ctx, path)
+	if err != nil {
+		return 0, fmt.Errorf("%w (existing data was already deleted by --clear-first; run the restore again)", err)
+	}
+	return imported, nil
+}
+
+// backupRecord holds the fields the ML service's import endpoint requires of
+// every record.
+type backupRecord struct {
+	Type   string          `json:"type"`
+	ID     json.RawMessage `json:"id"`
+	Vector json.RawMessage `json:"vector"`
+}
+
+// validateBackup checks that every line of path is a record the import
+// endpoint accepts, reporting the first that is not by its line number.
+func validateBackup(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("error opening backup file: %w", err)
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), 64<<20)
+	for line := 1; scanner.Scan(); line++ {
+		data := bytes.TrimSpace(scanner.Bytes())
+		if len(data) == 0 {
+			continue
+		}
+		var record backupRecord
+		if err := json.Unmarshal(data, &record); err != nil {
+			return fmt.Errorf("invalid backup record on line %d: %w", line, err)
+		}
+		switch {
+		case record.Type != "document" && record.Type != "image":
+			return fmt.Errorf("invalid backup record on line %d: unknown type %q", line, record.Type)
+		case isNullJSON(record.ID):
+			return fmt.Errorf("invalid backup record on line %d: missing id", line)
+		case isNullJSON(record.Vector):
+			return fmt.Errorf("invalid backup record on line %d: missing vector", line)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("error reading backup file: %w", err)
+	}
+	return nil
+}
+
+func isNullJSON(data json.RawMessage) bool {
+	return len(data) == 0 || string(data) == "null"
+}
+
+// importRecords sends an NDJSON file to the ML service's import endpoint.
+func importRecords(ctx context.Context, path string) (int, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return 0, fmt.Errorf("error opening backup file: %w", err)
+	}
+	defer f.Close()
+
+	resp, err := mlClient.Import(ctx, f)
+	if err != nil {
+		return 0, fmt.Errorf("error importing data: %w", err)
+	}
+	return resp.Imported, nil
+}
+
+func init() {
+	rootCmd.AddCommand(backupCmd)
+	rootCmd.AddCommand(restoreCmd)
+	backupCmd.Flags().StringVar(&backupDest, "dest", ".", "Directory to write the backup to")
+	restoreCmd.Flags().StringVar(&restoreFile, "file", "", "Backup file to restore")
+	restoreCmd.Flags().BoolVar(&restoreClear, "clear-first", false, "Delete all existing documents and images before restoring")
+	restoreCmd.Flags().BoolVarP(&restoreClearYes, "yes", "y", false, "Do not ask before --clear-first deletes data")
+	restoreCmd.Flags().BoolVar(&restoreClearYes, "force", false, "Same as --yes")
+	_ = restoreCmd.MarkFlagRequired("file")
+	_ = backupCmd.MarkFlagDirname("dest")
+	_ = restoreCmd.MarkFlagFilename("file")
+}
@@ -0,0 +1,231 @@
+package main
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fakeStore is an in-memory ML service that supports export, import and clear.
+type fakeStore struct {
+	records [][]byte
+	cleared int
+}
+
+func (s *fakeStore) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch {
+	case r.Method == http.MethodGet && r.URL.Path == "/export":
+		w.Header().Set("Content-Type", "application/x-ndjson")
+		for _, record := range s.records {
+			w.Write(append(record, '\n'))
+		}
+	case r.Method == http.MethodPost && r.URL.Path == "/import":
+		data, _ := io.ReadAll(r.Body)
+		imported := 0
+		for _, line := range bytes.Split(bytes.TrimSpace(data), []byte("\n")) {
+			if !json.Valid(line) {
+				http.Error(w, "invalid record", http.StatusBadRequest)
+				return
+			}
+			s.records = append(s.records, line)
+			imported++
+		}
+		fmt.Fprintf(w, `{"imported": %d}`, imported)
+	case r.Method == http.MethodDelete && r.URL.Path == "/data":
+		s.records = nil
+		s.cleared++
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func TestBackupRestoreRoundTrip(t *testing.T) {
+	original := [][]byte{
+		[]byte(`{"type":"document","id":"doc1","text":"first note","vector":[0.1,0.2]}`),
+		[]byte(`{"type":"image","id":"img1","metadata":{"filename":"cat.jpg"},"image_data":"aGVsbG8=","vector":[0.3]}`),
+	}
+	store := &fakeStore{records: append([][]byte(nil), original...)}
+	useTestServer(t, store)
+
+	dest := t.TempDir()
+	var err error
+	captureOutput(t, func() { err = executeCommand(t, "backup", "--dest", dest) })
+	if err != nil {
+		t.Fatalf("Unexpected backup error: %v", err)
+	}
+
+	path := filepath.Join(dest, "tidydata-backup-"+time.Now().Format("2006-01-02")+".json")
+	if _, err := os.Stat(path + ".sha256"); err != nil {
+		t.Fatalf("Expected checksum file: %v", err)
+	}
+
+	// Leave something in the store that --clear-first must remove.
+	store.records = append(store.records, []byte(`{"type":"document","id":"stale"}`))
+
+	var out string
+	out = captureOutput(t, func() { err = executeCommand(t, "restore", "--file", path, "--clear-first", "--yes") })
+	if err != nil {
+		t.Fatalf("Unexpected restore error: %v", err)
+	}
+	if store.cleared != 1 {
+		t.Errorf("Expected one clear, got %d", store.cleared)
+	}
+	if len(store.records) != len(original) {
+		t.Fatalf("Expected %d records after restore, got %d", len(original), len(store.records))
+	}
+	for i := range original {
+		if !bytes.Equal(store.records[i], original[i]) {
+			t.Errorf("Record %d changed in round trip: %s", i, store.records[i])
+		}
+	}
+	if !strings.Contains(out, "Restored 2 records") {
+		t.Errorf("Expected restore summary, got %q", out)
+	}
+}
+
+func TestRestoreRejectsModifiedBackup(t *testing.T) {
+	store := &fakeStore{records: [][]byte{[]byte(`{"type":"document","id":"doc1","text":"note"}`)}}
+	useTestServer(t, store)
+
+	dest := t.TempDir()
+	var err error
+	captureOutput(t, func() { err = executeCommand(t, "backup", "--dest", dest) })
+	if err != nil {
+		t.Fatalf("Unexpected backup error: %v", err)
+	}
+
+	path := filepath.Join(dest, "tidydata-backup-"+time.Now().Format("2006-01-02")+".json")
+	if err := os.WriteFile(path, []byte(`{"type":"document","id":"doc1","text":"tampered"}`+"\n"), 0o644); err != nil {
+		t.Fatalf("Error writing fixture: %v", err)
+	}
+
+	captureOutput(t, func() { err = executeCommand(t, "restore", "--file", path, "--clear-first", "--yes") })
+	if err == nil || !strings.Contains(err.Error(), "checksum mismatch") {
+		t.Errorf("Expected checksum mismatch error, got %v", err)
+	}
+	if store.cleared != 0 {
+		t.Error("Expected data not to be cleared when verification fails")
+	}
+
+	os.Remove(path + ".sha256")
+	captureOutput(t, func() { err = executeCommand(t, "restore", "--file", path) })
+	if err == nil || !strings.Contains(err.Error(), "error reading checksum") {
+		t.Errorf("Expected missing checksum error, got %v", err)
+	}
+}
+
+func TestBackupFailureKeepsExistingBackup(t *testing.T) {
+	store := &fakeStore{records: [][]byte{[]byte(`{"type":"document","id":"doc1","text":"note","vector":[0.1]}`)}}
+	exports := 0
+	useTestServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		store.ServeHTTP(w, r)
+		if exports++; exports > 1 {
+			// Break off the second export after its first record.
+			w.(http.Flusher).Flush()
+			panic(http.ErrAbortHandler)
+		}
+	}))
+
+	dest := t.TempDir()
+	var err error
+	captureOutput(t, func() { err = executeCommand(t, "backup", "--dest", dest) })
+	if err != nil {
+		t.Fatalf("Unexpected backup error: %v", err)
+	}
+	path := filepath.Join(dest, "tidydata-backup-"+time.Now().Format("2006-01-02")+".json")
+	before, _ := os.ReadFile(path)
+
+	captureOutput(t, func() { err = executeCommand(t, "backup", "--dest", dest) })
+	if err == nil {
+		t.Fatal("Expected the backup to fail")
+	}
+
+	after, _ := os.ReadFile(path)
+	if !bytes.Equal(before, after) {
+		t.Error("Expected the failed backup to leave the existing one unchanged")
+	}
+	if err := verifyBackup(path); err != nil {
+		t.Errorf("Expected the existing backup to still verify, got %v", err)
+	}
+	if entries, _ := os.ReadDir(dest); len(entries) != 2 {
+		t.Errorf("Expected only the backup and its checksum to be left, got %d files", len(entries))
+	}
+}
+
+func TestRestoreClearFirstValidatesRecords(t *testing.T) {
+	tests := []struct {
+		name   string
+		record string
+		errMsg string
+	}{
+		{name: "invalid JSON", record: `{"type":"document"`, errMsg: "line 2"},
+		{name: "unknown type", record: `{"type":"video","id":"v1","vector":[0.1]}`, errMsg: `unknown type "video"`},
+		{name: "missing id", record: `{"type":"document","vector":[0.1]}`, errMsg: "missing id"},
+		{name: "missing vector", record: `{"type":"image","id":"img1"}`, errMsg: "missing vector"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := &fakeStore{records: [][]byte{[]byte(`{"type":"document","id":"keep"}`)}}
+			useTestServer(t, store)
+
+			path := filepath.Join(t.TempDir(), "backup.json")
+			data := `{"type":"document","id":"doc1","vector":[0.1]}` + "\n" + tt.record + "\n"
+			writeBackupFixture(t, path, data)
+
+			var err error
+			captureOutput(t, func() { err = executeCommand(t, "restore", "--file", path, "--clear-first", "--yes") })
+			if err == nil || !strings.Contains(err.Error(), tt.errMsg) {
+				t.Errorf("Expected error containing %q, got %v", tt.errMsg, err)
+			}
+			if store.cleared != 0 || len(store.records) != 1 {
+				t.Error("Expected existing data to be kept when the backup is invalid")
+			}
+		})
+	}
+}
+
+func TestRestoreClearFirstReportsDeletedData(t *testing.T) {
+	store := &fakeStore{records: [][]byte{[]byte(`{"type":"document","id":"old"}`)}}
+	useTestServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/import" {
+			http.Error(w, "storage unavailable", http.StatusInternalServerError)
+			return
+		}
+		store.ServeHTTP(w, r)
+	}))
+
+	path := filepath.Join(t.TempDir(), "backup.json")
+	writeBackupFixture(t, path, `{"type":"document","id":"doc1","vector":[0.1]}`+"\n")
+
+	var err error
+	captureOutput(t, func() { err = executeCommand(t, "restore", "--file", path, "--clear-first", "--yes") })
+	if store.cleared != 1 {
+		t.Fatalf("Expected one clear, got %d", store.cleared)
+	}
+	if err == nil || !strings.Contains(err.Error(), "existing data was already deleted") {
+		t.Errorf("Expected the error to say the data was deleted, got %v", err)
+	}
+}
+
+// writeBackupFixture writes data to path along with a matching checksum.
+func writeBackupFixture(t *testing.T, path, data string) {
+	t.Helper()
+	sum := sha256.Sum256([]byte(data))
+	if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+		t.Fatalf("Error writing fixture: %v", err)
+	}
+	if err := os.WriteFile(path+".sha256", []byte(hex.EncodeToString(sum[:])+"  backup.json\n"), 0o644); err != nil {
+		t.Fatalf("Error writing fixture: %v", err)
+	}
+}
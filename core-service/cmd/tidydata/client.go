@@ -0,0 +1,166 @@
+package main
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/url"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/berkayuckac/tidydata/internal/api"
+)
+
+var (
+	mlServiceURL       = defaultMLServiceURL
+	caCertFile         string
+	insecureSkipVerify bool
+	proxyFlag          string
+	noFollowRedirects  bool
+	mlTimeout          time.Duration
+	mlAPIKey           string
+	logLevel           string
+	logFormat          string
+)
+
+// newMLClient builds the ML service client from the global connection flags.
+func newMLClient() (*api.MLClient, error) {
+	tlsConfig, err := buildTLSConfig(caCertFile, insecureSkipVerify, os.Stderr)
+	if err != nil {
+		return nil, err
+	}
+
+	var opts []api.Option
+	if tlsConfig != nil {
+		opts = append(opts, api.WithTLSConfig(tlsConfig))
+	}
+	if proxyFlag != "" {
+		proxyURL, err := parseProxyURL(proxyFlag)
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, api.WithProxy(proxyURL))
+	}
+	if noFollowRedirects {
+		opts = append(opts, api.WithFollowRedirects(false))
+	}
+	if mlTimeout > 0 {
+		opts = append(opts, api.WithTimeout(mlTimeout))
+	}
+	if mlAPIKey != "" {
+		opts = append(opts, api.WithAPIKey(mlAPIKey))
+	}
+	if logLevel != "" || logFormat != "" {
+		logger, err := newRequestLogger(os.Stderr, logFormat, logLevel)
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, api.WithRequestLogger(logger))
+	}
+	return api.NewMLClient(mlServiceURL, opts...)
+}
+
+// errorHint suggests how to fix err, or returns "" if there is nothing to
+// suggest.
+func errorHint(err error) string {
+	var rateErr *api.RateLimitError
+	var apiErr *api.APIError
+	switch {
+	case errors.Is(err, api.ErrUnauthorized):
+		return "check your API key (tidydata config set api-key)"
+	case errors.As(err, &rateErr) && rateErr.RetryAfter > 0:
+		return fmt.Sprintf("the ML service is busy, try again in %v", rateErr.RetryAfter.Round(time.Second))
+	case errors.As(err, &rateErr):
+		return "the ML service is busy, try again later"
+	case errors.As(err, &apiErr) && apiErr.StatusCode >= 300 && apiErr.StatusCode < 400:
+		return "the ML service answered with a redirect; check ml-url and any proxy in between"
+	}
+	return ""
+}
+
+// newRequestLogger returns a logger writing human-readable lines, or JSON
+// lines with a timestamp field when format is "json". The level defaults to
+// debug.
+func newRequestLogger(w io.Writer, format, levelName string) (*slog.Logger, error) {
+	level := slog.LevelDebug
+	if levelName != "" {
+		if err := level.UnmarshalText([]byte(levelName)); err != nil {
+			return nil, fmt.Errorf("invalid log level: %w", err)
+		}
+	}
+	opts := &slog.HandlerOptions{Level: level}
+
+	switch format {
+	case "", "text":
+		return slog.New(slog.NewTextHandler(w, opts)), nil
+	case "json":
+		opts.ReplaceAttr = func(groups []string, a slog.Attr) slog.Attr {
+			if len(groups) == 0 && a.Key == slog.TimeKey {
+				a.Key = "timestamp"
+			}
+			return a
+		}
+		return slog.New(slog.NewJSONHandler(w, opts)), nil
+	}
+	return nil, fmt.Errorf("invalid --log-format %q (use text or json)", format)
+}
+
+// tidydataDir is where the CLI keeps local state such as shell history.
+func tidydataDir() (string, error) {
+	home, err := homeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, ".tidydata"), nil
+}
+
+// buildTLSConfig returns nil when the system defaults should be used.
+// --insecure-skip-verify takes precedence over --ca-cert, since there is
+// nothing left to verify against once verification is off.
+func buildTLSConfig(caCertPath string, insecure bool, warn io.Writer) (*tls.Config, error) {
+	if insecure {
+		fmt.Fprintln(warn, "WARNING: TLS certificate verification is disabled (--insecure-skip-verify).")
+		fmt.Fprintln(warn, "WARNING: Connections to the ML service can be intercepted. Do not use this in production.")
+		if caCertPath != "" {
+			fmt.Fprintln(warn, "WARNING: --ca-cert is ignored because --insecure-skip-verify is set.")
+		}
+		return &tls.Config{InsecureSkipVerify: true}, nil
+	}
+	if caCertPath == "" {
+		return nil, nil
+	}
+
+	pem, err := os.ReadFile(caCertPath)
+	if err != nil {
+		return nil, fmt.Errorf("error reading CA certificate: %w", err)
+	}
+
+	pool, err := x509.SystemCertPool()
+	if err != nil {
+		pool = x509.NewCertPool()
+	}
+	if !pool.AppendCertsFromPEM(pem) {
+		return nil, fmt.Errorf("no valid certificates found in %s", caCertPath)
+	}
+	return &tls.Config{RootCAs: pool}, nil
+}
+
+func parseProxyURL(raw string) (*url.URL, error) {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return nil, fmt.Errorf("invalid proxy URL: %w", err)
+	}
+	switch u.Scheme {
+	case "http", "https", "socks5":
+	default:
+		return nil, fmt.Errorf("unsupported proxy scheme %q (use http, https or socks5)", u.Scheme)
+	}
+	if u.Host == "" {
+		return nil, fmt.Errorf("invalid proxy URL: missing host in %q", raw)
+	}
+	return u, nil
+}
@@ -0,0 +1,269 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/berkayuckac/tidydata/internal/api"
+)
+
+func writeServerCA(t *testing.T, server *httptest.Server) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "ca.pem")
+	data := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		t.Fatalf("Error writing CA file: %v", err)
+	}
+	return path
+}
+
+func TestBuildTLSConfigCACert(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"query": "test", "results": []}`))
+	}))
+	defer server.Close()
+
+	var warnings bytes.Buffer
+	config, err := buildTLSConfig(writeServerCA(t, server), false, &warnings)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if config.InsecureSkipVerify {
+		t.Error("Expected certificate verification to stay enabled")
+	}
+	if warnings.Len() != 0 {
+		t.Errorf("Expected no warnings, got %q", warnings.String())
+	}
+
+	client, err := api.NewMLClient(server.URL, api.WithTLSConfig(config))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if _, err := client.Search(context.Background(), "test", 1, 0.1); err != nil {
+		t.Errorf("Expected request to succeed with custom CA, got %v", err)
+	}
+}
+
+func TestBuildTLSConfigInsecurePrecedence(t *testing.T) {
+	var warnings bytes.Buffer
+	config, err := buildTLSConfig("/does/not/exist.pem", true, &warnings)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if !config.InsecureSkipVerify {
+		t.Error("Expected InsecureSkipVerify to be set")
+	}
+	if !strings.Contains(warnings.String(), "WARNING") || !strings.Contains(warnings.String(), "--ca-cert is ignored") {
+		t.Errorf("Expected warnings about insecure mode and ignored CA, got %q", warnings.String())
+	}
+}
+
+func TestBuildTLSConfigErrors(t *testing.T) {
+	if config, err := buildTLSConfig("", false, &bytes.Buffer{}); err != nil || config != nil {
+		t.Errorf("Expected nil config without TLS flags, got %v, %v", config, err)
+	}
+
+	if _, err := buildTLSConfig(filepath.Join(t.TempDir(), "missing.pem"), false, &bytes.Buffer{}); err == nil {
+		t.Error("Expected error for missing CA file")
+	}
+
+	invalid := filepath.Join(t.TempDir(), "invalid.pem")
+	if err := os.WriteFile(invalid, []byte("not a certificate"), 0o644); err != nil {
+		t.Fatalf("Error writing fixture: %v", err)
+	}
+	if _, err := buildTLSConfig(invalid, false, &bytes.Buffer{}); err == nil {
+		t.Error("Expected error for invalid CA file")
+	}
+}
+
+func TestParseProxyURL(t *testing.T) {
+	tests := []struct {
+		raw         string
+		expectError bool
+	}{
+		{raw: "http://proxy.corp:3128"},
+		{raw: "socks5://127.0.0.1:1080"},
+		{raw: "ftp://proxy.corp", expectError: true},
+		{raw: "proxy.corp:3128", expectError: true},
+		{raw: "http://", expectError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.raw, func(t *testing.T) {
+			u, err := parseProxyURL(tt.raw)
+			if tt.expectError {
+				if err == nil {
+					t.Errorf("Expected error but got none")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if u.String() != tt.raw {
+				t.Errorf("Expected %s, got %s", tt.raw, u.String())
+			}
+		})
+	}
+}
+
+func TestServiceURLTrailingSlash(t *testing.T) {
+	var path string
+	server := useTestServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path = r.URL.Path
+		w.Write([]byte(`{"query": "cats", "results": []}`))
+	}))
+	mlServiceURL = server.URL + "/"
+
+	var err error
+	captureOutput(t, func() { err = executeCommand(t, "search", "cats") })
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if path != "/search" {
+		t.Errorf("Expected request to /search, got %s", path)
+	}
+}
+
+func TestNewRequestLogger(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"query": "cats", "results": []}`))
+	}))
+	defer server.Close()
+	closed := httptest.NewServer(http.NotFoundHandler())
+	closed.Close()
+
+	var buf bytes.Buffer
+	logger, err := newRequestLogger(&buf, "json", "")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	for _, url := range []string{server.URL, closed.URL} {
+		client, err := api.NewMLClient(url, api.WithRequestLogger(logger), api.WithMaxRetries(0))
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		client.Search(context.Background(), "cats", 5, 0.1)
+	}
+
+	var lines []map[string]any
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		var entry map[string]any
+		if err := json.Unmarshal([]byte(line), &entry); err != nil {
+			t.Fatalf("Expected a JSON log line, got %q: %v", line, err)
+		}
+		lines = append(lines, entry)
+	}
+	if len(lines) != 4 {
+		t.Fatalf("Expected 4 log lines, got %d:\n%s", len(lines), buf.String())
+	}
+
+	response, failure := lines[1], lines[3]
+	for _, field := range []string{"timestamp", "operation", "url", "status", "duration_ms"} {
+		if _, ok := response[field]; !ok {
+			t.Errorf("Expected %s in response log, got %v", field, response)
+		}
+	}
+	if response["operation"] != "search" || response["status"] != float64(http.StatusOK) {
+		t.Errorf("Unexpected response log: %v", response)
+	}
+	for _, field := range []string{"timestamp", "operation", "url", "duration_ms", "error"} {
+		if _, ok := failure[field]; !ok {
+			t.Errorf("Expected %s in failure log, got %v", field, failure)
+		}
+	}
+
+	buf.Reset()
+	logger, err = newRequestLogger(&buf, "text", "info")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	logger.Debug("hidden")
+	logger.Info("ml response", "operation", "search")
+	if out := buf.String(); !strings.Contains(out, "level=INFO msg=\"ml response\" operation=search") || strings.Contains(out, "hidden") {
+		t.Errorf("Expected one human-readable info line, got %q", out)
+	}
+
+	if _, err := newRequestLogger(&buf, "xml", ""); err == nil {
+		t.Error("Expected error for unknown log format")
+	}
+	if _, err := newRequestLogger(&buf, "json", "loud"); err == nil {
+		t.Error("Expected error for unknown log level")
+	}
+}
+
+func TestErrorHint(t *testing.T) {
+	tests := []struct {
+		name     string
+		err      error
+		expected string
+	}{
+		{name: "unauthorized", err: &api.APIError{StatusCode: http.StatusUnauthorized}, expected: "check your API key (tidydata config set api-key)"},
+		{name: "wrapped forbidden", err: fmt.Errorf("error searching: %w", &api.APIError{StatusCode: http.StatusForbidden}), expected: "check your API key (tidydata config set api-key)"},
+		{name: "rate limited", err: &api.RateLimitError{APIError: &api.APIError{StatusCode: http.StatusTooManyRequests}, RetryAfter: 1500 * time.Millisecond}, expected: "the ML service is busy, try again in 2s"},
+		{name: "rate limited without retry after", err: &api.RateLimitError{APIError: &api.APIError{StatusCode: http.StatusTooManyRequests}}, expected: "the ML service is busy, try again later"},
+		{name: "bad request", err: &api.BadRequestError{APIError: &api.APIError{StatusCode: http.StatusBadRequest}, Message: "bad sort"}},
+		{name: "redirect", err: &api.APIError{StatusCode: http.StatusFound}, expected: "the ML service answered with a redirect; check ml-url and any proxy in between"},
+		{name: "server error", err: &api.APIError{StatusCode: http.StatusInternalServerError}},
+		{name: "other error", err: errors.New("connection refused")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if hint := errorHint(tt.err); hint != tt.expected {
+				t.Errorf("Expected hint %q, got %q", tt.expected, hint)
+			}
+		})
+	}
+}
+
+func TestUnauthorizedSearchHint(t *testing.T) {
+	useTestServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+		w.Write([]byte(`{"detail": "invalid API key"}`))
+	}))
+
+	var err error
+	captureOutput(t, func() { err = executeCommand(t, "search", "cats") })
+	if !errors.Is(err, api.ErrUnauthorized) {
+		t.Fatalf("Expected ErrUnauthorized, got %v", err)
+	}
+	if hint := errorHint(err); !strings.Contains(hint, "API key") {
+		t.Errorf("Expected an API key hint, got %q", hint)
+	}
+}
+
+func TestNoFollowRedirects(t *testing.T) {
+	useTestServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/login" {
+			w.Write([]byte(`{"query": "cats", "results": []}`))
+			return
+		}
+		http.Redirect(w, r, "/login", http.StatusFound)
+	}))
+
+	resetFlags(rootCmd)
+	var err error
+	captureOutput(t, func() { err = executeCommand(t, "search", "cats") })
+	if err != nil {
+		t.Fatalf("Expected the redirect to be followed by default, got %v", err)
+	}
+
+	resetFlags(rootCmd)
+	captureOutput(t, func() { err = executeCommand(t, "--no-follow-redirects", "search", "cats") })
+	var apiErr *api.APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusFound {
+		t.Errorf("Expected APIError with status 302, got %v", err)
+	}
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+const completionListLimit = 100
+
+var completionCmd = &cobra.Command{
+	Use:   "completion [bash|zsh|fish|powershell]",
+	Short: "Generate a shell completion script",
+	Long: `Generate a completion script for your shell.
+
+Bash:
+  source <(tidydata completion bash)
+
+Zsh:
+  tidydata completion zsh > "${fpath[1]}/_tidydata"
+
+Fish:
+  tidydata completion fish > ~/.config/fish/completions/tidydata.fish
+
+PowerShell:
+  tidydata completion powershell | Out-String | Invoke-Expression`,
+	DisableFlagsInUseLine: true,
+	ValidArgs:             []string{"bash", "zsh", "fish", "powershell"},
+	Args:                  cobra.MatchAll(cobra.ExactArgs(1), cobra.OnlyValidArgs),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		out := cmd.OutOrStdout()
+		switch args[0] {
+		case "bash":
+			return rootCmd.GenBashCompletionV2(out, true)
+		case "zsh":
+			return rootCmd.GenZshCompletion(out)
+		case "fish":
+			return rootCmd.GenFishCompletion(out, true)
+		case "powershell":
+			return rootCmd.GenPowerShellCompletionWithDesc(out)
+		default:
+			return fmt.Errorf("unsupported shell: %s", args[0])
+		}
+	},
+}
+
+// completeDocumentIDs suggests document IDs for the first positional argument.
+// Any failure to reach the ML service results in no suggestions.
+func completeDocumentIDs(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if len(args) > 0 {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	return suggestDocumentIDs(cmd.Context(), toComplete, nil), cobra.ShellCompDirectiveNoFileComp
+}
+
+// completeAllDocumentIDs suggests document IDs for every positional argument,
+// leaving out IDs that were already given.
+func completeAllDocumentIDs(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	return suggestDocumentIDs(cmd.Context(), toComplete, args), cobra.ShellCompDirectiveNoFileComp
+}
+
+// suggestDocumentIDs lists the stored document IDs that start with prefix.
+// The root command's PersistentPreRunE runs for __complete before the flags
+// of the command being completed are parsed, so mlClient does not see
+// connection flags such as --ml-url on that command line. A client is built
+// here, once they are parsed.
+func suggestDocumentIDs(ctx context.Context, prefix string, exclude []string) []string {
+	client, err := newMLClient()
+	if err != nil {
+		cobra.CompDebugln(fmt.Sprintf("error creating client: %v", err), true)
+		return nil
+	}
+	resp, err := client.ListDocuments(ctx, 0, completionListLimit)
+	if err != nil {
+		cobra.CompDebugln(fmt.Sprintf("error listing documents: %v", err), true)
+		return nil
+	}
+
+	var ids []string
+	for _, doc := range resp.Documents {
+		if strings.HasPrefix(doc.ID, prefix) && !slices.Contains(exclude, doc.ID) {
+			ids = append(ids, doc.ID+"\t"+completionPreview(doc.Text))
+		}
+	}
+	return ids
+}
+
+func completionPreview(text string) string {
+	text = strings.Join(strings.Fields(text), " ")
+	if runes := []rune(text); len(runes) > 40 {
+		return string(runes[:40]) + "..."
+	}
+	return text
+}
+
+func init() {
+	rootCmd.AddCommand(completionCmd)
+	updateCmd.ValidArgsFunction = completeDocumentIDs
+	deleteCmd.ValidArgsFunction = completeAllDocumentIDs
+}
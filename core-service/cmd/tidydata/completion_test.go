@@ -0,0 +1,100 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/spf13/cobra"
+)
+
+func TestCompletionScripts(t *testing.T) {
+	for _, shell := range []string{"bash", "zsh", "fish", "powershell"} {
+		t.Run(shell, func(t *testing.T) {
+			var out bytes.Buffer
+			rootCmd.SetOut(&out)
+			defer rootCmd.SetOut(nil)
+
+			if err := executeCommand(t, "completion", shell); err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if out.Len() == 0 {
+				t.Fatal("Expected non-empty completion script")
+			}
+			if !strings.Contains(out.String(), "tidydata") {
+				t.Error("Expected completion script to reference tidydata")
+			}
+		})
+	}
+}
+
+// complete runs cobra's hidden __complete command, which the generated
+// scripts call to get suggestions.
+func complete(t *testing.T, args ...string) string {
+	t.Helper()
+	var out bytes.Buffer
+	rootCmd.SetOut(&out)
+	defer rootCmd.SetOut(nil)
+
+	if err := executeCommand(t, append([]string{cobra.ShellCompRequestCmd}, args...)...); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	return out.String()
+}
+
+func TestCompleteCommandNames(t *testing.T) {
+	out := complete(t, "")
+	for _, name := range []string{"add", "search", "update", "delete", "image", "completion"} {
+		if !strings.Contains(out, "\n"+name+"\t") && !strings.HasPrefix(out, name+"\t") {
+			t.Errorf("Expected %s in suggestions, got:\n%s", name, out)
+		}
+	}
+}
+
+func TestCompleteSearchType(t *testing.T) {
+	out := complete(t, "search", "--type", "")
+	if !strings.HasPrefix(out, "text\nimage\nall\n") {
+		t.Errorf("Expected text, image and all suggestions, got:\n%s", out)
+	}
+}
+
+func TestCompleteDocumentIDs(t *testing.T) {
+	useTestServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"documents": [{"id": "abc1", "text": "first"}, {"id": "abd2", "text": "second"}, {"id": "xyz3", "text": "third"}], "total": 3}`))
+	}))
+	updateCmd.SetContext(context.Background())
+	deleteCmd.SetContext(context.Background())
+
+	ids, directive := completeDocumentIDs(updateCmd, nil, "ab")
+	if directive != cobra.ShellCompDirectiveNoFileComp {
+		t.Errorf("Expected NoFileComp directive, got %v", directive)
+	}
+	if len(ids) != 2 || !strings.HasPrefix(ids[0], "abc1\t") || !strings.HasPrefix(ids[1], "abd2\t") {
+		t.Errorf("Expected abc1 and abd2 suggestions, got %v", ids)
+	}
+
+	if ids, _ := completeDocumentIDs(updateCmd, []string{"abc1"}, ""); len(ids) != 0 {
+		t.Errorf("Expected no suggestions after the ID argument, got %v", ids)
+	}
+
+	ids, _ = completeAllDocumentIDs(deleteCmd, []string{"abc1"}, "")
+	if len(ids) != 2 || !strings.HasPrefix(ids[0], "abd2\t") || !strings.HasPrefix(ids[1], "xyz3\t") {
+		t.Errorf("Expected abd2 and xyz3 suggestions for delete, got %v", ids)
+	}
+}
+
+func TestCompleteDocumentIDsServiceDown(t *testing.T) {
+	server := useTestServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	server.Close()
+	updateCmd.SetContext(context.Background())
+
+	ids, directive := completeDocumentIDs(updateCmd, nil, "")
+	if len(ids) != 0 {
+		t.Errorf("Expected no suggestions when service is unreachable, got %v", ids)
+	}
+	if directive != cobra.ShellCompDirectiveNoFileComp {
+		t.Errorf("Expected NoFileComp directive, got %v", directive)
+	}
+}
@@ -0,0 +1,465 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+)
+
+// configKey describes a setting accepted by tidydata config.
+type configKey struct {
+	name        string
+	description string
+	validate    func(string) error
+}
+
+var configKeys = []configKey{
+	{"ml-url", "ML service URL", validateServiceURL},
+	{"api-key", "API key sent to the ML service", func(string) error { return nil }},
+	{"timeout", "ML service request timeout, e.g. 30s", validateDuration},
+	{"log-level", "Level for ML service request logs on stderr (debug, info, warn or error)", validateLogLevel},
+	{"default-threshold", "Default search threshold (0.0 to 1.0)", validateThreshold},
+	{"default-limit", "Default number of search results (at most 50 for image search and similar)", validateLimit},
+	{"dedupe-index", "File of content hashes used by add --dedupe", validateNotEmpty},
+}
+
+var configCmd = &cobra.Command{
+	Use:   "config",
+	Short: "Manage CLI settings",
+	Long: `Manage settings stored in ~/.tidydata/config.yaml. Settings belong to the
+profile selected with --profile or the TIDYDATA_PROFILE environment variable,
+or to the active profile (see tidydata profile).
+
+Keys:
+` + configKeyHelp(),
+}
+
+var configSetCmd = &cobra.Command{
+	Use:   "set [key] [value]",
+	Short: "Set a configuration value",
+	Args:  cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		key, value := args[0], args[1]
+		k, err := lookupConfigKey(key)
+		if err != nil {
+			return err
+		}
+		if err := k.validate(value); err != nil {
+			return fmt.Errorf("invalid value for %s: %w", key, err)
+		}
+
+		file, cfg, err := loadProfile()
+		if err != nil {
+			return err
+		}
+		cfg[key] = value
+		return saveConfig(file)
+	},
+}
+
+var configGetCmd = &cobra.Command{
+	Use:   "get [key]",
+	Short: "Print a configuration value",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		key := args[0]
+		if _, err := lookupConfigKey(key); err != nil {
+			return err
+		}
+
+		_, cfg, err := loadProfile()
+		if err != nil {
+			return err
+		}
+		value, ok := cfg[key]
+		if !ok {
+			return fmt.Errorf("%s is not set", key)
+		}
+		fmt.Println(value)
+		return nil
+	},
+}
+
+var configListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List configuration values",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		_, cfg, err := loadProfile()
+		if err != nil {
+			return err
+		}
+		if outputFormat == "json" {
+			return printJSON(cfg)
+		}
+
+		keys := make([]string, 0, len(cfg))
+		for key := range cfg {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			fmt.Printf("%s=%s\n", key, cfg[key])
+		}
+		return nil
+	},
+}
+
+var configShowCmd = &cobra.Command{
+	Use:   "show",
+	Short: "Show the selected profile and its settings",
+	Long: `Show which profile commands use, what selected it (--profile, the
+TIDYDATA_PROFILE environment variable or the active profile), and its
+settings. The API key is masked; use tidydata config get api-key to see it.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		file, cfg, err := loadProfile()
+		if err != nil {
+			return err
+		}
+		name, source := file.profileSource()
+		path, err := configPath()
+		if err != nil {
+			return err
+		}
+
+		settings := make(map[string]string, len(cfg))
+		for key, value := range cfg {
+			if key == "api-key" {
+				value = maskedValue
+			}
+			settings[key] = value
+		}
+
+		if outputFormat == "json" {
+			return printJSON(struct {
+				Profile  string            `json:"profile"`
+				Source   string            `json:"source"`
+				File     string            `json:"file"`
+				Settings map[string]string `json:"settings"`
+			}{name, source, path, settings})
+		}
+
+		fmt.Printf("Profile: %s (%s)\n", name, source)
+		fmt.Printf("File: %s\n", path)
+		keys := make([]string, 0, len(settings))
+		for key := range settings {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			fmt.Printf("%s=%s\n", key, settings[key])
+		}
+		return nil
+	},
+}
+
+// maskedValue replaces secrets in config show.
+const maskedValue = "********"
+
+var configResetCmd = &cobra.Command{
+	Use:   "reset [key]",
+	Short: "Remove one configuration value, or all of them",
+	Args:  cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if len(args) == 1 {
+			if _, err := lookupConfigKey(args[0]); err != nil {
+				return err
+			}
+		}
+
+		file, cfg, err := loadProfile()
+		if err != nil {
+			return err
+		}
+		if len(args) == 0 {
+			clear(cfg)
+		} else {
+			delete(cfg, args[0])
+		}
+		return saveConfig(file)
+	},
+}
+
+func init() {
+	configCmd.AddCommand(configSetCmd)
+	configCmd.AddCommand(configGetCmd)
+	configCmd.AddCommand(configListCmd)
+	configCmd.AddCommand(configShowCmd)
+	configCmd.AddCommand(configResetCmd)
+	rootCmd.AddCommand(configCmd)
+
+	keys := make([]string, len(configKeys))
+	for i, k := range configKeys {
+		keys[i] = k.name
+	}
+	keyCompletion := func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		if len(args) > 0 {
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		}
+		return keys, cobra.ShellCompDirectiveNoFileComp
+	}
+	configSetCmd.ValidArgsFunction = keyCompletion
+	configGetCmd.ValidArgsFunction = keyCompletion
+	configResetCmd.ValidArgsFunction = keyCompletion
+
+	for _, cmd := range []*cobra.Command{imageSimilarCmd, imageSearchCmd} {
+		if cmd.Annotations == nil {
+			cmd.Annotations = make(map[string]string)
+		}
+		cmd.Annotations[maxLimitAnnotation] = strconv.Itoa(maxSimilarLimit)
+	}
+}
+
+const defaultProfile = "default"
+
+// configFile is the layout of config.yaml: one set of settings per profile
+// and the profile used when --profile is not given.
+type configFile struct {
+	Active   string                       `yaml:"active,omitempty"`
+	Profiles map[string]map[string]string `yaml:"profiles"`
+}
+
+func configPath() (string, error) {
+	dir, err := tidydataDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "config.yaml"), nil
+}
+
+// loadConfig reads config.yaml, returning an empty file when there is none.
+// A file holding plain key: value pairs is read as the default profile.
+func loadConfig() (*configFile, error) {
+	path, err := configPath()
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return &configFile{Profiles: map[string]map[string]string{}}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error reading config: %w", err)
+	}
+
+	file := &configFile{}
+	if err := yaml.Unmarshal(data, file); err != nil {
+		return nil, fmt.Errorf("error parsing %s: %w", path, err)
+	}
+	if file.Profiles == nil {
+		settings := map[string]string{}
+		if err := yaml.Unmarshal(data, &settings); err != nil {
+			return nil, fmt.Errorf("error parsing %s: %w", path, err)
+		}
+		file.Profiles = map[string]map[string]string{defaultProfile: settings}
+	}
+	return file, nil
+}
+
+// profileEnv selects a profile like --profile, which takes precedence.
+const profileEnv = "TIDYDATA_PROFILE"
+
+// selectedProfile is --profile when given, then $TIDYDATA_PROFILE, and
+// otherwise the active profile.
+func (f *configFile) selectedProfile() string {
+	name, _ := f.profileSource()
+	return name
+}
+
+// profileSource returns the selected profile and what selected it.
+func (f *configFile) profileSource() (string, string) {
+	switch {
+	case profileFlag != "":
+		return profileFlag, "--profile"
+	case os.Getenv(profileEnv) != "":
+		return os.Getenv(profileEnv), profileEnv
+	case f.Active != "":
+		return f.Active, "active profile"
+	default:
+		return defaultProfile, "default"
+	}
+}
+
+// loadProfile returns the config file and the settings of the selected
+// profile. The default profile always exists; others must be created first.
+func loadProfile() (*configFile, map[string]string, error) {
+	file, err := loadConfig()
+	if err != nil {
+		return nil, nil, err
+	}
+	name, source := file.profileSource()
+	cfg, ok := file.Profiles[name]
+	if !ok {
+		if name != defaultProfile {
+			return nil, nil, fmt.Errorf("profile %q does not exist (selected by %s; create it with tidydata profile create %s)", name, source, name)
+		}
+		cfg = map[string]string{}
+		file.Profiles[name] = cfg
+	}
+	return file, cfg, nil
+}
+
+// saveConfig writes the file readable only by the user, since it may hold an
+// API key.
+func saveConfig(file *configFile) error {
+	path, err := configPath()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return fmt.Errorf("error creating config directory: %w", err)
+	}
+	data, err := yaml.Marshal(file)
+	if err != nil {
+		return fmt.Errorf("error encoding config: %w", err)
+	}
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		return fmt.Errorf("error writing config: %w", err)
+	}
+	return nil
+}
+
+// maxLimitAnnotation holds the largest --limit a command accepts. The
+// default-limit setting is capped to it, so a default meant for text search
+// does not make such commands fail.
+const maxLimitAnnotation = "tidydata_max_limit"
+
+// applyConfig fills in settings the user did not pass on the command line.
+// Search defaults apply to commands with both --limit and --threshold.
+func applyConfig(cmd *cobra.Command, cfg map[string]string) error {
+	for key, value := range cfg {
+		k, err := lookupConfigKey(key)
+		if err != nil {
+			return fmt.Errorf("config: %w", err)
+		}
+		if err := k.validate(value); err != nil {
+			return fmt.Errorf("config: invalid value for %s: %w", key, err)
+		}
+	}
+
+	if value, ok := cfg["ml-url"]; ok && mlServiceURL == defaultMLServiceURL {
+		mlServiceURL = value
+	}
+	if value, ok := cfg["timeout"]; ok {
+		mlTimeout, _ = time.ParseDuration(value)
+	}
+	if value, ok := cfg["api-key"]; ok {
+		mlAPIKey = value
+	}
+	if value, ok := cfg["log-level"]; ok {
+		logLevel = value
+	}
+	if value, ok := cfg["dedupe-index"]; ok && dedupeIndexPath == "" {
+		path, err := expandPath(value)
+		if err != nil {
+			return fmt.Errorf("config: dedupe-index: %w", err)
+		}
+		dedupeIndexPath = path
+	}
+
+	flags := cmd.Flags()
+	if flags.Lookup("limit") == nil || flags.Lookup("threshold") == nil {
+		return nil
+	}
+	if value, ok := cfg["default-limit"]; ok && !flags.Changed("limit") {
+		if maxLimit, err := strconv.Atoi(cmd.Annotations[maxLimitAnnotation]); err == nil {
+			if limit, _ := strconv.Atoi(value); limit > maxLimit {
+				value = strconv.Itoa(maxLimit)
+			}
+		}
+		if err := flags.Set("limit", value); err != nil {
+			return err
+		}
+	}
+	if value, ok := cfg["default-threshold"]; ok && !flags.Changed("threshold") {
+		if err := flags.Set("threshold", value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func lookupConfigKey(name string) (configKey, error) {
+	for _, k := range configKeys {
+		if k.name == name {
+			return k, nil
+		}
+	}
+	names := make([]string, len(configKeys))
+	for i, k := range configKeys {
+		names[i] = k.name
+	}
+	return configKey{}, fmt.Errorf("unknown config key %q (valid keys: %s)", name, strings.Join(names, ", "))
+}
+
+func configKeyHelp() string {
+	var b strings.Builder
+	for _, k := range configKeys {
+		fmt.Fprintf(&b, "  %-18s %s\n", k.name, k.description)
+	}
+	return b.String()
+}
+
+func validateServiceURL(value string) error {
+	u, err := url.Parse(value)
+	if err != nil {
+		return err
+	}
+	if (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("must be an http or https URL, got %q", value)
+	}
+	return nil
+}
+
+func validateNotEmpty(value string) error {
+	if strings.TrimSpace(value) == "" {
+		return fmt.Errorf("must not be empty")
+	}
+	return nil
+}
+
+func validateDuration(value string) error {
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		return fmt.Errorf("must be a duration such as 30s or 2m, got %q", value)
+	}
+	if d <= 0 {
+		return fmt.Errorf("must be positive, got %s", value)
+	}
+	return nil
+}
+
+func validateLogLevel(value string) error {
+	switch value {
+	case "debug", "info", "warn", "error":
+		return nil
+	}
+	return fmt.Errorf("must be debug, info, warn or error, got %q", value)
+}
+
+func validateThreshold(value string) error {
+	f, err := strconv.ParseFloat(value, 64)
+	if err != nil || f < 0 || f > 1 {
+		return fmt.Errorf("must be a number between 0.0 and 1.0, got %q", value)
+	}
+	return nil
+}
+
+func validateLimit(value string) error {
+	n, err := strconv.Atoi(value)
+	if err != nil || n <= 0 {
+		return fmt.Errorf("must be a positive integer, got %q", value)
+	}
+	return nil
+}
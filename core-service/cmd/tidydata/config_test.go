@@ -0,0 +1,249 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// useConfigDir points the config file at a temporary home directory.
+func useConfigDir(t *testing.T) string {
+	t.Helper()
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv(profileEnv, "")
+	t.Cleanup(func() {
+		mlTimeout = 0
+		mlAPIKey = ""
+		logLevel = ""
+	})
+	return filepath.Join(home, ".tidydata", "config.yaml")
+}
+
+// runConfig runs a config subcommand with flags left over from earlier
+// commands in the test cleared.
+func runConfig(t *testing.T, args ...string) (string, error) {
+	t.Helper()
+	resetFlags(rootCmd)
+	var err error
+	out := captureOutput(t, func() { err = executeCommand(t, append([]string{"config"}, args...)...) })
+	return out, err
+}
+
+func TestConfigSetGet(t *testing.T) {
+	path := useConfigDir(t)
+
+	if _, err := runConfig(t, "set", "timeout", "45s"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	out, err := runConfig(t, "get", "timeout")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if out != "45s\n" {
+		t.Errorf("Expected 45s, got %q", out)
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatalf("Expected config file: %v", err)
+	}
+	if perm := info.Mode().Perm(); perm != 0o600 {
+		t.Errorf("Expected config mode 0600, got %o", perm)
+	}
+
+	if _, err := runConfig(t, "get", "api-key"); err == nil || !strings.Contains(err.Error(), "not set") {
+		t.Errorf("Expected not set error, got %v", err)
+	}
+}
+
+func TestConfigSetValidation(t *testing.T) {
+	useConfigDir(t)
+
+	tests := []struct {
+		name        string
+		key         string
+		value       string
+		expectError string
+	}{
+		{name: "valid url", key: "ml-url", value: "https://ml.example.com"},
+		{name: "valid limit", key: "default-limit", value: "25"},
+		{name: "unknown key", key: "colour", value: "red", expectError: "valid keys: ml-url"},
+		{name: "bad duration", key: "timeout", value: "ten", expectError: "must be a duration"},
+		{name: "bad url", key: "ml-url", value: "localhost:8000", expectError: "http or https URL"},
+		{name: "bad log level", key: "log-level", value: "loud", expectError: "debug, info, warn or error"},
+		{name: "threshold out of range", key: "default-threshold", value: "1.5", expectError: "between 0.0 and 1.0"},
+		{name: "zero limit", key: "default-limit", value: "0", expectError: "positive integer"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := runConfig(t, "set", tt.key, tt.value)
+			if tt.expectError == "" {
+				if err != nil {
+					t.Errorf("Unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.expectError) {
+				t.Errorf("Expected error containing %q, got %v", tt.expectError, err)
+			}
+		})
+	}
+}
+
+func TestConfigListAndReset(t *testing.T) {
+	useConfigDir(t)
+
+	for _, kv := range [][2]string{{"log-level", "debug"}, {"default-limit", "5"}, {"api-key", "secret"}} {
+		if _, err := runConfig(t, "set", kv[0], kv[1]); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+	}
+
+	out, err := runConfig(t, "list")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if expected := "api-key=secret\ndefault-limit=5\nlog-level=debug\n"; out != expected {
+		t.Errorf("Expected %q, got %q", expected, out)
+	}
+
+	if _, err := runConfig(t, "reset", "api-key"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	out, err = runConfig(t, "list", "--output", "json")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	var cfg map[string]string
+	if err := json.Unmarshal([]byte(out), &cfg); err != nil {
+		t.Fatalf("Error decoding JSON: %v", err)
+	}
+	if len(cfg) != 2 || cfg["log-level"] != "debug" || cfg["default-limit"] != "5" {
+		t.Errorf("Unexpected config after reset: %v", cfg)
+	}
+
+	if _, err := runConfig(t, "reset"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	out, err = runConfig(t, "list")
+	if err != nil || out != "" {
+		t.Errorf("Expected empty list, got %q (err %v)", out, err)
+	}
+}
+
+func TestConfigSearchDefaults(t *testing.T) {
+	useConfigDir(t)
+
+	var query map[string]string
+	useTestServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query = map[string]string{
+			"limit":     r.URL.Query().Get("limit"),
+			"threshold": r.URL.Query().Get("score_threshold"),
+		}
+		w.Write([]byte(searchFixture))
+	}))
+
+	runConfig(t, "set", "default-limit", "3")
+	runConfig(t, "set", "default-threshold", "0.4")
+
+	captureOutput(t, func() {
+		if err := executeCommand(t, "search", "cats"); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+	})
+	if query["limit"] != "3" || !strings.HasPrefix(query["threshold"], "0.4") {
+		t.Errorf("Expected config defaults, got %v", query)
+	}
+
+	captureOutput(t, func() {
+		if err := executeCommand(t, "search", "cats", "--limit", "8"); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+	})
+	if query["limit"] != "8" || !strings.HasPrefix(query["threshold"], "0.4") {
+		t.Errorf("Expected --limit to override config, got %v", query)
+	}
+}
+
+func TestConfigDefaultLimitCappedForImages(t *testing.T) {
+	useConfigDir(t)
+
+	limits := map[string]string{}
+	useTestServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/search":
+			limits[r.URL.Path] = r.URL.Query().Get("limit")
+			w.Write([]byte(searchFixture))
+		case "/images/search":
+			limits[r.URL.Path] = r.URL.Query().Get("limit")
+			w.Write([]byte(`{"results": []}`))
+		default:
+			limits[r.URL.Path] = r.FormValue("limit")
+			w.Write([]byte(`{"query_image": "query_image", "results": []}`))
+		}
+	}))
+
+	imagePath := filepath.Join(t.TempDir(), "photo.jpg")
+	if err := os.WriteFile(imagePath, []byte("jpeg bytes"), 0o644); err != nil {
+		t.Fatalf("Error writing fixture: %v", err)
+	}
+
+	runConfig(t, "set", "default-limit", "100")
+
+	for _, args := range [][]string{{"search", "cats"}, {"image", "search", "cats"}, {"image", "similar", imagePath}} {
+		var err error
+		captureOutput(t, func() { err = executeCommand(t, args...) })
+		if err != nil {
+			t.Fatalf("Unexpected error for %v: %v", args, err)
+		}
+	}
+
+	expected := map[string]string{"/search": "100", "/images/search": "50", "/images/similar": "50"}
+	if !reflect.DeepEqual(limits, expected) {
+		t.Errorf("Expected limits %v, got %v", expected, limits)
+	}
+}
+
+func TestConfigInvalidFile(t *testing.T) {
+	path := useConfigDir(t)
+	os.MkdirAll(filepath.Dir(path), 0o700)
+	if err := os.WriteFile(path, []byte("timeout: forever\n"), 0o600); err != nil {
+		t.Fatalf("Error writing fixture: %v", err)
+	}
+
+	err := executeCommand(t, "search", "cats")
+	if err == nil || !strings.Contains(err.Error(), "invalid value for timeout") {
+		t.Errorf("Expected invalid config error, got %v", err)
+	}
+
+	// The config commands still work so the value can be fixed.
+	if _, err := runConfig(t, "set", "timeout", "1m"); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+}
+
+func TestConfigAPIKey(t *testing.T) {
+	useConfigDir(t)
+
+	var auth string
+	useTestServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		auth = r.Header.Get("Authorization")
+		w.Write([]byte(searchFixture))
+	}))
+
+	runConfig(t, "set", "api-key", "secret")
+	captureOutput(t, func() {
+		if err := executeCommand(t, "search", "cats"); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+	})
+	if auth != "Bearer secret" {
+		t.Errorf("Expected bearer token from config, got %q", auth)
+	}
+}
@@ -0,0 +1,128 @@
+package main
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+var (
+	dedupeFlag      bool
+	dedupeIndexPath string
+)
+
+// errAlreadyAdded is returned in place of adding text whose hash is already
+// in the dedupe index.
+var errAlreadyAdded = errors.New("content already added")
+
+func init() {
+	addCmd.Flags().BoolVar(&dedupeFlag, "dedupe", false, "Skip text that has already been added with --dedupe")
+	addCmd.Flags().StringVar(&dedupeIndexPath, "dedupe-index", "", "File of content hashes used by --dedupe (default ~/.tidydata/dedupe-index)")
+	_ = addCmd.MarkFlagFilename("dedupe-index")
+}
+
+// dedupeIndex is a set of SHA-256 hashes of added text, stored one per line.
+type dedupeIndex struct {
+	path   string
+	hashes map[string]bool
+}
+
+func openDedupeIndex(path string) (*dedupeIndex, error) {
+	if path == "" {
+		dir, err := tidydataDir()
+		if err != nil {
+			return nil, err
+		}
+		path = filepath.Join(dir, "dedupe-index")
+	}
+
+	ix := &dedupeIndex{path: path, hashes: make(map[string]bool)}
+	f, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return ix, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error opening dedupe index: %w", err)
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		if hash := strings.TrimSpace(scanner.Text()); hash != "" {
+			ix.hashes[hash] = true
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("error reading dedupe index: %w", err)
+	}
+	return ix, nil
+}
+
+func contentHash(text string) string {
+	sum := sha256.Sum256([]byte(text))
+	return hex.EncodeToString(sum[:])
+}
+
+func (ix *dedupeIndex) contains(text string) bool {
+	return ix.hashes[contentHash(text)]
+}
+
+// record adds the hash of text to the index file.
+func (ix *dedupeIndex) record(text string) error {
+	hash := contentHash(text)
+	if ix.hashes[hash] {
+		return nil
+	}
+	if err := os.MkdirAll(filepath.Dir(ix.path), 0o700); err != nil {
+		return fmt.Errorf("error creating dedupe index directory: %w", err)
+	}
+	f, err := os.OpenFile(ix.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return fmt.Errorf("error opening dedupe index: %w", err)
+	}
+	defer f.Close()
+	if _, err := fmt.Fprintln(f, hash); err != nil {
+		return fmt.Errorf("error writing dedupe index: %w", err)
+	}
+	ix.hashes[hash] = true
+	return nil
+}
+
+// dedupe wraps add so text already in the index is skipped with
+// errAlreadyAdded, and text that is added is recorded. Nothing is recorded
+// in dry-run mode.
+func (ix *dedupeIndex) dedupe(add func(text string) (string, error)) func(text string) (string, error) {
+	return func(text string) (string, error) {
+		if ix.contains(text) {
+			return "", errAlreadyAdded
+		}
+		id, err := add(text)
+		if err != nil || dryRun {
+			return id, err
+		}
+		ix.recordOrWarn(text)
+		return id, nil
+	}
+}
+
+// recordOrWarn records text, only warning on failure since the document
+// itself was added.
+func (ix *dedupeIndex) recordOrWarn(text string) {
+	if err := ix.record(text); err != nil {
+		fmt.Fprintf(os.Stderr, "warning: %v\n", err)
+	}
+}
+
+// printSkipped reports text that --dedupe did not add.
+func printSkipped() error {
+	if outputFormat == "json" {
+		return printJSON(map[string]bool{"skipped": true})
+	}
+	printInfo("Skipped: this content has already been added\n")
+	return nil
+}
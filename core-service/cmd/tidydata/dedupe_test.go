@@ -0,0 +1,90 @@
+package main
+
+import (
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestAddDedupe(t *testing.T) {
+	var added []string
+	fail := false
+	useTestServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if fail {
+			http.Error(w, "unavailable", http.StatusServiceUnavailable)
+			return
+		}
+		added = append(added, r.URL.Path)
+		w.Write([]byte(`{"document_id": "doc1", "status": "stored"}`))
+	}))
+	index := filepath.Join(t.TempDir(), "index")
+
+	add := func(text string) (string, error) {
+		t.Helper()
+		resetFlags(rootCmd)
+		var err error
+		out := captureOutput(t, func() { err = executeCommand(t, "add", text, "--dedupe", "--dedupe-index", index) })
+		return out, err
+	}
+
+	fail = true
+	if _, err := add("first note"); err == nil {
+		t.Fatal("Expected error from failing server")
+	}
+	if _, err := os.Stat(index); !os.IsNotExist(err) {
+		t.Errorf("Expected nothing recorded after a failed add, got %v", err)
+	}
+
+	fail = false
+	out, err := add("first note")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if !strings.Contains(out, "doc1") || len(added) != 1 {
+		t.Fatalf("Expected the first copy to be added, got %q (%d requests)", out, len(added))
+	}
+
+	out, err = add("first note")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if !strings.Contains(out, "Skipped") || len(added) != 1 {
+		t.Errorf("Expected the duplicate to be skipped, got %q (%d requests)", out, len(added))
+	}
+
+	if _, err := add("second note"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(added) != 2 {
+		t.Errorf("Expected different text to be added, got %d requests", len(added))
+	}
+}
+
+func TestAddFilesDedupe(t *testing.T) {
+	var added int
+	useTestServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		added++
+		w.Write([]byte(`{"document_id": "doc1", "status": "stored"}`))
+	}))
+
+	dir := t.TempDir()
+	a, b := filepath.Join(dir, "a.txt"), filepath.Join(dir, "b.txt")
+	os.WriteFile(a, []byte("same text"), 0o644)
+	os.WriteFile(b, []byte("same text"), 0o644)
+
+	var err error
+	out := captureOutput(t, func() {
+		err = executeCommand(t, "add", "-f", a, "-f", b, "--dedupe", "--dedupe-index", filepath.Join(dir, "index"))
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if added != 1 {
+		t.Errorf("Expected one add, got %d", added)
+	}
+	if !strings.Contains(out, b+": skipped") || !strings.Contains(out, "Added 1 of 2 files") {
+		t.Errorf("Expected the second file to be skipped, got:\n%s", out)
+	}
+}
@@ -0,0 +1,127 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/berkayuckac/tidydata/internal/api"
+	"github.com/berkayuckac/tidydata/internal/ui"
+	"github.com/spf13/cobra"
+)
+
+var (
+	dedupThreshold  float64
+	dedupAutoRemove bool
+	dedupKeep       string
+)
+
+var deduplicateCmd = &cobra.Command{
+	Use:   "deduplicate",
+	Short: "Find and remove near-duplicate documents",
+	Long: `Find groups of documents whose similarity is above the threshold.
+
+Each group is shown with the document that would be kept, and you are asked
+whether to delete the others. Use --auto-remove to delete without asking.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if dedupThreshold < 0 || dedupThreshold > 1 {
+			return fmt.Errorf("threshold must be between 0.0 and 1.0, got %.2f", dedupThreshold)
+		}
+		if _, _, err := splitDuplicates(nil, dedupKeep); err != nil {
+			return err
+		}
+
+		groups, err := mlClient.FindDuplicates(cmd.Context(), dedupThreshold)
+		if err != nil {
+			return fmt.Errorf("error finding duplicates: %w", err)
+		}
+		if len(groups) == 0 {
+			printInfo("No duplicate documents found.\n")
+			return nil
+		}
+
+		removed, failed := 0, 0
+		for i, group := range groups {
+			keep, discard, _ := splitDuplicates(group.Documents, dedupKeep)
+			if len(discard) == 0 {
+				continue
+			}
+
+			fmt.Printf("Group %d of %d (similarity %.2f)\n", i+1, len(groups), group.Score)
+			fmt.Printf("  keep:   %s  %s\n", keep.ID, dedupPreview(keep.Text))
+			for _, doc := range discard {
+				fmt.Printf("  remove: %s  %s\n", doc.ID, dedupPreview(doc.Text))
+			}
+
+			if !ui.Confirm("Remove duplicates in this group?", dedupAutoRemove) {
+				fmt.Println("Skipped.")
+				continue
+			}
+
+			for _, doc := range discard {
+				if dryRun {
+					printDryRun(http.MethodDelete, "/documents/"+url.PathEscape(doc.ID))
+					continue
+				}
+				if err := mlClient.DeleteDocument(cmd.Context(), doc.ID); err != nil {
+					failed++
+					fmt.Fprintf(os.Stderr, "error deleting %s: %v\n", doc.ID, err)
+					continue
+				}
+				removed++
+			}
+		}
+
+		printInfo("\nRemoved %d duplicate documents\n", removed)
+		if failed > 0 {
+			return fmt.Errorf("failed to delete %d documents", failed)
+		}
+		return nil
+	},
+}
+
+// splitDuplicates picks the document to keep from a duplicate group according
+// to the keep strategy and returns it along with the documents to discard.
+func splitDuplicates(docs []api.DocumentSummary, keep string) (api.DocumentSummary, []api.DocumentSummary, error) {
+	keepIndex := 0
+	switch keep {
+	case "first":
+	case "last":
+		keepIndex = len(docs) - 1
+	case "longest":
+		for i, doc := range docs {
+			if utf8.RuneCountInString(doc.Text) > utf8.RuneCountInString(docs[keepIndex].Text) {
+				keepIndex = i
+			}
+		}
+	default:
+		return api.DocumentSummary{}, nil, fmt.Errorf("keep must be one of first, last or longest, got %q", keep)
+	}
+	if len(docs) == 0 {
+		return api.DocumentSummary{}, nil, nil
+	}
+
+	discard := make([]api.DocumentSummary, 0, len(docs)-1)
+	discard = append(discard, docs[:keepIndex]...)
+	discard = append(discard, docs[keepIndex+1:]...)
+	return docs[keepIndex], discard, nil
+}
+
+func dedupPreview(text string) string {
+	text = strings.Join(strings.Fields(text), " ")
+	if runes := []rune(text); len(runes) > 60 {
+		return string(runes[:60]) + "..."
+	}
+	return text
+}
+
+func init() {
+	rootCmd.AddCommand(deduplicateCmd)
+	deduplicateCmd.Flags().Float64VarP(&dedupThreshold, "threshold", "t", 0.95, "Minimum similarity for documents to count as duplicates (0.0 to 1.0)")
+	deduplicateCmd.Flags().BoolVar(&dedupAutoRemove, "auto-remove", false, "Delete duplicates without asking")
+	deduplicateCmd.Flags().StringVar(&dedupKeep, "keep", "first", "Which document to keep in each group (first, last or longest)")
+}
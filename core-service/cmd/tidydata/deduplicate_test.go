@@ -0,0 +1,76 @@
+package main
+
+import (
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/berkayuckac/tidydata/internal/api"
+)
+
+func TestSplitDuplicates(t *testing.T) {
+	docs := []api.DocumentSummary{
+		{ID: "a", Text: "short"},
+		{ID: "b", Text: "the longest text"},
+		{ID: "c", Text: "medium text"},
+		{ID: "d", Text: "also the longest"},
+	}
+
+	tests := []struct {
+		keep            string
+		expectedKeep    string
+		expectedDiscard string
+	}{
+		{keep: "first", expectedKeep: "a", expectedDiscard: "b,c,d"},
+		{keep: "last", expectedKeep: "d", expectedDiscard: "a,b,c"},
+		{keep: "longest", expectedKeep: "b", expectedDiscard: "a,c,d"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.keep, func(t *testing.T) {
+			keep, discard, err := splitDuplicates(docs, tt.keep)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if keep.ID != tt.expectedKeep {
+				t.Errorf("Expected to keep %s, got %s", tt.expectedKeep, keep.ID)
+			}
+			var ids []string
+			for _, doc := range discard {
+				ids = append(ids, doc.ID)
+			}
+			if got := strings.Join(ids, ","); got != tt.expectedDiscard {
+				t.Errorf("Expected to discard %s, got %s", tt.expectedDiscard, got)
+			}
+		})
+	}
+
+	if _, _, err := splitDuplicates(docs, "random"); err == nil {
+		t.Error("Expected error for unknown keep strategy")
+	}
+}
+
+func TestDeduplicateAutoRemove(t *testing.T) {
+	var mu sync.Mutex
+	var deleted []string
+	useTestServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == "/documents/duplicates":
+			w.Write([]byte(`{"groups": [{"score": 0.97, "documents": [{"id": "a", "text": "x"}, {"id": "b", "text": "xyz"}, {"id": "c", "text": "xy"}]}]}`))
+		case r.Method == http.MethodDelete:
+			mu.Lock()
+			deleted = append(deleted, strings.TrimPrefix(r.URL.Path, "/documents/"))
+			mu.Unlock()
+		default:
+			t.Errorf("Unexpected request %s %s", r.Method, r.URL.Path)
+		}
+	}))
+
+	if err := executeCommand(t, "deduplicate", "--auto-remove", "--keep", "longest"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if got := strings.Join(deleted, ","); got != "a,c" {
+		t.Errorf("Expected a and c to be deleted, got %s", got)
+	}
+}
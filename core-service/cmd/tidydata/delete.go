@@ -0,0 +1,166 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+
+	"github.com/berkayuckac/tidydata/internal/api"
+	"github.com/berkayuckac/tidydata/internal/ui"
+	"github.com/spf13/cobra"
+)
+
+const deleteWorkers = 4
+
+var (
+	deleteFileFlag string
+	deleteYes      bool
+)
+
+var deleteCmd = &cobra.Command{
+	Use:   "delete [id...]",
+	Short: "Delete one or more documents",
+	Long: `Delete documents by ID. IDs can be given as arguments, read from a file
+with one ID per line (--file), or both.
+
+You are asked to confirm before anything is deleted unless --yes or --force
+is set.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ids := args
+		if deleteFileFlag != "" {
+			fromFile, err := readIDFile(deleteFileFlag)
+			if err != nil {
+				return err
+			}
+			ids = append(ids, fromFile...)
+		}
+		ids = uniqueIDs(ids)
+		if len(ids) == 0 {
+			return fmt.Errorf("either provide document IDs as arguments or use --file flag")
+		}
+
+		if dryRun {
+			for _, id := range ids {
+				printDryRun(http.MethodDelete, "/documents/"+url.PathEscape(id))
+			}
+			return nil
+		}
+
+		prompt := fmt.Sprintf("Delete %d documents?", len(ids))
+		if len(ids) == 1 {
+			prompt = fmt.Sprintf("Delete document %s?", ids[0])
+		}
+		if !ui.Confirm(prompt, deleteYes) {
+			return fmt.Errorf("aborted")
+		}
+
+		results := deleteDocuments(cmd.Context(), ids, deleteWorkers, func(id string) error {
+			return mlClient.DeleteDocument(cmd.Context(), id)
+		})
+		summaryErr := printDeleteSummary(results)
+		if err := batchInterrupted(cmd.Context(), len(results), len(ids), "documents"); err != nil {
+			return err
+		}
+		return summaryErr
+	},
+}
+
+type deleteResult struct {
+	ID  string
+	Err error
+}
+
+// deleteDocuments calls del for every ID using at most workers concurrent
+// calls. Results are returned in the same order as ids. Once ctx is
+// cancelled no more calls are started, and only the IDs already handed out
+// have results.
+func deleteDocuments(ctx context.Context, ids []string, workers int, del func(id string) error) []deleteResult {
+	return mapConcurrent(ctx, ids, workers, func(id string) deleteResult {
+		return deleteResult{ID: id, Err: del(id)}
+	})
+}
+
+type deleteOutput struct {
+	ID    string `json:"id"`
+	Error string `json:"error,omitempty"`
+}
+
+func printDeleteSummary(results []deleteResult) error {
+	failed := 0
+	outputs := make([]deleteOutput, 0, len(results))
+	for _, result := range results {
+		if result.Err != nil {
+			failed++
+			err := result.Err
+			if errors.Is(err, api.ErrNotFound) {
+				err = fmt.Errorf("no document found with ID: %s", result.ID)
+			}
+			fmt.Fprintf(os.Stderr, "%s: %v\n", result.ID, err)
+			outputs = append(outputs, deleteOutput{ID: result.ID, Error: err.Error()})
+			continue
+		}
+		outputs = append(outputs, deleteOutput{ID: result.ID})
+		switch {
+		case outputFormat == "json":
+		case quiet:
+			fmt.Println(result.ID)
+		default:
+			fmt.Printf("%s: deleted\n", result.ID)
+		}
+	}
+
+	if outputFormat == "json" {
+		if err := printJSON(outputs); err != nil {
+			return err
+		}
+	}
+	printInfo("\nDeleted %d of %d documents\n", len(results)-failed, len(results))
+	if failed > 0 {
+		return fmt.Errorf("%d of %d deletions failed", failed, len(results))
+	}
+	return nil
+}
+
+// readIDFile reads one document ID per line, ignoring blank lines and lines
+// starting with #.
+func readIDFile(path string) ([]string, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("error reading file: %w", err)
+	}
+
+	var ids []string
+	for _, line := range strings.Split(string(content), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		ids = append(ids, line)
+	}
+	return ids, nil
+}
+
+func uniqueIDs(ids []string) []string {
+	seen := make(map[string]bool, len(ids))
+	unique := make([]string, 0, len(ids))
+	for _, id := range ids {
+		if seen[id] {
+			continue
+		}
+		seen[id] = true
+		unique = append(unique, id)
+	}
+	return unique
+}
+
+func init() {
+	rootCmd.AddCommand(deleteCmd)
+	deleteCmd.Flags().StringVarP(&deleteFileFlag, "file", "f", "", "Path to a file with one document ID per line")
+	_ = deleteCmd.MarkFlagFilename("file")
+	deleteCmd.Flags().BoolVarP(&deleteYes, "yes", "y", false, "Delete without asking for confirmation")
+	deleteCmd.Flags().BoolVar(&deleteYes, "force", false, "Same as --yes")
+}
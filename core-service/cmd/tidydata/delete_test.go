@@ -0,0 +1,161 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestDeleteDocuments(t *testing.T) {
+	ids := []string{"a", "b", "c", "d", "e", "f", "g", "h"}
+
+	var running, maxRunning int32
+	results := deleteDocuments(context.Background(), ids, 3, func(id string) error {
+		n := atomic.AddInt32(&running, 1)
+		for {
+			current := atomic.LoadInt32(&maxRunning)
+			if n <= current || atomic.CompareAndSwapInt32(&maxRunning, current, n) {
+				break
+			}
+		}
+		time.Sleep(5 * time.Millisecond)
+		atomic.AddInt32(&running, -1)
+		if id == "c" {
+			return fmt.Errorf("boom")
+		}
+		return nil
+	})
+
+	if maxRunning > 3 {
+		t.Errorf("Expected at most 3 concurrent deletions, got %d", maxRunning)
+	}
+	if len(results) != len(ids) {
+		t.Fatalf("Expected %d results, got %d", len(ids), len(results))
+	}
+	for i, result := range results {
+		if result.ID != ids[i] {
+			t.Errorf("Expected result %d to be %s, got %s", i, ids[i], result.ID)
+		}
+		if (result.Err != nil) != (result.ID == "c") {
+			t.Errorf("Unexpected error state for %s: %v", result.ID, result.Err)
+		}
+	}
+}
+
+func TestDeleteDocumentsCancelled(t *testing.T) {
+	ids := []string{"a", "b", "c", "d", "e"}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var calls int32
+	results := deleteDocuments(ctx, ids, 1, func(id string) error {
+		if atomic.AddInt32(&calls, 1) == 2 {
+			cancel()
+		}
+		return nil
+	})
+
+	if calls != 2 {
+		t.Errorf("Expected deletions to stop after cancel, got %d calls", calls)
+	}
+	if len(results) != 2 || results[1].ID != "b" {
+		t.Errorf("Expected results for a and b only, got %+v", results)
+	}
+	if err := batchInterrupted(ctx, len(results), len(ids), "documents"); !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected interrupted error, got %v", err)
+	}
+}
+
+func TestDeleteCommand(t *testing.T) {
+	var mu sync.Mutex
+	var deleted []string
+	useTestServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodDelete {
+			t.Errorf("Expected DELETE request, got %s", r.Method)
+		}
+		id := strings.TrimPrefix(r.URL.Path, "/documents/")
+		if id == "missing" {
+			http.NotFound(w, r)
+			return
+		}
+		mu.Lock()
+		deleted = append(deleted, id)
+		mu.Unlock()
+		w.WriteHeader(http.StatusNoContent)
+	}))
+
+	idFile := filepath.Join(t.TempDir(), "ids.txt")
+	if err := os.WriteFile(idFile, []byte("doc3\n\n# old drafts\ndoc4\ndoc1\n"), 0o644); err != nil {
+		t.Fatalf("Error writing fixture: %v", err)
+	}
+
+	tests := []struct {
+		name           string
+		args           []string
+		expectError    bool
+		expectedCount  int
+		expectedOutput []string
+	}{
+		{
+			name:           "multiple IDs",
+			args:           []string{"delete", "doc1", "doc2", "--yes"},
+			expectedCount:  2,
+			expectedOutput: []string{"doc1: deleted", "doc2: deleted", "Deleted 2 of 2 documents"},
+		},
+		{
+			name:           "IDs from file are merged and deduplicated",
+			args:           []string{"delete", "doc1", "--file", idFile, "-y"},
+			expectedCount:  3,
+			expectedOutput: []string{"doc3: deleted", "doc4: deleted", "Deleted 3 of 3 documents"},
+		},
+		{
+			name:           "partial failure",
+			args:           []string{"delete", "doc1", "missing", "doc2", "--yes"},
+			expectError:    true,
+			expectedCount:  2,
+			expectedOutput: []string{"doc1: deleted", "doc2: deleted", "Deleted 2 of 3 documents"},
+		},
+		{
+			name:           "force skips confirmation",
+			args:           []string{"delete", "doc2", "--force"},
+			expectedCount:  1,
+			expectedOutput: []string{"doc2: deleted"},
+		},
+		{
+			name:        "no IDs",
+			args:        []string{"delete", "--yes"},
+			expectError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			deleted = nil
+			var err error
+			out := captureOutput(t, func() { err = executeCommand(t, tt.args...) })
+
+			if tt.expectError && err == nil {
+				t.Error("Expected error but got none")
+			}
+			if !tt.expectError && err != nil {
+				t.Errorf("Unexpected error: %v", err)
+			}
+			if len(deleted) != tt.expectedCount {
+				t.Errorf("Expected %d deletions, got %d (%v)", tt.expectedCount, len(deleted), deleted)
+			}
+			for _, expected := range tt.expectedOutput {
+				if !strings.Contains(out, expected) {
+					t.Errorf("Expected output to contain %q, got:\n%s", expected, out)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,19 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+)
+
+// printDryRun describes a request that --dry-run prevented from being sent.
+func printDryRun(method, path string, details ...string) {
+	fmt.Printf("[dry-run] %s %s%s\n", method, mlClient.BaseURL(), path)
+	for _, detail := range details {
+		fmt.Printf("  %s\n", detail)
+	}
+}
+
+func dryRunAddDocument(text string) (string, error) {
+	printDryRun(http.MethodPost, "/documents", fmt.Sprintf("text length: %d characters", len(text)))
+	return "(dry run)", nil
+}
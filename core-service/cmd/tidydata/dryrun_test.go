@@ -0,0 +1,50 @@
+package main
+
+import (
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+)
+
+func TestDryRunMakesNoHTTPCalls(t *testing.T) {
+	var requests int32
+	useTestServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+
+	dir := t.TempDir()
+	textFile := filepath.Join(dir, "note.txt")
+	if err := os.WriteFile(textFile, []byte("some note"), 0o644); err != nil {
+		t.Fatalf("Error writing fixture: %v", err)
+	}
+	imageFile := filepath.Join(dir, "photo.png")
+	if err := os.WriteFile(imageFile, []byte("not really a png"), 0o644); err != nil {
+		t.Fatalf("Error writing fixture: %v", err)
+	}
+
+	tests := []struct {
+		name string
+		args []string
+	}{
+		{name: "add text", args: []string{"--dry-run", "add", "hello world"}},
+		{name: "add file", args: []string{"--dry-run", "add", "--file", textFile}},
+		{name: "add multiple files", args: []string{"--dry-run", "add", "--file", textFile, "--file", textFile}},
+		{name: "add chunked", args: []string{"--dry-run", "add", "--chunk-size", "10", "First. Second sentence here."}},
+		{name: "image add", args: []string{"--dry-run", "image", "add", imageFile}},
+		{name: "update", args: []string{"--dry-run", "update", "doc123", "new text"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := executeCommand(t, tt.args...); err != nil {
+				t.Errorf("Unexpected error: %v", err)
+			}
+			if n := atomic.LoadInt32(&requests); n != 0 {
+				t.Errorf("Expected no HTTP requests in dry-run mode, got %d", n)
+			}
+		})
+	}
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+	"text/template"
+
+	"github.com/berkayuckac/tidydata/internal/api"
+)
+
+// templateEscapes turns the escapes people type in shell-quoted templates
+// into the characters they mean.
+var templateEscapes = strings.NewReplacer(`\t`, "\t", `\n`, "\n")
+
+// parseResultTemplate parses a --format template and checks it against an
+// empty result, so unknown fields are reported before any request is sent.
+func parseResultTemplate(text string) (*template.Template, error) {
+	tmpl, err := template.New("format").Option("missingkey=zero").Parse(templateEscapes.Replace(text))
+	if err == nil {
+		err = tmpl.Execute(io.Discard, api.UnifiedSearchResult{Explanation: &api.SearchExplanation{}})
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid --format template: %w\navailable fields: %s",
+			err, strings.Join(templateFields(reflect.TypeOf(api.UnifiedSearchResult{}), ""), ", "))
+	}
+	return tmpl, nil
+}
+
+// templateFields lists the field paths of t as they are written in a template.
+func templateFields(t reflect.Type, prefix string) []string {
+	if t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	var fields []string
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		path := prefix + "." + field.Name
+		if field.Type.Kind() == reflect.Struct ||
+			(field.Type.Kind() == reflect.Pointer && field.Type.Elem().Kind() == reflect.Struct) {
+			fields = append(fields, templateFields(field.Type, path)...)
+			continue
+		}
+		fields = append(fields, path)
+	}
+	return fields
+}
+
+// printTemplateResults renders each result through tmpl on its own line.
+func printTemplateResults(w io.Writer, tmpl *template.Template, results []api.UnifiedSearchResult) error {
+	for _, result := range results {
+		if err := tmpl.Execute(w, result); err != nil {
+			return fmt.Errorf("error rendering result %s: %w", result.ID, err)
+		}
+		fmt.Fprintln(w)
+	}
+	return nil
+}
@@ -0,0 +1,219 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const imageAddWorkers = 4
+
+var imageAddDir string
+
+// imageSidecarExtensions are the files that may describe an image; they are
+// not counted as skipped when walking a directory.
+var imageSidecarExtensions = map[string]bool{".txt": true, ".json": true}
+
+type imageFileResult struct {
+	Path    string
+	ImageID string
+	Err     error
+}
+
+func addImageDir(ctx context.Context, dir string) error {
+	paths, skipped, err := findImages(dir)
+	if err != nil {
+		return err
+	}
+	if len(paths) == 0 {
+		return fmt.Errorf("no images found in %s", dir)
+	}
+
+	if dryRun {
+		for _, path := range paths {
+			description, tags, err := readImageSidecar(path)
+			if err != nil {
+				return err
+			}
+			tags = imageTags(tags)
+			details := []string{fmt.Sprintf("filename: %s", filepath.Base(path))}
+			if description != "" {
+				details = append(details, fmt.Sprintf("description: %s", description))
+			}
+			if len(tags) > 0 {
+				details = append(details, fmt.Sprintf("tags: %s", strings.Join(tags, ", ")))
+			}
+			printDryRun(http.MethodPost, "/images", details...)
+		}
+		return nil
+	}
+
+	results := addImages(ctx, paths, imageAddWorkers, func(path string) (string, error) {
+		return addImageFile(ctx, path)
+	})
+	summaryErr := printAddImagesSummary(results, skipped)
+	if err := batchInterrupted(ctx, len(results), len(paths), "images"); err != nil {
+		return err
+	}
+	return summaryErr
+}
+
+// findImages returns the files under dir whose content is an image, in
+// lexical order, and how many other files it skipped. Hidden files and
+// directories are left out.
+func findImages(dir string) ([]string, int, error) {
+	var paths []string
+	skipped := 0
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if path != dir && strings.HasPrefix(d.Name(), ".") {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if d.IsDir() {
+			return nil
+		}
+		if isImageFile(path) {
+			paths = append(paths, path)
+		} else if !imageSidecarExtensions[strings.ToLower(filepath.Ext(path))] {
+			skipped++
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, 0, fmt.Errorf("error reading directory: %w", err)
+	}
+	return paths, skipped, nil
+}
+
+// isImageFile sniffs the start of path. Files that cannot be read count as
+// images, so the error is reported when they are added.
+func isImageFile(path string) bool {
+	f, err := os.Open(path)
+	if err != nil {
+		return true
+	}
+	defer f.Close()
+
+	buf := make([]byte, 512)
+	n, err := io.ReadFull(f, buf)
+	if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) && !errors.Is(err, io.EOF) {
+		return true
+	}
+	return strings.HasPrefix(http.DetectContentType(buf[:n]), "image/")
+}
+
+// readImageSidecar reads the description and tags of the image at path from
+// a .json file with the same name, or the description alone from a .txt file.
+func readImageSidecar(path string) (string, []string, error) {
+	base := strings.TrimSuffix(path, filepath.Ext(path))
+
+	data, err := os.ReadFile(base + ".json")
+	if err == nil {
+		var sidecar struct {
+			Description string   `json:"description"`
+			Tags        []string `json:"tags"`
+		}
+		if err := json.Unmarshal(data, &sidecar); err != nil {
+			return "", nil, fmt.Errorf("error parsing %s: %w", base+".json", err)
+		}
+		return sidecar.Description, sidecar.Tags, nil
+	}
+	if !errors.Is(err, fs.ErrNotExist) {
+		return "", nil, fmt.Errorf("error reading sidecar file: %w", err)
+	}
+
+	data, err = os.ReadFile(base + ".txt")
+	if err == nil {
+		return strings.TrimSpace(string(data)), nil, nil
+	}
+	if !errors.Is(err, fs.ErrNotExist) {
+		return "", nil, fmt.Errorf("error reading sidecar file: %w", err)
+	}
+	return "", nil, nil
+}
+
+func addImageFile(ctx context.Context, path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("error reading image file: %w", err)
+	}
+	description, tags, err := readImageSidecar(path)
+	if err != nil {
+		return "", err
+	}
+
+	resp, err := mlClient.AddImageWithMetadata(ctx, data, filepath.Base(path), imageContentType(path, data), description, imageTags(tags))
+	if err != nil {
+		return "", fmt.Errorf("error adding image: %w", err)
+	}
+	return resp.ImageID, nil
+}
+
+// addImages calls add for every path using at most workers concurrent
+// calls, continuing past failures. Results are returned in the same order
+// as paths. Once ctx is cancelled no more calls are started, and only the
+// paths already handed out have results.
+func addImages(ctx context.Context, paths []string, workers int, add func(path string) (string, error)) []imageFileResult {
+	return mapConcurrent(ctx, paths, workers, func(path string) imageFileResult {
+		id, err := add(path)
+		return imageFileResult{Path: path, ImageID: id, Err: err}
+	})
+}
+
+type imageFileOutput struct {
+	Path    string `json:"path"`
+	ImageID string `json:"image_id,omitempty"`
+	Error   string `json:"error,omitempty"`
+}
+
+func printAddImagesSummary(results []imageFileResult, skipped int) error {
+	failed := 0
+	outputs := make([]imageFileOutput, 0, len(results))
+	for _, result := range results {
+		if result.Err != nil {
+			failed++
+			fmt.Fprintf(os.Stderr, "%s: %v\n", result.Path, result.Err)
+			outputs = append(outputs, imageFileOutput{Path: result.Path, Error: result.Err.Error()})
+			continue
+		}
+		outputs = append(outputs, imageFileOutput{Path: result.Path, ImageID: result.ImageID})
+		switch {
+		case outputFormat == "json":
+		case quiet:
+			fmt.Println(result.ImageID)
+		default:
+			fmt.Printf("%s: %s\n", result.Path, result.ImageID)
+		}
+	}
+
+	if outputFormat == "json" {
+		if err := printJSON(outputs); err != nil {
+			return err
+		}
+	}
+	printInfo("\nAdded %d of %d images\n", len(results)-failed, len(results))
+	if skipped > 0 {
+		printInfo("Skipped %d files that are not images\n", skipped)
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d images failed", failed, len(results))
+	}
+	return nil
+}
+
+func init() {
+	imageAddCmd.Flags().StringVar(&imageAddDir, "dir", "", "Add every image in this directory and its subdirectories")
+	_ = imageAddCmd.MarkFlagDirname("dir")
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/berkayuckac/tidydata/internal/api"
+)
+
+var imageSortBy string
+
+// imageComparators order image results by --sort-by. Scores sort highest
+// first; filenames sort alphabetically ignoring case, with exact byte order
+// breaking ties.
+var imageComparators = map[string]func(a, b api.ImageResult) int{
+	"score": func(a, b api.ImageResult) int {
+		return cmp.Compare(b.Score, a.Score)
+	},
+	"filename": func(a, b api.ImageResult) int {
+		return compareFilenames(a.Metadata.Filename, b.Metadata.Filename)
+	},
+}
+
+func compareFilenames(a, b string) int {
+	if c := strings.Compare(strings.ToLower(a), strings.ToLower(b)); c != 0 {
+		return c
+	}
+	return strings.Compare(a, b)
+}
+
+func validateImageSortBy() error {
+	if _, ok := imageComparators[imageSortBy]; !ok {
+		return fmt.Errorf("--sort-by must be score or filename, got %q", imageSortBy)
+	}
+	return nil
+}
+
+// sortImageResults sorts results in place by field, keeping the order of
+// results that compare equal.
+func sortImageResults(results []api.ImageResult, field string) {
+	slices.SortStableFunc(results, imageComparators[field])
+}
+
+// sortImageSummaries sorts listed images by field. Listed images have no
+// score, so sorting by score keeps the order the server returned.
+func sortImageSummaries(images []api.ImageSummary, field string) {
+	if field != "filename" {
+		return
+	}
+	slices.SortStableFunc(images, func(a, b api.ImageSummary) int {
+		return compareFilenames(a.Metadata.Filename, b.Metadata.Filename)
+	})
+}
+
+func init() {
+	imageSimilarCmd.Flags().StringVar(&imageSortBy, "sort-by", "score", "Order similar images by score (highest first) or filename")
+	imageListCmd.Flags().StringVar(&imageSortBy, "sort-by", "score", "Order images by filename, or keep the server's order with score")
+}
@@ -0,0 +1,759 @@
+package main
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/berkayuckac/tidydata/internal/api"
+)
+
+func TestImageSimilarFlags(t *testing.T) {
+	var limit, threshold string
+	useTestServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseMultipartForm(1 << 20); err != nil {
+			t.Errorf("Error parsing multipart body: %v", err)
+		}
+		limit = r.FormValue("limit")
+		threshold = r.FormValue("score_threshold")
+		w.Write([]byte(`{"query_image": "query_image", "results": []}`))
+	}))
+
+	imagePath := filepath.Join(t.TempDir(), "photo.jpg")
+	if err := os.WriteFile(imagePath, []byte("jpeg bytes"), 0o644); err != nil {
+		t.Fatalf("Error writing fixture: %v", err)
+	}
+
+	tests := []struct {
+		name              string
+		args              []string
+		expectError       bool
+		expectedLimit     string
+		expectedThreshold string
+	}{
+		{name: "defaults", args: []string{"image", "similar", imagePath}, expectedLimit: "5", expectedThreshold: "0.300000"},
+		{name: "custom", args: []string{"image", "similar", imagePath, "--limit", "20", "--threshold", "0.6"}, expectedLimit: "20", expectedThreshold: "0.600000"},
+		{name: "limit too large", args: []string{"image", "similar", imagePath, "--limit", "51"}, expectError: true},
+		{name: "limit zero", args: []string{"image", "similar", imagePath, "--limit", "0"}, expectError: true},
+		{name: "threshold out of range", args: []string{"image", "similar", imagePath, "--threshold", "1.5"}, expectError: true},
+		{name: "negative threshold", args: []string{"image", "similar", imagePath, "--threshold", "-0.1"}, expectError: true},
+		{name: "high threshold still runs", args: []string{"image", "similar", imagePath, "--threshold", "0.95"}, expectedLimit: "5", expectedThreshold: "0.950000"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			limit, threshold = "", ""
+			var err error
+			captureOutput(t, func() { err = executeCommand(t, tt.args...) })
+
+			if tt.expectError {
+				if err == nil {
+					t.Error("Expected error but got none")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if limit != tt.expectedLimit {
+				t.Errorf("Expected limit %s, got %s", tt.expectedLimit, limit)
+			}
+			if threshold != tt.expectedThreshold {
+				t.Errorf("Expected threshold %s, got %s", tt.expectedThreshold, threshold)
+			}
+		})
+	}
+}
+
+// pngFixture is a 1x1 transparent PNG.
+var pngFixture = []byte{
+	0x89, 0x50, 0x4e, 0x47, 0x0d, 0x0a, 0x1a, 0x0a, 0x00, 0x00, 0x00, 0x0d,
+	0x49, 0x48, 0x44, 0x52, 0x00, 0x00, 0x00, 0x01, 0x00, 0x00, 0x00, 0x01,
+	0x08, 0x06, 0x00, 0x00, 0x00, 0x1f, 0x15, 0xc4, 0x89, 0x00, 0x00, 0x00,
+	0x0d, 0x49, 0x44, 0x41, 0x54, 0x78, 0x9c, 0x63, 0x00, 0x01, 0x00, 0x00,
+	0x05, 0x00, 0x01, 0x0d, 0x0a, 0x2d, 0xb4, 0x00, 0x00, 0x00, 0x00, 0x49,
+	0x45, 0x4e, 0x44, 0xae, 0x42, 0x60, 0x82,
+}
+
+func TestImageGet(t *testing.T) {
+	useTestServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/images/img1" {
+			http.NotFound(w, r)
+			return
+		}
+		json.NewEncoder(w).Encode(api.ImageDetail{
+			AddImageResponse: api.AddImageResponse{
+				ImageID:  "img1",
+				Metadata: api.ImageMetadata{Filename: "cat.png", ContentType: "image/png", Description: "a cat"},
+			},
+			ImageData: base64.StdEncoding.EncodeToString(pngFixture),
+		})
+	}))
+
+	dir := t.TempDir()
+	tests := []struct {
+		name           string
+		args           []string
+		expectError    bool
+		expectedPath   string
+		expectedOutput string
+	}{
+		{name: "metadata by default", args: []string{"image", "get", "img1"}, expectedOutput: "Filename: cat.png\nType: image/png\nDescription: a cat\nSize: 67 bytes\n"},
+		{name: "metadata json", args: []string{"image", "get", "img1", "-o", "json"}, expectedOutput: `"size": 67`},
+		{name: "directory uses original filename", args: []string{"image", "get", "img1", "--save-to", dir}, expectedPath: filepath.Join(dir, "cat.png")},
+		{name: "extension from content type", args: []string{"image", "get", "img1", "--save-to", filepath.Join(dir, "kitten")}, expectedPath: filepath.Join(dir, "kitten.png")},
+		{name: "explicit extension kept", args: []string{"image", "get", "img1", "--save-to", filepath.Join(dir, "cat.bin")}, expectedPath: filepath.Join(dir, "cat.bin")},
+		{name: "out alias", args: []string{"image", "get", "img1", "--out", filepath.Join(dir, "out.png")}, expectedPath: filepath.Join(dir, "out.png")},
+		{name: "not found", args: []string{"image", "get", "missing", "--save-to", dir}, expectError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resetFlags(rootCmd)
+			var err error
+			out := captureOutput(t, func() { err = executeCommand(t, tt.args...) })
+
+			if tt.expectError {
+				if err == nil || !strings.Contains(err.Error(), "no image found with ID: missing") {
+					t.Errorf("Expected not-found error, got %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if !strings.Contains(out, tt.expectedOutput) {
+				t.Errorf("Expected output to contain %q, got:\n%s", tt.expectedOutput, out)
+			}
+			if tt.expectedPath == "" {
+				return
+			}
+			data, err := os.ReadFile(tt.expectedPath)
+			if err != nil {
+				t.Fatalf("Expected image at %s: %v", tt.expectedPath, err)
+			}
+			if !bytes.Equal(data, pngFixture) {
+				t.Errorf("Expected decoded PNG fixture, got %d bytes", len(data))
+			}
+		})
+	}
+}
+
+func TestImageExtension(t *testing.T) {
+	jpegData := []byte{0xff, 0xd8, 0xff, 0xe0, 0x00, 0x10, 'J', 'F', 'I', 'F', 0x00}
+
+	tests := []struct {
+		name        string
+		contentType string
+		data        []byte
+		expected    string
+	}{
+		{name: "png", contentType: "image/png", expected: ".png"},
+		{name: "jpeg", contentType: "image/jpeg", expected: ".jpg"},
+		{name: "gif", contentType: "image/gif", expected: ".gif"},
+		{name: "webp", contentType: "image/webp", expected: ".webp"},
+		{name: "parameters ignored", contentType: "image/png; name=cat", expected: ".png"},
+		{name: "content type wins over data", contentType: "image/png", data: jpegData, expected: ".png"},
+		{name: "sniffed when missing", data: pngFixture, expected: ".png"},
+		{name: "sniffed when not an image type", contentType: "application/octet-stream", data: jpegData, expected: ".jpg"},
+		{name: "sniffed when unknown", contentType: "image/x-unknown", data: pngFixture, expected: ".png"},
+		{name: "unknown", contentType: "image/x-unknown", data: []byte("not an image"), expected: ".bin"},
+		{name: "nothing known", expected: ".bin"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := imageExtension(tt.contentType, tt.data); got != tt.expected {
+				t.Errorf("Expected %q, got %q", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestImageSavePath(t *testing.T) {
+	dir := t.TempDir()
+
+	tests := []struct {
+		name     string
+		saveTo   string
+		metadata api.ImageMetadata
+		expected string
+	}{
+		{name: "original filename", saveTo: dir, metadata: api.ImageMetadata{Filename: "cat.png", ContentType: "image/png"}, expected: filepath.Join(dir, "cat.png")},
+		{name: "equivalent extension kept", saveTo: dir, metadata: api.ImageMetadata{Filename: "cat.jpeg", ContentType: "image/jpeg"}, expected: filepath.Join(dir, "cat.jpeg")},
+		{name: "mismatched extension replaced", saveTo: dir, metadata: api.ImageMetadata{Filename: "photo.jpg", ContentType: "image/png"}, expected: filepath.Join(dir, "photo.png")},
+		{name: "mismatch found by sniffing", saveTo: dir, metadata: api.ImageMetadata{Filename: "photo.jpg"}, expected: filepath.Join(dir, "photo.png")},
+		{name: "non-image extension kept", saveTo: dir, metadata: api.ImageMetadata{Filename: "cat.v2", ContentType: "image/png"}, expected: filepath.Join(dir, "cat.v2")},
+		{name: "id without filename", saveTo: dir, metadata: api.ImageMetadata{ContentType: "image/gif"}, expected: filepath.Join(dir, "img1.gif")},
+		{name: "explicit path kept", saveTo: filepath.Join(dir, "out.jpg"), metadata: api.ImageMetadata{ContentType: "image/png"}, expected: filepath.Join(dir, "out.jpg")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := imageSavePath(tt.saveTo, "img1", tt.metadata, pngFixture); got != tt.expected {
+				t.Errorf("Expected %s, got %s", tt.expected, got)
+			}
+		})
+	}
+
+	if got := imageSavePath(filepath.Join(dir, "blob"), "img1", api.ImageMetadata{}, []byte("data")); got != filepath.Join(dir, "blob.bin") {
+		t.Errorf("Expected .bin fallback, got %s", got)
+	}
+}
+
+func useImageListServer(t *testing.T, total int, requests *[]string) {
+	t.Helper()
+	useTestServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		*requests = append(*requests, r.URL.RawQuery)
+		offset, _ := strconv.Atoi(r.URL.Query().Get("offset"))
+		limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+
+		var images []api.ImageSummary
+		for i := offset; i < total && i < offset+limit; i++ {
+			images = append(images, api.ImageSummary{
+				ID:       fmt.Sprintf("img%d", i),
+				Metadata: api.ImageMetadata{Filename: fmt.Sprintf("photo%d.jpg", i), Description: "desc"},
+			})
+		}
+		json.NewEncoder(w).Encode(api.ListImagesResponse{Images: images, Total: total})
+	}))
+}
+
+func TestImageListAll(t *testing.T) {
+	var requests []string
+	useImageListServer(t, 5, &requests)
+
+	var err error
+	out := captureOutput(t, func() { err = executeCommand(t, "image", "list", "--all", "--limit", "2", "-q") })
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if out != "img0\nimg1\nimg2\nimg3\nimg4\n" {
+		t.Errorf("Expected all five IDs, got %q", out)
+	}
+	expected := []string{"limit=2&offset=0", "limit=2&offset=2", "limit=2&offset=4"}
+	if !reflect.DeepEqual(requests, expected) {
+		t.Errorf("Expected requests %v, got %v", expected, requests)
+	}
+}
+
+func TestImageListPage(t *testing.T) {
+	var requests []string
+	useImageListServer(t, 5, &requests)
+
+	tests := []struct {
+		name         string
+		args         []string
+		expectMore   bool
+		expectedRows []string
+	}{
+		{name: "full page", args: []string{"image", "list", "--limit", "2"}, expectMore: true, expectedRows: []string{"img0", "img1"}},
+		{name: "last page", args: []string{"image", "list", "--limit", "2", "--offset", "4"}, expectedRows: []string{"img4"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var err error
+			out := captureOutput(t, func() { err = executeCommand(t, tt.args...) })
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if !strings.HasPrefix(out, "ID") || !strings.Contains(out, "FILENAME") {
+				t.Errorf("Expected table header, got:\n%s", out)
+			}
+			for _, id := range tt.expectedRows {
+				if !strings.Contains(out, id+"  ") {
+					t.Errorf("Expected row for %s, got:\n%s", id, out)
+				}
+			}
+			if hasMore := strings.Contains(out, "More images may be available"); hasMore != tt.expectMore {
+				t.Errorf("Expected more hint %v, got:\n%s", tt.expectMore, out)
+			}
+		})
+	}
+}
+
+func TestImageListJSON(t *testing.T) {
+	var requests []string
+	useImageListServer(t, 1, &requests)
+
+	var err error
+	out := captureOutput(t, func() { err = executeCommand(t, "image", "list", "-o", "json") })
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	var images []api.ImageSummary
+	if err := json.Unmarshal([]byte(out), &images); err != nil {
+		t.Fatalf("Expected valid JSON, got %q: %v", out, err)
+	}
+	if len(images) != 1 || images[0].Metadata.Filename != "photo0.jpg" {
+		t.Errorf("Unexpected images: %+v", images)
+	}
+}
+
+func TestImageDelete(t *testing.T) {
+	var deleted []string
+	useTestServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodDelete {
+			t.Errorf("Expected DELETE request, got %s", r.Method)
+		}
+		if r.URL.Path == "/images/missing" {
+			http.NotFound(w, r)
+			return
+		}
+		deleted = append(deleted, strings.TrimPrefix(r.URL.Path, "/images/"))
+		w.WriteHeader(http.StatusNoContent)
+	}))
+
+	var err error
+	out := captureOutput(t, func() { err = executeCommand(t, "image", "delete", "img1", "--yes") })
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(deleted, []string{"img1"}) {
+		t.Errorf("Expected img1 to be deleted, got %v", deleted)
+	}
+	if out != "Successfully deleted image with ID: img1\n" {
+		t.Errorf("Unexpected output %q", out)
+	}
+
+	captureOutput(t, func() { err = executeCommand(t, "image", "delete", "missing", "-y") })
+	if err == nil || err.Error() != "no image found with ID: missing" {
+		t.Errorf("Expected not-found error, got %v", err)
+	}
+}
+
+func TestImageContentType(t *testing.T) {
+	png := []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR")
+
+	tests := []struct {
+		name     string
+		path     string
+		data     []byte
+		expected string
+	}{
+		{name: "sniffed type wins over extension", path: "photo.jpg", data: png, expected: "image/png"},
+		{name: "extension when data is not recognised", path: "photo.jpg", data: []byte("not really an image"), expected: "image/jpeg"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := imageContentType(tt.path, tt.data); got != tt.expected {
+				t.Errorf("Expected %s, got %s", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestPrintImageTable(t *testing.T) {
+	added := time.Date(2025, 1, 31, 12, 30, 0, 0, time.Local)
+	var out strings.Builder
+	printImageTable(&out, []api.ImageSummary{
+		{ID: "img1", Metadata: api.ImageMetadata{Filename: "cat.jpg", ContentType: "image/jpeg", Description: "a cat"}, AddedAt: float64(added.Unix())},
+		{ID: "img22", Metadata: api.ImageMetadata{Filename: "dog.png", ContentType: "image/png"}},
+	})
+
+	lines := strings.Split(strings.TrimRight(out.String(), "\n"), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("Expected header and 2 rows, got:\n%s", out.String())
+	}
+	if fields := strings.Fields(lines[0]); !reflect.DeepEqual(fields, []string{"ID", "FILENAME", "TYPE", "ADDED", "DESCRIPTION"}) {
+		t.Errorf("Unexpected header %q", lines[0])
+	}
+	if !strings.Contains(lines[1], "2025-01-31 12:30") || !strings.Contains(lines[2], " - ") {
+		t.Errorf("Expected the added time or a dash, got:\n%s", out.String())
+	}
+	if strings.Index(lines[1], "cat.jpg") != strings.Index(lines[2], "dog.png") {
+		t.Errorf("Expected aligned columns, got:\n%s", out.String())
+	}
+}
+
+func TestImageSimilarNoResults(t *testing.T) {
+	useTestServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"query_image": "query_image"}`))
+	}))
+
+	imagePath := filepath.Join(t.TempDir(), "photo.jpg")
+	if err := os.WriteFile(imagePath, []byte("jpeg bytes"), 0o644); err != nil {
+		t.Fatalf("Error writing fixture: %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		args     []string
+		expected string
+	}{
+		{name: "text", args: []string{"image", "similar", imagePath}, expected: "No similar images found"},
+		{name: "json", args: []string{"image", "similar", imagePath, "-o", "json"}, expected: `"results": []`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resetFlags(rootCmd)
+			var err error
+			out := captureOutput(t, func() { err = executeCommand(t, tt.args...) })
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if !strings.Contains(out, tt.expected) {
+				t.Errorf("Expected output to contain %q, got:\n%s", tt.expected, out)
+			}
+		})
+	}
+}
+
+func TestImageAddDir(t *testing.T) {
+	type upload struct {
+		description string
+		tags        []string
+	}
+	var mu sync.Mutex
+	uploads := map[string]upload{}
+	useTestServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseMultipartForm(1 << 20); err != nil {
+			t.Errorf("Error parsing multipart body: %v", err)
+		}
+		_, header, err := r.FormFile("image")
+		if err != nil {
+			t.Fatalf("Expected an image file: %v", err)
+		}
+		mu.Lock()
+		uploads[header.Filename] = upload{r.FormValue("description"), r.MultipartForm.Value["tags[]"]}
+		mu.Unlock()
+		if header.Filename == "broken.png" {
+			http.Error(w, "cannot embed", http.StatusInternalServerError)
+			return
+		}
+		fmt.Fprintf(w, `{"image_id": "id-%s", "status": "stored"}`, header.Filename)
+	}))
+
+	dir := t.TempDir()
+	files := map[string][]byte{
+		"cat.png":          pngFixture,
+		"cat.txt":          []byte("A cat\n"),
+		"broken.png":       pngFixture,
+		"notes.md":         []byte("# not an image"),
+		"fake.jpg":         []byte("text with an image extension"),
+		".hidden.png":      pngFixture,
+		"nested/dog.png":   pngFixture,
+		"nested/dog.json":  []byte(`{"description": "A dog", "tags": ["pet", "dog"]}`),
+		".git/ignored.png": pngFixture,
+	}
+	for name, data := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatalf("Error creating directory: %v", err)
+		}
+		if err := os.WriteFile(path, data, 0o644); err != nil {
+			t.Fatalf("Error writing fixture: %v", err)
+		}
+	}
+
+	resetFlags(rootCmd)
+	var err error
+	out := captureOutput(t, func() { err = executeCommand(t, "image", "add", "--dir", dir) })
+
+	if err == nil || !strings.Contains(err.Error(), "1 of 3 images failed") {
+		t.Errorf("Expected 1 of 3 images to fail, got %v", err)
+	}
+	expectedUploads := map[string]upload{
+		"cat.png":    {description: "A cat"},
+		"broken.png": {},
+		"dog.png":    {description: "A dog", tags: []string{"pet", "dog"}},
+	}
+	if !reflect.DeepEqual(uploads, expectedUploads) {
+		t.Errorf("Expected uploads %v, got %v", expectedUploads, uploads)
+	}
+	for _, expected := range []string{
+		filepath.Join(dir, "cat.png") + ": id-cat.png",
+		filepath.Join(dir, "nested", "dog.png") + ": id-dog.png",
+		"Added 2 of 3 images",
+		"Skipped 2 files that are not images",
+	} {
+		if !strings.Contains(out, expected) {
+			t.Errorf("Expected output to contain %q, got:\n%s", expected, out)
+		}
+	}
+
+	textOnly := t.TempDir()
+	if err := os.WriteFile(filepath.Join(textOnly, "notes.txt"), []byte("hello"), 0o644); err != nil {
+		t.Fatalf("Error writing fixture: %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		args     []string
+		expected string
+	}{
+		{name: "no images", args: []string{"image", "add", "--dir", textOnly}, expected: "no images found in " + textOnly},
+		{name: "missing directory", args: []string{"image", "add", "--dir", filepath.Join(dir, "missing")}, expected: "error reading directory"},
+		{name: "path and dir", args: []string{"image", "add", filepath.Join(dir, "cat.png"), "--dir", dir}, expected: "not several"},
+		{name: "neither", args: []string{"image", "add"}, expected: "either provide an image path"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resetFlags(rootCmd)
+			var err error
+			captureOutput(t, func() { err = executeCommand(t, tt.args...) })
+			if err == nil || !strings.Contains(err.Error(), tt.expected) {
+				t.Errorf("Expected error containing %q, got %v", tt.expected, err)
+			}
+		})
+	}
+}
+
+func TestSortImageResults(t *testing.T) {
+	results := func() []api.ImageResult {
+		return []api.ImageResult{
+			{ID: "a", Score: 0.5, Metadata: api.ImageMetadata{Filename: "zebra.png"}},
+			{ID: "b", Score: 0.9, Metadata: api.ImageMetadata{Filename: "Cat.png"}},
+			{ID: "c", Score: 0.5, Metadata: api.ImageMetadata{Filename: "apple.png"}},
+			{ID: "d", Score: 0.7, Metadata: api.ImageMetadata{Filename: "cat.png"}},
+			{ID: "e", Score: 0.7, Metadata: api.ImageMetadata{Filename: "apple.png"}},
+		}
+	}
+
+	tests := []struct {
+		name     string
+		field    string
+		expected []string
+	}{
+		// Equal scores keep their original order.
+		{name: "score", field: "score", expected: []string{"b", "d", "e", "a", "c"}},
+		// Case is ignored except to break ties; equal names keep their order.
+		{name: "filename", field: "filename", expected: []string{"c", "e", "b", "d", "a"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sorted := results()
+			sortImageResults(sorted, tt.field)
+			var ids []string
+			for _, r := range sorted {
+				ids = append(ids, r.ID)
+			}
+			if !reflect.DeepEqual(ids, tt.expected) {
+				t.Errorf("Expected order %v, got %v", tt.expected, ids)
+			}
+		})
+	}
+}
+
+func TestImageListSortBy(t *testing.T) {
+	useTestServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(api.ListImagesResponse{Images: []api.ImageSummary{
+			{ID: "img1", Metadata: api.ImageMetadata{Filename: "b.png"}},
+			{ID: "img2", Metadata: api.ImageMetadata{Filename: "a.png"}},
+			{ID: "img3", Metadata: api.ImageMetadata{Filename: "C.png"}},
+		}})
+	}))
+
+	tests := []struct {
+		name        string
+		args        []string
+		expected    string
+		expectError bool
+	}{
+		{name: "server order by default", args: []string{"image", "list", "-q"}, expected: "img1\nimg2\nimg3\n"},
+		{name: "filename", args: []string{"image", "list", "-q", "--sort-by", "filename"}, expected: "img2\nimg1\nimg3\n"},
+		{name: "invalid", args: []string{"image", "list", "--sort-by", "size"}, expectError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resetFlags(rootCmd)
+			var err error
+			out := captureOutput(t, func() { err = executeCommand(t, tt.args...) })
+
+			if tt.expectError {
+				if err == nil || !strings.Contains(err.Error(), "--sort-by must be score or filename") {
+					t.Errorf("Expected --sort-by error, got %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if out != tt.expected {
+				t.Errorf("Expected %q, got %q", tt.expected, out)
+			}
+		})
+	}
+}
+
+func TestImageAddStdin(t *testing.T) {
+	var filename, contentType string
+	useTestServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseMultipartForm(1 << 20); err != nil {
+			t.Errorf("Error parsing multipart body: %v", err)
+		}
+		file, header, err := r.FormFile("image")
+		if err != nil {
+			t.Fatalf("Expected an image file: %v", err)
+		}
+		data, _ := io.ReadAll(file)
+		if !bytes.Equal(data, pngFixture) {
+			t.Errorf("Expected the piped PNG, got %d bytes", len(data))
+		}
+		filename, contentType = header.Filename, r.FormValue("content_type")
+		w.Write([]byte(`{"image_id": "img1", "status": "stored"}`))
+	}))
+
+	tests := []struct {
+		name                string
+		args                []string
+		stdin               []byte
+		expectError         string
+		expectedFilename    string
+		expectedContentType string
+	}{
+		{name: "detected type", args: []string{"image", "add", "--stdin"}, stdin: pngFixture, expectedFilename: "stdin.png", expectedContentType: "image/png"},
+		{name: "given type", args: []string{"image", "add", "--stdin", "--mime-type", "image/jpeg"}, stdin: pngFixture, expectedFilename: "stdin.jpg", expectedContentType: "image/jpeg"},
+		{name: "not an image", args: []string{"image", "add", "--stdin"}, stdin: []byte("plain text"), expectError: "stdin does not appear to be an image"},
+		{name: "non-image type", args: []string{"image", "add", "--stdin", "--mime-type", "text/plain"}, stdin: pngFixture, expectError: "--mime-type must be an image type"},
+		{name: "empty", args: []string{"image", "add", "--stdin"}, expectError: "no image data on stdin"},
+		{name: "mime type without stdin", args: []string{"image", "add", "cat.png", "--mime-type", "image/png"}, expectError: "--mime-type can only be used with --stdin"},
+		{name: "path and stdin", args: []string{"image", "add", "cat.png", "--stdin"}, stdin: pngFixture, expectError: "not several"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			filename, contentType = "", ""
+			rootCmd.SetIn(bytes.NewReader(tt.stdin))
+			t.Cleanup(func() { rootCmd.SetIn(nil) })
+
+			resetFlags(rootCmd)
+			var err error
+			out := captureOutput(t, func() { err = executeCommand(t, tt.args...) })
+
+			if tt.expectError != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.expectError) {
+					t.Errorf("Expected error containing %q, got %v", tt.expectError, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if filename != tt.expectedFilename || contentType != tt.expectedContentType {
+				t.Errorf("Expected %s as %s, got %s as %s", tt.expectedFilename, tt.expectedContentType, filename, contentType)
+			}
+			if !strings.Contains(out, "Successfully added image with ID: img1") {
+				t.Errorf("Unexpected output: %s", out)
+			}
+		})
+	}
+}
+
+func TestImageAddTags(t *testing.T) {
+	var tags []string
+	useTestServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseMultipartForm(1 << 20); err != nil {
+			t.Errorf("Error parsing multipart body: %v", err)
+		}
+		tags = r.MultipartForm.Value["tags[]"]
+		w.Write([]byte(`{"image_id": "img1", "status": "stored"}`))
+	}))
+
+	dir := t.TempDir()
+	image := filepath.Join(dir, "cat.png")
+	if err := os.WriteFile(image, pngFixture, 0o644); err != nil {
+		t.Fatalf("Error writing fixture: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "cat.json"), []byte(`{"tags": ["cat", "indoor"]}`), 0o644); err != nil {
+		t.Fatalf("Error writing fixture: %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		args     []string
+		expected []string
+	}{
+		{name: "no tags", args: []string{"image", "add", image}},
+		{name: "comma-separated", args: []string{"image", "add", image, "--tags", "pet, cat,,pet"}, expected: []string{"pet", "cat"}},
+		{name: "repeated flag", args: []string{"image", "add", image, "--tags", "pet", "--tags", "cat"}, expected: []string{"pet", "cat"}},
+		{name: "merged with sidecar", args: []string{"image", "add", "--dir", dir, "--tags", "pet,cat"}, expected: []string{"pet", "cat", "indoor"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tags = nil
+			resetFlags(rootCmd)
+			var err error
+			captureOutput(t, func() { err = executeCommand(t, tt.args...) })
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(tags, tt.expected) {
+				t.Errorf("Expected tags %v, got %v", tt.expected, tags)
+			}
+		})
+	}
+}
+
+func TestImageSearchCommand(t *testing.T) {
+	var requests int
+	useTestServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if r.URL.Path != "/images/search" {
+			t.Errorf("Expected /images/search, got %s", r.URL.Path)
+		}
+		if r.URL.Query().Get("query") == "cat" {
+			w.Write([]byte(`{"results": [{"id": "img1", "score": 0.42, "metadata": {"filename": "cat.png"}}]}`))
+			return
+		}
+		w.Write([]byte(`{"results": null}`))
+	}))
+
+	tests := []struct {
+		name        string
+		args        []string
+		expected    string
+		expectError string
+	}{
+		{name: "results", args: []string{"image", "search", "cat"}, expected: "File: cat.png\n"},
+		{name: "no results", args: []string{"image", "search", "dog"}, expected: "No results found.\n"},
+		{name: "json without results", args: []string{"image", "search", "dog", "-o", "json"}, expected: `"results": []`},
+		{name: "limit too high", args: []string{"image", "search", "cat", "--limit", "51"}, expectError: "limit must be between 1 and 50"},
+		{name: "limit too low", args: []string{"image", "search", "cat", "--limit", "0"}, expectError: "limit must be between 1 and 50"},
+		{name: "bad threshold", args: []string{"image", "search", "cat", "--threshold", "1.5"}, expectError: "threshold must be between 0.0 and 1.0"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resetFlags(rootCmd)
+			requests = 0
+			var err error
+			out := captureOutput(t, func() { err = executeCommand(t, tt.args...) })
+
+			if tt.expectError != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.expectError) {
+					t.Errorf("Expected error containing %q, got %v", tt.expectError, err)
+				}
+				if requests != 0 {
+					t.Errorf("Expected no request, got %d", requests)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if !strings.Contains(out, tt.expected) {
+				t.Errorf("Expected output to contain %q, got:\n%s", tt.expected, out)
+			}
+		})
+	}
+}
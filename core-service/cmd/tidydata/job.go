@@ -0,0 +1,167 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/berkayuckac/tidydata/internal/api"
+	"github.com/spf13/cobra"
+)
+
+var (
+	jobWait         bool
+	jobWaitTimeout  time.Duration
+	jobPollInterval time.Duration
+	jobListState    string
+)
+
+var jobCmd = &cobra.Command{
+	Use:   "job",
+	Short: "Follow and cancel background jobs",
+	Long: `Follow jobs the ML service runs in the background, such as documents added
+with tidydata add --async.`,
+}
+
+var jobStatusCmd = &cobra.Command{
+	Use:   "status [job-id]",
+	Short: "Show the state of a background job",
+	Long: `Show the state and progress of a background job. With --wait the command
+polls until the job is done or has failed.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if jobWaitTimeout < 0 {
+			return fmt.Errorf("--wait-timeout must not be negative, got %v", jobWaitTimeout)
+		}
+		if jobPollInterval <= 0 {
+			return fmt.Errorf("--poll-interval must be positive, got %v", jobPollInterval)
+		}
+
+		ctx := cmd.Context()
+		if jobWaitTimeout > 0 {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithTimeout(ctx, jobWaitTimeout)
+			defer cancel()
+		}
+
+		jobID := args[0]
+		status, err := mlClient.GetJobStatus(ctx, jobID)
+		if err != nil {
+			return fmt.Errorf("error getting job status: %w", err)
+		}
+		if jobWait && !status.Done() {
+			status, err = waitUntilDone(ctx, jobPollInterval, func(ctx context.Context) (*api.JobStatus, error) {
+				return mlClient.GetJobStatus(ctx, jobID)
+			}, func(s *api.JobStatus) {
+				printInfo("%s\n", jobSummary(s))
+			})
+			if err != nil {
+				return fmt.Errorf("error waiting for job %s: %w", jobID, err)
+			}
+		}
+
+		if err := printResult(status, status.State, jobSummary(status)); err != nil {
+			return err
+		}
+		if status.State == api.JobFailed {
+			return fmt.Errorf("job %s failed: %s", status.JobID, status.Error)
+		}
+		return nil
+	},
+}
+
+var jobListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List background jobs",
+	Long: `List background jobs, optionally only those in one state: pending, running,
+done, failed, cancelled or all.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if outputFormat == "csv" {
+			return fmt.Errorf("job list cannot be used with --output csv")
+		}
+
+		resp, err := mlClient.ListJobs(cmd.Context(), jobListState)
+		if err != nil {
+			return fmt.Errorf("error listing jobs: %w", err)
+		}
+
+		if outputFormat == "json" {
+			return printJSON(resp.Jobs)
+		}
+		if quiet {
+			for _, job := range resp.Jobs {
+				fmt.Println(job.JobID)
+			}
+			return nil
+		}
+		if len(resp.Jobs) == 0 {
+			fmt.Println("No jobs found.")
+			return nil
+		}
+		printJobTable(os.Stdout, resp.Jobs)
+		return nil
+	},
+}
+
+var jobCancelCmd = &cobra.Command{
+	Use:   "cancel [job-id]",
+	Short: "Cancel a background job that has not finished",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		jobID := args[0]
+		if dryRun {
+			printDryRun(http.MethodDelete, "/jobs/"+url.PathEscape(jobID))
+			return nil
+		}
+
+		if err := mlClient.CancelJob(cmd.Context(), jobID); err != nil {
+			var apiErr *api.APIError
+			if errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusConflict {
+				return fmt.Errorf("job %s has already finished", jobID)
+			}
+			return fmt.Errorf("error cancelling job: %w", err)
+		}
+		return printResult(map[string]string{"job_id": jobID}, jobID,
+			fmt.Sprintf("Cancelled job %s", jobID))
+	},
+}
+
+func printJobTable(w io.Writer, jobs []api.JobStatus) {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "ID\tSTATE\tPROGRESS\tDOCUMENT\tERROR")
+	for _, job := range jobs {
+		document := job.DocumentID
+		if document == "" {
+			document = "-"
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%.0f%%\t%s\t%s\n", job.JobID, job.State, job.Progress*100, document, job.Error)
+	}
+	tw.Flush()
+}
+
+// jobSummary describes status in one line, e.g. "Job abc: running (40%)".
+func jobSummary(status *api.JobStatus) string {
+	summary := fmt.Sprintf("Job %s: %s (%.0f%%)", status.JobID, status.State, status.Progress*100)
+	if status.DocumentID != "" {
+		summary += fmt.Sprintf(", document ID %s", status.DocumentID)
+	}
+	return summary
+}
+
+func init() {
+	rootCmd.AddCommand(jobCmd)
+	jobCmd.AddCommand(jobStatusCmd)
+	jobCmd.AddCommand(jobListCmd)
+	jobCmd.AddCommand(jobCancelCmd)
+	jobStatusCmd.Flags().BoolVar(&jobWait, "wait", false, "Wait until the job has finished, showing progress")
+	jobStatusCmd.Flags().DurationVar(&jobWaitTimeout, "wait-timeout", 0, "Give up waiting after this long (0 waits indefinitely)")
+	jobStatusCmd.Flags().DurationVar(&jobPollInterval, "poll-interval", time.Second, "How often to check the job with --wait")
+	jobListCmd.Flags().StringVar(&jobListState, "state", api.JobAll, "Only list jobs in this state (pending, running, done, failed, cancelled or all)")
+}
@@ -0,0 +1,250 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestJobStatusCommand(t *testing.T) {
+	finalStatus := `{"job_id": "job-1", "state": "done", "progress": 1, "document_id": "doc-1"}`
+	polls := 0
+	useTestServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/jobs/job-1" {
+			http.NotFound(w, r)
+			return
+		}
+		polls++
+		switch {
+		case polls == 1:
+			w.Write([]byte(`{"job_id": "job-1", "state": "pending", "progress": 0}`))
+		case polls == 2:
+			w.Write([]byte(`{"job_id": "job-1", "state": "running", "progress": 0.5}`))
+		default:
+			w.Write([]byte(finalStatus))
+		}
+	}))
+
+	tests := []struct {
+		name        string
+		args        []string
+		final       string
+		expectPolls int
+		expectError string
+		expected    []string
+	}{
+		{
+			name:        "without wait",
+			args:        []string{"job", "status", "job-1"},
+			expectPolls: 1,
+			expected:    []string{"Job job-1: pending (0%)\n"},
+		},
+		{
+			name:        "wait until done",
+			args:        []string{"job", "status", "job-1", "--wait", "--poll-interval", "1ms"},
+			expectPolls: 3,
+			expected:    []string{"Job job-1: running (50%)\n", "Job job-1: done (100%), document ID doc-1\n"},
+		},
+		{
+			name:        "wait until failed",
+			args:        []string{"job", "status", "job-1", "--wait", "--poll-interval", "1ms"},
+			final:       `{"job_id": "job-1", "state": "failed", "progress": 0.5, "error": "model not loaded"}`,
+			expectPolls: 3,
+			expectError: "job job-1 failed: model not loaded",
+		},
+		{name: "unknown job", args: []string{"job", "status", "job-2"}, expectError: "job job-2: not found"},
+		{name: "bad poll interval", args: []string{"job", "status", "job-1", "--poll-interval", "0s"}, expectError: "--poll-interval"},
+		{name: "negative wait timeout", args: []string{"job", "status", "job-1", "--wait-timeout", "-1s"}, expectError: "--wait-timeout must not be negative"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resetFlags(rootCmd)
+			polls = 0
+			finalStatus = `{"job_id": "job-1", "state": "done", "progress": 1, "document_id": "doc-1"}`
+			if tt.final != "" {
+				finalStatus = tt.final
+			}
+			var err error
+			out := captureOutput(t, func() { err = executeCommand(t, tt.args...) })
+
+			if tt.expectPolls != 0 && polls != tt.expectPolls {
+				t.Errorf("Expected %d polls, got %d", tt.expectPolls, polls)
+			}
+			if tt.expectError != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.expectError) {
+					t.Errorf("Expected error containing %q, got %v", tt.expectError, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			for _, expected := range tt.expected {
+				if !strings.Contains(out, expected) {
+					t.Errorf("Expected output to contain %q, got:\n%s", expected, out)
+				}
+			}
+		})
+	}
+}
+
+func TestAddAsync(t *testing.T) {
+	var received map[string]any
+	useTestServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.URL.Path != "/documents/async" {
+			t.Errorf("Unexpected request %s %s", r.Method, r.URL.Path)
+		}
+		json.NewDecoder(r.Body).Decode(&received)
+		w.WriteHeader(http.StatusAccepted)
+		w.Write([]byte(`{"job_id": "job-1", "state": "pending", "progress": 0}`))
+	}))
+
+	tests := []struct {
+		name        string
+		args        []string
+		expected    string
+		expectError string
+	}{
+		{
+			name:     "text",
+			args:     []string{"add", "--async", "hello", "--meta", "project=apollo"},
+			expected: "Queued document as job job-1; follow it with tidydata job status job-1\n",
+		},
+		{name: "quiet", args: []string{"add", "--async", "-q", "hello"}, expected: "job-1\n"},
+		{name: "several files", args: []string{"add", "--async", "-f", "a.txt", "-f", "b.txt"}, expectError: "--async adds a single document"},
+		{name: "with chunking", args: []string{"add", "--async", "--chunk-size", "10", "hello"}, expectError: "[async chunk-size]"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resetFlags(rootCmd)
+			received = nil
+			var err error
+			out := captureOutput(t, func() { err = executeCommand(t, tt.args...) })
+
+			if tt.expectError != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.expectError) {
+					t.Errorf("Expected error containing %q, got %v", tt.expectError, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if out != tt.expected {
+				t.Errorf("Expected output %q, got %q", tt.expected, out)
+			}
+			if received["text"] != "hello" {
+				t.Errorf("Expected text hello to be sent, got %v", received)
+			}
+		})
+	}
+}
+
+func TestJobListCommand(t *testing.T) {
+	var state string
+	useTestServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		state = r.URL.Query().Get("state")
+		if state == "failed" {
+			w.Write([]byte(`{"jobs": [], "total": 0}`))
+			return
+		}
+		w.Write([]byte(`{"jobs": [{"job_id": "job-1", "state": "done", "progress": 1, "document_id": "doc-1"}, {"job_id": "job-2", "state": "running", "progress": 0.25}], "total": 2}`))
+	}))
+
+	tests := []struct {
+		name        string
+		args        []string
+		expectState string
+		expected    []string
+		expectError string
+	}{
+		{
+			name:        "all by default",
+			args:        []string{"job", "list"},
+			expectState: "all",
+			expected:    []string{"ID     STATE    PROGRESS  DOCUMENT  ERROR\n", "job-1  done     100%      doc-1", "job-2  running  25%       -"},
+		},
+		{name: "state filter", args: []string{"job", "list", "--state", "running", "-q"}, expectState: "running", expected: []string{"job-1\njob-2\n"}},
+		{name: "json", args: []string{"job", "list", "--output", "json"}, expectState: "all", expected: []string{`"job_id": "job-2"`}},
+		{name: "none found", args: []string{"job", "list", "--state", "failed"}, expectState: "failed", expected: []string{"No jobs found.\n"}},
+		{name: "unknown state", args: []string{"job", "list", "--state", "finished"}, expectError: "job state must be"},
+		{name: "csv", args: []string{"job", "list", "--output", "csv"}, expectError: "cannot be used with --output csv"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resetFlags(rootCmd)
+			state = ""
+			var err error
+			out := captureOutput(t, func() { err = executeCommand(t, tt.args...) })
+
+			if tt.expectError != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.expectError) {
+					t.Errorf("Expected error containing %q, got %v", tt.expectError, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if state != tt.expectState {
+				t.Errorf("Expected state=%s, got %q", tt.expectState, state)
+			}
+			for _, expected := range tt.expected {
+				if !strings.Contains(out, expected) {
+					t.Errorf("Expected output to contain %q, got:\n%s", expected, out)
+				}
+			}
+		})
+	}
+}
+
+func TestJobCancelCommand(t *testing.T) {
+	useTestServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodDelete {
+			t.Errorf("Expected DELETE, got %s", r.Method)
+		}
+		switch r.URL.Path {
+		case "/jobs/job-1":
+			w.WriteHeader(http.StatusNoContent)
+		case "/jobs/job-2":
+			w.WriteHeader(http.StatusConflict)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+
+	tests := []struct {
+		id          string
+		expected    string
+		expectError string
+	}{
+		{id: "job-1", expected: "Cancelled job job-1\n"},
+		{id: "job-2", expectError: "job job-2 has already finished"},
+		{id: "job-3", expectError: "job job-3: not found"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.id, func(t *testing.T) {
+			resetFlags(rootCmd)
+			var err error
+			out := captureOutput(t, func() { err = executeCommand(t, "job", "cancel", tt.id) })
+
+			if tt.expectError != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.expectError) {
+					t.Errorf("Expected error containing %q, got %v", tt.expectError, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if out != tt.expected {
+				t.Errorf("Expected output %q, got %q", tt.expected, out)
+			}
+		})
+	}
+}
@@ -1,33 +1,155 @@
 package main
 
 import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
 	"fmt"
-	"io/ioutil"
+	"io"
+	"math"
 	"mime"
+	"net/http"
+	"net/url"
 	"os"
+	"os/signal"
 	"path/filepath"
+	"sort"
+	"strconv"
 	"strings"
+	"sync"
+	"syscall"
+	"text/tabwriter"
+	"text/template"
+	"time"
+	"unicode"
 
 	"github.com/berkayuckac/tidydata/internal/api"
+	"github.com/berkayuckac/tidydata/internal/extract"
+	"github.com/berkayuckac/tidydata/internal/fetch"
+	"github.com/berkayuckac/tidydata/internal/ui"
 	"github.com/spf13/cobra"
 )
 
 var (
-	mlClient  *api.MLClient
-	fileFlag  string
-	version   = "v0.2.1"
-	threshold float64
+	mlClient             *api.MLClient
+	fileFlags            []string
+	metaFlags            []string
+	urlFlag              string
+	timeout              time.Duration
+	dryRun               bool
+	stripMarkup          bool
+	noColor              bool
+	chunkSize            int
+	chunkOverlap         int
+	addAsync             bool
+	version              = "v0.2.1"
+	threshold            float64
+	searchLimit          int
+	searchType           string
+	interactive          bool
+	showTiming           bool
+	noTiming             bool
+	minResults           int
+	streamResults        bool
+	countOnly            bool
+	searchDeduplicate    bool
+	searchDedupThreshold float64
+	previewLength        int
+	highlightTerms       bool
+	explain              bool
+	searchSort           string
+	sortAsc              bool
+	sortDesc             bool
+	matchAll             bool
+	matchAny             bool
+	searchFormat         string
+	outputFormat         string
+	imageSearchLimit     int
+	imageSearchThreshold float64
+	crossModal           bool
+	imageSaveTo          string
+	imageAddStdin        bool
+	imageAddTags         []string
+	imageMimeType        string
+	imageListOffset      int
+	imageListLimit       int
+	imageListAll         bool
+	imageDeleteYes       bool
+	similarLimit         int
+	similarThreshold     float64
 )
 
-const defaultMLServiceURL = "http://localhost:8000" // TODO: Make this configurable
+const maxSimilarLimit = 50
+
+const defaultMLServiceURL = "http://localhost:8000"
 
 func init() {
-	mlClient = api.NewMLClient(defaultMLServiceURL)
 	rootCmd.AddCommand(addCmd)
 	rootCmd.AddCommand(searchCmd)
 	rootCmd.AddCommand(imageCmd)
-	addCmd.Flags().StringVarP(&fileFlag, "file", "f", "", "Path to file containing text to add")
+	addCmd.Flags().StringArrayVarP(&fileFlags, "file", "f", nil, "Path to file containing text to add (repeatable)")
+	addCmd.Flags().StringArrayVar(&metaFlags, "meta", nil, "Metadata to attach as key=value (repeatable)")
+	addCmd.Flags().StringVarP(&urlFlag, "url", "u", "", "URL of a web page to fetch and add")
+	addCmd.Flags().BoolVar(&stripMarkup, "strip-markup", false, "Strip Markdown syntax from files regardless of their extension")
+	addCmd.Flags().IntVar(&chunkSize, "chunk-size", 0, "Split text into chunks of at most this many characters (0 disables chunking)")
+	addCmd.Flags().IntVar(&chunkOverlap, "chunk-overlap", 0, "Number of characters shared between consecutive chunks")
+	addCmd.Flags().BoolVar(&addAsync, "async", false, "Queue the document and return a job ID without waiting for it to be stored")
+	_ = addCmd.MarkFlagFilename("file")
+	addCmd.MarkFlagsMutuallyExclusive("file", "url")
+	addCmd.MarkFlagsMutuallyExclusive("async", "chunk-size")
+	addCmd.MarkFlagsMutuallyExclusive("async", "dedupe")
+	rootCmd.PersistentFlags().BoolVar(&dryRun, "dry-run", false, "Print what would be sent to the ML service without sending it")
+	rootCmd.PersistentFlags().StringVarP(&outputFormat, "output", "o", "text", "Output format (text, json, csv, or table for search)")
+	rootCmd.PersistentFlags().BoolVarP(&quiet, "quiet", "q", false, "Print only IDs and result data, without informational messages")
+	rootCmd.PersistentFlags().BoolVar(&noColor, "no-color", false, "Disable colored output (also honors NO_COLOR)")
+	rootCmd.PersistentFlags().StringVar(&caCertFile, "ca-cert", "", "PEM file with a CA certificate to trust for an HTTPS ML service")
+	_ = rootCmd.MarkPersistentFlagFilename("ca-cert")
+	rootCmd.PersistentFlags().BoolVar(&insecureSkipVerify, "insecure-skip-verify", false, "Disable TLS certificate verification (insecure, for testing only)")
+	rootCmd.PersistentFlags().StringVar(&proxyFlag, "proxy", "", "Proxy URL for the ML service (http, https or socks5; overrides HTTP_PROXY/HTTPS_PROXY)")
+	rootCmd.PersistentFlags().BoolVar(&noFollowRedirects, "no-follow-redirects", false, "Fail on a redirect from the ML service instead of following it")
+	rootCmd.PersistentFlags().StringVar(&profileFlag, "profile", "", "Configuration profile to use instead of TIDYDATA_PROFILE or the active one")
+	rootCmd.PersistentFlags().StringVar(&logFormat, "log-format", "", "Log ML service requests to stderr as text or json (at the configured log-level, or debug)")
+	_ = rootCmd.RegisterFlagCompletionFunc("profile", completeProfiles)
+	rootCmd.PersistentFlags().DurationVar(&timeout, "timeout", 30*time.Second, "Timeout for fetching remote content")
 	searchCmd.Flags().Float64VarP(&threshold, "threshold", "t", 0.1, "Minimum similarity score threshold (0.0 to 1.0)")
+	searchCmd.Flags().IntVarP(&searchLimit, "limit", "l", 10, "Maximum number of results to return")
+	searchCmd.Flags().StringVar(&searchType, "type", "all", "Only show results of this type (text, image or all)")
+	_ = searchCmd.RegisterFlagCompletionFunc("type",
+		cobra.FixedCompletions([]string{"text", "image", "all"}, cobra.ShellCompDirectiveNoFileComp))
+	searchCmd.Flags().StringVar(&searchSort, "sort", "", "Order results by score, date or type")
+	searchCmd.Flags().BoolVar(&sortAsc, "asc", false, "Sort in ascending order")
+	searchCmd.Flags().BoolVar(&sortDesc, "desc", false, "Sort in descending order (the default)")
+	searchCmd.MarkFlagsMutuallyExclusive("asc", "desc")
+	searchCmd.Flags().BoolVar(&matchAll, "all", false, "Join multiple query terms with AND")
+	searchCmd.Flags().BoolVar(&matchAny, "any", false, "Join multiple query terms with OR")
+	searchCmd.MarkFlagsMutuallyExclusive("all", "any")
+	_ = searchCmd.RegisterFlagCompletionFunc("sort",
+		cobra.FixedCompletions(api.SortFields, cobra.ShellCompDirectiveNoFileComp))
+	searchCmd.Flags().BoolVar(&explain, "explain", false, "Show how each result's score was reached")
+	searchCmd.Flags().StringVar(&searchFormat, "format", "", "Render each result with a Go template, e.g. '{{.Score}}\\t{{.Content.Text}}'")
+	searchCmd.Flags().BoolVar(&showTiming, "timing", false, "Show server processing time and client round-trip time instead of the query time")
+	searchCmd.Flags().BoolVar(&noTiming, "no-timing", false, "Leave out the query time, in text and JSON output")
+	searchCmd.MarkFlagsMutuallyExclusive("timing", "no-timing")
+	searchCmd.Flags().IntVar(&minResults, "min-results", 0, "Lower the threshold step by step until at least this many results are found")
+	searchCmd.Flags().BoolVarP(&interactive, "interactive", "i", false, "Read queries from stdin until EOF or quit")
+	searchCmd.Flags().IntVar(&previewLength, "preview-length", 0, "Cut text results to this many characters in text and table output (0 shows the full text)")
+	searchCmd.Flags().BoolVar(&highlightTerms, "highlight", false, "Emphasize the words of text results that match the query (bold with color, **word** without; ignored with --output json or csv)")
+	searchCmd.Flags().BoolVar(&streamResults, "stream", false, "Print results as the server sends them instead of waiting for all of them")
+	searchCmd.Flags().BoolVar(&countOnly, "count", false, "Print only the number of results above the threshold, ignoring --limit")
+	searchCmd.Flags().BoolVar(&searchDeduplicate, "deduplicate", false, "Leave out results whose content nearly repeats a result already shown")
+	searchCmd.Flags().Float64Var(&searchDedupThreshold, "dedup-threshold", api.DefaultDedupThreshold, "Similarity (0.0 to 1.0) above which --deduplicate treats results as the same")
+	searchCmd.MarkFlagsMutuallyExclusive("stream", "interactive")
+	searchCmd.MarkFlagsMutuallyExclusive("stream", "min-results")
+	searchCmd.MarkFlagsMutuallyExclusive("stream", "sort")
+	searchCmd.MarkFlagsMutuallyExclusive("stream", "explain")
+	searchCmd.MarkFlagsMutuallyExclusive("stream", "highlight")
+	searchCmd.MarkFlagsMutuallyExclusive("count", "stream")
+	searchCmd.MarkFlagsMutuallyExclusive("count", "interactive")
+	searchCmd.MarkFlagsMutuallyExclusive("count", "min-results")
+	searchCmd.MarkFlagsMutuallyExclusive("count", "format")
+	searchCmd.MarkFlagsMutuallyExclusive("deduplicate", "stream")
+	searchCmd.MarkFlagsMutuallyExclusive("deduplicate", "count")
 	rootCmd.Version = version
 }
 
@@ -38,107 +160,870 @@ var rootCmd = &cobra.Command{
 	Long: `TidyData is a personal knowledge management system that enables semantic search
 across your text content and images. It uses language and vision models to understand
 the meaning of your content and find relevant information quickly.`,
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		if outputFormat != "text" && outputFormat != "json" && outputFormat != "csv" && outputFormat != "table" {
+			return fmt.Errorf("output must be text, json, csv or table, got %q", outputFormat)
+		}
+		if err := expandPathFlags(cmd.Flags()); err != nil {
+			return err
+		}
+
+		// Config and profile commands must keep working when the file holds
+		// a bad value.
+		if cmd.Parent() != configCmd && cmd.Parent() != profileCmd {
+			_, cfg, err := loadProfile()
+			if err != nil {
+				return err
+			}
+			if err := applyConfig(cmd, cfg); err != nil {
+				return err
+			}
+		}
+
+		client, err := newMLClient()
+		if err != nil {
+			return err
+		}
+		mlClient = client
+
+		if shouldPage(cmd) {
+			return startPager()
+		}
+		return nil
+	},
 }
 
 var addCmd = &cobra.Command{
 	Use:   "add [text]",
 	Short: "Add text content to your knowledge base",
-	Args:  cobra.ExactArgs(1),
+	Args:  cobra.MaximumNArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
+		var err error
+		if maxFileBytes, err = parseByteSize(maxSizeFlag); err != nil {
+			return fmt.Errorf("--max-size: %w", err)
+		}
+		if addWatchDir != "" {
+			if len(args) > 0 {
+				return fmt.Errorf("--watch cannot be combined with text to add")
+			}
+			return runWatch(cmd.Context(), addWatchDir)
+		}
+		if chunkSize < 0 || chunkOverlap < 0 {
+			return fmt.Errorf("--chunk-size and --chunk-overlap must not be negative")
+		}
+		if chunkSize > 0 && chunkOverlap >= chunkSize {
+			return fmt.Errorf("--chunk-overlap (%d) must be smaller than --chunk-size (%d)", chunkOverlap, chunkSize)
+		}
+		if addAsync && len(fileFlags) > 1 {
+			return fmt.Errorf("--async adds a single document, got %d files", len(fileFlags))
+		}
+
+		metadata, err := parseMetadata(metaFlags)
+		if err != nil {
+			return err
+		}
+
+		var index *dedupeIndex
+		if dedupeFlag {
+			if index, err = openDedupeIndex(dedupeIndexPath); err != nil {
+				return err
+			}
+		}
+
+		if len(fileFlags) > 1 {
+			add := func(text string) (string, error) {
+				return mlClient.AddDocumentWithMetadata(cmd.Context(), text, metadata)
+			}
+			if dryRun {
+				add = dryRunAddDocument
+			} else if chunkSize > 0 {
+				add = func(text string) (string, error) {
+					ids, err := mlClient.AddDocuments(cmd.Context(), chunkDocuments(text, metadata))
+					return strings.Join(ids, ", "), err
+				}
+			}
+			if index != nil {
+				add = index.dedupe(add)
+			}
+			results := addFiles(cmd.Context(), fileFlags, add)
+			summaryErr := printAddFilesSummary(results)
+			if err := batchInterrupted(cmd.Context(), len(results), len(fileFlags), "files"); err != nil {
+				return err
+			}
+			return summaryErr
+		}
+
 		var text string
-		if fileFlag != "" {
-			content, err := os.ReadFile(fileFlag)
+		if urlFlag != "" {
+			fetched, err := fetch.FetchText(cmd.Context(), &http.Client{Timeout: timeout}, urlFlag, fetch.DefaultMaxBytes)
 			if err != nil {
-				return fmt.Errorf("error reading file: %w", err)
+				return fmt.Errorf("error fetching URL: %w", err)
+			}
+			text = fetched
+			if metadata == nil {
+				metadata = make(map[string]string)
 			}
-			text = string(content)
+			metadata["source_url"] = urlFlag
+		} else if len(fileFlags) == 1 {
+			content, err := readDocumentFile(fileFlags[0])
+			if err != nil {
+				return err
+			}
+			text = content
 		} else if len(args) > 0 {
 			text = args[0]
 		} else {
 			return fmt.Errorf("either provide text as an argument or use --file flag")
 		}
+		if strings.TrimSpace(text) == "" {
+			return fmt.Errorf("document text must not be empty")
+		}
+
+		if index != nil && index.contains(text) {
+			return printSkipped()
+		}
+
+		if chunkSize > 0 {
+			if err := addChunks(cmd.Context(), chunkDocuments(text, metadata)); err != nil {
+				return err
+			}
+			if index != nil && !dryRun {
+				index.recordOrWarn(text)
+			}
+			return nil
+		}
+
+		if addAsync {
+			return addDocumentAsync(cmd.Context(), api.Document{Text: text, Metadata: metadata})
+		}
+
+		if dryRun {
+			_, err := dryRunAddDocument(text)
+			return err
+		}
 
-		docID, err := mlClient.AddDocument(text)
+		docID, err := mlClient.AddDocumentWithMetadata(cmd.Context(), text, metadata)
 		if err != nil {
 			return fmt.Errorf("error adding document: %w", err)
 		}
+		if index != nil {
+			index.recordOrWarn(text)
+		}
 
-		fmt.Printf("Successfully added document with ID: %s\n", docID)
-		return nil
+		return printResult(map[string]string{"document_id": docID}, docID,
+			fmt.Sprintf("Successfully added document with ID: %s", docID))
 	},
 }
 
+// addDocumentAsync queues doc and prints its job ID without waiting for the
+// document to be stored.
+func addDocumentAsync(ctx context.Context, doc api.Document) error {
+	if dryRun {
+		printDryRun(http.MethodPost, "/documents/async", fmt.Sprintf("text length: %d characters", len(doc.Text)))
+		return nil
+	}
+
+	job, err := mlClient.AddDocumentAsync(ctx, doc)
+	if err != nil {
+		return fmt.Errorf("error queueing document: %w", err)
+	}
+	return printResult(job, job.JobID,
+		fmt.Sprintf("Queued document as job %s; follow it with tidydata job status %s", job.JobID, job.JobID))
+}
+
+// parseMetadata turns repeated key=value flags into a metadata map. It returns
+// nil when no flags are given so the request body stays unchanged.
+func parseMetadata(pairs []string) (map[string]string, error) {
+	if len(pairs) == 0 {
+		return nil, nil
+	}
+	metadata := make(map[string]string, len(pairs))
+	for _, pair := range pairs {
+		key, value, ok := strings.Cut(pair, "=")
+		key = strings.TrimSpace(key)
+		if !ok || key == "" {
+			return nil, fmt.Errorf("invalid metadata %q: expected key=value", pair)
+		}
+		metadata[key] = value
+	}
+	return metadata, nil
+}
+
+func chunkDocuments(text string, metadata map[string]string) []api.Document {
+	chunks := extract.ChunkText(text, chunkSize, chunkOverlap)
+	docs := make([]api.Document, len(chunks))
+	for i, chunk := range chunks {
+		docs[i] = api.Document{Text: chunk, Metadata: metadata}
+	}
+	return docs
+}
+
+func addChunks(ctx context.Context, docs []api.Document) error {
+	if len(docs) == 0 {
+		return fmt.Errorf("no text to add")
+	}
+
+	if dryRun {
+		total := 0
+		for _, doc := range docs {
+			total += len(doc.Text)
+		}
+		printDryRun(http.MethodPost, "/documents/batch",
+			fmt.Sprintf("chunks: %d", len(docs)),
+			fmt.Sprintf("text length: %d characters", total))
+		return nil
+	}
+
+	ids, err := mlClient.AddDocuments(ctx, docs)
+	if err != nil {
+		return fmt.Errorf("error adding documents: %w", err)
+	}
+
+	if outputFormat == "json" {
+		return printJSON(map[string][]string{"document_ids": ids})
+	}
+	for i, id := range ids {
+		if quiet {
+			fmt.Println(id)
+			continue
+		}
+		fmt.Printf("Chunk %d: %s\n", i+1, id)
+	}
+	printInfo("Successfully added %d chunks\n", len(ids))
+	return nil
+}
+
+// readDocumentFile reads path and extracts its text based on the file extension.
+// Markdown files, or any file when --strip-markup is set, have their syntax removed.
+func readDocumentFile(path string) (string, error) {
+	if maxFileBytes > 0 {
+		info, err := os.Stat(path)
+		if err != nil {
+			return "", fmt.Errorf("error reading file: %w", err)
+		}
+		if info.Size() > maxFileBytes {
+			return "", fmt.Errorf("file size %s exceeds max allowed %s (use --max-size to increase)",
+				formatByteSize(info.Size()), formatByteSize(maxFileBytes))
+		}
+	}
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("error reading file: %w", err)
+	}
+
+	text, err := extract.ExtractText(content, mime.TypeByExtension(filepath.Ext(path)))
+	if err != nil {
+		return "", fmt.Errorf("error extracting text from %s: %w", path, err)
+	}
+
+	if ext := strings.ToLower(filepath.Ext(path)); stripMarkup || ext == ".md" || ext == ".markdown" {
+		text = extract.StripMarkdown(text)
+	}
+	return text, nil
+}
+
+type fileResult struct {
+	Path    string
+	DocID   string
+	Skipped bool
+	Err     error
+}
+
+// addFiles adds each file as a separate document, continuing past failures.
+// It stops before the next file once ctx is cancelled.
+func addFiles(ctx context.Context, paths []string, add func(text string) (string, error)) []fileResult {
+	results := make([]fileResult, 0, len(paths))
+	for _, path := range paths {
+		if ctx.Err() != nil {
+			break
+		}
+		content, err := readDocumentFile(path)
+		if err != nil {
+			results = append(results, fileResult{Path: path, Err: err})
+			continue
+		}
+
+		docID, err := add(content)
+		if errors.Is(err, errAlreadyAdded) {
+			results = append(results, fileResult{Path: path, Skipped: true})
+			continue
+		}
+		if err != nil {
+			results = append(results, fileResult{Path: path, Err: fmt.Errorf("error adding document: %w", err)})
+			continue
+		}
+		results = append(results, fileResult{Path: path, DocID: docID})
+	}
+	return results
+}
+
+type fileOutput struct {
+	Path       string `json:"path"`
+	DocumentID string `json:"document_id,omitempty"`
+	Skipped    bool   `json:"skipped,omitempty"`
+	Error      string `json:"error,omitempty"`
+}
+
+func printAddFilesSummary(results []fileResult) error {
+	failed, skipped := 0, 0
+	outputs := make([]fileOutput, 0, len(results))
+	for _, result := range results {
+		if result.Err != nil {
+			failed++
+			fmt.Fprintf(os.Stderr, "%s: %v\n", result.Path, result.Err)
+			outputs = append(outputs, fileOutput{Path: result.Path, Error: result.Err.Error()})
+			continue
+		}
+		if result.Skipped {
+			skipped++
+			outputs = append(outputs, fileOutput{Path: result.Path, Skipped: true})
+			printInfo("%s: skipped, already added\n", result.Path)
+			continue
+		}
+		outputs = append(outputs, fileOutput{Path: result.Path, DocumentID: result.DocID})
+		switch {
+		case outputFormat == "json":
+		case quiet:
+			fmt.Println(result.DocID)
+		default:
+			fmt.Printf("%s: %s\n", result.Path, result.DocID)
+		}
+	}
+
+	if outputFormat == "json" {
+		if err := printJSON(outputs); err != nil {
+			return err
+		}
+	}
+	printInfo("\nAdded %d of %d files\n", len(results)-failed-skipped, len(results))
+	if failed > 0 {
+		return fmt.Errorf("%d of %d files failed", failed, len(results))
+	}
+	return nil
+}
+
 var searchCmd = &cobra.Command{
-	Use:   "search [query]",
+	Use:   "search [query...]",
 	Short: "Search your knowledge base",
 	Long: `Search across your text and image content using natural language queries.
-Results will include both relevant text and images, ranked by relevance.`,
-	Args: cobra.ExactArgs(1),
+Results will include both relevant text and images, ranked by relevance.
+
+Several arguments are joined into one query. Quote a multi-word term to keep
+it together; with --all or --any the terms are joined with AND or OR.
+
+Use --interactive to run several queries in one session.`,
+	Args: cobra.ArbitraryArgs,
 	RunE: func(cmd *cobra.Command, args []string) error {
-		query := args[0]
-		resp, err := mlClient.Search(query, 10, threshold)
+		opts := searchOptions{limit: searchLimit, threshold: threshold, sourceType: searchType}
+		if err := opts.validate(); err != nil {
+			return err
+		}
+		if previewLength < 0 {
+			return fmt.Errorf("--preview-length must not be negative, got %d", previewLength)
+		}
+		if searchDedupThreshold < 0 || searchDedupThreshold > 1 {
+			return fmt.Errorf("--dedup-threshold must be between 0.0 and 1.0, got %.2f", searchDedupThreshold)
+		}
+		if searchAppend && searchSaveTo == "" {
+			return fmt.Errorf("--append can only be used with --save-to")
+		}
+		if err := validateSaveImages(); err != nil {
+			return err
+		}
+		if minResults < 0 || minResults > opts.limit {
+			return fmt.Errorf("--min-results must be between 0 and --limit (%d), got %d", opts.limit, minResults)
+		}
+		warnHighThreshold(os.Stderr, opts.threshold)
+
+		var tmpl *template.Template
+		if searchFormat != "" {
+			if outputFormat != "text" {
+				return fmt.Errorf("--format cannot be used with --output %s", outputFormat)
+			}
+			var err error
+			if tmpl, err = parseResultTemplate(searchFormat); err != nil {
+				return err
+			}
+		}
+
+		search := func(params api.SearchParams) (*api.UnifiedSearchResponse, error) {
+			return mlClient.SearchWithParams(cmd.Context(), params)
+		}
+		if searchDeduplicate {
+			search = deduplicatedSearch(search, searchDedupThreshold)
+		}
+		if interactive {
+			return runInteractiveSearch(cmd.Context(), os.Stdin, os.Stdout, opts, func(query string, limit int, scoreThreshold float64) (*api.UnifiedSearchResponse, error) {
+				return search(api.SearchParams{Query: query, Limit: limit, ScoreThreshold: scoreThreshold})
+			})
+		}
+		if len(args) == 0 {
+			return fmt.Errorf("either provide a query as an argument or use --interactive")
+		}
+
+		query := joinQueryTerms(args, searchMatch())
+		if countOnly {
+			if opts.sourceType != "all" {
+				return fmt.Errorf("--count cannot be used with --type %s", opts.sourceType)
+			}
+			count, err := mlClient.SearchCount(cmd.Context(), query, opts.threshold)
+			if err != nil {
+				return fmt.Errorf("error searching: %w", err)
+			}
+			return printResult(map[string]any{"query": query, "count": count}, strconv.Itoa(count),
+				fmt.Sprintf("%d results for: %s (threshold: %.2f)", count, query, opts.threshold))
+		}
+		if streamResults {
+			if outputFormat == "csv" || outputFormat == "table" {
+				return fmt.Errorf("--stream cannot be used with --output %s", outputFormat)
+			}
+			if tmpl == nil {
+				printInfo("Search results for: %s (threshold: %.2f)\n\n", query, opts.threshold)
+			}
+			if err := streamSearch(cmd.Context(), os.Stdout, query, opts, tmpl); err != nil {
+				return fmt.Errorf("error searching: %w", err)
+			}
+			return nil
+		}
+
+		start := time.Now()
+		params := api.SearchParams{
+			Query:          query,
+			Limit:          opts.limit,
+			ScoreThreshold: opts.threshold,
+			Explain:        explain,
+			Highlight:      highlightTerms && outputFormat != "json" && outputFormat != "csv",
+			Sort:           searchSort,
+			Order:          searchOrder(),
+		}
+		resp, used, err := searchWithMinResults(params, opts.sourceType, minResults, search)
 		if err != nil {
 			return fmt.Errorf("error searching: %w", err)
 		}
+		elapsed := time.Since(start)
+		if params.Highlight {
+			markQueryTerms(resp.Results, args)
+		}
+		if used != opts.threshold {
+			printInfo("Lowered threshold from %.2f to %.2f to find at least %d results\n", opts.threshold, used, minResults)
+			opts.threshold = used
+		}
 
-		fmt.Printf("Search results for: %s (threshold: %.2f)\n", query, threshold)
-		fmt.Printf("Time taken: %.6f seconds\n\n", resp.TimeTaken)
+		if err := saveResultImages(searchResultImages(resp.Results)); err != nil {
+			return err
+		}
+		if searchSaveTo != "" {
+			return saveSearchResults(cmd, query, resp.Results)
+		}
+		if outputFormat == "json" {
+			if resp.Results == nil {
+				resp.Results = []api.UnifiedSearchResult{}
+			}
+			out := searchOutput{UnifiedSearchResponse: resp}
+			if minResults > 0 {
+				out.Threshold = &used
+			}
+			if !noTiming {
+				out.TimeTaken = &resp.TimeTaken
+			}
+			if showTiming {
+				clientTime := elapsed.Seconds()
+				out.ClientTime = &clientTime
+			}
+			return printJSON(out)
+		}
+		if outputFormat == "csv" {
+			return printSearchCSV(os.Stdout, resp.Results)
+		}
 
-		for _, result := range resp.Results {
-			fmt.Printf("Score: %.2f\n", result.Score)
-			if result.SourceType == "text" {
-				fmt.Printf("Type: Text\n")
-				fmt.Printf("Content: %s\n", result.Content.Text)
-			} else {
-				fmt.Printf("Type: Image\n")
-				fmt.Printf("File: %s\n", result.Content.Metadata.Filename)
-				if result.Content.Metadata.Description != "" {
-					fmt.Printf("Description: %s\n", result.Content.Metadata.Description)
-				}
+		if tmpl != nil {
+			return printTemplateResults(os.Stdout, tmpl, resp.Results)
+		}
+
+		printInfo("Search results for: %s (threshold: %.2f)\n", query, opts.threshold)
+		if showTiming {
+			printInfo("Time taken: %.6f seconds (server), %.6f seconds (round trip)\n", resp.TimeTaken, elapsed.Seconds())
+		}
+		printInfo("\n")
+
+		if len(resp.Results) == 0 {
+			printInfo("No results found above threshold %.2f\n", opts.threshold)
+		} else if outputFormat == "table" {
+			if err := printSearchTable(os.Stdout, resp.Results); err != nil {
+				return err
 			}
-			fmt.Println("---")
+		} else {
+			printSearchResults(os.Stdout, resp.Results)
+		}
+		// --timing already printed the server time with the round trip.
+		if !noTiming && !showTiming {
+			printInfo("Query time: %.3fs\n", resp.TimeTaken)
 		}
 		return nil
 	},
 }
 
+func searchOrder() string {
+	switch {
+	case sortAsc:
+		return "asc"
+	case sortDesc:
+		return "desc"
+	default:
+		return ""
+	}
+}
+
+// highThreshold is the score above which few results are likely to match.
+const highThreshold = 0.9
+
+func warnHighThreshold(w io.Writer, threshold float64) {
+	if threshold > highThreshold {
+		fmt.Fprintf(w, "Warning: threshold above %.2f may return no results.\n", highThreshold)
+	}
+}
+
+func searchMatch() string {
+	switch {
+	case matchAll:
+		return "AND"
+	case matchAny:
+		return "OR"
+	default:
+		return ""
+	}
+}
+
+// joinQueryTerms builds one query from the search arguments. When there are
+// several, terms containing spaces are quoted so they stay together, and
+// operator ("AND" or "OR") goes between the terms if given.
+func joinQueryTerms(terms []string, operator string) string {
+	if len(terms) == 1 {
+		return terms[0]
+	}
+	quoted := make([]string, len(terms))
+	for i, term := range terms {
+		if strings.ContainsFunc(term, unicode.IsSpace) {
+			term = strconv.Quote(term)
+		}
+		quoted[i] = term
+	}
+	sep := " "
+	if operator != "" {
+		sep = " " + operator + " "
+	}
+	return strings.Join(quoted, sep)
+}
+
+// searchOutput is the JSON form of a search. time_taken is left out with
+// --no-timing, --timing adds the client-measured round trip, and
+// --min-results adds the threshold that was used.
+type searchOutput struct {
+	*api.UnifiedSearchResponse
+	TimeTaken  *float64 `json:"time_taken,omitempty"`
+	ClientTime *float64 `json:"client_time,omitempty"`
+	Threshold  *float64 `json:"threshold,omitempty"`
+}
+
+const (
+	// thresholdStep is how far --min-results lowers the threshold per retry.
+	thresholdStep       = 0.1
+	maxThresholdRetries = 10
+)
+
+// searchWithMinResults runs search, and while fewer than minResults results
+// of sourceType come back, retries with the threshold lowered by
+// thresholdStep until it reaches 0.0. It returns the filtered response and
+// the threshold that produced it.
+func searchWithMinResults(params api.SearchParams, sourceType string, minResults int, search func(api.SearchParams) (*api.UnifiedSearchResponse, error)) (*api.UnifiedSearchResponse, float64, error) {
+	for retry := 0; ; retry++ {
+		resp, err := search(params)
+		if err != nil {
+			return nil, 0, err
+		}
+		resp.Results = filterResults(resp.Results, sourceType)
+		if len(resp.Results) >= minResults || params.ScoreThreshold <= 0 || retry >= maxThresholdRetries {
+			return resp, params.ScoreThreshold, nil
+		}
+		// Rounding keeps repeated steps from drifting, e.g. to 0.30000000000000004.
+		params.ScoreThreshold = max(0, math.Round((params.ScoreThreshold-thresholdStep)*100)/100)
+	}
+}
+
+// deduplicatedSearch wraps search to drop results that nearly repeat an
+// earlier one, as DeduplicateResults does.
+func deduplicatedSearch(search func(api.SearchParams) (*api.UnifiedSearchResponse, error), threshold float64) func(api.SearchParams) (*api.UnifiedSearchResponse, error) {
+	return func(params api.SearchParams) (*api.UnifiedSearchResponse, error) {
+		resp, err := search(params)
+		if err != nil {
+			return nil, err
+		}
+		resp.Results = api.DeduplicateResults(resp.Results, threshold)
+		return resp, nil
+	}
+}
+
+// streamSearch prints each result as it arrives: as a block in text mode,
+// through tmpl if set, or as one JSON object per line with --output json.
+func streamSearch(ctx context.Context, w io.Writer, query string, opts searchOptions, tmpl *template.Template) error {
+	found := 0
+	encoder := json.NewEncoder(w)
+	err := mlClient.SearchStream(ctx, query, opts.limit, opts.threshold, func(result api.UnifiedSearchResult) error {
+		if opts.sourceType != "all" && result.SourceType != opts.sourceType {
+			return nil
+		}
+		found++
+		results := []api.UnifiedSearchResult{result}
+		switch {
+		case outputFormat == "json":
+			return encoder.Encode(result)
+		case tmpl != nil:
+			return printTemplateResults(w, tmpl, results)
+		default:
+			printSearchResults(w, results)
+			return nil
+		}
+	})
+	if err != nil {
+		return err
+	}
+	if found == 0 {
+		printInfo("No results found above threshold %.2f\n", opts.threshold)
+	}
+	return nil
+}
+
+// markQueryTerms marks the words of the search arguments in text results the
+// ML service did not highlight, such as those from a service without
+// highlight support.
+func markQueryTerms(results []api.UnifiedSearchResult, args []string) {
+	terms := ui.QueryTerms(args...)
+	for i, result := range results {
+		if result.SourceType == "text" && result.Content.HighlightedText == "" {
+			if marked := ui.MarkTerms(result.Content.Text, terms); marked != result.Content.Text {
+				results[i].Content.HighlightedText = marked
+			}
+		}
+	}
+}
+
+func printSearchResults(w io.Writer, results []api.UnifiedSearchResult) {
+	color := ui.ColorEnabled(noColor, w)
+	for _, result := range results {
+		fmt.Fprintf(w, "Score: %s\n", ui.Score(result.Score, color))
+		if result.SourceType == "text" {
+			fmt.Fprintf(w, "Type: Text\n")
+			content := ui.Truncate(result.Content.Text, previewLength)
+			if result.Content.HighlightedText != "" {
+				content = ui.Highlight(result.Content.HighlightedText, color, previewLength)
+			}
+			fmt.Fprintf(w, "Content: %s\n", content)
+			if len(result.Content.DocumentMetadata) > 0 {
+				fmt.Fprintf(w, "Metadata: %s\n", formatMetadata(result.Content.DocumentMetadata))
+			}
+		} else {
+			fmt.Fprintf(w, "Type: Image\n")
+			fmt.Fprintf(w, "File: %s\n", result.Content.Metadata.Filename)
+			if result.Content.Metadata.Description != "" {
+				fmt.Fprintf(w, "Description: %s\n", result.Content.Metadata.Description)
+			}
+			if len(result.Content.Metadata.Tags) > 0 {
+				fmt.Fprintf(w, "Tags: %s\n", strings.Join(result.Content.Metadata.Tags, ", "))
+			}
+		}
+		if e := result.Explanation; e != nil {
+			fmt.Fprintf(w, "Explanation: embedding distance %.4f, token overlap %.2f, model %s\n",
+				e.EmbeddingDistance, e.TokenOverlap, e.ModelName)
+			if len(e.MatchedTerms) > 0 {
+				fmt.Fprintf(w, "Matched terms: %s\n", strings.Join(e.MatchedTerms, ", "))
+			}
+		} else if explain {
+			fmt.Fprintln(w, "Explanation: not provided by the ML service")
+		}
+		fmt.Fprintln(w, "---")
+	}
+}
+
+func formatMetadata(metadata map[string]string) string {
+	keys := make([]string, 0, len(metadata))
+	for key := range metadata {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	pairs := make([]string, len(keys))
+	for i, key := range keys {
+		pairs[i] = key + "=" + metadata[key]
+	}
+	return strings.Join(pairs, ", ")
+}
+
+// filterResults keeps only results of the given source type ("text" or
+// "image"); "all" keeps everything.
+func filterResults(results []api.UnifiedSearchResult, sourceType string) []api.UnifiedSearchResult {
+	if sourceType == "" || sourceType == "all" {
+		return results
+	}
+	filtered := make([]api.UnifiedSearchResult, 0, len(results))
+	for _, result := range results {
+		if result.SourceType == sourceType {
+			filtered = append(filtered, result)
+		}
+	}
+	return filtered
+}
+
 var imageCmd = &cobra.Command{
 	Use:   "image",
 	Short: "Image operations",
 	Long:  `Commands for managing and finding similar images in your knowledge base.`,
 }
 
+// readImageFile reads path after checking that its extension is an image type.
+func readImageFile(path string) ([]byte, error) {
+	if _, err := os.Stat(path); err != nil {
+		return nil, fmt.Errorf("error accessing image file: %w", err)
+	}
+
+	imageData, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("error reading image file: %w", err)
+	}
+
+	mimeType := mime.TypeByExtension(filepath.Ext(path))
+	if mimeType == "" || !strings.HasPrefix(mimeType, "image/") {
+		return nil, fmt.Errorf("file does not appear to be an image: %s", path)
+	}
+	return imageData, nil
+}
+
+// imageContentType prefers the type sniffed from data, falling back to the
+// one implied by the file extension.
+func imageContentType(path string, data []byte) string {
+	if sniffed := http.DetectContentType(data); strings.HasPrefix(sniffed, "image/") {
+		return sniffed
+	}
+	return mime.TypeByExtension(filepath.Ext(path))
+}
+
 var imageAddCmd = &cobra.Command{
 	Use:   "add [image_path]",
 	Short: "Add an image to your knowledge base",
-	Args:  cobra.ExactArgs(1),
-	RunE: func(cmd *cobra.Command, args []string) error {
-		imagePath := args[0]
+	Long: `Add an image to your knowledge base, or with --dir every image in a
+directory and its subdirectories.
+
+With --stdin the image is read from standard input and named stdin with an
+extension for its type, which is taken from --mime-type or detected from the
+data, for example: ffmpeg ... | tidydata image add --stdin --mime-type image/jpeg
 
-		if _, err := os.Stat(imagePath); err != nil {
-			return fmt.Errorf("error accessing image file: %w", err)
+With --dir, files are recognized as images by their content, and an image can
+have a sidecar file with the same name: photo.txt holds a description of
+photo.jpg, and photo.json a description and tags, as
+{"description": "...", "tags": [...]}.`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if imageMimeType != "" && !imageAddStdin {
+			return fmt.Errorf("--mime-type can only be used with --stdin")
+		}
+		if imageAddDir != "" {
+			if len(args) > 0 || imageAddStdin {
+				return fmt.Errorf("provide either an image path, --dir or --stdin, not several")
+			}
+			return addImageDir(cmd.Context(), imageAddDir)
 		}
 
-		imageData, err := ioutil.ReadFile(imagePath)
-		if err != nil {
-			return fmt.Errorf("error reading image file: %w", err)
+		var imageData []byte
+		var filename, contentType string
+		switch {
+		case imageAddStdin:
+			if len(args) > 0 {
+				return fmt.Errorf("provide either an image path, --dir or --stdin, not several")
+			}
+			var err error
+			if imageData, contentType, err = readImageStdin(cmd.InOrStdin(), imageMimeType); err != nil {
+				return err
+			}
+			filename = "stdin" + imageExtension(contentType, imageData)
+		case len(args) == 0:
+			return fmt.Errorf("either provide an image path as an argument or use --dir or --stdin")
+		default:
+			var err error
+			if imageData, err = readImageFile(args[0]); err != nil {
+				return err
+			}
+			filename = filepath.Base(args[0])
+			contentType = imageContentType(args[0], imageData)
 		}
 
-		mimeType := mime.TypeByExtension(filepath.Ext(imagePath))
-		if mimeType == "" || !strings.HasPrefix(mimeType, "image/") {
-			return fmt.Errorf("file does not appear to be an image: %s", imagePath)
+		tags := imageTags(nil)
+		if dryRun {
+			details := []string{
+				fmt.Sprintf("filename: %s", filename),
+				fmt.Sprintf("content type: %s", contentType),
+				fmt.Sprintf("image size: %d bytes", len(imageData)),
+			}
+			if len(tags) > 0 {
+				details = append(details, fmt.Sprintf("tags: %s", strings.Join(tags, ", ")))
+			}
+			printDryRun(http.MethodPost, "/images", details...)
+			return nil
 		}
 
-		resp, err := mlClient.AddImage(imageData, filepath.Base(imagePath))
+		resp, err := mlClient.AddImageWithMetadata(cmd.Context(), imageData, filename, contentType, "", tags)
 		if err != nil {
 			return fmt.Errorf("error adding image: %w", err)
 		}
 
-		fmt.Printf("Successfully added image with ID: %s\n", resp.ImageID)
-		return nil
+		return printResult(resp, resp.ImageID,
+			fmt.Sprintf("Successfully added image with ID: %s", resp.ImageID))
 	},
 }
 
+// imageTags returns the --tags of image add followed by those in extra,
+// trimmed and without empty or repeated tags.
+func imageTags(extra []string) []string {
+	var tags []string
+	seen := make(map[string]bool)
+	for _, list := range [][]string{imageAddTags, extra} {
+		for _, tag := range list {
+			tag = strings.TrimSpace(tag)
+			if tag != "" && !seen[tag] {
+				seen[tag] = true
+				tags = append(tags, tag)
+			}
+		}
+	}
+	return tags
+}
+
+// readImageStdin reads an image from r. Its content type is mimeType when
+// set, and otherwise detected from the data.
+func readImageStdin(r io.Reader, mimeType string) ([]byte, string, error) {
+	if mimeType != "" {
+		if mediaType, _, err := mime.ParseMediaType(mimeType); err != nil || !strings.HasPrefix(mediaType, "image/") {
+			return nil, "", fmt.Errorf("--mime-type must be an image type, got %q", mimeType)
+		}
+	}
+
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, "", fmt.Errorf("error reading image from stdin: %w", err)
+	}
+	if len(data) == 0 {
+		return nil, "", fmt.Errorf("no image data on stdin")
+	}
+
+	if mimeType != "" {
+		return data, mimeType, nil
+	}
+	sniffed := http.DetectContentType(data)
+	if !strings.HasPrefix(sniffed, "image/") {
+		return nil, "", fmt.Errorf("stdin does not appear to be an image (detected %s); set --mime-type", sniffed)
+	}
+	return data, sniffed, nil
+}
+
 var imageSimilarCmd = &cobra.Command{
 	Use:   "similar [image_path]",
 	Short: "Find similar images",
@@ -146,46 +1031,457 @@ var imageSimilarCmd = &cobra.Command{
 	RunE: func(cmd *cobra.Command, args []string) error {
 		imagePath := args[0]
 
-		if _, err := os.Stat(imagePath); err != nil {
-			return fmt.Errorf("error accessing image file: %w", err)
+		if similarLimit < 1 || similarLimit > maxSimilarLimit {
+			return fmt.Errorf("limit must be between 1 and %d, got %d", maxSimilarLimit, similarLimit)
+		}
+		if similarThreshold < 0 || similarThreshold > 1 {
+			return fmt.Errorf("threshold must be between 0.0 and 1.0, got %.2f", similarThreshold)
+		}
+		if err := validateImageSortBy(); err != nil {
+			return err
+		}
+		if crossModal && imageSortBy != "score" {
+			return fmt.Errorf("--sort-by %s cannot be used with --cross-modal", imageSortBy)
 		}
+		if err := validateSaveImages(); err != nil {
+			return err
+		}
+		warnHighThreshold(os.Stderr, similarThreshold)
 
-		imageData, err := ioutil.ReadFile(imagePath)
+		imageData, err := readImageFile(imagePath)
 		if err != nil {
-			return fmt.Errorf("error reading image file: %w", err)
+			return err
 		}
 
-		mimeType := mime.TypeByExtension(filepath.Ext(imagePath))
-		if mimeType == "" || !strings.HasPrefix(mimeType, "image/") {
-			return fmt.Errorf("file does not appear to be an image: %s", imagePath)
+		if crossModal {
+			resp, err := mlClient.ImageToTextSearch(cmd.Context(), imageData, similarLimit, similarThreshold)
+			if err != nil {
+				return fmt.Errorf("error searching documents: %w", err)
+			}
+
+			if outputFormat == "json" {
+				if resp.Results == nil {
+					resp.Results = []api.UnifiedSearchResult{}
+				}
+				return printJSON(resp)
+			}
+			printInfo("Documents related to: %s\n\n", filepath.Base(imagePath))
+			if len(resp.Results) == 0 {
+				printInfo("No related documents found\n")
+				return nil
+			}
+			printSearchResults(os.Stdout, resp.Results)
+			return nil
 		}
 
-		resp, err := mlClient.FindSimilarImages(imageData, 5, 0.3)
+		resp, err := mlClient.FindSimilarImages(cmd.Context(), imageData, similarLimit, similarThreshold)
 		if err != nil {
 			return fmt.Errorf("error finding similar images: %w", err)
 		}
+		sortImageResults(resp.Results, imageSortBy)
+		if err := saveResultImages(similarResultImages(resp.Results)); err != nil {
+			return err
+		}
 
-		fmt.Printf("Similar images to: %s\n\n", filepath.Base(imagePath))
-		for _, result := range resp.Results {
-			fmt.Printf("Score: %.2f\n", result.Score)
-			fmt.Printf("File: %s\n", result.Metadata.Filename)
-			if result.Metadata.Description != "" {
-				fmt.Printf("Description: %s\n", result.Metadata.Description)
+		if outputFormat == "json" {
+			if resp.Results == nil {
+				resp.Results = []api.ImageResult{}
 			}
-			fmt.Println("---")
+			return printJSON(resp)
 		}
+		printInfo("Similar images to: %s\n\n", filepath.Base(imagePath))
+		if len(resp.Results) == 0 {
+			printInfo("No similar images found\n")
+			return nil
+		}
+		printImageResults(resp.Results)
 		return nil
 	},
 }
 
+var imageSearchCmd = &cobra.Command{
+	Use:   "search [query]",
+	Short: "Search images using a text query",
+	Long: `Search only your images using a natural language description.
+Unlike the top-level search command, text documents are not included in the results.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		query := args[0]
+		if imageSearchLimit < 1 || imageSearchLimit > maxSimilarLimit {
+			return fmt.Errorf("limit must be between 1 and %d, got %d", maxSimilarLimit, imageSearchLimit)
+		}
+		if imageSearchThreshold < 0 || imageSearchThreshold > 1 {
+			return fmt.Errorf("threshold must be between 0.0 and 1.0, got %.2f", imageSearchThreshold)
+		}
+
+		resp, err := mlClient.TextToImageSearch(cmd.Context(), query, imageSearchLimit, imageSearchThreshold)
+		if err != nil {
+			return fmt.Errorf("error searching images: %w", err)
+		}
+
+		if outputFormat == "json" {
+			if resp.Results == nil {
+				resp.Results = []api.ImageResult{}
+			}
+			return printJSON(resp)
+		}
+		printInfo("Images matching: %s (threshold: %.2f)\n\n", query, imageSearchThreshold)
+		if len(resp.Results) == 0 {
+			printInfo("No results found.\n")
+			return nil
+		}
+		printImageResults(resp.Results)
+		return nil
+	},
+}
+
+var imageGetCmd = &cobra.Command{
+	Use:   "get [id]",
+	Short: "Show a stored image, optionally saving it",
+	Long: `Show a stored image's metadata, or with --save-to write the image to disk.
+
+If --save-to is a directory the image keeps its original filename, with its
+extension corrected if it names a different image type. If the path has no
+extension, one is added based on the image's content type, or on its data
+when the type is unknown, falling back to .bin.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		id := args[0]
+		image, err := mlClient.GetImage(cmd.Context(), id)
+		if err != nil {
+			if errors.Is(err, api.ErrNotFound) {
+				return fmt.Errorf("no image found with ID: %s", id)
+			}
+			return fmt.Errorf("error getting image: %w", err)
+		}
+
+		data, err := base64.StdEncoding.DecodeString(image.ImageData)
+		if err != nil {
+			return fmt.Errorf("error decoding image data: %w", err)
+		}
+
+		if imageSaveTo == "" {
+			return printImageDetail(image, len(data))
+		}
+
+		path := imageSavePath(imageSaveTo, id, image.Metadata, data)
+		if err := os.WriteFile(path, data, 0o644); err != nil {
+			return fmt.Errorf("error writing image: %w", err)
+		}
+
+		return printResult(map[string]string{"id": id, "path": path}, path,
+			fmt.Sprintf("Saved image %s to %s (%d bytes)", id, path, len(data)))
+	},
+}
+
+// imageSavePath resolves --save-to: a directory gets the image's original
+// filename, and a path without an extension gets one from the content type.
+// An original filename whose extension names a different image type, such
+// as a PNG stored as photo.jpg, has it replaced.
+func imageSavePath(saveTo, id string, metadata api.ImageMetadata, data []byte) string {
+	ext := imageExtension(metadata.ContentType, data)
+	path := saveTo
+	if info, err := os.Stat(saveTo); err == nil && info.IsDir() {
+		name := filepath.Base(metadata.Filename)
+		if metadata.Filename == "" || name == "." || name == string(filepath.Separator) {
+			name = filepath.Base(id)
+		}
+		old := filepath.Ext(name)
+		if oldType := mime.TypeByExtension(old); ext != ".bin" && strings.HasPrefix(oldType, "image/") && oldType != mime.TypeByExtension(ext) {
+			name = strings.TrimSuffix(name, old) + ext
+		}
+		path = filepath.Join(saveTo, name)
+	}
+	if filepath.Ext(path) == "" {
+		path += ext
+	}
+	return path
+}
+
+func printImageDetail(image *api.ImageDetail, size int) error {
+	if outputFormat == "json" {
+		return printJSON(struct {
+			ID       string            `json:"id"`
+			Metadata api.ImageMetadata `json:"metadata"`
+			Size     int               `json:"size"`
+		}{image.ImageID, image.Metadata, size})
+	}
+	if quiet || outputFormat == "csv" {
+		fmt.Println(image.ImageID)
+		return nil
+	}
+	fmt.Printf("ID: %s\n", image.ImageID)
+	fmt.Printf("Filename: %s\n", image.Metadata.Filename)
+	fmt.Printf("Type: %s\n", image.Metadata.ContentType)
+	if image.Metadata.Description != "" {
+		fmt.Printf("Description: %s\n", image.Metadata.Description)
+	}
+	if len(image.Metadata.Tags) > 0 {
+		fmt.Printf("Tags: %s\n", strings.Join(image.Metadata.Tags, ", "))
+	}
+	fmt.Printf("Size: %d bytes\n", size)
+	return nil
+}
+
+var imageDeleteCmd = &cobra.Command{
+	Use:   "delete [id]",
+	Short: "Delete a stored image",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		id := args[0]
+
+		if dryRun {
+			printDryRun(http.MethodDelete, "/images/"+url.PathEscape(id))
+			return nil
+		}
+
+		if !ui.Confirm(fmt.Sprintf("Delete image %s?", id), imageDeleteYes) {
+			return fmt.Errorf("aborted")
+		}
+
+		if err := mlClient.DeleteImage(cmd.Context(), id); err != nil {
+			if errors.Is(err, api.ErrNotFound) {
+				return fmt.Errorf("no image found with ID: %s", id)
+			}
+			return fmt.Errorf("error deleting image: %w", err)
+		}
+
+		return printResult(map[string]string{"id": id}, id,
+			fmt.Sprintf("Successfully deleted image with ID: %s", id))
+	},
+}
+
+var imageListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List stored images",
+	Long: `List stored images one page at a time. Use --offset to move through pages,
+or --all to fetch every page.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if imageListLimit <= 0 {
+			return fmt.Errorf("limit must be positive, got %d", imageListLimit)
+		}
+		if imageListOffset < 0 {
+			return fmt.Errorf("offset must not be negative, got %d", imageListOffset)
+		}
+		if err := validateImageSortBy(); err != nil {
+			return err
+		}
+
+		var images []api.ImageSummary
+		more := false
+		if imageListAll {
+			all, err := listAllImages(cmd.Context(), imageListOffset, imageListLimit)
+			if err != nil {
+				return fmt.Errorf("error listing images: %w", err)
+			}
+			images = all
+		} else {
+			resp, err := mlClient.ListImages(cmd.Context(), imageListOffset, imageListLimit)
+			if err != nil {
+				return fmt.Errorf("error listing images: %w", err)
+			}
+			images = resp.Images
+			more = len(images) == imageListLimit
+		}
+		sortImageSummaries(images, imageSortBy)
+
+		switch outputFormat {
+		case "json":
+			return printJSON(images)
+		case "csv":
+			return printImageCSV(os.Stdout, images)
+		}
+		if quiet {
+			for _, image := range images {
+				fmt.Println(image.ID)
+			}
+			return nil
+		}
+
+		if len(images) == 0 {
+			fmt.Println("No images found.")
+			return nil
+		}
+		printImageTable(os.Stdout, images)
+		if more {
+			fmt.Printf("\nMore images may be available: use --offset %d or --all\n", imageListOffset+len(images))
+		}
+		return nil
+	},
+}
+
+// listAllImages pages through images starting at offset until a page comes
+// back shorter than pageSize.
+func listAllImages(ctx context.Context, offset, pageSize int) ([]api.ImageSummary, error) {
+	var images []api.ImageSummary
+	for {
+		resp, err := mlClient.ListImages(ctx, offset, pageSize)
+		if err != nil {
+			return nil, err
+		}
+		images = append(images, resp.Images...)
+		if len(resp.Images) < pageSize {
+			return images, nil
+		}
+		offset += len(resp.Images)
+	}
+}
+
+func printImageTable(w io.Writer, images []api.ImageSummary) {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "ID\tFILENAME\tTYPE\tADDED\tDESCRIPTION")
+	for _, image := range images {
+		added := "-"
+		if image.AddedAt > 0 {
+			added = time.Unix(int64(image.AddedAt), 0).Format("2006-01-02 15:04")
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\n",
+			image.ID, image.Metadata.Filename, image.Metadata.ContentType, added, image.Metadata.Description)
+	}
+	tw.Flush()
+}
+
+// imageExtension returns the file extension for an image, taken from the
+// content type reported by the server or else sniffed from data, and ".bin"
+// when neither is known.
+func imageExtension(contentType string, data []byte) string {
+	if ext := extensionByType(contentType); ext != "" {
+		return ext
+	}
+	if sniffed := http.DetectContentType(data); strings.HasPrefix(sniffed, "image/") {
+		if ext := extensionByType(sniffed); ext != "" {
+			return ext
+		}
+	}
+	return ".bin"
+}
+
+// extensionByType returns the extension for an image content type,
+// preferring the common spelling where several exist.
+func extensionByType(contentType string) string {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil || !strings.HasPrefix(mediaType, "image/") {
+		return ""
+	}
+	switch mediaType {
+	case "image/jpeg":
+		return ".jpg"
+	case "image/png":
+		return ".png"
+	case "image/gif":
+		return ".gif"
+	case "image/webp":
+		return ".webp"
+	}
+	if exts, err := mime.ExtensionsByType(mediaType); err == nil && len(exts) > 0 {
+		return exts[0]
+	}
+	return ""
+}
+
+func printImageResults(results []api.ImageResult) {
+	color := ui.ColorEnabled(noColor, os.Stdout)
+	for _, result := range results {
+		fmt.Printf("Score: %s\n", ui.Score(result.Score, color))
+		fmt.Printf("File: %s\n", result.Metadata.Filename)
+		if result.Metadata.Description != "" {
+			fmt.Printf("Description: %s\n", result.Metadata.Description)
+		}
+		if len(result.Metadata.Tags) > 0 {
+			fmt.Printf("Tags: %s\n", strings.Join(result.Metadata.Tags, ", "))
+		}
+		fmt.Println("---")
+	}
+}
+
 func init() {
 	imageCmd.AddCommand(imageAddCmd)
 	imageCmd.AddCommand(imageSimilarCmd)
+	imageCmd.AddCommand(imageSearchCmd)
+	imageCmd.AddCommand(imageGetCmd)
+	imageCmd.AddCommand(imageListCmd)
+	imageCmd.AddCommand(imageDeleteCmd)
+	imageAddCmd.Flags().BoolVar(&imageAddStdin, "stdin", false, "Read the image from standard input")
+	imageAddCmd.Flags().StringSliceVar(&imageAddTags, "tags", nil, "Comma-separated tags to store with the image, e.g. pet,cat (added to sidecar tags with --dir)")
+	imageAddCmd.Flags().StringVar(&imageMimeType, "mime-type", "", "Content type of the image read with --stdin, e.g. image/jpeg (detected from the data if unset)")
+	imageDeleteCmd.Flags().BoolVarP(&imageDeleteYes, "yes", "y", false, "Delete without asking for confirmation")
+	imageDeleteCmd.Flags().BoolVar(&imageDeleteYes, "force", false, "Same as --yes")
+	imageListCmd.Flags().IntVar(&imageListOffset, "offset", 0, "Number of images to skip")
+	imageListCmd.Flags().IntVarP(&imageListLimit, "limit", "l", 20, "Number of images per page")
+	imageListCmd.Flags().BoolVar(&imageListAll, "all", false, "Fetch every page")
+	imageGetCmd.Flags().StringVar(&imageSaveTo, "save-to", "", "File or directory to write the image to")
+	imageGetCmd.Flags().StringVar(&imageSaveTo, "out", "", "Same as --save-to")
+	_ = imageGetCmd.MarkFlagFilename("save-to")
+	_ = imageGetCmd.MarkFlagFilename("out")
+	imageSimilarCmd.Flags().IntVarP(&similarLimit, "limit", "l", 5, fmt.Sprintf("Maximum number of results to return (1 to %d)", maxSimilarLimit))
+	imageSimilarCmd.Flags().Float64VarP(&similarThreshold, "threshold", "t", 0.3, "Minimum similarity score threshold (0.0 to 1.0)")
+	imageSimilarCmd.Flags().BoolVar(&crossModal, "cross-modal", false, "Find text documents related to the image instead of similar images")
+	imageSearchCmd.Flags().IntVarP(&imageSearchLimit, "limit", "l", 10, fmt.Sprintf("Maximum number of images to return (1 to %d)", maxSimilarLimit))
+	imageSearchCmd.Flags().Float64VarP(&imageSearchThreshold, "threshold", "t", 0.1, "Minimum similarity score threshold (0.0 to 1.0)")
+}
+
+// mapConcurrent calls fn for every item using at most workers concurrent
+// calls and returns the results in the same order as items. Once ctx is
+// cancelled no more calls are started, and only the items already handed out
+// have results.
+func mapConcurrent[T, R any](ctx context.Context, items []T, workers int, fn func(T) R) []R {
+	results := make([]R, len(items))
+	jobs := make(chan int)
+
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				results[i] = fn(items[i])
+			}
+		}()
+	}
+
+	dispatched := 0
+dispatch:
+	for i := range items {
+		if ctx.Err() != nil {
+			break
+		}
+		select {
+		case jobs <- i:
+			dispatched++
+		case <-ctx.Done():
+			break dispatch
+		}
+	}
+	close(jobs)
+	wg.Wait()
+	return results[:dispatched]
+}
+
+// batchInterrupted returns an error when ctx was cancelled before all total
+// items of a batch were processed.
+func batchInterrupted(ctx context.Context, done, total int, items string) error {
+	if err := ctx.Err(); err != nil && done < total {
+		return fmt.Errorf("interrupted after %d of %d %s: %w", done, total, items, err)
+	}
+	return nil
 }
 
 func main() {
-	if err := rootCmd.Execute(); err != nil {
+	// Commands see a cancelled cmd.Context() on Ctrl-C or SIGTERM.
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	err := rootCmd.ExecuteContext(ctx)
+	interrupted := ctx.Err() != nil
+	stop()
+	stopPager()
+	if err != nil {
 		fmt.Fprintln(os.Stderr, err)
+		if hint := errorHint(err); hint != "" {
+			fmt.Fprintln(os.Stderr, "Hint:", hint)
+		}
+		if interrupted {
+			os.Exit(130)
+		}
 		os.Exit(1)
 	}
 }
@@ -0,0 +1,326 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/berkayuckac/tidydata/internal/api"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+// executeCommand runs the root command with args, resetting every flag to its
+// default afterwards so state does not leak between tests.
+func executeCommand(t *testing.T, args ...string) error {
+	t.Helper()
+	t.Cleanup(func() { resetFlags(rootCmd) })
+	rootCmd.SetArgs(args)
+	return rootCmd.Execute()
+}
+
+// useTestServer points commands run through executeCommand at handler.
+func useTestServer(t *testing.T, handler http.Handler) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+
+	original := mlServiceURL
+	mlServiceURL = server.URL
+	t.Cleanup(func() { mlServiceURL = original })
+	return server
+}
+
+// useMLClient points code that uses mlClient directly, without running a
+// command, at baseURL.
+func useMLClient(t *testing.T, baseURL string) {
+	t.Helper()
+	client, err := api.NewMLClient(baseURL)
+	if err != nil {
+		t.Fatalf("Unexpected error creating client: %v", err)
+	}
+	original := mlClient
+	mlClient = client
+	t.Cleanup(func() { mlClient = original })
+}
+
+// captureOutput returns everything fn writes to os.Stdout.
+func captureOutput(t *testing.T, fn func()) string {
+	t.Helper()
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatalf("Error creating pipe: %v", err)
+	}
+
+	original := os.Stdout
+	os.Stdout = w
+	defer func() { os.Stdout = original }()
+
+	done := make(chan string)
+	go func() {
+		data, _ := io.ReadAll(r)
+		done <- string(data)
+	}()
+
+	fn()
+	w.Close()
+	return <-done
+}
+
+func resetFlags(cmd *cobra.Command) {
+	reset := func(f *pflag.Flag) {
+		if sv, ok := f.Value.(pflag.SliceValue); ok {
+			_ = sv.Replace(nil)
+		} else {
+			_ = f.Value.Set(f.DefValue)
+		}
+		f.Changed = false
+	}
+	cmd.Flags().VisitAll(reset)
+	cmd.PersistentFlags().VisitAll(reset)
+	for _, child := range cmd.Commands() {
+		resetFlags(child)
+	}
+}
+
+func TestAddFiles(t *testing.T) {
+	dir := t.TempDir()
+	valid := filepath.Join(dir, "valid.txt")
+	if err := os.WriteFile(valid, []byte("valid content"), 0o644); err != nil {
+		t.Fatalf("Error writing fixture: %v", err)
+	}
+	rejected := filepath.Join(dir, "rejected.txt")
+	if err := os.WriteFile(rejected, []byte("rejected content"), 0o644); err != nil {
+		t.Fatalf("Error writing fixture: %v", err)
+	}
+	missing := filepath.Join(dir, "missing.txt")
+
+	var added []string
+	add := func(text string) (string, error) {
+		if text == "rejected content" {
+			return "", errors.New("server error")
+		}
+		added = append(added, text)
+		return "doc-" + text, nil
+	}
+
+	results := addFiles(context.Background(), []string{valid, missing, rejected}, add)
+
+	if len(results) != 3 {
+		t.Fatalf("Expected 3 results, got %d", len(results))
+	}
+	if results[0].Err != nil || results[0].DocID != "doc-valid content" {
+		t.Errorf("Expected valid file to be added, got %+v", results[0])
+	}
+	if results[1].Err == nil {
+		t.Error("Expected error for missing file")
+	}
+	if results[2].Err == nil {
+		t.Error("Expected error for rejected file")
+	}
+	if len(added) != 1 {
+		t.Errorf("Expected 1 document added, got %d", len(added))
+	}
+
+	if err := printAddFilesSummary(results); err == nil {
+		t.Error("Expected summary error when some files failed")
+	}
+}
+
+func TestAddFilesAllValid(t *testing.T) {
+	dir := t.TempDir()
+	var paths []string
+	for _, name := range []string{"a.txt", "b.txt"} {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(name), 0o644); err != nil {
+			t.Fatalf("Error writing fixture: %v", err)
+		}
+		paths = append(paths, path)
+	}
+
+	results := addFiles(context.Background(), paths, func(text string) (string, error) {
+		return "doc-" + text, nil
+	})
+
+	for _, result := range results {
+		if result.Err != nil {
+			t.Errorf("Unexpected error for %s: %v", result.Path, result.Err)
+		}
+	}
+	if err := printAddFilesSummary(results); err != nil {
+		t.Errorf("Unexpected summary error: %v", err)
+	}
+}
+
+func TestAddFilesCancelled(t *testing.T) {
+	dir := t.TempDir()
+	var paths []string
+	for _, name := range []string{"a.txt", "b.txt", "c.txt"} {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(name), 0o644); err != nil {
+			t.Fatalf("Error writing fixture: %v", err)
+		}
+		paths = append(paths, path)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	var added []string
+	results := addFiles(ctx, paths, func(text string) (string, error) {
+		added = append(added, text)
+		cancel()
+		return "doc-" + text, nil
+	})
+
+	if len(added) != 1 || len(results) != 1 || results[0].DocID != "doc-a.txt" {
+		t.Errorf("Expected only the first file to be added, got %v and %+v", added, results)
+	}
+	if err := batchInterrupted(ctx, len(results), len(paths), "files"); err == nil || !strings.Contains(err.Error(), "interrupted after 1 of 3 files") {
+		t.Errorf("Expected interrupted error, got %v", err)
+	}
+	if err := batchInterrupted(ctx, len(paths), len(paths), "files"); err != nil {
+		t.Errorf("Expected no error once every file was handled, got %v", err)
+	}
+}
+
+func TestReadDocumentFileMarkdown(t *testing.T) {
+	dir := t.TempDir()
+	md := filepath.Join(dir, "note.md")
+	txt := filepath.Join(dir, "note.txt")
+	for _, path := range []string{md, txt} {
+		if err := os.WriteFile(path, []byte("# Heading\n**bold** text"), 0o644); err != nil {
+			t.Fatalf("Error writing fixture: %v", err)
+		}
+	}
+
+	text, err := readDocumentFile(md)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if text != "Heading\nbold text" {
+		t.Errorf("Expected markdown to be stripped, got %q", text)
+	}
+
+	text, err = readDocumentFile(txt)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if text != "# Heading\n**bold** text" {
+		t.Errorf("Expected plain text file to be unchanged, got %q", text)
+	}
+
+	stripMarkup = true
+	defer func() { stripMarkup = false }()
+	text, err = readDocumentFile(txt)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if text != "Heading\nbold text" {
+		t.Errorf("Expected --strip-markup to strip plain text file, got %q", text)
+	}
+}
+
+func TestReadDocumentFileHTML(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"page.html", "page.htm"} {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte("<html><head><title>Page</title></head><body><nav>Menu</nav><p>Body text</p></body></html>"), 0o644); err != nil {
+			t.Fatalf("Error writing fixture: %v", err)
+		}
+
+		text, err := readDocumentFile(path)
+		if err != nil {
+			t.Fatalf("Unexpected error for %s: %v", name, err)
+		}
+		if text != "Page\n\nBody text" {
+			t.Errorf("Expected HTML to be stripped for %s, got %q", name, text)
+		}
+	}
+}
+
+func TestAddMetadataFlag(t *testing.T) {
+	var body map[string]any
+	useTestServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body = nil
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Errorf("Error decoding request body: %v", err)
+		}
+		w.Write([]byte(`{"document_id": "doc123", "status": "stored"}`))
+	}))
+
+	tests := []struct {
+		name        string
+		args        []string
+		expectError bool
+		expected    map[string]any
+	}{
+		{name: "no metadata", args: []string{"add", "note"}},
+		{
+			name:     "repeated pairs",
+			args:     []string{"add", "note", "--meta", "project=apollo", "--meta", "url=https://x.test/?a=b"},
+			expected: map[string]any{"project": "apollo", "url": "https://x.test/?a=b"},
+		},
+		{name: "missing separator", args: []string{"add", "note", "--meta", "project"}, expectError: true},
+		{name: "empty key", args: []string{"add", "note", "--meta", "=apollo"}, expectError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var err error
+			captureOutput(t, func() { err = executeCommand(t, tt.args...) })
+
+			if tt.expectError {
+				if err == nil {
+					t.Error("Expected error but got none")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			metadata, ok := body["metadata"]
+			if tt.expected == nil {
+				if ok {
+					t.Errorf("Expected no metadata in body, got %v", metadata)
+				}
+				return
+			}
+			if !reflect.DeepEqual(metadata, tt.expected) {
+				t.Errorf("Expected metadata %v, got %v", tt.expected, metadata)
+			}
+		})
+	}
+}
+
+func TestAddEmptyText(t *testing.T) {
+	useTestServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("Expected no request, got %s %s", r.Method, r.URL.Path)
+	}))
+	blank := filepath.Join(t.TempDir(), "blank.txt")
+	if err := os.WriteFile(blank, []byte(" \n\n"), 0o644); err != nil {
+		t.Fatalf("Error writing fixture: %v", err)
+	}
+
+	for _, args := range [][]string{
+		{"add", ""},
+		{"add", "  \t\n"},
+		{"add", "--file", blank},
+		{"add", "--async", " "},
+	} {
+		resetFlags(rootCmd)
+		var err error
+		captureOutput(t, func() { err = executeCommand(t, args...) })
+		if err == nil || err.Error() != "document text must not be empty" {
+			t.Errorf("%v: expected empty text error, got %v", args, err)
+		}
+	}
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+
+	"github.com/berkayuckac/tidydata/internal/api"
+	"github.com/berkayuckac/tidydata/internal/ui"
+)
+
+var quiet bool
+
+func printJSON(v any) error {
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	encoder.SetEscapeHTML(false)
+	return encoder.Encode(v)
+}
+
+// printInfo prints a message meant for people rather than scripts. It is
+// suppressed by --quiet and by --output json or csv.
+func printInfo(format string, a ...any) {
+	if quiet || outputFormat == "json" || outputFormat == "csv" {
+		return
+	}
+	fmt.Printf(format, a...)
+}
+
+// printResult reports a command that produced a single ID: v as JSON with
+// --output json, the bare id with --quiet or --output csv, and message
+// otherwise.
+func printResult(v any, id, message string) error {
+	switch {
+	case outputFormat == "json":
+		return printJSON(v)
+	case quiet || outputFormat == "csv":
+		fmt.Println(id)
+	default:
+		fmt.Println(message)
+	}
+	return nil
+}
+
+// printSearchCSV writes one row per result. The content column holds the text
+// of a document or the filename of an image.
+func printSearchCSV(w io.Writer, results []api.UnifiedSearchResult) error {
+	return writeSearchCSV(w, results, true)
+}
+
+func writeSearchCSV(w io.Writer, results []api.UnifiedSearchResult, header bool) error {
+	cw := csv.NewWriter(w)
+	if header {
+		cw.Write([]string{"id", "score", "source_type", "content"})
+	}
+	for _, result := range results {
+		content := result.Content.Text
+		if result.SourceType == "image" {
+			content = result.Content.Metadata.Filename
+		}
+		cw.Write([]string{result.ID, strconv.FormatFloat(result.Score, 'f', -1, 64), result.SourceType, content})
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// printSearchTable writes results as aligned columns sized to the terminal,
+// or to --preview-length.
+func printSearchTable(w io.Writer, results []api.UnifiedSearchResult) error {
+	tf := ui.NewTableFormatter(w, noColor)
+	tf.ContentWidth = previewLength
+	rows := make([]ui.TableRow, len(results))
+	for i, result := range results {
+		content := result.Content.Text
+		if result.Content.HighlightedText != "" {
+			content = result.Content.HighlightedText
+			tf.Highlight = true
+		}
+		if result.SourceType == "image" {
+			content = result.Content.Metadata.Filename
+		}
+		rows[i] = ui.TableRow{Score: result.Score, Type: result.SourceType, ID: result.ID, Content: content}
+	}
+	return tf.Format(w, rows)
+}
+
+func printImageCSV(w io.Writer, images []api.ImageSummary) error {
+	cw := csv.NewWriter(w)
+	cw.Write([]string{"id", "filename", "description"})
+	for _, image := range images {
+		cw.Write([]string{image.ID, image.Metadata.Filename, image.Metadata.Description})
+	}
+	cw.Flush()
+	return cw.Error()
+}
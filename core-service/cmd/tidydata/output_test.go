@@ -0,0 +1,157 @@
+package main
+
+import (
+	"encoding/csv"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestQuietOutput(t *testing.T) {
+	useTestServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/documents":
+			w.Write([]byte(`{"document_id": "doc123", "status": "stored"}`))
+		case "/documents/batch":
+			w.Write([]byte(`{"document_ids": ["doc1", "doc2"]}`))
+		case "/search":
+			w.Write([]byte(searchFixture))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+
+	tests := []struct {
+		name     string
+		args     []string
+		expected string
+	}{
+		{
+			name:     "add without quiet",
+			args:     []string{"add", "hello"},
+			expected: "Successfully added document with ID: doc123\n",
+		},
+		{
+			name:     "add quiet",
+			args:     []string{"add", "hello", "--quiet"},
+			expected: "doc123\n",
+		},
+		{
+			name:     "add quiet json",
+			args:     []string{"add", "hello", "-q", "--output", "json"},
+			expected: "{\n  \"document_id\": \"doc123\"\n}\n",
+		},
+		{
+			name:     "add chunks quiet",
+			args:     []string{"add", "First sentence. Second sentence.", "--chunk-size", "16", "-q"},
+			expected: "doc1\ndoc2\n",
+		},
+		{
+			name:     "search quiet drops header",
+			args:     []string{"search", "cats", "--type", "text", "--timing", "-q"},
+			expected: "Score: 0.80\nType: Text\nContent: cats are great\n---\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var err error
+			out := captureOutput(t, func() { err = executeCommand(t, tt.args...) })
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if out != tt.expected {
+				t.Errorf("Expected output %q, got %q", tt.expected, out)
+			}
+		})
+	}
+}
+
+func TestCSVOutput(t *testing.T) {
+	useTestServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/search":
+			w.Write([]byte(`{"query": "cats", "results": [
+				{"id": "doc1", "score": 0.8, "source_type": "text", "content": {"text": "cats, dogs\nand \"birds\""}},
+				{"id": "img1", "score": 0.25, "source_type": "image", "content": {"metadata": {"filename": "cat.jpg"}}}
+			], "time_taken": 0.01}`))
+		case "/images":
+			w.Write([]byte(`{"images": [
+				{"id": "img1", "metadata": {"filename": "cat.jpg", "description": "a cat, asleep"}},
+				{"id": "img2", "metadata": {"filename": "dog.png"}}
+			], "total": 2}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+
+	tests := []struct {
+		name     string
+		args     []string
+		expected [][]string
+	}{
+		{
+			name: "search",
+			args: []string{"search", "cats", "--output", "csv", "--timing"},
+			expected: [][]string{
+				{"id", "score", "source_type", "content"},
+				{"doc1", "0.8", "text", "cats, dogs\nand \"birds\""},
+				{"img1", "0.25", "image", "cat.jpg"},
+			},
+		},
+		{
+			name: "image list",
+			args: []string{"image", "list", "-o", "csv"},
+			expected: [][]string{
+				{"id", "filename", "description"},
+				{"img1", "cat.jpg", "a cat, asleep"},
+				{"img2", "dog.png", ""},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var err error
+			out := captureOutput(t, func() { err = executeCommand(t, tt.args...) })
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			records, err := csv.NewReader(strings.NewReader(out)).ReadAll()
+			if err != nil {
+				t.Fatalf("Output is not valid CSV: %v\n%s", err, out)
+			}
+			if !reflect.DeepEqual(records, tt.expected) {
+				t.Errorf("Expected rows %q, got %q", tt.expected, records)
+			}
+		})
+	}
+}
+
+func TestTableOutput(t *testing.T) {
+	useSearchServer(t, searchFixture)
+
+	resetFlags(rootCmd)
+	var err error
+	out := captureOutput(t, func() { err = executeCommand(t, "search", "cats", "--output", "table") })
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	for _, expected := range []string{
+		"SCORE  TYPE   ID    CONTENT\n",
+		"0.80   text   doc1  cats are great\n",
+		"0.30   image  img1  cat.jpg\n",
+		"Query time: 0.012s",
+	} {
+		if !strings.Contains(out, expected) {
+			t.Errorf("Expected output to contain %q, got:\n%s", expected, out)
+		}
+	}
+
+	resetFlags(rootCmd)
+	if err := executeCommand(t, "search", "cats", "--output", "table", "--stream"); err == nil {
+		t.Error("Expected error combining --output table and --stream")
+	}
+}
@@ -0,0 +1,117 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/berkayuckac/tidydata/internal/ui"
+	"github.com/spf13/cobra"
+)
+
+// noPagerAnnotation marks commands whose output is never paged.
+const noPagerAnnotation = "tidydata_no_pager"
+
+var usePager bool
+
+// stdoutIsTerminal reports whether standard output is a terminal; replaced
+// in tests.
+var stdoutIsTerminal = func() bool { return ui.IsTerminal(os.Stdout) }
+
+// activePager is the pager os.Stdout writes to while a command runs with
+// --pager.
+var activePager *pager
+
+type pager struct {
+	cmd    *exec.Cmd
+	w      *os.File
+	stdout *os.File
+}
+
+// pagerCommand returns the pager from $PAGER, or less when it is unset.
+func pagerCommand() []string {
+	if fields := strings.Fields(os.Getenv("PAGER")); len(fields) > 0 {
+		return fields
+	}
+	return []string{"less"}
+}
+
+// shouldPage reports whether the output of cmd goes through a pager: only
+// with --pager, for text or table output to a terminal, and not for commands
+// that ask questions or run until stopped.
+func shouldPage(cmd *cobra.Command) bool {
+	if !usePager || (outputFormat != "text" && outputFormat != "table") || !stdoutIsTerminal() {
+		return false
+	}
+	if cmd.Annotations[noPagerAnnotation] != "" {
+		return false
+	}
+	for _, name := range []string{"interactive", "watch"} {
+		if f := cmd.Flags().Lookup(name); f != nil && f.Changed {
+			return false
+		}
+	}
+	return pagerCommand()[0] != "cat"
+}
+
+// startPager starts the pager and points os.Stdout at it until stopPager.
+// When $PAGER is unset and less is not installed, output is not paged.
+func startPager() error {
+	args := pagerCommand()
+	r, w, err := os.Pipe()
+	if err != nil {
+		return fmt.Errorf("error starting pager: %w", err)
+	}
+
+	cmd := exec.Command(args[0], args[1:]...)
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = r, os.Stdout, os.Stderr
+	cmd.Env = os.Environ()
+	less := filepath.Base(args[0]) == "less"
+	if less && os.Getenv("LESS") == "" {
+		// As git does: quit if the output fits on one screen, pass colors
+		// through and leave the screen as it was.
+		cmd.Env = append(cmd.Env, "LESS=FRX")
+	}
+	if err := cmd.Start(); err != nil {
+		r.Close()
+		w.Close()
+		if errors.Is(err, exec.ErrNotFound) && os.Getenv("PAGER") == "" {
+			return nil
+		}
+		return fmt.Errorf("error starting pager %s: %w", args[0], err)
+	}
+	r.Close()
+
+	activePager = &pager{cmd: cmd, w: w, stdout: os.Stdout}
+	os.Stdout = w
+	ui.ForceColor = less && (os.Getenv("LESS") == "" || strings.ContainsAny(os.Getenv("LESS"), "Rr"))
+	return nil
+}
+
+// stopPager waits for the user to quit the pager and restores os.Stdout.
+// Once the pager has quit, writes to it fail with EPIPE instead of ending
+// the program as they would on the real stdout, so quitting early is safe.
+func stopPager() {
+	if activePager == nil {
+		return
+	}
+	activePager.w.Close()
+	// The pager's exit status says nothing about the command's output.
+	_ = activePager.cmd.Wait()
+	os.Stdout = activePager.stdout
+	ui.ForceColor = false
+	activePager = nil
+}
+
+func init() {
+	for _, cmd := range []*cobra.Command{shellCmd, watchCmd, completionCmd, restoreCmd, deduplicateCmd, deleteCmd, imageDeleteCmd} {
+		if cmd.Annotations == nil {
+			cmd.Annotations = make(map[string]string)
+		}
+		cmd.Annotations[noPagerAnnotation] = "true"
+	}
+	rootCmd.PersistentFlags().BoolVar(&usePager, "pager", false, "Show text output in $PAGER (less by default) when writing to a terminal")
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"os/exec"
+	"strings"
+	"testing"
+)
+
+func TestPager(t *testing.T) {
+	for _, name := range []string{"sed", "true"} {
+		if _, err := exec.LookPath(name); err != nil {
+			t.Skipf("%s is not installed", name)
+		}
+	}
+	jobs := 1
+	useTestServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		list := make([]string, jobs)
+		for i := range list {
+			list[i] = fmt.Sprintf(`{"job_id": "job-%d", "state": "done", "progress": 1}`, i)
+		}
+		fmt.Fprintf(w, `{"jobs": [%s], "total": %d}`, strings.Join(list, ", "), jobs)
+	}))
+	original := stdoutIsTerminal
+	stdoutIsTerminal = func() bool { return true }
+	t.Cleanup(func() { stdoutIsTerminal = original })
+
+	tests := []struct {
+		name        string
+		pager       string
+		args        []string
+		jobs        int
+		expected    string
+		expectError string
+	}{
+		{name: "piped through the pager", pager: "sed s/^/paged:/", args: []string{"job", "list", "--pager"}, expected: "paged:job-0  done"},
+		{name: "only with --pager", pager: "sed s/^/paged:/", args: []string{"job", "list"}, expected: "\njob-0  done"},
+		{name: "not for json", pager: "sed s/^/paged:/", args: []string{"job", "list", "--pager", "-o", "json"}, expected: `[
+  {
+    "job_id": "job-0"`},
+		{name: "cat disables paging", pager: "cat", args: []string{"job", "list", "--pager"}, expected: "\njob-0  done"},
+		{name: "pager quits early", pager: "true", args: []string{"job", "list", "--pager"}, jobs: 50000},
+		{name: "missing pager", pager: "no-such-pager-tidydata", args: []string{"job", "list", "--pager"}, expectError: "error starting pager no-such-pager-tidydata"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("PAGER", tt.pager)
+			jobs = max(tt.jobs, 1)
+			resetFlags(rootCmd)
+			var err error
+			out := captureOutput(t, func() {
+				err = executeCommand(t, tt.args...)
+				stopPager()
+			})
+
+			if tt.expectError != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.expectError) {
+					t.Errorf("Expected error containing %q, got %v", tt.expectError, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if !strings.Contains(out, tt.expected) {
+				t.Errorf("Expected output to contain %q, got:\n%s", tt.expected, out)
+			}
+			if tt.jobs > 0 && out != "" {
+				t.Errorf("Expected no output from a pager that reads nothing, got %d bytes", len(out))
+			}
+		})
+	}
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+// homeDir returns the user's home directory.
+func homeDir() (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("cannot determine home directory: %w", err)
+	}
+	return home, nil
+}
+
+// expandPath expands a leading ~ to the user's home directory, so paths
+// quoted in flags or stored in the config behave as they would in a shell.
+// Other paths are returned as given, so that messages show them as the user
+// typed them.
+func expandPath(path string) (string, error) {
+	if path != "~" && !strings.HasPrefix(path, "~/") && !strings.HasPrefix(path, "~"+string(filepath.Separator)) {
+		return path, nil
+	}
+	home, err := homeDir()
+	if err != nil {
+		return "", fmt.Errorf("error expanding %s: %w", path, err)
+	}
+	return filepath.Join(home, path[1:]), nil
+}
+
+// isPathFlag reports whether f was marked as a file or directory with
+// MarkFlagFilename or MarkFlagDirname.
+func isPathFlag(f *pflag.Flag) bool {
+	_, file := f.Annotations[cobra.BashCompFilenameExt]
+	_, dir := f.Annotations[cobra.BashCompSubdirsInDir]
+	return file || dir
+}
+
+// expandPathFlags runs expandPath on every path flag set on the command line.
+func expandPathFlags(flags *pflag.FlagSet) error {
+	var err error
+	flags.Visit(func(f *pflag.Flag) {
+		if err != nil || !isPathFlag(f) {
+			return
+		}
+		if sv, ok := f.Value.(pflag.SliceValue); ok {
+			paths := sv.GetSlice()
+			for i, path := range paths {
+				if paths[i], err = expandPath(path); err != nil {
+					return
+				}
+			}
+			err = sv.Replace(paths)
+			return
+		}
+		var path string
+		if path, err = expandPath(f.Value.String()); err == nil {
+			err = f.Value.Set(path)
+		}
+	})
+	return err
+}
@@ -0,0 +1,144 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/spf13/cobra"
+)
+
+func TestExpandPath(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+
+	tests := []struct {
+		path     string
+		expected string
+	}{
+		{path: "", expected: ""},
+		{path: "~", expected: home},
+		{path: "~/", expected: home},
+		{path: "~/sub/notes.txt", expected: filepath.Join(home, "sub", "notes.txt")},
+		{path: "notes.txt", expected: "notes.txt"},
+		{path: "./sub/../notes.txt", expected: "./sub/../notes.txt"},
+		{path: "/tmp/notes.txt", expected: "/tmp/notes.txt"},
+		{path: "~other/notes.txt", expected: "~other/notes.txt"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			path, err := expandPath(tt.path)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if path != tt.expected {
+				t.Errorf("Expected %q, got %q", tt.expected, path)
+			}
+		})
+	}
+}
+
+func TestExpandPathWithoutHome(t *testing.T) {
+	t.Setenv("HOME", "")
+
+	_, err := expandPath("~/notes.txt")
+	if err == nil || !strings.Contains(err.Error(), "cannot determine home directory") {
+		t.Errorf("Expected a home directory error, got %v", err)
+	}
+	if path, err := expandPath("/tmp/notes.txt"); err != nil || path != "/tmp/notes.txt" {
+		t.Errorf("Expected an absolute path to need no home directory, got %q, %v", path, err)
+	}
+}
+
+func TestExpandPathFlags(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+
+	var files []string
+	var dir, name string
+	cmd := &cobra.Command{Use: "test"}
+	cmd.Flags().StringArrayVar(&files, "file", nil, "")
+	cmd.Flags().StringVar(&dir, "dir", "", "")
+	cmd.Flags().StringVar(&name, "name", "", "")
+	_ = cmd.MarkFlagFilename("file")
+	_ = cmd.MarkFlagDirname("dir")
+
+	if err := cmd.ParseFlags([]string{"--file", "~/a.txt", "--file", "notes/b.txt", "--dir", "~", "--name", "~/c"}); err != nil {
+		t.Fatalf("Error parsing flags: %v", err)
+	}
+	if err := expandPathFlags(cmd.Flags()); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if len(files) != 2 || files[0] != filepath.Join(home, "a.txt") || files[1] != "notes/b.txt" {
+		t.Errorf("Expected expanded files, got %v", files)
+	}
+	if dir != home {
+		t.Errorf("Expected dir %q, got %q", home, dir)
+	}
+	if name != "~/c" {
+		t.Errorf("Expected a flag that is not a path to be unchanged, got %q", name)
+	}
+}
+
+func TestUpdateFileInHome(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	if err := os.WriteFile(filepath.Join(home, "note.txt"), []byte("from home"), 0o644); err != nil {
+		t.Fatalf("Error writing fixture: %v", err)
+	}
+
+	var received string
+	useTestServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Text string `json:"text"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Errorf("Error decoding request: %v", err)
+		}
+		received = body.Text
+		w.Write([]byte(`{"status": "updated"}`))
+	}))
+
+	var err error
+	captureOutput(t, func() { err = executeCommand(t, "update", "doc-1", "-f", "~/note.txt") })
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if received != "from home" {
+		t.Errorf("Expected the text of ~/note.txt, got %q", received)
+	}
+}
+
+func TestAddFilesKeepTypedPaths(t *testing.T) {
+	t.Chdir(t.TempDir())
+	if err := os.Mkdir("notes", 0o755); err != nil {
+		t.Fatalf("Error creating fixture directory: %v", err)
+	}
+	for _, name := range []string{filepath.Join("notes", "a.txt"), "b.txt"} {
+		if err := os.WriteFile(name, []byte("some text"), 0o644); err != nil {
+			t.Fatalf("Error writing fixture: %v", err)
+		}
+	}
+	useTestServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"document_id": "doc-1", "status": "stored"}`))
+	}))
+
+	var err error
+	out := captureOutput(t, func() { err = executeCommand(t, "add", "-f", "notes/a.txt", "-f", "b.txt") })
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	for _, expected := range []string{"notes/a.txt: doc-1\n", "b.txt: doc-1\n"} {
+		if !strings.Contains(out, expected) {
+			t.Errorf("Expected output to contain %q, got:\n%s", expected, out)
+		}
+	}
+	if strings.Contains(out, string(filepath.Separator)+"notes") {
+		t.Errorf("Expected paths as typed, got:\n%s", out)
+	}
+}
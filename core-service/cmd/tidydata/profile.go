@@ -0,0 +1,153 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/spf13/cobra"
+)
+
+var profileFlag string
+
+var profileCmd = &cobra.Command{
+	Use:   "profile",
+	Short: "Manage configuration profiles",
+	Long: `Manage named sets of settings, for example one per ML service. Commands use
+the profile given with --profile, then the one named by the TIDYDATA_PROFILE
+environment variable, and otherwise the active profile, which starts out as
+"default".`,
+}
+
+var profileCreateCmd = &cobra.Command{
+	Use:   "create [name]",
+	Short: "Create an empty profile",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		name := args[0]
+		file, err := loadConfig()
+		if err != nil {
+			return err
+		}
+		if _, ok := file.Profiles[name]; ok {
+			return fmt.Errorf("profile %q already exists", name)
+		}
+		file.Profiles[name] = map[string]string{}
+		if err := saveConfig(file); err != nil {
+			return err
+		}
+		printInfo("Created profile %s\n", name)
+		return nil
+	},
+}
+
+var profileSwitchCmd = &cobra.Command{
+	Use:   "switch [name]",
+	Short: "Make a profile the active one",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		name := args[0]
+		file, err := loadConfig()
+		if err != nil {
+			return err
+		}
+		if _, ok := file.Profiles[name]; !ok && name != defaultProfile {
+			return fmt.Errorf("profile %q does not exist", name)
+		}
+		file.Active = name
+		if err := saveConfig(file); err != nil {
+			return err
+		}
+		printInfo("Switched to profile %s\n", name)
+		return nil
+	},
+}
+
+var profileListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List profiles, marking the active one",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		file, err := loadConfig()
+		if err != nil {
+			return err
+		}
+		names := profileNames(file)
+		active := file.selectedProfile()
+
+		if outputFormat == "json" {
+			return printJSON(struct {
+				Active   string   `json:"active"`
+				Profiles []string `json:"profiles"`
+			}{active, names})
+		}
+		for _, name := range names {
+			marker := " "
+			if name == active {
+				marker = "*"
+			}
+			fmt.Printf("%s %s\n", marker, name)
+		}
+		return nil
+	},
+}
+
+var profileDeleteCmd = &cobra.Command{
+	Use:   "delete [name]",
+	Short: "Delete a profile and its settings",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		name := args[0]
+		if name == defaultProfile {
+			return fmt.Errorf("the default profile cannot be deleted (use tidydata config reset to clear it)")
+		}
+		file, err := loadConfig()
+		if err != nil {
+			return err
+		}
+		if _, ok := file.Profiles[name]; !ok {
+			return fmt.Errorf("profile %q does not exist", name)
+		}
+		delete(file.Profiles, name)
+		if file.Active == name {
+			file.Active = ""
+		}
+		if err := saveConfig(file); err != nil {
+			return err
+		}
+		printInfo("Deleted profile %s\n", name)
+		return nil
+	},
+}
+
+func init() {
+	profileCmd.AddCommand(profileCreateCmd)
+	profileCmd.AddCommand(profileSwitchCmd)
+	profileCmd.AddCommand(profileListCmd)
+	profileCmd.AddCommand(profileDeleteCmd)
+	rootCmd.AddCommand(profileCmd)
+	profileSwitchCmd.ValidArgsFunction = completeProfiles
+	profileDeleteCmd.ValidArgsFunction = completeProfiles
+}
+
+func completeProfiles(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if len(args) > 0 {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	file, err := loadConfig()
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveError
+	}
+	return profileNames(file), cobra.ShellCompDirectiveNoFileComp
+}
+
+// profileNames lists the stored profiles, always including default.
+func profileNames(file *configFile) []string {
+	names := []string{defaultProfile}
+	for name := range file.Profiles {
+		if name != defaultProfile {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names[1:])
+	return names
+}
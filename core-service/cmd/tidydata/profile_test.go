@@ -0,0 +1,216 @@
+package main
+
+import (
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func runProfile(t *testing.T, args ...string) (string, error) {
+	t.Helper()
+	resetFlags(rootCmd)
+	var err error
+	out := captureOutput(t, func() { err = executeCommand(t, append([]string{"profile"}, args...)...) })
+	return out, err
+}
+
+func TestProfileIsolation(t *testing.T) {
+	useConfigDir(t)
+
+	for _, name := range []string{"work", "home"} {
+		if _, err := runProfile(t, "create", name); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+	}
+	if _, err := runConfig(t, "set", "default-limit", "3", "--profile", "work"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if _, err := runConfig(t, "set", "log-level", "debug", "--profile", "home"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	tests := []struct {
+		profile  string
+		expected string
+	}{
+		{profile: "work", expected: "default-limit=3\n"},
+		{profile: "home", expected: "log-level=debug\n"},
+		{profile: "default", expected: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.profile, func(t *testing.T) {
+			out, err := runConfig(t, "list", "--profile", tt.profile)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if out != tt.expected {
+				t.Errorf("Expected %q, got %q", tt.expected, out)
+			}
+		})
+	}
+}
+
+func TestProfileSwitch(t *testing.T) {
+	useConfigDir(t)
+
+	var limit string
+	useTestServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		limit = r.URL.Query().Get("limit")
+		w.Write([]byte(searchFixture))
+	}))
+
+	runProfile(t, "create", "work")
+	runConfig(t, "set", "default-limit", "3", "--profile", "work")
+	runConfig(t, "set", "default-limit", "7")
+
+	search := func(args ...string) {
+		t.Helper()
+		resetFlags(rootCmd)
+		captureOutput(t, func() {
+			if err := executeCommand(t, append([]string{"search", "cats"}, args...)...); err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+		})
+	}
+
+	search()
+	if limit != "7" {
+		t.Errorf("Expected default profile limit 7, got %s", limit)
+	}
+
+	if _, err := runProfile(t, "switch", "work"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	search()
+	if limit != "3" {
+		t.Errorf("Expected work profile limit 3 after switch, got %s", limit)
+	}
+
+	search("--profile", "default")
+	if limit != "7" {
+		t.Errorf("Expected --profile to override the active profile, got %s", limit)
+	}
+
+	out, err := runProfile(t, "list")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if expected := "  default\n* work\n"; out != expected {
+		t.Errorf("Expected %q, got %q", expected, out)
+	}
+}
+
+func TestProfilePrecedence(t *testing.T) {
+	path := useConfigDir(t)
+
+	for _, name := range []string{"local", "staging", "prod"} {
+		runProfile(t, "create", name)
+		runConfig(t, "set", "ml-url", "http://"+name+":8000", "--profile", name)
+	}
+	runConfig(t, "set", "api-key", "secret", "--profile", "prod")
+	runProfile(t, "switch", "local")
+
+	tests := []struct {
+		name        string
+		env         string
+		args        []string
+		expected    string
+		expectError string
+	}{
+		{name: "active profile", expected: "Profile: local (active profile)\nFile: " + path + "\nml-url=http://local:8000\n"},
+		{name: "environment over active", env: "staging", expected: "Profile: staging (TIDYDATA_PROFILE)\n"},
+		{name: "flag over environment", env: "staging", args: []string{"--profile", "prod"}, expected: "Profile: prod (--profile)\n"},
+		{name: "api key masked", args: []string{"--profile", "prod"}, expected: "api-key=********\nml-url=http://prod:8000\n"},
+		{name: "missing from environment", env: "qa", expectError: `profile "qa" does not exist (selected by TIDYDATA_PROFILE`},
+		{name: "missing from flag", env: "staging", args: []string{"--profile", "qa"}, expectError: `profile "qa" does not exist (selected by --profile`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv(profileEnv, tt.env)
+			out, err := runConfig(t, append([]string{"show"}, tt.args...)...)
+
+			if tt.expectError != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.expectError) {
+					t.Errorf("Expected error containing %q, got %v", tt.expectError, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if !strings.Contains(out, tt.expected) {
+				t.Errorf("Expected output to contain %q, got:\n%s", tt.expected, out)
+			}
+		})
+	}
+
+	t.Setenv(profileEnv, "staging")
+	out, err := runProfile(t, "list")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if expected := "  default\n  local\n  prod\n* staging\n"; out != expected {
+		t.Errorf("Expected the environment's profile marked, got %q", out)
+	}
+
+	out, err = runConfig(t, "show", "-o", "json")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if !strings.Contains(out, `"profile": "staging"`) || !strings.Contains(out, `"source": "TIDYDATA_PROFILE"`) {
+		t.Errorf("Unexpected JSON output: %s", out)
+	}
+}
+
+func TestProfileErrors(t *testing.T) {
+	path := useConfigDir(t)
+
+	if err := executeCommand(t, "search", "cats", "--profile", "missing"); err == nil || !strings.Contains(err.Error(), `profile "missing" does not exist`) {
+		t.Errorf("Expected missing profile error, got %v", err)
+	}
+	if _, err := runProfile(t, "switch", "missing"); err == nil {
+		t.Error("Expected error switching to a missing profile")
+	}
+	if _, err := runProfile(t, "delete", "default"); err == nil {
+		t.Error("Expected error deleting the default profile")
+	}
+
+	runProfile(t, "create", "work")
+	if _, err := runProfile(t, "create", "work"); err == nil || !strings.Contains(err.Error(), "already exists") {
+		t.Errorf("Expected already exists error, got %v", err)
+	}
+	runProfile(t, "switch", "work")
+	if _, err := runProfile(t, "delete", "work"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	file, err := loadConfig()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if _, ok := file.Profiles["work"]; ok || file.Active != "" {
+		t.Errorf("Expected work profile removed and active reset, got %+v", file)
+	}
+
+	if _, err := os.Stat(filepath.Dir(path)); err != nil {
+		t.Errorf("Expected config directory: %v", err)
+	}
+}
+
+func TestLoadConfigFlatFile(t *testing.T) {
+	path := useConfigDir(t)
+	os.MkdirAll(filepath.Dir(path), 0o700)
+	if err := os.WriteFile(path, []byte("default-limit: \"5\"\n"), 0o600); err != nil {
+		t.Fatalf("Error writing fixture: %v", err)
+	}
+
+	out, err := runConfig(t, "get", "default-limit")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if out != "5\n" {
+		t.Errorf("Expected flat settings to load as the default profile, got %q", out)
+	}
+}
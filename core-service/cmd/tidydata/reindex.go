@@ -0,0 +1,100 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/berkayuckac/tidydata/internal/api"
+	"github.com/spf13/cobra"
+)
+
+var (
+	reindexWait         bool
+	reindexWaitTimeout  time.Duration
+	reindexPollInterval time.Duration
+)
+
+var reindexCmd = &cobra.Command{
+	Use:   "reindex",
+	Short: "Recompute the embeddings of all content",
+	Long: `Ask the ML service to recompute the embeddings of every document and
+image, for example after upgrading its models. The job runs on the server;
+with --wait the command polls its status until it finishes.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if reindexWaitTimeout < 0 {
+			return fmt.Errorf("--wait-timeout must not be negative, got %v", reindexWaitTimeout)
+		}
+		if reindexPollInterval <= 0 {
+			return fmt.Errorf("--poll-interval must be positive, got %v", reindexPollInterval)
+		}
+		if dryRun {
+			printDryRun(http.MethodPost, "/reindex")
+			return nil
+		}
+
+		ctx := cmd.Context()
+		if reindexWaitTimeout > 0 {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithTimeout(ctx, reindexWaitTimeout)
+			defer cancel()
+		}
+
+		status, err := mlClient.Reindex(ctx)
+		if err != nil {
+			return fmt.Errorf("error starting reindex: %w", err)
+		}
+		if reindexWait && !status.Done() {
+			status, err = waitUntilDone(ctx, reindexPollInterval, mlClient.ReindexStatus, func(s *api.ReindexResponse) {
+				printInfo("Reindexed %d of %d items\n", s.Processed, s.Total)
+			})
+			if err != nil {
+				return fmt.Errorf("error waiting for reindex: %w", err)
+			}
+		}
+		if status.Status == api.ReindexFailed {
+			return fmt.Errorf("reindex failed: %s", status.Error)
+		}
+
+		message := fmt.Sprintf("Reindex started for %d items", status.Total)
+		if status.Done() {
+			message = fmt.Sprintf("Reindexed %d items", status.Processed)
+		} else if !reindexWait {
+			message += "; run with --wait to follow it"
+		}
+		return printResult(status, status.Status, message)
+	},
+}
+
+// waitUntilDone polls status every interval, reporting each result to
+// progress, until it reports done or ctx ends.
+func waitUntilDone[S interface{ Done() bool }](ctx context.Context, interval time.Duration, status func(context.Context) (S, error), progress func(S)) (S, error) {
+	var zero S
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return zero, ctx.Err()
+		case <-ticker.C:
+		}
+
+		s, err := status(ctx)
+		if err != nil {
+			return zero, err
+		}
+		if s.Done() {
+			return s, nil
+		}
+		progress(s)
+	}
+}
+
+func init() {
+	rootCmd.AddCommand(reindexCmd)
+	reindexCmd.Flags().BoolVar(&reindexWait, "wait", false, "Wait until the reindex has finished, showing progress")
+	reindexCmd.Flags().DurationVar(&reindexWaitTimeout, "wait-timeout", 0, "Give up waiting after this long (0 waits indefinitely)")
+	reindexCmd.Flags().DurationVar(&reindexPollInterval, "poll-interval", 2*time.Second, "How often to check progress with --wait")
+}
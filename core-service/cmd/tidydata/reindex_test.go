@@ -0,0 +1,125 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/berkayuckac/tidydata/internal/api"
+)
+
+func TestWaitUntilDone(t *testing.T) {
+	states := []api.ReindexResponse{
+		{Status: api.ReindexRunning, Total: 3, Processed: 1},
+		{Status: api.ReindexRunning, Total: 3, Processed: 2},
+		{Status: api.ReindexCompleted, Total: 3, Processed: 3},
+	}
+	calls := 0
+	status := func(context.Context) (*api.ReindexResponse, error) {
+		s := states[min(calls, len(states)-1)]
+		calls++
+		return &s, nil
+	}
+
+	var progress []int
+	final, err := waitUntilDone(context.Background(), time.Millisecond, status, func(s *api.ReindexResponse) {
+		progress = append(progress, s.Processed)
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if final.Status != api.ReindexCompleted || calls != 3 {
+		t.Errorf("Expected completed after 3 polls, got %+v after %d", final, calls)
+	}
+	if len(progress) != 2 || progress[0] != 1 || progress[1] != 2 {
+		t.Errorf("Expected progress [1 2], got %v", progress)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	running := func(context.Context) (*api.ReindexResponse, error) {
+		return &api.ReindexResponse{Status: api.ReindexRunning}, nil
+	}
+	if _, err := waitUntilDone(ctx, time.Millisecond, running, func(*api.ReindexResponse) {}); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected deadline exceeded, got %v", err)
+	}
+}
+
+func TestReindexCommand(t *testing.T) {
+	finalStatus := `{"status": "completed", "total": 3, "processed": 3}`
+	polls := 0
+	useTestServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/reindex":
+			polls = 0
+			w.WriteHeader(http.StatusAccepted)
+			w.Write([]byte(`{"status": "running", "total": 3, "processed": 0}`))
+		case "/reindex/status":
+			polls++
+			if polls < 2 {
+				w.Write([]byte(`{"status": "running", "total": 3, "processed": 1}`))
+				return
+			}
+			w.Write([]byte(finalStatus))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+
+	tests := []struct {
+		name        string
+		args        []string
+		final       string
+		expectError string
+		expected    []string
+	}{
+		{
+			name:     "without wait",
+			args:     []string{"reindex"},
+			expected: []string{"Reindex started for 3 items; run with --wait to follow it"},
+		},
+		{
+			name:     "wait until completed",
+			args:     []string{"reindex", "--wait", "--poll-interval", "1ms"},
+			expected: []string{"Reindexed 1 of 3 items\n", "Reindexed 3 items\n"},
+		},
+		{
+			name:        "wait until failed",
+			args:        []string{"reindex", "--wait", "--poll-interval", "1ms"},
+			final:       `{"status": "failed", "total": 3, "processed": 2, "error": "out of memory"}`,
+			expectError: "reindex failed: out of memory",
+		},
+		{name: "bad poll interval", args: []string{"reindex", "--poll-interval", "0s"}, expectError: "--poll-interval"},
+		{name: "negative wait timeout", args: []string{"reindex", "--wait-timeout", "-1s"}, expectError: "--wait-timeout must not be negative"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resetFlags(rootCmd)
+			finalStatus = `{"status": "completed", "total": 3, "processed": 3}`
+			if tt.final != "" {
+				finalStatus = tt.final
+			}
+			var err error
+			out := captureOutput(t, func() { err = executeCommand(t, tt.args...) })
+
+			if tt.expectError != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.expectError) {
+					t.Errorf("Expected error containing %q, got %v", tt.expectError, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			for _, expected := range tt.expected {
+				if !strings.Contains(out, expected) {
+					t.Errorf("Expected output to contain %q, got:\n%s", expected, out)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,146 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/berkayuckac/tidydata/internal/api"
+)
+
+type searchOptions struct {
+	limit      int
+	threshold  float64
+	sourceType string
+}
+
+func (o searchOptions) validate() error {
+	if o.limit <= 0 {
+		return fmt.Errorf("limit must be positive, got %d", o.limit)
+	}
+	if o.threshold < 0 || o.threshold > 1 {
+		return fmt.Errorf("threshold must be between 0.0 and 1.0, got %.2f", o.threshold)
+	}
+	switch o.sourceType {
+	case "all", "text", "image":
+		return nil
+	default:
+		return fmt.Errorf("type must be one of text, image or all, got %q", o.sourceType)
+	}
+}
+
+type searchFunc func(query string, limit int, scoreThreshold float64) (*api.UnifiedSearchResponse, error)
+
+const replHelp = `Enter a query to search, or one of:
+  :limit N       set the maximum number of results
+  :threshold F   set the minimum similarity score (0.0 to 1.0)
+  :type T        show only text, image or all results
+  :help          show this help
+  quit           exit (Ctrl-D and Ctrl-C also exit)
+`
+
+// runInteractiveSearch reads queries from in line by line and writes results
+// to out until in is exhausted, the user types quit, or ctx is cancelled.
+func runInteractiveSearch(ctx context.Context, in io.Reader, out io.Writer, opts searchOptions, search searchFunc) error {
+	lines := make(chan string)
+	go func() {
+		defer close(lines)
+		scanner := bufio.NewScanner(in)
+		for scanner.Scan() {
+			select {
+			case lines <- scanner.Text():
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	fmt.Fprint(out, "Type :help for commands.\n")
+	for {
+		fmt.Fprint(out, "tidydata> ")
+
+		var line string
+		select {
+		case <-ctx.Done():
+			fmt.Fprintln(out)
+			return nil
+		case l, ok := <-lines:
+			if !ok {
+				fmt.Fprintln(out)
+				return nil
+			}
+			line = strings.TrimSpace(l)
+		}
+
+		switch {
+		case line == "":
+			continue
+		case line == "quit" || line == "exit":
+			return nil
+		case strings.HasPrefix(line, ":"):
+			if err := applyReplCommand(&opts, line, out); err != nil {
+				fmt.Fprintf(out, "Error: %v\n", err)
+			}
+			continue
+		}
+
+		resp, err := search(line, opts.limit, opts.threshold)
+		if err != nil {
+			fmt.Fprintf(out, "Error: %v\n", err)
+			if hint := errorHint(err); hint != "" {
+				fmt.Fprintf(out, "Hint: %s\n", hint)
+			}
+			continue
+		}
+		results := filterResults(resp.Results, opts.sourceType)
+		if len(results) == 0 {
+			fmt.Fprintln(out, "No results found.")
+			continue
+		}
+		printSearchResults(out, results)
+	}
+}
+
+func applyReplCommand(opts *searchOptions, line string, out io.Writer) error {
+	fields := strings.Fields(strings.TrimPrefix(line, ":"))
+	if len(fields) == 0 {
+		return fmt.Errorf("empty command")
+	}
+	if fields[0] == "help" {
+		fmt.Fprint(out, replHelp)
+		return nil
+	}
+	if len(fields) != 2 {
+		return fmt.Errorf("usage: :%s <value>", fields[0])
+	}
+
+	updated := *opts
+	switch fields[0] {
+	case "limit":
+		limit, err := strconv.Atoi(fields[1])
+		if err != nil {
+			return fmt.Errorf("invalid limit %q", fields[1])
+		}
+		updated.limit = limit
+	case "threshold":
+		threshold, err := strconv.ParseFloat(fields[1], 64)
+		if err != nil {
+			return fmt.Errorf("invalid threshold %q", fields[1])
+		}
+		updated.threshold = threshold
+	case "type":
+		updated.sourceType = fields[1]
+	default:
+		return fmt.Errorf("unknown command :%s (type :help for commands)", fields[0])
+	}
+
+	if err := updated.validate(); err != nil {
+		return err
+	}
+	*opts = updated
+	fmt.Fprintf(out, "%s set to %s\n", fields[0], fields[1])
+	return nil
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/berkayuckac/tidydata/internal/api"
+)
+
+type searchCall struct {
+	query     string
+	limit     int
+	threshold float64
+}
+
+func fakeSearch(calls *[]searchCall) searchFunc {
+	return func(query string, limit int, scoreThreshold float64) (*api.UnifiedSearchResponse, error) {
+		*calls = append(*calls, searchCall{query: query, limit: limit, threshold: scoreThreshold})
+		return &api.UnifiedSearchResponse{
+			Query: query,
+			Results: []api.UnifiedSearchResult{
+				{ID: "doc1", Score: 0.8, SourceType: "text", Content: api.UnifiedContent{Text: "text about " + query}},
+				{ID: "img1", Score: 0.4, SourceType: "image", Content: api.UnifiedContent{Metadata: api.ImageMetadata{Filename: query + ".jpg"}}},
+			},
+		}, nil
+	}
+}
+
+func TestRunInteractiveSearch(t *testing.T) {
+	var calls []searchCall
+	in := strings.NewReader("cats\n:limit 20\n:threshold 0.5\n:type image\n\ndogs\nquit\nnever searched\n")
+	var out bytes.Buffer
+
+	opts := searchOptions{limit: 10, threshold: 0.1, sourceType: "all"}
+	if err := runInteractiveSearch(context.Background(), in, &out, opts, fakeSearch(&calls)); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	expected := []searchCall{
+		{query: "cats", limit: 10, threshold: 0.1},
+		{query: "dogs", limit: 20, threshold: 0.5},
+	}
+	if len(calls) != len(expected) {
+		t.Fatalf("Expected %d searches, got %d: %+v", len(expected), len(calls), calls)
+	}
+	for i, call := range calls {
+		if call != expected[i] {
+			t.Errorf("Expected search %+v, got %+v", expected[i], call)
+		}
+	}
+
+	output := out.String()
+	if !strings.Contains(output, "Content: text about cats") {
+		t.Error("Expected text result for first query")
+	}
+	if strings.Contains(output, "Content: text about dogs") {
+		t.Error("Expected text results to be filtered out after :type image")
+	}
+	if !strings.Contains(output, "File: dogs.jpg") {
+		t.Error("Expected image result for second query")
+	}
+}
+
+func TestRunInteractiveSearchInvalidCommand(t *testing.T) {
+	var calls []searchCall
+	in := strings.NewReader(":limit zero\n:threshold 2\n:type video\n:bogus 1\nquery\n")
+	var out bytes.Buffer
+
+	opts := searchOptions{limit: 10, threshold: 0.1, sourceType: "all"}
+	if err := runInteractiveSearch(context.Background(), in, &out, opts, fakeSearch(&calls)); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if n := strings.Count(out.String(), "Error:"); n != 4 {
+		t.Errorf("Expected 4 errors, got %d:\n%s", n, out.String())
+	}
+	if len(calls) != 1 || calls[0] != (searchCall{query: "query", limit: 10, threshold: 0.1}) {
+		t.Errorf("Expected defaults to be kept after invalid commands, got %+v", calls)
+	}
+}
+
+func TestRunInteractiveSearchCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	pr, pw := io.Pipe()
+	defer pw.Close()
+
+	done := make(chan error, 1)
+	go func() {
+		var calls []searchCall
+		done <- runInteractiveSearch(ctx, pr, io.Discard, searchOptions{limit: 10, sourceType: "all"}, fakeSearch(&calls))
+	}()
+
+	cancel()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Errorf("Unexpected error: %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Expected interactive search to stop after cancellation")
+	}
+}
@@ -0,0 +1,119 @@
+package main
+
+import (
+	"encoding/base64"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/berkayuckac/tidydata/internal/api"
+	"github.com/berkayuckac/tidydata/internal/thumbnail"
+	"github.com/spf13/cobra"
+)
+
+var (
+	saveImagesDir string
+	thumbnailSize string
+)
+
+// resultImage is an image search result to write with --save-images.
+type resultImage struct {
+	ID       string
+	Metadata api.ImageMetadata
+	// Data is the base64 image the ML service returned.
+	Data string
+}
+
+func validateSaveImages() error {
+	if thumbnailSize == "" {
+		return nil
+	}
+	if saveImagesDir == "" {
+		return fmt.Errorf("--thumbnail can only be used with --save-images")
+	}
+	_, _, err := thumbnail.ParseSize(thumbnailSize)
+	return err
+}
+
+// saveResultImages writes images to --save-images under their original
+// filenames. With --thumbnail each also gets a scaled copy named
+// <name>.thumb.<ext>; an image that cannot be decoded is saved without one
+// and a warning.
+func saveResultImages(images []resultImage) error {
+	if saveImagesDir == "" {
+		return nil
+	}
+	var width, height int
+	if thumbnailSize != "" {
+		var err error
+		if width, height, err = thumbnail.ParseSize(thumbnailSize); err != nil {
+			return err
+		}
+	}
+	if err := os.MkdirAll(saveImagesDir, 0o755); err != nil {
+		return fmt.Errorf("error creating %s: %w", saveImagesDir, err)
+	}
+
+	saved := 0
+	for _, image := range images {
+		if image.Data == "" {
+			fmt.Fprintf(os.Stderr, "warning: no image data returned for %s\n", image.ID)
+			continue
+		}
+		data, err := base64.StdEncoding.DecodeString(image.Data)
+		if err != nil {
+			return fmt.Errorf("error decoding image %s: %w", image.ID, err)
+		}
+		path := imageSavePath(saveImagesDir, image.ID, image.Metadata, data)
+		if err := os.WriteFile(path, data, 0o644); err != nil {
+			return fmt.Errorf("error writing %s: %w", path, err)
+		}
+		saved++
+
+		if width == 0 {
+			continue
+		}
+		thumb, ext, err := thumbnail.Generate(data, width, height)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "warning: no thumbnail for %s: %v\n", path, err)
+			continue
+		}
+		thumbPath := strings.TrimSuffix(path, filepath.Ext(path)) + ".thumb" + ext
+		if err := os.WriteFile(thumbPath, thumb, 0o644); err != nil {
+			return fmt.Errorf("error writing %s: %w", thumbPath, err)
+		}
+	}
+	printInfo("Saved %d images to %s\n", saved, saveImagesDir)
+	return nil
+}
+
+func searchResultImages(results []api.UnifiedSearchResult) []resultImage {
+	var images []resultImage
+	for _, result := range results {
+		if result.SourceType == "image" {
+			images = append(images, resultImage{ID: result.ID, Metadata: result.Content.Metadata, Data: result.Content.ImageData})
+		}
+	}
+	return images
+}
+
+func similarResultImages(results []api.ImageResult) []resultImage {
+	images := make([]resultImage, len(results))
+	for i, result := range results {
+		images[i] = resultImage{ID: result.ID, Metadata: result.Metadata, Data: result.ImageData}
+	}
+	return images
+}
+
+func init() {
+	for _, cmd := range []*cobra.Command{searchCmd, imageSimilarCmd} {
+		cmd.Flags().StringVar(&saveImagesDir, "save-images", "", "Write the image results to this directory")
+		cmd.Flags().StringVar(&thumbnailSize, "thumbnail", "", "With --save-images, also write a thumbnail scaled to fit WxH, e.g. 200x150")
+		_ = cmd.MarkFlagDirname("save-images")
+	}
+	searchCmd.MarkFlagsMutuallyExclusive("save-images", "stream")
+	searchCmd.MarkFlagsMutuallyExclusive("save-images", "interactive")
+	searchCmd.MarkFlagsMutuallyExclusive("save-images", "count")
+	imageSimilarCmd.MarkFlagsMutuallyExclusive("save-images", "cross-modal")
+}
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"image"
+	"image/png"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestImageSimilarSaveImages(t *testing.T) {
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, image.NewRGBA(image.Rect(0, 0, 40, 20))); err != nil {
+		t.Fatalf("Error encoding fixture: %v", err)
+	}
+	pngData := base64.StdEncoding.EncodeToString(buf.Bytes())
+	broken := base64.StdEncoding.EncodeToString([]byte("RIFF....WEBPVP8 "))
+
+	useTestServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, `{"query_image": "query_image", "results": [
+			{"id": "img-1", "score": 0.9, "metadata": {"filename": "cat.png", "content_type": "image/png"}, "image_data": %q},
+			{"id": "img-2", "score": 0.8, "metadata": {"filename": "dog.webp", "content_type": "image/webp"}, "image_data": %q}]}`, pngData, broken)
+	}))
+
+	imagePath := filepath.Join(t.TempDir(), "photo.jpg")
+	if err := os.WriteFile(imagePath, []byte("jpeg bytes"), 0o644); err != nil {
+		t.Fatalf("Error writing fixture: %v", err)
+	}
+
+	tests := []struct {
+		name         string
+		thumbnail    string
+		expectThumbs bool
+		expectError  string
+	}{
+		{name: "images only"},
+		{name: "with thumbnails", thumbnail: "10x10", expectThumbs: true},
+		{name: "bad size", thumbnail: "10", expectError: "invalid size"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resetFlags(rootCmd)
+			dir := filepath.Join(t.TempDir(), "saved")
+			args := []string{"image", "similar", imagePath, "--save-images", dir}
+			if tt.thumbnail != "" {
+				args = append(args, "--thumbnail", tt.thumbnail)
+			}
+			var err error
+			captureOutput(t, func() { err = executeCommand(t, args...) })
+
+			if tt.expectError != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.expectError) {
+					t.Errorf("Expected error containing %q, got %v", tt.expectError, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			for _, name := range []string{"cat.png", "dog.webp"} {
+				if _, err := os.Stat(filepath.Join(dir, name)); err != nil {
+					t.Errorf("Expected %s to be saved: %v", name, err)
+				}
+			}
+			if _, err := os.Stat(filepath.Join(dir, "dog.thumb.webp")); err == nil {
+				t.Error("Expected no thumbnail for an image that cannot be decoded")
+			}
+
+			f, err := os.Open(filepath.Join(dir, "cat.thumb.png"))
+			if !tt.expectThumbs {
+				if err == nil {
+					f.Close()
+					t.Error("Expected no thumbnail without --thumbnail")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Expected a thumbnail: %v", err)
+			}
+			defer f.Close()
+			thumb, err := png.Decode(f)
+			if err != nil {
+				t.Fatalf("Error decoding thumbnail: %v", err)
+			}
+			if bounds := thumb.Bounds(); bounds.Dx() != 10 || bounds.Dy() != 5 {
+				t.Errorf("Expected a 10x5 thumbnail, got %dx%d", bounds.Dx(), bounds.Dy())
+			}
+		})
+	}
+}
+
+func TestThumbnailRequiresSaveImages(t *testing.T) {
+	resetFlags(rootCmd)
+	var err error
+	captureOutput(t, func() { err = executeCommand(t, "search", "cats", "--thumbnail", "10x10") })
+	if err == nil || !strings.Contains(err.Error(), "--thumbnail can only be used with --save-images") {
+		t.Errorf("Expected an error about --save-images, got %v", err)
+	}
+}
@@ -0,0 +1,121 @@
+package main
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/berkayuckac/tidydata/internal/api"
+	"github.com/spf13/cobra"
+)
+
+var (
+	searchSaveTo string
+	searchAppend bool
+)
+
+// saveSearchResults writes results to --save-to as a JSON list, CSV rows or
+// text, JSON unless --output was given. With --append the results are added to
+// the file; JSON and CSV results whose ID the file already holds are left out.
+func saveSearchResults(cmd *cobra.Command, query string, results []api.UnifiedSearchResult) error {
+	format := "json"
+	if cmd.Flags().Changed("output") {
+		format = outputFormat
+	}
+	if format == "table" {
+		return fmt.Errorf("--save-to cannot be used with --output table")
+	}
+
+	var existing []byte
+	if searchAppend {
+		data, err := os.ReadFile(searchSaveTo)
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("error reading %s: %w", searchSaveTo, err)
+		}
+		existing = data
+	}
+
+	var buf bytes.Buffer
+	added := len(results)
+	switch format {
+	case "json":
+		saved := []api.UnifiedSearchResult{}
+		if len(bytes.TrimSpace(existing)) > 0 {
+			if err := json.Unmarshal(existing, &saved); err != nil {
+				return fmt.Errorf("%s does not hold a JSON list of results: %w", searchSaveTo, err)
+			}
+		}
+		results = newSearchResults(savedIDs(saved), results)
+		added = len(results)
+		encoder := json.NewEncoder(&buf)
+		encoder.SetIndent("", "  ")
+		encoder.SetEscapeHTML(false)
+		if err := encoder.Encode(append(saved, results...)); err != nil {
+			return err
+		}
+	case "csv":
+		header := len(bytes.TrimSpace(existing)) == 0
+		if !header {
+			records, err := csv.NewReader(bytes.NewReader(existing)).ReadAll()
+			if err != nil {
+				return fmt.Errorf("%s does not hold CSV results: %w", searchSaveTo, err)
+			}
+			ids := make(map[string]bool, len(records))
+			for _, record := range records[1:] {
+				ids[record[0]] = true
+			}
+			results = newSearchResults(ids, results)
+			added = len(results)
+		}
+		buf.Write(existing)
+		if err := writeSearchCSV(&buf, results, header); err != nil {
+			return err
+		}
+	default:
+		if len(existing) > 0 {
+			buf.Write(existing)
+			buf.WriteString("\n")
+		}
+		fmt.Fprintf(&buf, "Search results for: %s\n\n", query)
+		printSearchResults(&buf, results)
+	}
+
+	if err := os.WriteFile(searchSaveTo, buf.Bytes(), 0o644); err != nil {
+		return fmt.Errorf("error writing %s: %w", searchSaveTo, err)
+	}
+	printInfo("Saved %d results to %s\n", added, searchSaveTo)
+	return nil
+}
+
+func savedIDs(results []api.UnifiedSearchResult) map[string]bool {
+	ids := make(map[string]bool, len(results))
+	for _, result := range results {
+		ids[result.ID] = true
+	}
+	return ids
+}
+
+// newSearchResults returns the results whose ID is not in ids.
+func newSearchResults(ids map[string]bool, results []api.UnifiedSearchResult) []api.UnifiedSearchResult {
+	var out []api.UnifiedSearchResult
+	for _, result := range results {
+		if !ids[result.ID] {
+			ids[result.ID] = true
+			out = append(out, result)
+		}
+	}
+	return out
+}
+
+func init() {
+	searchCmd.Flags().StringVar(&searchSaveTo, "save-to", "", "Write results to this file, as JSON unless --output is csv or text")
+	searchCmd.Flags().BoolVar(&searchAppend, "append", false, "Add results to the --save-to file instead of replacing it")
+	_ = searchCmd.MarkFlagFilename("save-to")
+	searchCmd.MarkFlagsMutuallyExclusive("save-to", "stream")
+	searchCmd.MarkFlagsMutuallyExclusive("save-to", "interactive")
+	searchCmd.MarkFlagsMutuallyExclusive("save-to", "count")
+	searchCmd.MarkFlagsMutuallyExclusive("save-to", "format")
+}
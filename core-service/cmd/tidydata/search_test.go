@@ -0,0 +1,882 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/berkayuckac/tidydata/internal/api"
+)
+
+const searchFixture = `{
+	"query": "cats",
+	"results": [
+		{"id": "doc1", "score": 0.8, "source_type": "text", "content": {"text": "cats are great"}},
+		{"id": "img1", "score": 0.3, "source_type": "image", "content": {"metadata": {"filename": "cat.jpg"}}}
+	],
+	"time_taken": 0.0123
+}`
+
+func useSearchServer(t *testing.T, body string) {
+	t.Helper()
+	useTestServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(body))
+	}))
+}
+
+func TestSearchTiming(t *testing.T) {
+	useSearchServer(t, searchFixture)
+
+	tests := []struct {
+		name            string
+		args            []string
+		expectTiming    bool
+		expectQueryTime bool
+	}{
+		{name: "without flag", args: []string{"search", "cats"}, expectQueryTime: true},
+		{name: "with flag", args: []string{"search", "cats", "--timing"}, expectTiming: true},
+		{name: "no timing", args: []string{"search", "cats", "--no-timing"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resetFlags(rootCmd)
+			var err error
+			out := captureOutput(t, func() { err = executeCommand(t, tt.args...) })
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			hasTiming := strings.Contains(out, "Time taken: 0.012300 seconds (server)")
+			if hasTiming != tt.expectTiming {
+				t.Errorf("Expected timing line present to be %v, got output:\n%s", tt.expectTiming, out)
+			}
+			hasQueryTime := strings.HasSuffix(out, "---\nQuery time: 0.012s\n")
+			if hasQueryTime != tt.expectQueryTime {
+				t.Errorf("Expected query time at the end to be %v, got output:\n%s", tt.expectQueryTime, out)
+			}
+			if !strings.Contains(out, "Content: cats are great") {
+				t.Errorf("Expected results in output, got:\n%s", out)
+			}
+		})
+	}
+}
+
+func TestSearchJSONTiming(t *testing.T) {
+	useSearchServer(t, searchFixture)
+
+	for _, timing := range []bool{false, true} {
+		args := []string{"search", "cats", "--output", "json"}
+		if timing {
+			args = append(args, "--timing")
+		}
+
+		resetFlags(rootCmd)
+		var err error
+		out := captureOutput(t, func() { err = executeCommand(t, args...) })
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+
+		var decoded map[string]any
+		if err := json.Unmarshal([]byte(out), &decoded); err != nil {
+			t.Fatalf("Expected valid JSON, got %q: %v", out, err)
+		}
+		if decoded["time_taken"] != 0.0123 {
+			t.Errorf("Expected time_taken 0.0123, got %v", decoded["time_taken"])
+		}
+		if _, ok := decoded["client_time"]; ok != timing {
+			t.Errorf("Expected client_time present to be %v, got %v", timing, decoded)
+		}
+	}
+}
+
+func TestSearchNoTiming(t *testing.T) {
+	useSearchServer(t, searchFixture)
+
+	resetFlags(rootCmd)
+	var err error
+	out := captureOutput(t, func() { err = executeCommand(t, "search", "cats", "--output", "json", "--no-timing") })
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	var decoded map[string]any
+	if err := json.Unmarshal([]byte(out), &decoded); err != nil {
+		t.Fatalf("Expected valid JSON, got %q: %v", out, err)
+	}
+	if _, ok := decoded["time_taken"]; ok {
+		t.Errorf("Expected no time_taken with --no-timing, got %v", decoded)
+	}
+
+	resetFlags(rootCmd)
+	err = executeCommand(t, "search", "cats", "--timing", "--no-timing")
+	if err == nil {
+		t.Error("Expected error combining --timing and --no-timing")
+	}
+}
+
+func TestPrintSearchResultsMetadata(t *testing.T) {
+	var out bytes.Buffer
+	printSearchResults(&out, []api.UnifiedSearchResult{{
+		Score:      0.7,
+		SourceType: "text",
+		Content: api.UnifiedContent{
+			Text:             "launch notes",
+			DocumentMetadata: map[string]string{"tag": "draft", "project": "apollo"},
+		},
+	}})
+
+	if !strings.Contains(out.String(), "Metadata: project=apollo, tag=draft\n") {
+		t.Errorf("Expected sorted metadata line, got:\n%s", out.String())
+	}
+}
+
+func TestSearchExplain(t *testing.T) {
+	tests := []struct {
+		name        string
+		explanation string
+		expected    []string
+		unexpected  string
+	}{
+		{
+			name:        "explanation",
+			explanation: `, "explanation": {"embedding_distance": 0.27, "token_overlap": 1, "model_name": "all-mpnet-base-v2"}`,
+			expected:    []string{"Explanation: embedding distance 0.2700, token overlap 1.00, model all-mpnet-base-v2\n"},
+			unexpected:  "Matched terms",
+		},
+		{
+			name:        "matched terms",
+			explanation: `, "explanation": {"embedding_distance": 0.27, "token_overlap": 1, "model_name": "all-mpnet-base-v2", "matched_terms": ["cats", "nap"]}`,
+			expected:    []string{"Explanation: embedding distance 0.2700", "Matched terms: cats, nap\n"},
+		},
+		{
+			name:     "omitted by server",
+			expected: []string{"Content: cats\nExplanation: not provided by the ML service\n---\n"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var explainParam string
+			useTestServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				explainParam = r.URL.Query().Get("explain")
+				w.Write([]byte(`{"query": "cats", "results": [{"id": "doc1", "score": 0.73, "source_type": "text", "content": {"text": "cats"}` +
+					tt.explanation + `}]}`))
+			}))
+
+			resetFlags(rootCmd)
+			var err error
+			out := captureOutput(t, func() { err = executeCommand(t, "search", "cats", "--explain") })
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if explainParam != "true" {
+				t.Errorf("Expected explain=true, got %q", explainParam)
+			}
+			for _, expected := range tt.expected {
+				if !strings.Contains(out, expected) {
+					t.Errorf("Expected output to contain %q, got:\n%s", expected, out)
+				}
+			}
+			if tt.unexpected != "" && strings.Contains(out, tt.unexpected) {
+				t.Errorf("Expected no %q in output, got:\n%s", tt.unexpected, out)
+			}
+		})
+	}
+
+	resetFlags(rootCmd)
+	useSearchServer(t, searchFixture)
+	out := captureOutput(t, func() { _ = executeCommand(t, "search", "cats") })
+	if strings.Contains(out, "Explanation") {
+		t.Errorf("Expected no explanation without --explain, got:\n%s", out)
+	}
+}
+
+func TestSearchSortFlags(t *testing.T) {
+	var rawQuery string
+	useTestServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rawQuery = r.URL.RawQuery
+		w.Write([]byte(searchFixture))
+	}))
+
+	tests := []struct {
+		name        string
+		args        []string
+		expectError bool
+		expected    string
+	}{
+		{name: "default", args: []string{"search", "cats"}, expected: "limit=10&query=cats&score_threshold=0.100000"},
+		{name: "date ascending", args: []string{"search", "cats", "--sort", "date", "--asc"}, expected: "limit=10&order=asc&query=cats&score_threshold=0.100000&sort=date"},
+		{name: "type descending", args: []string{"search", "cats", "--sort", "type", "--desc"}, expected: "limit=10&order=desc&query=cats&score_threshold=0.100000&sort=type"},
+		{name: "unknown field", args: []string{"search", "cats", "--sort", "size"}, expectError: true},
+		{name: "asc and desc", args: []string{"search", "cats", "--asc", "--desc"}, expectError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rawQuery = ""
+			var err error
+			captureOutput(t, func() { err = executeCommand(t, tt.args...) })
+
+			if tt.expectError {
+				if err == nil {
+					t.Error("Expected error but got none")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if rawQuery != tt.expected {
+				t.Errorf("Expected query %q, got %q", tt.expected, rawQuery)
+			}
+		})
+	}
+}
+
+func TestSearchFormat(t *testing.T) {
+	useSearchServer(t, searchFixture)
+
+	var err error
+	out := captureOutput(t, func() {
+		err = executeCommand(t, "search", "cats", "--format", `{{.ID}}\t{{printf "%.1f" .Score}}\t{{.Content.Text}}{{.Content.Metadata.Filename}}`)
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	expected := "doc1\t0.8\tcats are great\nimg1\t0.3\tcat.jpg\n"
+	if out != expected {
+		t.Errorf("Expected %q, got %q", expected, out)
+	}
+}
+
+func TestSearchFormatInvalid(t *testing.T) {
+	requests := 0
+	useTestServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+	}))
+
+	tests := []struct {
+		name   string
+		format string
+	}{
+		{name: "parse error", format: "{{.Score"},
+		{name: "unknown field", format: "{{.Title}}"},
+		{name: "unknown nested field", format: "{{.Content.Title}}"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := executeCommand(t, "search", "cats", "--format", tt.format)
+			if err == nil {
+				t.Fatal("Expected error but got none")
+			}
+			for _, field := range []string{".Score", ".Content.Text", ".Content.Metadata.Filename", ".Explanation.ModelName"} {
+				if !strings.Contains(err.Error(), field) {
+					t.Errorf("Expected error to list %s, got %q", field, err)
+				}
+			}
+		})
+	}
+
+	if requests != 0 {
+		t.Errorf("Expected no requests for an invalid template, got %d", requests)
+	}
+}
+
+func TestSearchNoResults(t *testing.T) {
+	useSearchServer(t, `{"query": "cats", "results": [], "time_taken": 0.01}`)
+
+	tests := []struct {
+		name     string
+		args     []string
+		expected string
+	}{
+		{name: "text", args: []string{"search", "cats", "--threshold", "0.5"}, expected: "No results found above threshold 0.50\n"},
+		{name: "json", args: []string{"search", "cats", "--output", "json"}, expected: `"results": []`},
+		{name: "quiet", args: []string{"search", "cats", "-q"}, expected: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resetFlags(rootCmd)
+			var err error
+			out := captureOutput(t, func() { err = executeCommand(t, tt.args...) })
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if tt.expected == "" && out != "" {
+				t.Errorf("Expected no output, got %q", out)
+			}
+			if !strings.Contains(out, tt.expected) {
+				t.Errorf("Expected output to contain %q, got:\n%s", tt.expected, out)
+			}
+		})
+	}
+}
+
+func TestSearchQueryTerms(t *testing.T) {
+	var query string
+	useTestServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query = r.URL.Query().Get("query")
+		w.Write([]byte(`{"query": "", "results": []}`))
+	}))
+
+	tests := []struct {
+		name          string
+		args          []string
+		expectError   bool
+		expectedQuery string
+	}{
+		{name: "single term", args: []string{"search", "red cats"}, expectedQuery: "red cats"},
+		{name: "several terms", args: []string{"search", "foo", "bar", "baz"}, expectedQuery: "foo bar baz"},
+		{name: "multi-word term quoted", args: []string{"search", "red cats", "dogs"}, expectedQuery: `"red cats" dogs`},
+		{name: "all", args: []string{"search", "--all", "red cats", "dogs"}, expectedQuery: `"red cats" AND dogs`},
+		{name: "any", args: []string{"search", "--any", "foo", "bar"}, expectedQuery: "foo OR bar"},
+		{name: "any with one term", args: []string{"search", "--any", "foo"}, expectedQuery: "foo"},
+		{name: "all and any", args: []string{"search", "--all", "--any", "foo", "bar"}, expectError: true},
+		{name: "no terms", args: []string{"search"}, expectError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resetFlags(rootCmd)
+			query = ""
+			var err error
+			captureOutput(t, func() { err = executeCommand(t, tt.args...) })
+
+			if tt.expectError {
+				if err == nil {
+					t.Error("Expected error but got none")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if query != tt.expectedQuery {
+				t.Errorf("Expected query %q, got %q", tt.expectedQuery, query)
+			}
+		})
+	}
+}
+
+func TestWarnHighThreshold(t *testing.T) {
+	tests := []struct {
+		threshold  float64
+		expectWarn bool
+	}{
+		{threshold: 0},
+		{threshold: 0.5},
+		{threshold: 0.9},
+		{threshold: 0.91, expectWarn: true},
+		{threshold: 1, expectWarn: true},
+	}
+
+	for _, tt := range tests {
+		var w bytes.Buffer
+		warnHighThreshold(&w, tt.threshold)
+		if tt.expectWarn && w.String() != "Warning: threshold above 0.90 may return no results.\n" {
+			t.Errorf("threshold %.2f: expected warning, got %q", tt.threshold, w.String())
+		}
+		if !tt.expectWarn && w.Len() > 0 {
+			t.Errorf("threshold %.2f: expected no warning, got %q", tt.threshold, w.String())
+		}
+	}
+}
+
+func TestSearchThresholdBounds(t *testing.T) {
+	useSearchServer(t, `{"query": "cats", "results": []}`)
+
+	tests := []struct {
+		threshold   string
+		expectError bool
+	}{
+		{threshold: "-0.01", expectError: true},
+		{threshold: "0"},
+		{threshold: "0.99"},
+		{threshold: "1"},
+		{threshold: "1.01", expectError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.threshold, func(t *testing.T) {
+			resetFlags(rootCmd)
+			var err error
+			captureOutput(t, func() { err = executeCommand(t, "search", "cats", "--threshold", tt.threshold) })
+			if tt.expectError && err == nil {
+				t.Error("Expected error but got none")
+			}
+			if !tt.expectError && err != nil {
+				t.Errorf("Unexpected error: %v", err)
+			}
+		})
+	}
+}
+
+func TestSearchMinResults(t *testing.T) {
+	var thresholds []string
+	useTestServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		param := r.URL.Query().Get("score_threshold")
+		thresholds = append(thresholds, strings.TrimRight(strings.TrimRight(param, "0"), "."))
+		min, _ := strconv.ParseFloat(param, 64)
+		resp := api.UnifiedSearchResponse{Query: "cats", Results: []api.UnifiedSearchResult{}}
+		for i, score := range []float64{0.9, 0.6, 0.35, 0.15} {
+			if score >= min {
+				resp.Results = append(resp.Results, api.UnifiedSearchResult{
+					ID: fmt.Sprintf("doc%d", i), Score: score, SourceType: "text", Content: api.UnifiedContent{Text: "cats"},
+				})
+			}
+		}
+		json.NewEncoder(w).Encode(resp)
+	}))
+
+	tests := []struct {
+		name               string
+		args               []string
+		expectError        bool
+		expectedThresholds string
+		expectedOutput     string
+	}{
+		{
+			name:               "not set",
+			args:               []string{"search", "cats", "--threshold", "0.8"},
+			expectedThresholds: "[0.8]",
+		},
+		{
+			name:               "already enough",
+			args:               []string{"search", "cats", "--threshold", "0.5", "--min-results", "2"},
+			expectedThresholds: "[0.5]",
+		},
+		{
+			name:               "lowered until found",
+			args:               []string{"search", "cats", "--threshold", "0.8", "--min-results", "3"},
+			expectedThresholds: "[0.8 0.7 0.6 0.5 0.4 0.3]",
+			expectedOutput:     "Lowered threshold from 0.80 to 0.30 to find at least 3 results\nSearch results for: cats (threshold: 0.30)",
+		},
+		{
+			name:               "stops at zero",
+			args:               []string{"search", "cats", "--threshold", "0.3", "--min-results", "5"},
+			expectedThresholds: "[0.3 0.2 0.1 0]",
+			expectedOutput:     "Lowered threshold from 0.30 to 0.00",
+		},
+		{
+			name:               "json reports threshold",
+			args:               []string{"search", "cats", "--threshold", "0.2", "--min-results", "4", "-o", "json"},
+			expectedThresholds: "[0.2 0.1]",
+			expectedOutput:     `"threshold": 0.1`,
+		},
+		{name: "more than limit", args: []string{"search", "cats", "--limit", "2", "--min-results", "3"}, expectError: true},
+		{name: "negative", args: []string{"search", "cats", "--min-results", "-1"}, expectError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resetFlags(rootCmd)
+			thresholds = nil
+			var err error
+			out := captureOutput(t, func() { err = executeCommand(t, tt.args...) })
+
+			if tt.expectError {
+				if err == nil {
+					t.Error("Expected error but got none")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if got := fmt.Sprint(thresholds); got != tt.expectedThresholds {
+				t.Errorf("Expected thresholds %s, got %s", tt.expectedThresholds, got)
+			}
+			if !strings.Contains(out, tt.expectedOutput) {
+				t.Errorf("Expected output to contain %q, got:\n%s", tt.expectedOutput, out)
+			}
+		})
+	}
+}
+
+func TestSearchStream(t *testing.T) {
+	useTestServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("stream") != "true" {
+			t.Errorf("Expected stream=true, got %q", r.URL.RawQuery)
+		}
+		w.Write([]byte(`{"id": "doc1", "score": 0.8, "source_type": "text", "content": {"text": "cats are great"}}` + "\n"))
+		w.Write([]byte(`{"id": "img1", "score": 0.3, "source_type": "image", "content": {"metadata": {"filename": "cat.jpg"}}}` + "\n"))
+	}))
+
+	tests := []struct {
+		name        string
+		args        []string
+		expectError bool
+		expected    []string
+		unexpected  string
+	}{
+		{
+			name:     "text",
+			args:     []string{"search", "cats", "--stream"},
+			expected: []string{"Search results for: cats", "Content: cats are great", "File: cat.jpg"},
+		},
+		{
+			name:       "type filter",
+			args:       []string{"search", "cats", "--stream", "--type", "image"},
+			expected:   []string{"File: cat.jpg"},
+			unexpected: "cats are great",
+		},
+		{
+			name:     "json lines",
+			args:     []string{"search", "cats", "--stream", "-o", "json"},
+			expected: []string{`{"id":"doc1","score":0.8,`, "\n" + `{"id":"img1","score":0.3,`},
+		},
+		{name: "csv", args: []string{"search", "cats", "--stream", "-o", "csv"}, expectError: true},
+		{name: "with min-results", args: []string{"search", "cats", "--stream", "--min-results", "1"}, expectError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resetFlags(rootCmd)
+			var err error
+			out := captureOutput(t, func() { err = executeCommand(t, tt.args...) })
+
+			if tt.expectError {
+				if err == nil {
+					t.Error("Expected error but got none")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			for _, expected := range tt.expected {
+				if !strings.Contains(out, expected) {
+					t.Errorf("Expected output to contain %q, got:\n%s", expected, out)
+				}
+			}
+			if tt.unexpected != "" && strings.Contains(out, tt.unexpected) {
+				t.Errorf("Expected output not to contain %q, got:\n%s", tt.unexpected, out)
+			}
+		})
+	}
+}
+
+func TestSearchPreviewLength(t *testing.T) {
+	useSearchServer(t, `{"query": "cats", "results": [
+		{"id": "doc1", "score": 0.8, "source_type": "text", "content": {"text": "ünïcödé cats are great"}}
+	]}`)
+
+	tests := []struct {
+		name        string
+		args        []string
+		expectError bool
+		expected    string
+	}{
+		{name: "full text by default", args: []string{"search", "cats"}, expected: "Content: ünïcödé cats are great\n"},
+		{name: "cut to length", args: []string{"search", "cats", "--preview-length", "10"}, expected: "Content: ünïcödé...\n"},
+		{name: "longer than text", args: []string{"search", "cats", "--preview-length", "100"}, expected: "Content: ünïcödé cats are great\n"},
+		{name: "json keeps full text", args: []string{"search", "cats", "--preview-length", "10", "-o", "json"}, expected: `"text": "ünïcödé cats are great"`},
+		{name: "negative", args: []string{"search", "cats", "--preview-length", "-1"}, expectError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resetFlags(rootCmd)
+			var err error
+			out := captureOutput(t, func() { err = executeCommand(t, tt.args...) })
+
+			if tt.expectError {
+				if err == nil {
+					t.Error("Expected error but got none")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if !strings.Contains(out, tt.expected) {
+				t.Errorf("Expected output to contain %q, got:\n%s", tt.expected, out)
+			}
+		})
+	}
+}
+
+func TestSearchHighlight(t *testing.T) {
+	var highlightParam string
+	useTestServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		highlightParam = r.URL.Query().Get("highlight")
+		highlighted := ""
+		if highlightParam == "true" {
+			highlighted = `, "highlighted_text": "<mark>cats</mark> are great"`
+		}
+		fmt.Fprintf(w, `{"query": "cats", "results": [
+			{"id": "doc1", "score": 0.8, "source_type": "text", "content": {"text": "cats are great"%s}},
+			{"id": "doc2", "score": 0.7, "source_type": "text", "content": {"text": "Many CATS nap"}},
+			{"id": "doc3", "score": 0.6, "source_type": "text", "content": {"text": "Work for a dog or a cat"}}
+		]}`, highlighted)
+	}))
+
+	tests := []struct {
+		name           string
+		args           []string
+		expectedParam  string
+		expectedOutput []string
+		unexpected     string
+	}{
+		{
+			name:           "markers without color",
+			args:           []string{"search", "cats", "--highlight"},
+			expectedParam:  "true",
+			expectedOutput: []string{"Content: **cats** are great\n", "Content: Many **CATS** nap\n"},
+		},
+		{
+			name:           "table",
+			args:           []string{"search", "cats", "--highlight", "-o", "table"},
+			expectedParam:  "true",
+			expectedOutput: []string{"doc1  **cats** are great", "doc2  Many **CATS** nap"},
+		},
+		{
+			name:           "disabled for json",
+			args:           []string{"search", "cats", "--highlight", "-o", "json"},
+			expectedOutput: []string{`"text": "cats are great"`},
+			unexpected:     "highlighted_text",
+		},
+		{
+			name:           "any marks whole words only",
+			args:           []string{"search", "--any", "cats", "dog", "--highlight"},
+			expectedParam:  "true",
+			expectedOutput: []string{"Content: Many **CATS** nap\n", "Content: Work for a **dog** or a cat\n"},
+		},
+		{name: "not requested", args: []string{"search", "cats"}, expectedOutput: []string{"Content: Many CATS nap\n"}, unexpected: "**"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resetFlags(rootCmd)
+			var err error
+			out := captureOutput(t, func() { err = executeCommand(t, tt.args...) })
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if highlightParam != tt.expectedParam {
+				t.Errorf("Expected highlight=%q, got %q", tt.expectedParam, highlightParam)
+			}
+			for _, expected := range tt.expectedOutput {
+				if !strings.Contains(out, expected) {
+					t.Errorf("Expected output to contain %q, got:\n%s", expected, out)
+				}
+			}
+			if tt.unexpected != "" && strings.Contains(out, tt.unexpected) {
+				t.Errorf("Expected output not to contain %q, got:\n%s", tt.unexpected, out)
+			}
+		})
+	}
+}
+
+func TestSearchCount(t *testing.T) {
+	tests := []struct {
+		name        string
+		body        string
+		args        []string
+		expectError bool
+		expected    string
+	}{
+		{name: "server count", body: `{"count": 7}`, args: []string{"search", "cats", "--count"}, expected: "7 results for: cats (threshold: 0.10)\n"},
+		{name: "counted from results", body: searchFixture, args: []string{"search", "cats", "--count"}, expected: "2 results for: cats"},
+		{name: "quiet", body: `{"count": 7}`, args: []string{"search", "cats", "--count", "-q"}, expected: "7\n"},
+		{name: "json", body: `{"count": 7}`, args: []string{"search", "cats", "--count", "-o", "json"}, expected: `"count": 7`},
+		{name: "with type", body: `{"count": 7}`, args: []string{"search", "cats", "--count", "--type", "text"}, expectError: true},
+		{name: "with stream", body: `{"count": 7}`, args: []string{"search", "cats", "--count", "--stream"}, expectError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			useTestServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Query().Get("count_only") != "true" {
+					t.Errorf("Expected count_only=true, got %q", r.URL.RawQuery)
+				}
+				w.Write([]byte(tt.body))
+			}))
+			resetFlags(rootCmd)
+			var err error
+			out := captureOutput(t, func() { err = executeCommand(t, tt.args...) })
+
+			if tt.expectError {
+				if err == nil {
+					t.Error("Expected error but got none")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if !strings.Contains(out, tt.expected) {
+				t.Errorf("Expected output to contain %q, got:\n%s", tt.expected, out)
+			}
+		})
+	}
+}
+
+func TestSearchDeduplicate(t *testing.T) {
+	useSearchServer(t, `{"query": "cats", "results": [
+		{"id": "doc1", "score": 0.9, "source_type": "text", "content": {"text": "Cats sleep most of the day in warm sunny spots."}},
+		{"id": "chunk1", "score": 0.85, "source_type": "text", "content": {"text": "cats sleep most of the day in warm sunny spots"}},
+		{"id": "doc2", "score": 0.8, "source_type": "text", "content": {"text": "Cats sleep most of the night in warm sunny spots."}}
+	], "time_taken": 0.01}`)
+
+	tests := []struct {
+		name     string
+		args     []string
+		expected []string
+	}{
+		{name: "off by default", args: []string{"search", "cats", "-o", "csv"}, expected: []string{"doc1", "chunk1", "doc2"}},
+		{name: "exact repeats removed", args: []string{"search", "cats", "-o", "csv", "--deduplicate"}, expected: []string{"doc1", "doc2"}},
+		{name: "lower threshold", args: []string{"search", "cats", "-o", "csv", "--deduplicate", "--dedup-threshold", "0.8"}, expected: []string{"doc1"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resetFlags(rootCmd)
+			var err error
+			out := captureOutput(t, func() { err = executeCommand(t, tt.args...) })
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			var ids []string
+			for _, line := range strings.Split(strings.TrimSpace(out), "\n")[1:] {
+				ids = append(ids, strings.Split(line, ",")[0])
+			}
+			if !reflect.DeepEqual(ids, tt.expected) {
+				t.Errorf("Expected %v, got %v", tt.expected, ids)
+			}
+		})
+	}
+
+	resetFlags(rootCmd)
+	if err := executeCommand(t, "search", "cats", "--deduplicate", "--dedup-threshold", "1.5"); err == nil {
+		t.Error("Expected an error for a threshold above 1")
+	}
+}
+
+func TestSearchSaveTo(t *testing.T) {
+	useTestServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("query") == "dogs" {
+			w.Write([]byte(`{"query": "dogs", "results": [
+				{"id": "doc2", "score": 0.7, "source_type": "text", "content": {"text": "Cats and dogs"}},
+				{"id": "doc3", "score": 0.6, "source_type": "text", "content": {"text": "Dogs bark"}}
+			], "time_taken": 0.01}`))
+			return
+		}
+		w.Write([]byte(`{"query": "cats", "results": [
+			{"id": "doc1", "score": 0.9, "source_type": "text", "content": {"text": "Cats sleep"}},
+			{"id": "doc2", "score": 0.8, "source_type": "text", "content": {"text": "Cats and dogs"}}
+		], "time_taken": 0.01}`))
+	}))
+	dir := t.TempDir()
+
+	savedIDs := func(t *testing.T, path string) []string {
+		t.Helper()
+		data, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatalf("Error reading saved results: %v", err)
+		}
+		var results []api.UnifiedSearchResult
+		if err := json.Unmarshal(data, &results); err != nil {
+			t.Fatalf("Saved results are not a JSON list: %v\n%s", err, data)
+		}
+		var ids []string
+		for _, result := range results {
+			ids = append(ids, result.ID)
+		}
+		return ids
+	}
+
+	tests := []struct {
+		name     string
+		args     []string
+		expected []string
+		output   string
+	}{
+		{name: "created as json", args: []string{"search", "cats"}, expected: []string{"doc1", "doc2"}, output: "Saved 2 results to "},
+		{name: "replaced", args: []string{"search", "dogs"}, expected: []string{"doc2", "doc3"}, output: "Saved 2 results to "},
+		{name: "appended without repeats", args: []string{"search", "cats", "--append"}, expected: []string{"doc2", "doc3", "doc1"}, output: "Saved 1 results to "},
+	}
+
+	path := filepath.Join(dir, "results.json")
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resetFlags(rootCmd)
+			var err error
+			out := captureOutput(t, func() { err = executeCommand(t, append(tt.args, "--save-to", path)...) })
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if !strings.Contains(out, tt.output+path) {
+				t.Errorf("Expected output to contain %q, got %q", tt.output+path, out)
+			}
+			if ids := savedIDs(t, path); !reflect.DeepEqual(ids, tt.expected) {
+				t.Errorf("Expected %v, got %v", tt.expected, ids)
+			}
+		})
+	}
+
+	t.Run("csv appended", func(t *testing.T) {
+		path := filepath.Join(dir, "results.csv")
+		for _, query := range []string{"cats", "dogs"} {
+			resetFlags(rootCmd)
+			if err := executeCommand(t, "search", query, "-o", "csv", "--save-to", path, "--append"); err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatalf("Error reading saved results: %v", err)
+		}
+		expected := "id,score,source_type,content\ndoc1,0.9,text,Cats sleep\ndoc2,0.8,text,Cats and dogs\ndoc3,0.6,text,Dogs bark\n"
+		if string(data) != expected {
+			t.Errorf("Expected %q, got %q", expected, data)
+		}
+	})
+
+	t.Run("text appended", func(t *testing.T) {
+		path := filepath.Join(dir, "results.txt")
+		for _, query := range []string{"cats", "dogs"} {
+			resetFlags(rootCmd)
+			if err := executeCommand(t, "search", query, "-o", "text", "--save-to", path, "--append"); err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatalf("Error reading saved results: %v", err)
+		}
+		if !strings.Contains(string(data), "Search results for: cats\n") || !strings.Contains(string(data), "Search results for: dogs\n") {
+			t.Errorf("Expected both searches in the file, got:\n%s", data)
+		}
+	})
+
+	errorTests := []struct {
+		name     string
+		args     []string
+		expected string
+	}{
+		{name: "append without save-to", args: []string{"search", "cats", "--append"}, expected: "--append can only be used with --save-to"},
+		{name: "table output", args: []string{"search", "cats", "-o", "table", "--save-to", path}, expected: "--save-to cannot be used with --output table"},
+		{name: "not a results file", args: []string{"search", "cats", "--save-to", filepath.Join(dir, "results.txt"), "--append"}, expected: "does not hold a JSON list of results"},
+	}
+	for _, tt := range errorTests {
+		t.Run(tt.name, func(t *testing.T) {
+			resetFlags(rootCmd)
+			err := executeCommand(t, tt.args...)
+			if err == nil || !strings.Contains(err.Error(), tt.expected) {
+				t.Errorf("Expected error containing %q, got %v", tt.expected, err)
+			}
+		})
+	}
+}
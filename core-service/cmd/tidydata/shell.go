@@ -0,0 +1,173 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	shellLimit     int
+	shellThreshold float64
+)
+
+const shellHelp = `Commands:
+  search <query>     search text and images
+  add <text>         add a text document
+  image add <path>   add an image
+  help               show this help
+  exit               leave the shell (Ctrl-D also exits)
+`
+
+var shellCmd = &cobra.Command{
+	Use:   "shell",
+	Short: "Start an interactive shell",
+	Long: `Start an interactive shell for running several commands against the ML
+service in one session. Commands are appended to ~/.tidydata/history.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		opts := searchOptions{limit: shellLimit, threshold: shellThreshold, sourceType: "all"}
+		if err := opts.validate(); err != nil {
+			return err
+		}
+
+		var history io.Writer
+		if f, err := openShellHistory(); err != nil {
+			fmt.Fprintf(os.Stderr, "history disabled: %v\n", err)
+		} else {
+			defer f.Close()
+			history = f
+		}
+		return runShell(cmd.Context(), os.Stdin, os.Stdout, history, opts)
+	},
+}
+
+// runShell reads commands from in until it is exhausted or the user types
+// exit. Each command is recorded in history when it is non-nil. Command
+// errors are printed and do not end the session.
+func runShell(ctx context.Context, in io.Reader, out io.Writer, history io.Writer, opts searchOptions) error {
+	scanner := bufio.NewScanner(in)
+	fmt.Fprint(out, "Type help for commands.\n")
+	for {
+		fmt.Fprint(out, "tidydata> ")
+		if !scanner.Scan() {
+			fmt.Fprintln(out)
+			return scanner.Err()
+		}
+
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		if history != nil {
+			fmt.Fprintln(history, line)
+		}
+		if line == "exit" || line == "quit" {
+			return nil
+		}
+
+		if err := runShellCommand(ctx, line, out, opts); err != nil {
+			fmt.Fprintf(out, "Error: %v\n", err)
+			if hint := errorHint(err); hint != "" {
+				fmt.Fprintf(out, "Hint: %s\n", hint)
+			}
+		}
+	}
+}
+
+func runShellCommand(ctx context.Context, line string, out io.Writer, opts searchOptions) error {
+	command, rest, _ := strings.Cut(line, " ")
+	rest = strings.TrimSpace(rest)
+
+	switch command {
+	case "help":
+		fmt.Fprint(out, shellHelp)
+		return nil
+	case "search":
+		if rest == "" {
+			return fmt.Errorf("usage: search <query>")
+		}
+		resp, err := mlClient.Search(ctx, rest, opts.limit, opts.threshold)
+		if err != nil {
+			return fmt.Errorf("error searching: %w", err)
+		}
+		if len(resp.Results) == 0 {
+			fmt.Fprintln(out, "No results found.")
+			return nil
+		}
+		printSearchResults(out, resp.Results)
+		return nil
+	case "add":
+		if rest == "" {
+			return fmt.Errorf("usage: add <text>")
+		}
+		if dryRun {
+			_, err := dryRunAddDocument(rest)
+			return err
+		}
+		docID, err := mlClient.AddDocument(ctx, rest)
+		if err != nil {
+			return fmt.Errorf("error adding document: %w", err)
+		}
+		fmt.Fprintf(out, "Successfully added document with ID: %s\n", docID)
+		return nil
+	case "image":
+		sub, path, _ := strings.Cut(rest, " ")
+		path = strings.TrimSpace(path)
+		if sub != "add" || path == "" {
+			return fmt.Errorf("usage: image add <path>")
+		}
+		imageData, err := readImageFile(path)
+		if err != nil {
+			return err
+		}
+		contentType := imageContentType(path, imageData)
+		if dryRun {
+			printDryRun(http.MethodPost, "/images",
+				fmt.Sprintf("filename: %s", filepath.Base(path)),
+				fmt.Sprintf("content type: %s", contentType),
+				fmt.Sprintf("image size: %d bytes", len(imageData)))
+			return nil
+		}
+		resp, err := mlClient.AddImage(ctx, imageData, filepath.Base(path), contentType)
+		if err != nil {
+			return fmt.Errorf("error adding image: %w", err)
+		}
+		fmt.Fprintf(out, "Successfully added image with ID: %s\n", resp.ImageID)
+		return nil
+	default:
+		return fmt.Errorf("unknown command %q (type help for commands)", command)
+	}
+}
+
+func shellHistoryPath() (string, error) {
+	dir, err := tidydataDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "history"), nil
+}
+
+func openShellHistory() (*os.File, error) {
+	path, err := shellHistoryPath()
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return nil, err
+	}
+	return os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+}
+
+func init() {
+	rootCmd.AddCommand(shellCmd)
+	shellCmd.Flags().IntVarP(&shellLimit, "limit", "l", 10, "Maximum number of search results to return")
+	shellCmd.Flags().Float64VarP(&shellThreshold, "threshold", "t", 0.1, "Minimum similarity score threshold (0.0 to 1.0)")
+}
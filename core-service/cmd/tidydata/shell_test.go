@@ -0,0 +1,84 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRunShell(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/search":
+			w.Write([]byte(searchFixture))
+		case "/documents":
+			w.Write([]byte(`{"document_id": "doc123", "status": "stored"}`))
+		case "/images":
+			w.Write([]byte(`{"image_id": "img456", "status": "stored"}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	useMLClient(t, server.URL)
+
+	imagePath := filepath.Join(t.TempDir(), "photo.png")
+	if err := os.WriteFile(imagePath, []byte("png bytes"), 0o644); err != nil {
+		t.Fatalf("Error writing fixture: %v", err)
+	}
+
+	in := bytes.NewBufferString("help\nsearch cats\n\nadd a new note\nimage add " + imagePath + "\nsearch\nbogus\nexit\nadd never added\n")
+	var out, history bytes.Buffer
+	opts := searchOptions{limit: 10, threshold: 0.1, sourceType: "all"}
+	if err := runShell(context.Background(), in, &out, &history, opts); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	for _, expected := range []string{
+		"image add <path>",
+		"Content: cats are great",
+		"File: cat.jpg",
+		"Successfully added document with ID: doc123",
+		"Successfully added image with ID: img456",
+		"Error: usage: search <query>",
+		`Error: unknown command "bogus"`,
+	} {
+		if !strings.Contains(out.String(), expected) {
+			t.Errorf("Expected output to contain %q, got:\n%s", expected, out.String())
+		}
+	}
+	if strings.Contains(out.String(), "never added") {
+		t.Error("Expected commands after exit to be ignored")
+	}
+
+	expectedHistory := "help\nsearch cats\nadd a new note\nimage add " + imagePath + "\nsearch\nbogus\nexit\n"
+	if history.String() != expectedHistory {
+		t.Errorf("Expected history %q, got %q", expectedHistory, history.String())
+	}
+}
+
+func TestOpenShellHistory(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+
+	f, err := openShellHistory()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	f.WriteString("search cats\n")
+	f.Close()
+
+	data, err := os.ReadFile(filepath.Join(home, ".tidydata", "history"))
+	if err != nil {
+		t.Fatalf("Expected history file to exist: %v", err)
+	}
+	if string(data) != "search cats\n" {
+		t.Errorf("Expected history %q, got %q", "search cats\n", data)
+	}
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// defaultMaxFileSize is the --max-size of add and update: the largest file
+// read as a document.
+const defaultMaxFileSize = 10 << 20
+
+var (
+	maxSizeFlag  string
+	maxFileBytes int64 = defaultMaxFileSize
+)
+
+// byteUnits are the suffixes parseByteSize accepts, largest first.
+var byteUnits = []struct {
+	suffix string
+	size   int64
+}{
+	{"GB", 1 << 30},
+	{"MB", 1 << 20},
+	{"KB", 1 << 10},
+	{"B", 1},
+}
+
+// parseByteSize parses a size such as 10MB, 512KB or 2048, where a plain
+// number is in bytes. Units are powers of 1024 and are not case-sensitive.
+func parseByteSize(s string) (int64, error) {
+	value := strings.ToUpper(strings.TrimSpace(s))
+	unit := int64(1)
+	for _, u := range byteUnits {
+		if strings.HasSuffix(value, u.suffix) {
+			value, unit = strings.TrimSpace(strings.TrimSuffix(value, u.suffix)), u.size
+			break
+		}
+	}
+	n, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || n < 0 || n > (1<<63-1)/unit {
+		return 0, fmt.Errorf("invalid size %q: expected a number of bytes, optionally followed by KB, MB or GB", s)
+	}
+	return n * unit, nil
+}
+
+// formatByteSize formats n in the largest unit it reaches, e.g. 52MB or 1.5KB.
+func formatByteSize(n int64) string {
+	for _, u := range byteUnits {
+		if n >= u.size {
+			if n%u.size == 0 {
+				return fmt.Sprintf("%d%s", n/u.size, u.suffix)
+			}
+			return fmt.Sprintf("%.1f%s", float64(n)/float64(u.size), u.suffix)
+		}
+	}
+	return fmt.Sprintf("%dB", n)
+}
+
+func init() {
+	addCmd.Flags().StringVar(&maxSizeFlag, "max-size", formatByteSize(defaultMaxFileSize), "Largest file to add, e.g. 512KB or 50MB (0 disables the limit)")
+	updateCmd.Flags().StringVar(&maxSizeFlag, "max-size", formatByteSize(defaultMaxFileSize), "Largest file to read with --file, e.g. 512KB or 50MB (0 disables the limit)")
+}
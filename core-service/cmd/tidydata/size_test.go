@@ -0,0 +1,119 @@
+package main
+
+import (
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestParseByteSize(t *testing.T) {
+	tests := []struct {
+		input       string
+		expected    int64
+		expectError bool
+	}{
+		{input: "2048", expected: 2048},
+		{input: "512KB", expected: 512 << 10},
+		{input: "10mb", expected: 10 << 20},
+		{input: "1 GB", expected: 1 << 30},
+		{input: "7B", expected: 7},
+		{input: "0", expected: 0},
+		{input: "", expectError: true},
+		{input: "MB", expectError: true},
+		{input: "1.5MB", expectError: true},
+		{input: "-1KB", expectError: true},
+		{input: "10TB", expectError: true},
+		{input: "99999999999GB", expectError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			n, err := parseByteSize(tt.input)
+			if tt.expectError {
+				if err == nil {
+					t.Errorf("Expected error, got %d", n)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if n != tt.expected {
+				t.Errorf("Expected %d, got %d", tt.expected, n)
+			}
+		})
+	}
+}
+
+func TestFormatByteSize(t *testing.T) {
+	tests := map[int64]string{
+		0:          "0B",
+		500:        "500B",
+		1536:       "1.5KB",
+		52 << 20:   "52MB",
+		3 << 30:    "3GB",
+		10<<20 + 1: "10.0MB",
+	}
+	for n, expected := range tests {
+		if got := formatByteSize(n); got != expected {
+			t.Errorf("Expected %d to format as %s, got %s", n, expected, got)
+		}
+	}
+}
+
+func TestAddMaxSize(t *testing.T) {
+	var received int
+	useTestServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received++
+		w.Write([]byte(`{"document_id": "doc123", "status": "stored"}`))
+	}))
+
+	dir := t.TempDir()
+	atLimit := filepath.Join(dir, "at-limit.txt")
+	if err := os.WriteFile(atLimit, []byte(strings.Repeat("a", 1<<10)), 0o644); err != nil {
+		t.Fatalf("Error writing fixture: %v", err)
+	}
+	overLimit := filepath.Join(dir, "over-limit.txt")
+	if err := os.WriteFile(overLimit, []byte(strings.Repeat("a", 2<<10)), 0o644); err != nil {
+		t.Fatalf("Error writing fixture: %v", err)
+	}
+
+	tests := []struct {
+		name        string
+		args        []string
+		expectError string
+	}{
+		{name: "at the limit", args: []string{"add", "--file", atLimit, "--max-size", "1KB"}},
+		{name: "above the limit", args: []string{"add", "--file", overLimit, "--max-size", "1KB"}, expectError: "file size 2KB exceeds max allowed 1KB (use --max-size to increase)"},
+		{name: "under the default", args: []string{"add", "--file", overLimit}},
+		{name: "limit disabled", args: []string{"add", "--file", overLimit, "--max-size", "0"}},
+		{name: "invalid size", args: []string{"add", "--file", atLimit, "--max-size", "big"}, expectError: "--max-size: invalid size"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resetFlags(rootCmd)
+			received = 0
+			var err error
+			captureOutput(t, func() { err = executeCommand(t, tt.args...) })
+
+			if tt.expectError != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.expectError) {
+					t.Errorf("Expected error containing %q, got %v", tt.expectError, err)
+				}
+				if received != 0 {
+					t.Errorf("Expected no document to be sent, got %d requests", received)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if received != 1 {
+				t.Errorf("Expected 1 request, got %d", received)
+			}
+		})
+	}
+}
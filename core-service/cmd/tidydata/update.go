@@ -0,0 +1,61 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"github.com/berkayuckac/tidydata/internal/api"
+	"github.com/spf13/cobra"
+)
+
+var updateFileFlag string
+
+var updateCmd = &cobra.Command{
+	Use:   "update [id] [text]",
+	Short: "Replace the text of an existing document",
+	Args:  cobra.RangeArgs(1, 2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		id := args[0]
+
+		var text string
+		if updateFileFlag != "" {
+			if len(args) > 1 {
+				return fmt.Errorf("provide the new text either as an argument or with --file, not both")
+			}
+			var err error
+			if maxFileBytes, err = parseByteSize(maxSizeFlag); err != nil {
+				return fmt.Errorf("--max-size: %w", err)
+			}
+			if text, err = readDocumentFile(updateFileFlag); err != nil {
+				return err
+			}
+		} else if len(args) > 1 {
+			text = args[1]
+		} else {
+			return fmt.Errorf("either provide text as an argument or use --file flag")
+		}
+
+		if dryRun {
+			printDryRun(http.MethodPut, "/documents/"+url.PathEscape(id), fmt.Sprintf("text length: %d characters", len(text)))
+			return nil
+		}
+
+		if err := mlClient.UpdateDocument(cmd.Context(), id, text); err != nil {
+			if errors.Is(err, api.ErrNotFound) {
+				return fmt.Errorf("no document found with ID: %s", id)
+			}
+			return fmt.Errorf("error updating document: %w", err)
+		}
+
+		return printResult(map[string]string{"document_id": id}, id,
+			fmt.Sprintf("Successfully updated document with ID: %s", id))
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(updateCmd)
+	updateCmd.Flags().StringVarP(&updateFileFlag, "file", "f", "", "Path to file containing the new text")
+	_ = updateCmd.MarkFlagFilename("file")
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestUpdateCommand(t *testing.T) {
+	dir := t.TempDir()
+	markdown := filepath.Join(dir, "notes.md")
+	if err := os.WriteFile(markdown, []byte("# Title\n\nSome **bold** text."), 0o644); err != nil {
+		t.Fatalf("Error writing fixture: %v", err)
+	}
+
+	tests := []struct {
+		name          string
+		args          []string
+		expectedText  string
+		expectedError string
+	}{
+		{
+			name:         "text argument",
+			args:         []string{"update", "doc-1", "new text"},
+			expectedText: "new text",
+		},
+		{
+			name:         "markdown file is extracted",
+			args:         []string{"update", "doc-1", "--file", markdown},
+			expectedText: "Title\n\nSome bold text.",
+		},
+		{
+			name:          "text and file",
+			args:          []string{"update", "doc-1", "new text", "--file", markdown},
+			expectedError: "not both",
+		},
+		{
+			name:          "file over max size",
+			args:          []string{"update", "doc-1", "--file", markdown, "--max-size", "10B"},
+			expectedError: "exceeds max allowed 10B",
+		},
+		{
+			name:          "no text",
+			args:          []string{"update", "doc-1"},
+			expectedError: "either provide text",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// --max-size sets the limit that watch and add also read.
+			t.Cleanup(func() { maxFileBytes = defaultMaxFileSize })
+			var received string
+			useTestServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.Method != http.MethodPut || r.URL.Path != "/documents/doc-1" {
+					t.Errorf("Expected PUT /documents/doc-1, got %s %s", r.Method, r.URL.Path)
+				}
+				var body struct {
+					Text string `json:"text"`
+				}
+				if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+					t.Errorf("Error decoding request: %v", err)
+				}
+				received = body.Text
+				w.Write([]byte(`{"document_id": "doc-1", "status": "updated"}`))
+			}))
+
+			var err error
+			captureOutput(t, func() { err = executeCommand(t, tt.args...) })
+			if tt.expectedError != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.expectedError) {
+					t.Errorf("Expected error containing %q, got %v", tt.expectedError, err)
+				}
+				if received != "" {
+					t.Errorf("Expected no request, got text %q", received)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if received != tt.expectedText {
+				t.Errorf("Expected text %q, got %q", tt.expectedText, received)
+			}
+		})
+	}
+}
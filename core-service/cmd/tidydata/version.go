@@ -0,0 +1,163 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+// latestReleaseURL is the GitHub API endpoint for the newest release.
+var latestReleaseURL = "https://api.github.com/repos/berkayuckac/tidydata/releases/latest"
+
+var versionCheck bool
+
+var versionCmd = &cobra.Command{
+	Use:   "version",
+	Short: "Print the version of tidydata",
+	Long: `Print the version of tidydata. With --check, also ask GitHub for the latest
+release and report whether an update is available.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if !versionCheck {
+			return printResult(map[string]string{"version": version}, version, "tidydata "+version)
+		}
+
+		latest, err := latestRelease(cmd.Context(), &http.Client{Timeout: timeout}, latestReleaseURL)
+		if err != nil {
+			return fmt.Errorf("error checking for updates: %w", err)
+		}
+		cmp, err := compareVersions(latest, version)
+		if err != nil {
+			return fmt.Errorf("error checking for updates: %w", err)
+		}
+
+		message := fmt.Sprintf("tidydata %s\nUp to date", version)
+		if cmp > 0 {
+			message = fmt.Sprintf("tidydata %s\nNew version available: %s", version, latest)
+		}
+		return printResult(map[string]any{"version": version, "latest": latest, "update_available": cmp > 0}, latest, message)
+	},
+}
+
+// latestRelease returns the tag name of the latest release at url.
+func latestRelease(ctx context.Context, client *http.Client, url string) (string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return "", fmt.Errorf("error creating request: %w", err)
+	}
+	req.Header.Set("Accept", "application/vnd.github+json")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("error sending request: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+	}
+
+	var release struct {
+		TagName string `json:"tag_name"`
+	}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(&release); err != nil {
+		return "", fmt.Errorf("error decoding response: %w", err)
+	}
+	if release.TagName == "" {
+		return "", fmt.Errorf("release has no tag name")
+	}
+	return release.TagName, nil
+}
+
+// compareVersions compares two semantic versions such as v1.2.3 or
+// 1.2.3-rc.1, returning -1, 0 or 1. A pre-release sorts before the release
+// it precedes; build metadata is ignored.
+func compareVersions(a, b string) (int, error) {
+	va, preA, err := parseVersion(a)
+	if err != nil {
+		return 0, err
+	}
+	vb, preB, err := parseVersion(b)
+	if err != nil {
+		return 0, err
+	}
+	for i := range va {
+		if va[i] != vb[i] {
+			if va[i] < vb[i] {
+				return -1, nil
+			}
+			return 1, nil
+		}
+	}
+	switch {
+	case preA == preB:
+		return 0, nil
+	case preA == "":
+		return 1, nil
+	case preB == "":
+		return -1, nil
+	}
+	return comparePrerelease(preA, preB), nil
+}
+
+func parseVersion(v string) ([3]int, string, error) {
+	var parts [3]int
+	s, _, _ := strings.Cut(strings.TrimPrefix(strings.TrimSpace(v), "v"), "+")
+	s, pre, _ := strings.Cut(s, "-")
+	fields := strings.Split(s, ".")
+	if len(fields) != 3 {
+		return parts, "", fmt.Errorf("invalid version %q: expected MAJOR.MINOR.PATCH", v)
+	}
+	for i, field := range fields {
+		n, err := strconv.Atoi(field)
+		if err != nil || n < 0 {
+			return parts, "", fmt.Errorf("invalid version %q: expected MAJOR.MINOR.PATCH", v)
+		}
+		parts[i] = n
+	}
+	return parts, pre, nil
+}
+
+// comparePrerelease compares dot-separated pre-release identifiers, numbers
+// numerically and anything else as text, as semantic versioning orders them.
+func comparePrerelease(a, b string) int {
+	idsA, idsB := strings.Split(a, "."), strings.Split(b, ".")
+	for i := 0; i < len(idsA) && i < len(idsB); i++ {
+		na, errA := strconv.Atoi(idsA[i])
+		nb, errB := strconv.Atoi(idsB[i])
+		switch {
+		case errA == nil && errB == nil:
+			if na != nb {
+				if na < nb {
+					return -1
+				}
+				return 1
+			}
+		case errA == nil:
+			return -1
+		case errB == nil:
+			return 1
+		default:
+			if c := strings.Compare(idsA[i], idsB[i]); c != 0 {
+				return c
+			}
+		}
+	}
+	switch {
+	case len(idsA) < len(idsB):
+		return -1
+	case len(idsA) > len(idsB):
+		return 1
+	}
+	return 0
+}
+
+func init() {
+	rootCmd.AddCommand(versionCmd)
+	versionCmd.Flags().BoolVar(&versionCheck, "check", false, "Check GitHub for a newer release (honors --timeout)")
+}
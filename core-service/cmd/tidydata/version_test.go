@@ -0,0 +1,135 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func useReleaseServer(t *testing.T, handler http.Handler) {
+	t.Helper()
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+	original := latestReleaseURL
+	latestReleaseURL = server.URL + "/repos/berkayuckac/tidydata/releases/latest"
+	t.Cleanup(func() { latestReleaseURL = original })
+}
+
+func TestVersionCheck(t *testing.T) {
+	var tag string
+	useReleaseServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/repos/berkayuckac/tidydata/releases/latest" {
+			t.Errorf("Unexpected path %s", r.URL.Path)
+		}
+		if accept := r.Header.Get("Accept"); accept != "application/vnd.github+json" {
+			t.Errorf("Expected GitHub JSON accept header, got %q", accept)
+		}
+		if tag == "" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		w.Write([]byte(`{"tag_name": "` + tag + `", "name": "Release"}`))
+	}))
+
+	tests := []struct {
+		name        string
+		tag         string
+		args        []string
+		expected    string
+		expectError string
+	}{
+		{name: "version only", args: []string{"version"}, expected: "tidydata " + version + "\n"},
+		{name: "newer release", tag: "v9.0.0", args: []string{"version", "--check"}, expected: "tidydata " + version + "\nNew version available: v9.0.0\n"},
+		{name: "same release", tag: version, args: []string{"version", "--check"}, expected: "tidydata " + version + "\nUp to date\n"},
+		{name: "older release", tag: "v0.0.1", args: []string{"version", "--check"}, expected: "tidydata " + version + "\nUp to date\n"},
+		{name: "json", tag: "v9.0.0", args: []string{"version", "--check", "--output", "json"}, expected: `"update_available": true`},
+		{name: "api error", args: []string{"version", "--check"}, expectError: "unexpected status code: 403"},
+		{name: "bad tag", tag: "nightly", args: []string{"version", "--check"}, expectError: `invalid version "nightly"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resetFlags(rootCmd)
+			tag = tt.tag
+			var err error
+			out := captureOutput(t, func() { err = executeCommand(t, tt.args...) })
+
+			if tt.expectError != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.expectError) {
+					t.Errorf("Expected error containing %q, got %v", tt.expectError, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if !strings.Contains(out, tt.expected) {
+				t.Errorf("Expected output to contain %q, got %q", tt.expected, out)
+			}
+		})
+	}
+}
+
+func TestVersionCheckTimeout(t *testing.T) {
+	done := make(chan struct{})
+	defer close(done)
+	useReleaseServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-done:
+		case <-r.Context().Done():
+		}
+	}))
+
+	resetFlags(rootCmd)
+	start := time.Now()
+	var err error
+	captureOutput(t, func() { err = executeCommand(t, "version", "--check", "--timeout", "50ms") })
+	if err == nil || !strings.Contains(err.Error(), "error checking for updates") {
+		t.Errorf("Expected a timeout error, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("Expected --timeout to stop the check, took %v", elapsed)
+	}
+}
+
+func TestCompareVersions(t *testing.T) {
+	tests := []struct {
+		a, b        string
+		expected    int
+		expectError bool
+	}{
+		{a: "v1.2.3", b: "v1.2.3", expected: 0},
+		{a: "1.2.3", b: "v1.2.3", expected: 0},
+		{a: "v1.2.4", b: "v1.2.3", expected: 1},
+		{a: "v1.10.0", b: "v1.9.9", expected: 1},
+		{a: "v0.2.1", b: "v1.0.0", expected: -1},
+		{a: "v1.0.0-rc.1", b: "v1.0.0", expected: -1},
+		{a: "v1.0.0", b: "v1.0.0-rc.1", expected: 1},
+		{a: "v1.0.0-rc.2", b: "v1.0.0-rc.10", expected: -1},
+		{a: "v1.0.0-alpha", b: "v1.0.0-beta", expected: -1},
+		{a: "v1.0.0-alpha", b: "v1.0.0-alpha.1", expected: -1},
+		{a: "v1.0.0+build.5", b: "v1.0.0", expected: 0},
+		{a: "v1.2", b: "v1.2.0", expectError: true},
+		{a: "latest", b: "v1.2.0", expectError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.a+" vs "+tt.b, func(t *testing.T) {
+			cmp, err := compareVersions(tt.a, tt.b)
+			if tt.expectError {
+				if err == nil {
+					t.Errorf("Expected an error, got %d", cmp)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if cmp != tt.expected {
+				t.Errorf("Expected %d, got %d", tt.expected, cmp)
+			}
+		})
+	}
+}
@@ -0,0 +1,451 @@
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/berkayuckac/tidydata/internal/api"
+	"github.com/fsnotify/fsnotify"
+	"github.com/spf13/cobra"
+)
+
+const watchDebounce = 500 * time.Millisecond
+
+var (
+	watchDir           string
+	watchPoll          bool
+	watchPollInterval  time.Duration
+	watchDeleteRemoved bool
+	addWatchDir        string
+)
+
+var watchExtensions = map[string]bool{
+	".txt":      true,
+	".md":       true,
+	".markdown": true,
+	".html":     true,
+	".htm":      true,
+	".pdf":      true,
+}
+
+var watchCmd = &cobra.Command{
+	Use:   "watch",
+	Short: "Index new and changed files in a directory",
+	Long: `Watch a directory and add .txt, .md, .html and .pdf files to your knowledge
+base as they are created or changed. Existing files are indexed on start.
+
+When a file changes, its document is updated with the new text. With
+--delete-removed, deleting a file also deletes its document. Indexed files are
+tracked in ~/.tidydata/watch-state.json so restarts do not add unchanged files
+again. Subdirectories are not watched.
+
+Use --poll on systems where file system notifications are unavailable.
+tidydata add --watch DIR does the same as tidydata watch --dir DIR.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runWatch(cmd.Context(), watchDir)
+	},
+}
+
+func runWatch(ctx context.Context, dir string) error {
+	info, err := os.Stat(dir)
+	if err != nil {
+		return fmt.Errorf("error accessing directory: %w", err)
+	}
+	if !info.IsDir() {
+		return fmt.Errorf("not a directory: %s", dir)
+	}
+	if watchPoll && watchPollInterval <= 0 {
+		return fmt.Errorf("--poll-interval must be positive, got %v", watchPollInterval)
+	}
+
+	statePath, err := watchStatePath()
+	if err != nil {
+		return err
+	}
+	ix, err := newWatchIndexer(ctx, statePath, os.Stdout)
+	if err != nil {
+		return err
+	}
+	ix.deleteRemoved = watchDeleteRemoved
+
+	d := newDebouncer(watchDebounce, func(path string) {
+		if err := ix.index(path); err != nil {
+			fmt.Fprintf(os.Stderr, "%s: %v\n", path, err)
+		}
+	})
+	defer d.stop()
+
+	printInfo("Watching %s (Ctrl-C to stop)\n", dir)
+	if watchPoll {
+		return pollDir(ctx, dir, watchPollInterval, d.trigger)
+	}
+	return watchEvents(ctx, dir, d.trigger)
+}
+
+type watchedFile struct {
+	DocumentID string `json:"document_id"`
+	Hash       string `json:"hash"`
+}
+
+// watchIndexer adds files to the knowledge base and remembers which document
+// each file became, keyed by absolute path.
+type watchIndexer struct {
+	mu        sync.Mutex
+	statePath string
+	files     map[string]watchedFile
+	add       func(text string) (string, error)
+	update    func(id, text string) error
+	remove    func(id string) error
+	out       io.Writer
+	// deleteRemoved deletes the document of a file that no longer exists.
+	deleteRemoved bool
+}
+
+func newWatchIndexer(ctx context.Context, statePath string, out io.Writer) (*watchIndexer, error) {
+	ix := &watchIndexer{
+		statePath: statePath,
+		files:     make(map[string]watchedFile),
+		add: func(text string) (string, error) {
+			return mlClient.AddDocument(ctx, text)
+		},
+		update: func(id, text string) error {
+			return mlClient.UpdateDocument(ctx, id, text)
+		},
+		remove: func(id string) error {
+			return mlClient.DeleteDocument(ctx, id)
+		},
+		out: out,
+	}
+	if dryRun {
+		ix.add = dryRunAddDocument
+		ix.update = func(id, text string) error {
+			printDryRun(http.MethodPut, "/documents/"+url.PathEscape(id))
+			return nil
+		}
+		ix.remove = func(id string) error {
+			printDryRun(http.MethodDelete, "/documents/"+url.PathEscape(id))
+			return nil
+		}
+	}
+
+	data, err := os.ReadFile(statePath)
+	if errors.Is(err, os.ErrNotExist) {
+		return ix, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error reading watch state: %w", err)
+	}
+	if err := json.Unmarshal(data, &ix.files); err != nil {
+		return nil, fmt.Errorf("error parsing watch state %s: %w", statePath, err)
+	}
+	return ix, nil
+}
+
+// index adds path unless its content is unchanged since it was last indexed.
+// A changed file updates its document in place, and a missing file is passed
+// to removed.
+func (ix *watchIndexer) index(path string) error {
+	path, err := filepath.Abs(path)
+	if err != nil {
+		return err
+	}
+	content, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return ix.removed(path)
+	}
+	if err != nil {
+		return fmt.Errorf("error reading file: %w", err)
+	}
+	sum := sha256.Sum256(content)
+	hash := hex.EncodeToString(sum[:])
+
+	ix.mu.Lock()
+	defer ix.mu.Unlock()
+
+	previous, seen := ix.files[path]
+	if seen && previous.Hash == hash {
+		return nil
+	}
+
+	text, err := readDocumentFile(path)
+	if err != nil {
+		return err
+	}
+	if strings.TrimSpace(text) == "" {
+		return nil
+	}
+
+	docID := previous.DocumentID
+	err = api.ErrNotFound
+	if seen {
+		err = ix.update(docID, text)
+	}
+	// A document deleted outside the watcher is added again.
+	if errors.Is(err, api.ErrNotFound) {
+		if docID, err = ix.add(text); err != nil {
+			return fmt.Errorf("error adding document: %w", err)
+		}
+	} else if err != nil {
+		return fmt.Errorf("error updating document: %w", err)
+	}
+
+	ix.files[path] = watchedFile{DocumentID: docID, Hash: hash}
+	if err := ix.persist(); err != nil {
+		return err
+	}
+
+	if quiet {
+		fmt.Fprintln(ix.out, docID)
+	} else {
+		fmt.Fprintf(ix.out, "Indexed %s: %s\n", path, docID)
+	}
+	return nil
+}
+
+// removed forgets a file that no longer exists, deleting its document first
+// when deleteRemoved is set. Without it the document is kept.
+func (ix *watchIndexer) removed(path string) error {
+	ix.mu.Lock()
+	defer ix.mu.Unlock()
+
+	previous, seen := ix.files[path]
+	if !seen || !ix.deleteRemoved {
+		return nil
+	}
+	if err := ix.remove(previous.DocumentID); err != nil && !errors.Is(err, api.ErrNotFound) {
+		return fmt.Errorf("error deleting document %s: %w", previous.DocumentID, err)
+	}
+
+	delete(ix.files, path)
+	if err := ix.persist(); err != nil {
+		return err
+	}
+
+	if quiet {
+		fmt.Fprintln(ix.out, previous.DocumentID)
+	} else {
+		fmt.Fprintf(ix.out, "Removed %s: %s\n", path, previous.DocumentID)
+	}
+	return nil
+}
+
+// persist saves the state, except in dry-run mode.
+func (ix *watchIndexer) persist() error {
+	if dryRun {
+		return nil
+	}
+	return ix.save()
+}
+
+func (ix *watchIndexer) save() error {
+	data, err := json.MarshalIndent(ix.files, "", "  ")
+	if err != nil {
+		return fmt.Errorf("error encoding watch state: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(ix.statePath), 0o700); err != nil {
+		return fmt.Errorf("error saving watch state: %w", err)
+	}
+	tmp := ix.statePath + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return fmt.Errorf("error saving watch state: %w", err)
+	}
+	if err := os.Rename(tmp, ix.statePath); err != nil {
+		return fmt.Errorf("error saving watch state: %w", err)
+	}
+	return nil
+}
+
+// debouncer calls fn for a path once no trigger for that path has arrived
+// for delay, so an editor's burst of writes is indexed once.
+type debouncer struct {
+	mu     sync.Mutex
+	delay  time.Duration
+	timers map[string]*time.Timer
+	fn     func(path string)
+}
+
+func newDebouncer(delay time.Duration, fn func(path string)) *debouncer {
+	return &debouncer{delay: delay, timers: make(map[string]*time.Timer), fn: fn}
+}
+
+func (d *debouncer) trigger(path string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if timer, ok := d.timers[path]; ok {
+		timer.Stop()
+	}
+	d.timers[path] = time.AfterFunc(d.delay, func() {
+		d.mu.Lock()
+		delete(d.timers, path)
+		d.mu.Unlock()
+		d.fn(path)
+	})
+}
+
+func (d *debouncer) stop() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	for path, timer := range d.timers {
+		timer.Stop()
+		delete(d.timers, path)
+	}
+}
+
+func watchable(path string) bool {
+	return watchExtensions[strings.ToLower(filepath.Ext(path))]
+}
+
+// scanDir triggers every watchable file whose size or modification time
+// differs from what seen recorded, and every file in seen that is gone, and
+// updates seen.
+func scanDir(dir string, seen map[string]os.FileInfo, trigger func(path string)) error {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return fmt.Errorf("error reading directory: %w", err)
+	}
+	present := make(map[string]bool, len(entries))
+	for _, entry := range entries {
+		present[filepath.Join(dir, entry.Name())] = true
+	}
+	for path := range seen {
+		if !present[path] {
+			delete(seen, path)
+			trigger(path)
+		}
+	}
+	for _, entry := range entries {
+		if entry.IsDir() || !watchable(entry.Name()) {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+		path := filepath.Join(dir, entry.Name())
+		if prev, ok := seen[path]; ok && prev.Size() == info.Size() && prev.ModTime().Equal(info.ModTime()) {
+			continue
+		}
+		seen[path] = info
+		trigger(path)
+	}
+	return nil
+}
+
+// fileWatcher is the part of fsnotify.Watcher that watchEvents uses, so tests
+// can send events without touching the file system.
+type fileWatcher interface {
+	Add(name string) error
+	Close() error
+	Events() <-chan fsnotify.Event
+	Errors() <-chan error
+}
+
+type fsnotifyWatcher struct {
+	*fsnotify.Watcher
+}
+
+func (w fsnotifyWatcher) Events() <-chan fsnotify.Event { return w.Watcher.Events }
+func (w fsnotifyWatcher) Errors() <-chan error          { return w.Watcher.Errors }
+
+var newFileWatcher = func() (fileWatcher, error) {
+	w, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, err
+	}
+	return fsnotifyWatcher{w}, nil
+}
+
+func watchEvents(ctx context.Context, dir string, trigger func(path string)) error {
+	watcher, err := newFileWatcher()
+	if err != nil {
+		return fmt.Errorf("error starting watcher (try --poll): %w", err)
+	}
+	defer watcher.Close()
+
+	if err := watcher.Add(dir); err != nil {
+		return fmt.Errorf("error watching %s (try --poll): %w", dir, err)
+	}
+	if err := scanDir(dir, make(map[string]os.FileInfo), trigger); err != nil {
+		return err
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case event, ok := <-watcher.Events():
+			if !ok {
+				return nil
+			}
+			if event.Has(fsnotify.Create|fsnotify.Write|fsnotify.Remove|fsnotify.Rename) && watchable(event.Name) {
+				trigger(event.Name)
+			}
+		case err, ok := <-watcher.Errors():
+			if !ok {
+				return nil
+			}
+			fmt.Fprintf(os.Stderr, "watch error: %v\n", err)
+		}
+	}
+}
+
+func pollDir(ctx context.Context, dir string, interval time.Duration, trigger func(path string)) error {
+	seen := make(map[string]os.FileInfo)
+	if err := scanDir(dir, seen, trigger); err != nil {
+		return err
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+			if err := scanDir(dir, seen, trigger); err != nil {
+				fmt.Fprintf(os.Stderr, "watch error: %v\n", err)
+			}
+		}
+	}
+}
+
+func watchStatePath() (string, error) {
+	dir, err := tidydataDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "watch-state.json"), nil
+}
+
+func init() {
+	rootCmd.AddCommand(watchCmd)
+	watchCmd.Flags().StringVar(&watchDir, "dir", "", "Directory to watch")
+	watchCmd.Flags().BoolVar(&watchPoll, "poll", false, "Scan the directory periodically instead of using file system notifications")
+	watchCmd.Flags().DurationVar(&watchPollInterval, "poll-interval", 2*time.Second, "How often to scan the directory with --poll")
+	watchCmd.Flags().BoolVar(&watchDeleteRemoved, "delete-removed", false, "Delete the document of a file that is deleted")
+	_ = watchCmd.MarkFlagRequired("dir")
+	_ = watchCmd.MarkFlagDirname("dir")
+
+	addCmd.Flags().StringVar(&addWatchDir, "watch", "", "Keep adding new and changed files in this directory (see tidydata watch)")
+	addCmd.Flags().BoolVar(&watchPoll, "poll", false, "With --watch, scan the directory periodically instead of using file system notifications")
+	addCmd.Flags().DurationVar(&watchPollInterval, "poll-interval", 2*time.Second, "How often to scan the directory with --poll")
+	addCmd.Flags().BoolVar(&watchDeleteRemoved, "delete-removed", false, "With --watch, delete the document of a file that is deleted")
+	_ = addCmd.MarkFlagDirname("watch")
+	addCmd.MarkFlagsMutuallyExclusive("watch", "file")
+	addCmd.MarkFlagsMutuallyExclusive("watch", "url")
+	addCmd.MarkFlagsMutuallyExclusive("watch", "async")
+}
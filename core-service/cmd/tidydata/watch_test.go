@@ -0,0 +1,291 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/berkayuckac/tidydata/internal/api"
+	"github.com/fsnotify/fsnotify"
+)
+
+func TestWatchIndexer(t *testing.T) {
+	dir := t.TempDir()
+	statePath := filepath.Join(dir, "state", "watch-state.json")
+	note := filepath.Join(dir, "note.md")
+	if err := os.WriteFile(note, []byte("# Title\n\nfirst version"), 0o644); err != nil {
+		t.Fatalf("Error writing fixture: %v", err)
+	}
+
+	var added []string
+	var updated []string
+	ix, err := newWatchIndexer(context.Background(), statePath, &bytes.Buffer{})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	ix.add = func(text string) (string, error) {
+		added = append(added, text)
+		return fmt.Sprintf("doc%d", len(added)), nil
+	}
+	ix.update = func(id, text string) error {
+		updated = append(updated, id+": "+text)
+		return nil
+	}
+
+	if err := ix.index(note); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if err := ix.index(note); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(added) != 1 || added[0] != "Title\n\nfirst version" {
+		t.Fatalf("Expected one stripped Markdown add, got %q", added)
+	}
+
+	if err := os.WriteFile(note, []byte("second version"), 0o644); err != nil {
+		t.Fatalf("Error writing fixture: %v", err)
+	}
+	if err := ix.index(note); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(added) != 1 || len(updated) != 1 || updated[0] != "doc1: second version" {
+		t.Errorf("Expected changed file to update doc1, got added=%q updated=%q", added, updated)
+	}
+
+	// A document deleted behind the watcher's back is added again.
+	ix.update = func(id, text string) error {
+		return fmt.Errorf("document %s: %w", id, api.ErrNotFound)
+	}
+	if err := os.WriteFile(note, []byte("third version"), 0o644); err != nil {
+		t.Fatalf("Error writing fixture: %v", err)
+	}
+	if err := ix.index(note); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(added) != 2 || added[1] != "third version" {
+		t.Errorf("Expected missing document to be added again, got %q", added)
+	}
+
+	// A new indexer loads the saved state and skips the unchanged file.
+	reloaded, err := newWatchIndexer(context.Background(), statePath, &bytes.Buffer{})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if got := reloaded.files[note].DocumentID; got != "doc2" {
+		t.Errorf("Expected saved document ID doc2, got %q", got)
+	}
+	reloaded.add = func(text string) (string, error) {
+		t.Error("Expected unchanged file not to be added again")
+		return "", nil
+	}
+	if err := reloaded.index(note); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+}
+
+func TestWatchIndexerRemoved(t *testing.T) {
+	tests := []struct {
+		name            string
+		deleteRemoved   bool
+		expectedRemoved []string
+		expectTracked   bool
+	}{
+		{name: "kept by default", expectTracked: true},
+		{name: "delete removed", deleteRemoved: true, expectedRemoved: []string{"doc1"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			note := filepath.Join(dir, "note.txt")
+			if err := os.WriteFile(note, []byte("hello"), 0o644); err != nil {
+				t.Fatalf("Error writing fixture: %v", err)
+			}
+
+			ix, err := newWatchIndexer(context.Background(), filepath.Join(dir, "watch-state.json"), &bytes.Buffer{})
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			ix.deleteRemoved = tt.deleteRemoved
+			ix.add = func(text string) (string, error) { return "doc1", nil }
+			var removed []string
+			ix.remove = func(id string) error {
+				removed = append(removed, id)
+				return nil
+			}
+
+			if err := ix.index(note); err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if err := os.Remove(note); err != nil {
+				t.Fatalf("Error removing fixture: %v", err)
+			}
+			if err := ix.index(note); err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			if fmt.Sprint(removed) != fmt.Sprint(tt.expectedRemoved) {
+				t.Errorf("Expected removed %v, got %v", tt.expectedRemoved, removed)
+			}
+			if _, tracked := ix.files[note]; tracked != tt.expectTracked {
+				t.Errorf("Expected tracked=%v after removal, got %v", tt.expectTracked, tracked)
+			}
+		})
+	}
+}
+
+func TestDebouncer(t *testing.T) {
+	var mu sync.Mutex
+	calls := make(map[string]int)
+	d := newDebouncer(20*time.Millisecond, func(path string) {
+		mu.Lock()
+		calls[path]++
+		mu.Unlock()
+	})
+
+	for i := 0; i < 5; i++ {
+		d.trigger("a.txt")
+		time.Sleep(2 * time.Millisecond)
+	}
+	d.trigger("b.txt")
+	time.Sleep(100 * time.Millisecond)
+
+	mu.Lock()
+	defer mu.Unlock()
+	if calls["a.txt"] != 1 || calls["b.txt"] != 1 {
+		t.Errorf("Expected one call per path, got %v", calls)
+	}
+}
+
+func TestPollDir(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "existing.txt"), []byte("old"), 0o644); err != nil {
+		t.Fatalf("Error writing fixture: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "ignored.jpg"), []byte("img"), 0o644); err != nil {
+		t.Fatalf("Error writing fixture: %v", err)
+	}
+
+	triggered := make(chan string, 10)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go pollDir(ctx, dir, 10*time.Millisecond, func(path string) { triggered <- filepath.Base(path) })
+
+	expectTrigger(t, triggered, "existing.txt")
+	if err := os.WriteFile(filepath.Join(dir, "new.md"), []byte("new"), 0o644); err != nil {
+		t.Fatalf("Error writing fixture: %v", err)
+	}
+	expectTrigger(t, triggered, "new.md")
+	if err := os.Remove(filepath.Join(dir, "existing.txt")); err != nil {
+		t.Fatalf("Error removing fixture: %v", err)
+	}
+	expectTrigger(t, triggered, "existing.txt")
+
+	select {
+	case name := <-triggered:
+		t.Errorf("Unexpected trigger for %s", name)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func TestWatchEvents(t *testing.T) {
+	dir := t.TempDir()
+	triggered := make(chan string, 10)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	ready := make(chan error, 1)
+	go func() {
+		ready <- watchEvents(ctx, dir, func(path string) { triggered <- filepath.Base(path) })
+	}()
+	time.Sleep(50 * time.Millisecond)
+	select {
+	case err := <-ready:
+		t.Skipf("File system notifications unavailable: %v", err)
+	default:
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, "note.txt"), []byte("hello"), 0o644); err != nil {
+		t.Fatalf("Error writing fixture: %v", err)
+	}
+	expectTrigger(t, triggered, "note.txt")
+}
+
+// fakeWatcher is a fileWatcher whose events are sent by the test.
+type fakeWatcher struct {
+	events chan fsnotify.Event
+	errors chan error
+	added  []string
+}
+
+func (w *fakeWatcher) Add(name string) error {
+	w.added = append(w.added, name)
+	return nil
+}
+func (w *fakeWatcher) Close() error                  { return nil }
+func (w *fakeWatcher) Events() <-chan fsnotify.Event { return w.events }
+func (w *fakeWatcher) Errors() <-chan error          { return w.errors }
+
+func TestWatchEventsFake(t *testing.T) {
+	watcher := &fakeWatcher{events: make(chan fsnotify.Event), errors: make(chan error)}
+	original := newFileWatcher
+	newFileWatcher = func() (fileWatcher, error) { return watcher, nil }
+	t.Cleanup(func() { newFileWatcher = original })
+
+	dir := t.TempDir()
+	triggered := make(chan string, 10)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	done := make(chan error, 1)
+	go func() {
+		done <- watchEvents(ctx, dir, func(path string) { triggered <- filepath.Base(path) })
+	}()
+
+	events := []struct {
+		event     fsnotify.Event
+		triggered string
+	}{
+		{event: fsnotify.Event{Name: filepath.Join(dir, "new.md"), Op: fsnotify.Create}, triggered: "new.md"},
+		{event: fsnotify.Event{Name: filepath.Join(dir, "new.md"), Op: fsnotify.Write}, triggered: "new.md"},
+		{event: fsnotify.Event{Name: filepath.Join(dir, "photo.jpg"), Op: fsnotify.Create}},
+		{event: fsnotify.Event{Name: filepath.Join(dir, "new.md"), Op: fsnotify.Chmod}},
+		{event: fsnotify.Event{Name: filepath.Join(dir, "old.txt"), Op: fsnotify.Remove}, triggered: "old.txt"},
+		{event: fsnotify.Event{Name: filepath.Join(dir, "moved.txt"), Op: fsnotify.Rename}, triggered: "moved.txt"},
+	}
+	for _, e := range events {
+		watcher.events <- e.event
+		if e.triggered != "" {
+			expectTrigger(t, triggered, e.triggered)
+		}
+	}
+	select {
+	case name := <-triggered:
+		t.Errorf("Unexpected trigger for %s", name)
+	default:
+	}
+
+	cancel()
+	if err := <-done; err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+	if len(watcher.added) != 1 || watcher.added[0] != dir {
+		t.Errorf("Expected %s to be watched, got %v", dir, watcher.added)
+	}
+}
+
+func expectTrigger(t *testing.T, triggered <-chan string, expected string) {
+	t.Helper()
+	select {
+	case name := <-triggered:
+		if name != expected {
+			t.Errorf("Expected trigger for %s, got %s", expected, name)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatalf("Timed out waiting for %s", expected)
+	}
+}
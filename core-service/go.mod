@@ -5,9 +5,18 @@ go 1.24.2
 // This program is licensed under the GNU General Public License v3.0
 // See LICENSE file in the root directory
 
-require github.com/spf13/cobra v1.9.1
+require (
+	github.com/fsnotify/fsnotify v1.8.0
+	github.com/ledongthuc/pdf v0.0.0-20260907135840-6c8c28e0e8a0
+	github.com/spf13/cobra v1.9.1
+	github.com/spf13/pflag v1.0.6
+	golang.org/x/image v0.25.0
+	golang.org/x/net v0.43.0
+	golang.org/x/term v0.34.0
+	gopkg.in/yaml.v3 v3.0.1
+)
 
 require (
 	github.com/inconshreveable/mousetrap v1.1.0 // indirect
-	github.com/spf13/pflag v1.0.6 // indirect
+	golang.org/x/sys v0.35.0 // indirect
 )
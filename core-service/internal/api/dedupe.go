@@ -0,0 +1,129 @@
+package api
+
+import (
+	"math"
+	"strings"
+	"unicode"
+)
+
+// DefaultDedupThreshold is the similarity above which DeduplicateResults
+// treats two results as the same content.
+const DefaultDedupThreshold = 0.98
+
+// minContainmentWords is the fewest words, stopwords aside, a text needs
+// before it counts as a duplicate of a longer text that contains it, so
+// that a result of a few words does not hide every text using them.
+const minContainmentWords = 5
+
+// stopwords are common English words left out when comparing results, so
+// that texts do not look alike only because they share them.
+var stopwords = map[string]bool{
+	"a": true, "an": true, "and": true, "are": true, "as": true, "at": true,
+	"be": true, "but": true, "by": true, "for": true, "from": true, "has": true,
+	"have": true, "he": true, "her": true, "his": true, "i": true, "if": true,
+	"in": true, "is": true, "it": true, "its": true, "of": true, "on": true,
+	"or": true, "our": true, "she": true, "so": true, "that": true, "the": true,
+	"their": true, "them": true, "they": true, "this": true, "to": true,
+	"was": true, "we": true, "were": true, "what": true, "when": true,
+	"which": true, "who": true, "will": true, "with": true, "you": true,
+	"your": true,
+}
+
+// DeduplicateResults returns results without those whose content has a
+// similarity above threshold to an earlier result of the same source type,
+// keeping the order of the rest. Content is a document's text or an image's
+// description, compared by its words without case, punctuation or
+// stopwords. Two results are as similar as the cosine similarity of their
+// word counts, or as the share of the shorter one's words found in the
+// longer one if that is higher, so that a chunk matches the document it was
+// cut from. Results without content are always kept.
+func DeduplicateResults(results []UnifiedSearchResult, threshold float64) []UnifiedSearchResult {
+	type shown struct {
+		sourceType string
+		vector     map[string]float64
+	}
+	var kept []shown
+	out := make([]UnifiedSearchResult, 0, len(results))
+
+outer:
+	for _, result := range results {
+		vector := termVector(resultContent(result))
+		if len(vector) > 0 {
+			for _, k := range kept {
+				if k.sourceType == result.SourceType && contentSimilarity(vector, k.vector) > threshold {
+					continue outer
+				}
+			}
+			kept = append(kept, shown{result.SourceType, vector})
+		}
+		out = append(out, result)
+	}
+	return out
+}
+
+func resultContent(result UnifiedSearchResult) string {
+	if result.SourceType == "image" {
+		return result.Content.Metadata.Description
+	}
+	return result.Content.Text
+}
+
+// termVector counts the lower-cased words of text, leaving out stopwords.
+func termVector(text string) map[string]float64 {
+	vector := make(map[string]float64)
+	for _, word := range strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	}) {
+		if !stopwords[word] {
+			vector[word]++
+		}
+	}
+	return vector
+}
+
+// contentSimilarity returns the higher of the cosine similarity of a and b
+// and the containment of the shorter in the longer.
+func contentSimilarity(a, b map[string]float64) float64 {
+	return max(cosineSimilarity(a, b), containment(a, b))
+}
+
+// containment returns the share of the words of the shorter of a and b, by
+// count, that the other also has. It is 0 when the shorter has fewer than
+// minContainmentWords words.
+func containment(a, b map[string]float64) float64 {
+	if wordCount(a) > wordCount(b) {
+		a, b = b, a
+	}
+	total := wordCount(a)
+	if total < minContainmentWords {
+		return 0
+	}
+	var shared float64
+	for word, count := range a {
+		shared += min(count, b[word])
+	}
+	return shared / total
+}
+
+func wordCount(vector map[string]float64) float64 {
+	var n float64
+	for _, count := range vector {
+		n += count
+	}
+	return n
+}
+
+func cosineSimilarity(a, b map[string]float64) float64 {
+	var dot, normA, normB float64
+	for word, count := range a {
+		dot += count * b[word]
+		normA += count * count
+	}
+	for _, count := range b {
+		normB += count * count
+	}
+	if normA == 0 || normB == 0 {
+		return 0
+	}
+	return dot / (math.Sqrt(normA) * math.Sqrt(normB))
+}
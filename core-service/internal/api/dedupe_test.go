@@ -0,0 +1,181 @@
+package api
+
+import (
+	"math"
+	"reflect"
+	"testing"
+)
+
+func TestDeduplicateResults(t *testing.T) {
+	text := func(id, content string) UnifiedSearchResult {
+		return UnifiedSearchResult{ID: id, SourceType: "text", Content: UnifiedContent{Text: content}}
+	}
+	image := func(id, description string) UnifiedSearchResult {
+		return UnifiedSearchResult{ID: id, SourceType: "image", Content: UnifiedContent{Metadata: ImageMetadata{Description: description}}}
+	}
+
+	tests := []struct {
+		name      string
+		results   []UnifiedSearchResult
+		threshold float64
+		expected  []string
+	}{
+		{name: "empty", expected: []string{}},
+		{
+			name:      "distinct results kept",
+			results:   []UnifiedSearchResult{text("a", "cats sleep all day"), text("b", "dogs bark at night")},
+			threshold: DefaultDedupThreshold,
+			expected:  []string{"a", "b"},
+		},
+		{
+			name: "case and punctuation ignored",
+			results: []UnifiedSearchResult{
+				text("parent", "The quick brown fox jumps over the lazy dog."),
+				text("chunk", "the quick brown fox, jumps over the lazy dog"),
+			},
+			threshold: DefaultDedupThreshold,
+			expected:  []string{"parent"},
+		},
+		{
+			name: "near duplicate below threshold kept",
+			results: []UnifiedSearchResult{
+				text("parent", "the quick brown fox jumps over the lazy dog"),
+				text("chunk", "the quick brown fox jumps over the lazy cat"),
+			},
+			threshold: DefaultDedupThreshold,
+			expected:  []string{"parent", "chunk"},
+		},
+		{
+			name: "near duplicate above lower threshold removed",
+			results: []UnifiedSearchResult{
+				text("parent", "the quick brown fox jumps over the lazy dog"),
+				text("chunk", "the quick brown fox jumps over the lazy cat"),
+			},
+			threshold: 0.8,
+			expected:  []string{"parent"},
+		},
+		{
+			name: "chunk of an earlier parent removed",
+			results: []UnifiedSearchResult{
+				text("parent", "Qdrant stores the vectors. The ML service embeds every document with MPNet before storing it. Search compares the query vector with them."),
+				text("chunk", "The ML service embeds every document with MPNet before storing it."),
+			},
+			threshold: DefaultDedupThreshold,
+			expected:  []string{"parent"},
+		},
+		{
+			name: "parent of an earlier chunk removed",
+			results: []UnifiedSearchResult{
+				text("chunk", "The ML service embeds every document with MPNet before storing it."),
+				text("parent", "Qdrant stores the vectors. The ML service embeds every document with MPNet before storing it. Search compares the query vector with them."),
+			},
+			threshold: DefaultDedupThreshold,
+			expected:  []string{"chunk"},
+		},
+		{
+			name: "short text inside a longer one kept",
+			results: []UnifiedSearchResult{
+				text("long", "cats sleep all day long in the warm sun"),
+				text("short", "cats sleep"),
+			},
+			threshold: DefaultDedupThreshold,
+			expected:  []string{"long", "short"},
+		},
+		{
+			name: "shared stopwords ignored",
+			results: []UnifiedSearchResult{
+				text("a", "what is the cat"),
+				text("b", "what is the dog"),
+			},
+			threshold: 0.7,
+			expected:  []string{"a", "b"},
+		},
+		{
+			name: "compared with every kept result",
+			results: []UnifiedSearchResult{
+				text("a", "red apples"),
+				text("b", "green pears"),
+				text("c", "Green pears!"),
+				text("d", "red apples"),
+			},
+			threshold: DefaultDedupThreshold,
+			expected:  []string{"a", "b"},
+		},
+		{
+			name:      "images compared by description",
+			results:   []UnifiedSearchResult{image("img1", "a cat on a sofa"), image("img2", "A cat on a sofa"), image("img3", "a dog")},
+			threshold: DefaultDedupThreshold,
+			expected:  []string{"img1", "img3"},
+		},
+		{
+			name:      "different source types kept",
+			results:   []UnifiedSearchResult{text("doc", "a cat on a sofa"), image("img", "a cat on a sofa")},
+			threshold: DefaultDedupThreshold,
+			expected:  []string{"doc", "img"},
+		},
+		{
+			name:      "results without content kept",
+			results:   []UnifiedSearchResult{image("img1", ""), image("img2", "")},
+			threshold: DefaultDedupThreshold,
+			expected:  []string{"img1", "img2"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ids := []string{}
+			for _, r := range DeduplicateResults(tt.results, tt.threshold) {
+				ids = append(ids, r.ID)
+			}
+			if !reflect.DeepEqual(ids, tt.expected) {
+				t.Errorf("Expected %v, got %v", tt.expected, ids)
+			}
+		})
+	}
+}
+
+func TestCosineSimilarity(t *testing.T) {
+	tests := []struct {
+		name     string
+		a, b     string
+		expected float64
+	}{
+		{name: "identical", a: "one two three", b: "three two one", expected: 1},
+		{name: "disjoint", a: "one two", b: "three four", expected: 0},
+		{name: "half shared", a: "one two", b: "one three", expected: 0.5},
+		{name: "empty", a: "", b: "one", expected: 0},
+		{name: "stopwords ignored", a: "the cat and the dog", b: "a cat, a dog", expected: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := cosineSimilarity(termVector(tt.a), termVector(tt.b))
+			if math.Abs(got-tt.expected) > 1e-9 {
+				t.Errorf("Expected %v, got %v", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestContainment(t *testing.T) {
+	tests := []struct {
+		name     string
+		a, b     string
+		expected float64
+	}{
+		{name: "contained", a: "red green blue yellow purple", b: "black red green blue yellow purple white", expected: 1},
+		{name: "either order", a: "black red green blue yellow purple white", b: "red green blue yellow purple", expected: 1},
+		{name: "partly contained", a: "red green blue yellow orange", b: "red green blue yellow purple", expected: 0.8},
+		{name: "repeated words counted", a: "red red red green blue", b: "red green blue yellow purple", expected: 0.6},
+		{name: "too few words", a: "red green", b: "red green blue", expected: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := containment(termVector(tt.a), termVector(tt.b))
+			if math.Abs(got-tt.expected) > 1e-9 {
+				t.Errorf("Expected %v, got %v", tt.expected, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,145 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+var (
+	ErrNotFound = errors.New("not found")
+	// ErrUnauthorized is returned when the ML service rejects the API key.
+	ErrUnauthorized     = errors.New("unauthorized")
+	ErrResponseTooLarge = errors.New("response too large")
+	// ErrInvalidResponse is returned when a response lacks a field the
+	// client needs, usually because the ML service changed its API.
+	ErrInvalidResponse = errors.New("invalid response")
+)
+
+// maxErrorBodyBytes bounds how much of an error response is kept in
+// APIError.Body.
+const maxErrorBodyBytes = 4 << 10
+
+// APIError is returned when the ML service answers with an unexpected
+// status. RequestID is the X-Request-ID the client sent, if any, for finding
+// the request in the service's logs. Body holds the start of the response.
+//
+// A 404 matches ErrNotFound and a 401 or 403 matches ErrUnauthorized with
+// errors.Is.
+type APIError struct {
+	StatusCode int
+	RequestID  string
+	Body       string
+}
+
+func (e *APIError) Error() string {
+	if e.RequestID == "" {
+		return fmt.Sprintf("unexpected status code: %d", e.StatusCode)
+	}
+	return fmt.Sprintf("unexpected status code: %d (request ID %s)", e.StatusCode, e.RequestID)
+}
+
+func (e *APIError) Unwrap() error {
+	switch e.StatusCode {
+	case http.StatusNotFound:
+		return ErrNotFound
+	case http.StatusUnauthorized, http.StatusForbidden:
+		return ErrUnauthorized
+	}
+	return nil
+}
+
+// RateLimitError is returned for 429 Too Many Requests once retries are used
+// up. RetryAfter is how long the service asked to wait, or zero if it did not
+// say.
+type RateLimitError struct {
+	*APIError
+	RetryAfter time.Duration
+}
+
+func (e *RateLimitError) Error() string {
+	if e.RetryAfter <= 0 {
+		return fmt.Sprintf("rate limited: %v", e.APIError)
+	}
+	return fmt.Sprintf("rate limited, retry after %v: %v", e.RetryAfter, e.APIError)
+}
+
+func (e *RateLimitError) Unwrap() error { return e.APIError }
+
+// BadRequestError is returned for 400 Bad Request and 422 Unprocessable
+// Entity. Message is the reason the ML service gave.
+type BadRequestError struct {
+	*APIError
+	Message string
+}
+
+func (e *BadRequestError) Error() string {
+	if e.Message == "" {
+		return fmt.Sprintf("bad request: %v", e.APIError)
+	}
+	return fmt.Sprintf("bad request: %s: %v", e.Message, e.APIError)
+}
+
+func (e *BadRequestError) Unwrap() error { return e.APIError }
+
+// newAPIError builds the error for resp from its status and the start of its
+// body. The caller still closes resp.Body.
+func newAPIError(resp *http.Response) error {
+	err := &APIError{StatusCode: resp.StatusCode}
+	if resp.Request != nil {
+		err.RequestID = resp.Request.Header.Get(requestIDHeader)
+	}
+	if resp.Body != nil {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorBodyBytes))
+		err.Body = strings.TrimSpace(string(body))
+	}
+
+	switch resp.StatusCode {
+	case http.StatusTooManyRequests:
+		return &RateLimitError{APIError: err, RetryAfter: retryAfter(resp.Header.Get("Retry-After"), time.Now())}
+	case http.StatusBadRequest, http.StatusUnprocessableEntity:
+		return &BadRequestError{APIError: err, Message: errorDetail(err.Body)}
+	}
+	return err
+}
+
+// errorDetail returns the FastAPI "detail" of body, which is either a string
+// or a list of validation errors, or body itself if it has none.
+func errorDetail(body string) string {
+	var resp struct {
+		Detail json.RawMessage `json:"detail"`
+	}
+	if err := json.Unmarshal([]byte(body), &resp); err != nil || len(resp.Detail) == 0 {
+		return body
+	}
+
+	var detail string
+	if err := json.Unmarshal(resp.Detail, &detail); err == nil {
+		return detail
+	}
+	var items []struct {
+		Loc []any  `json:"loc"`
+		Msg string `json:"msg"`
+	}
+	if err := json.Unmarshal(resp.Detail, &items); err == nil {
+		messages := make([]string, 0, len(items))
+		for _, item := range items {
+			if item.Msg == "" {
+				continue
+			}
+			if len(item.Loc) > 0 {
+				messages = append(messages, fmt.Sprintf("%v: %s", item.Loc[len(item.Loc)-1], item.Msg))
+			} else {
+				messages = append(messages, item.Msg)
+			}
+		}
+		if len(messages) > 0 {
+			return strings.Join(messages, "; ")
+		}
+	}
+	return body
+}
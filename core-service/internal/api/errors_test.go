@@ -0,0 +1,104 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestAPIErrorTypes(t *testing.T) {
+	tests := []struct {
+		name              string
+		status            int
+		header            map[string]string
+		body              string
+		expectSentinel    error
+		expectRetryAfter  time.Duration
+		expectRateLimited bool
+		expectMessage     string
+		expectBadRequest  bool
+		expectError       string
+	}{
+		{name: "not found", status: http.StatusNotFound, body: `{"detail": "Not Found"}`, expectSentinel: ErrNotFound, expectError: "unexpected status code: 404"},
+		{name: "unauthorized", status: http.StatusUnauthorized, expectSentinel: ErrUnauthorized, expectError: "unexpected status code: 401"},
+		{name: "forbidden", status: http.StatusForbidden, expectSentinel: ErrUnauthorized, expectError: "unexpected status code: 403"},
+		{name: "rate limited", status: http.StatusTooManyRequests, header: map[string]string{"Retry-After": "30"}, expectRateLimited: true, expectRetryAfter: 30 * time.Second, expectError: "rate limited, retry after 30s: unexpected status code: 429"},
+		{name: "rate limited without retry after", status: http.StatusTooManyRequests, expectRateLimited: true, expectError: "rate limited: unexpected status code: 429"},
+		{name: "bad request detail", status: http.StatusBadRequest, body: `{"detail": "order must be asc or desc"}`, expectBadRequest: true, expectMessage: "order must be asc or desc", expectError: "bad request: order must be asc or desc: unexpected status code: 400"},
+		{name: "validation errors", status: http.StatusUnprocessableEntity, body: `{"detail": [{"loc": ["query", "limit"], "msg": "value is not a valid integer"}, {"loc": ["body"], "msg": "field required"}]}`, expectBadRequest: true, expectMessage: "limit: value is not a valid integer; body: field required"},
+		{name: "bad request plain body", status: http.StatusBadRequest, body: "invalid gzip body\n", expectBadRequest: true, expectMessage: "invalid gzip body"},
+		{name: "server error", status: http.StatusInternalServerError, body: "boom", expectError: "unexpected status code: 500"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				for key, value := range tt.header {
+					w.Header().Set(key, value)
+				}
+				w.WriteHeader(tt.status)
+				w.Write([]byte(tt.body))
+			}))
+			defer server.Close()
+
+			client := newTestClient(t, server.URL, WithMaxRetries(0))
+			_, err := client.Search(context.Background(), "cats", 10, 0.1)
+
+			var apiErr *APIError
+			if !errors.As(err, &apiErr) || apiErr.StatusCode != tt.status {
+				t.Fatalf("Expected APIError with status %d, got %v", tt.status, err)
+			}
+			if tt.expectSentinel != nil && !errors.Is(err, tt.expectSentinel) {
+				t.Errorf("Expected %v, got %v", tt.expectSentinel, err)
+			}
+			for _, sentinel := range []error{ErrNotFound, ErrUnauthorized} {
+				if sentinel != tt.expectSentinel && errors.Is(err, sentinel) {
+					t.Errorf("Expected no %v, got %v", sentinel, err)
+				}
+			}
+
+			var rateErr *RateLimitError
+			if errors.As(err, &rateErr) != tt.expectRateLimited {
+				t.Errorf("Expected RateLimitError %v, got %v", tt.expectRateLimited, err)
+			} else if tt.expectRateLimited && rateErr.RetryAfter != tt.expectRetryAfter {
+				t.Errorf("Expected RetryAfter %v, got %v", tt.expectRetryAfter, rateErr.RetryAfter)
+			}
+
+			var badErr *BadRequestError
+			if errors.As(err, &badErr) != tt.expectBadRequest {
+				t.Errorf("Expected BadRequestError %v, got %v", tt.expectBadRequest, err)
+			} else if tt.expectBadRequest && badErr.Message != tt.expectMessage {
+				t.Errorf("Expected message %q, got %q", tt.expectMessage, badErr.Message)
+			}
+
+			if !strings.HasPrefix(err.Error(), tt.expectError) {
+				t.Errorf("Expected error starting with %q, got %q", tt.expectError, err.Error())
+			}
+		})
+	}
+}
+
+func TestAPIErrorBody(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+		for range 2 * maxErrorBodyBytes {
+			w.Write([]byte("x"))
+		}
+	}))
+	defer server.Close()
+
+	client := newTestClient(t, server.URL, WithMaxRetries(0))
+	_, err := client.Search(context.Background(), "cats", 10, 0.1)
+
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) {
+		t.Fatalf("Expected APIError, got %v", err)
+	}
+	if len(apiErr.Body) != maxErrorBodyBytes {
+		t.Errorf("Expected body of %d bytes, got %d", maxErrorBodyBytes, len(apiErr.Body))
+	}
+}
@@ -0,0 +1,51 @@
+package api
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// gzipTransport compresses request bodies and marks them with
+// Content-Encoding: gzip. Bodies that already have an encoding are sent as
+// they are.
+type gzipTransport struct {
+	next http.RoundTripper
+}
+
+func (t *gzipTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Body == nil || req.Body == http.NoBody || req.Header.Get("Content-Encoding") != "" {
+		return t.next.RoundTrip(req)
+	}
+
+	clone := req.Clone(req.Context())
+	clone.Header.Set("Content-Encoding", "gzip")
+	clone.ContentLength = -1
+	clone.Body = compressBody(req.Body)
+	if req.GetBody != nil {
+		// A retry compresses a fresh copy of the body instead of keeping
+		// the compressed bytes in memory.
+		clone.GetBody = func() (io.ReadCloser, error) {
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, err
+			}
+			return compressBody(body), nil
+		}
+	}
+	return t.next.RoundTrip(clone)
+}
+
+// compressBody returns body gzipped as it is read, so the compressed body is
+// never held in memory as a whole. It closes body once it is read.
+func compressBody(body io.ReadCloser) io.ReadCloser {
+	return newBodyStream(func(w io.Writer) error {
+		defer body.Close()
+		zw := gzip.NewWriter(w)
+		if _, err := io.Copy(zw, body); err != nil {
+			return fmt.Errorf("error compressing request body: %w", err)
+		}
+		return zw.Close()
+	})
+}
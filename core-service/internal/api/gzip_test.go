@@ -0,0 +1,178 @@
+package api
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// decodeBody returns the request body, decompressing it when the request
+// says it is gzipped.
+func decodeBody(t *testing.T, r *http.Request) []byte {
+	t.Helper()
+	body := io.Reader(r.Body)
+	if r.Header.Get("Content-Encoding") == "gzip" {
+		zr, err := gzip.NewReader(r.Body)
+		if err != nil {
+			t.Fatalf("Error opening gzip body: %v", err)
+		}
+		body = zr
+	}
+	data, err := io.ReadAll(body)
+	if err != nil {
+		t.Fatalf("Error reading body: %v", err)
+	}
+	return data
+}
+
+func TestWithGzipRequests(t *testing.T) {
+	tests := []struct {
+		name           string
+		enabled        bool
+		expectEncoding string
+	}{
+		{name: "enabled", enabled: true, expectEncoding: "gzip"},
+		{name: "disabled"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			encodings := make(map[string]string)
+			var doc Document
+			var imageData, filename string
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				encodings[r.Method+" "+r.URL.Path] = r.Header.Get("Content-Encoding")
+				body := decodeBody(t, r)
+				switch r.URL.Path {
+				case "/documents":
+					if err := json.Unmarshal(body, &doc); err != nil {
+						t.Errorf("Error decoding document: %v", err)
+					}
+					w.Write([]byte(`{"document_id": "doc1"}`))
+				case "/images":
+					_, params, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+					if err != nil {
+						t.Fatalf("Error parsing content type: %v", err)
+					}
+					form, err := multipart.NewReader(bytes.NewReader(body), params["boundary"]).ReadForm(1 << 20)
+					if err != nil {
+						t.Fatalf("Error parsing multipart body: %v", err)
+					}
+					file, err := form.File["image"][0].Open()
+					if err != nil {
+						t.Fatalf("Error opening uploaded file: %v", err)
+					}
+					data, _ := io.ReadAll(file)
+					imageData, filename = string(data), form.File["image"][0].Filename
+					w.Write([]byte(`{"image_id": "img1", "status": "ok"}`))
+				case "/search":
+					w.Write([]byte(`{"query": "test", "results": []}`))
+				}
+			}))
+			defer server.Close()
+
+			client := newTestClient(t, server.URL, WithGzipRequests(tt.enabled))
+			if _, err := client.AddDocumentWithMetadata(context.Background(), "a long document", map[string]string{"source": "notes"}); err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if _, err := client.AddImage(context.Background(), []byte("png bytes"), "cat.png", "image/png"); err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if _, err := client.Search(context.Background(), "test", 5, 0.1); err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			if doc.Text != "a long document" || doc.Metadata["source"] != "notes" {
+				t.Errorf("Expected original document after decompression, got %+v", doc)
+			}
+			if imageData != "png bytes" || filename != "cat.png" {
+				t.Errorf("Expected original image upload after decompression, got %q named %q", imageData, filename)
+			}
+			for _, req := range []string{"POST /documents", "POST /images"} {
+				if encodings[req] != tt.expectEncoding {
+					t.Errorf("%s: expected Content-Encoding %q, got %q", req, tt.expectEncoding, encodings[req])
+				}
+			}
+			if encodings["GET /search"] != "" {
+				t.Errorf("Expected no Content-Encoding on a request without a body, got %q", encodings["GET /search"])
+			}
+		})
+	}
+}
+
+func TestGzipRequestsRetried(t *testing.T) {
+	var bodies []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		bodies = append(bodies, string(decodeBody(t, r)))
+		if len(bodies) == 1 {
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		w.Write([]byte(`{"document_id": "doc1"}`))
+	}))
+	defer server.Close()
+
+	client := newTestClient(t, server.URL, WithGzipRequests(true))
+	if _, err := client.AddDocument(context.Background(), "hello"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(bodies) != 2 || bodies[0] != bodies[1] {
+		t.Errorf("Expected the same body on both attempts, got %q", bodies)
+	}
+}
+
+func TestGzipRequestsStreamed(t *testing.T) {
+	tests := []struct {
+		name     string
+		reader   func() io.Reader
+		attempts int
+	}{
+		// io.MultiReader hides Seek, so the body cannot be sent again.
+		{name: "one-shot reader", reader: func() io.Reader { return io.MultiReader(strings.NewReader("streamed text")) }, attempts: 1},
+		{name: "seekable reader retried", reader: func() io.Reader { return strings.NewReader("streamed text") }, attempts: 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var bodies []string
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.Header.Get("Content-Encoding") != "gzip" {
+					t.Errorf("Expected Content-Encoding gzip, got %q", r.Header.Get("Content-Encoding"))
+				}
+				if r.ContentLength != -1 {
+					t.Errorf("Expected a chunked body of unknown length, got Content-Length %d", r.ContentLength)
+				}
+				bodies = append(bodies, string(decodeBody(t, r)))
+				if len(bodies) < tt.attempts {
+					w.Header().Set("Retry-After", "0")
+					w.WriteHeader(http.StatusTooManyRequests)
+					return
+				}
+				w.Write([]byte(`{"document_id": "doc1"}`))
+			}))
+			defer server.Close()
+
+			client := newTestClient(t, server.URL, WithGzipRequests(true))
+			if _, err := client.AddDocumentFromReader(context.Background(), tt.reader(), nil); err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if len(bodies) != tt.attempts {
+				t.Fatalf("Expected %d requests, got %d", tt.attempts, len(bodies))
+			}
+			for _, body := range bodies {
+				if body != `{"text":"streamed text"}` {
+					t.Errorf("Expected the document after decompression, got %q", body)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,148 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"unicode/utf8"
+)
+
+// jsonStringWriter writes everything written to it to w as the inside of a
+// JSON string, escaping quotes, backslashes and control characters. Invalid
+// UTF-8 is replaced with \ufffd. A multi-byte sequence split across writes is
+// held back until it is complete, so Flush must be called after the last
+// write.
+type jsonStringWriter struct {
+	w io.Writer
+	// pending holds the start of a multi-byte sequence cut off at the end of
+	// the last write.
+	pending []byte
+}
+
+func (j *jsonStringWriter) Write(p []byte) (int, error) {
+	data := p
+	if len(j.pending) > 0 {
+		data = append(j.pending, p...)
+		j.pending = nil
+	}
+
+	buf := make([]byte, 0, len(data)+16)
+	for i := 0; i < len(data); {
+		b := data[i]
+		if b >= utf8.RuneSelf {
+			if !utf8.FullRune(data[i:]) {
+				j.pending = append([]byte(nil), data[i:]...)
+				break
+			}
+			r, size := utf8.DecodeRune(data[i:])
+			if r == utf8.RuneError && size == 1 {
+				buf = append(buf, `\ufffd`...)
+			} else {
+				buf = append(buf, data[i:i+size]...)
+			}
+			i += size
+			continue
+		}
+		switch {
+		case b == '"' || b == '\\':
+			buf = append(buf, '\\', b)
+		case b == '\n':
+			buf = append(buf, '\\', 'n')
+		case b == '\r':
+			buf = append(buf, '\\', 'r')
+		case b == '\t':
+			buf = append(buf, '\\', 't')
+		case b < 0x20:
+			buf = fmt.Appendf(buf, `\u%04x`, b)
+		default:
+			buf = append(buf, b)
+		}
+		i++
+	}
+	if _, err := j.w.Write(buf); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// Flush writes \ufffd for a multi-byte sequence left incomplete by the last
+// write.
+func (j *jsonStringWriter) Flush() error {
+	if len(j.pending) == 0 {
+		return nil
+	}
+	j.pending = nil
+	_, err := io.WriteString(j.w, `\ufffd`)
+	return err
+}
+
+// bodyStream is a request body produced by a goroutine while it is being
+// sent, so large uploads are not buffered in memory.
+type bodyStream struct {
+	*io.PipeReader
+	// done is closed once the writing goroutine has returned.
+	done chan struct{}
+}
+
+func newBodyStream(write func(w io.Writer) error) *bodyStream {
+	pr, pw := io.Pipe()
+	s := &bodyStream{PipeReader: pr, done: make(chan struct{})}
+	go func() {
+		defer close(s.done)
+		pw.CloseWithError(write(pw))
+	}()
+	return s
+}
+
+// newStreamRequest returns a chunked request whose body write produces from
+// r. When r is an io.Seeker, r is rewound so the body can be produced again
+// for a retry. The returned func closes the body of the last attempt.
+func newStreamRequest(ctx context.Context, method, url string, r io.Reader, write func(w io.Writer) error) (*http.Request, func(), error) {
+	body := newBodyStream(write)
+	req, err := http.NewRequestWithContext(ctx, method, url, body)
+	if err != nil {
+		body.Close()
+		return nil, nil, err
+	}
+	if seeker, ok := r.(io.Seeker); ok {
+		if start, err := seeker.Seek(0, io.SeekCurrent); err == nil {
+			req.GetBody = func() (io.ReadCloser, error) {
+				// The previous attempt must stop reading r before it is rewound.
+				body.Close()
+				<-body.done
+				if _, err := seeker.Seek(start, io.SeekStart); err != nil {
+					return nil, err
+				}
+				body = newBodyStream(write)
+				return body, nil
+			}
+		}
+	}
+	req.TransferEncoding = []string{"chunked"}
+	return req, func() { body.Close() }, nil
+}
+
+// writeDocument writes the text read from r as a document JSON object.
+func writeDocument(w io.Writer, r io.Reader, tagsJSON []byte) error {
+	if _, err := io.WriteString(w, `{"text":"`); err != nil {
+		return err
+	}
+	text := &jsonStringWriter{w: w}
+	if _, err := io.Copy(text, r); err != nil {
+		return err
+	}
+	if err := text.Flush(); err != nil {
+		return err
+	}
+	if _, err := io.WriteString(w, `"`); err != nil {
+		return err
+	}
+	if tagsJSON != nil {
+		if _, err := fmt.Fprintf(w, `,"tags":%s`, tagsJSON); err != nil {
+			return err
+		}
+	}
+	_, err := io.WriteString(w, `}`)
+	return err
+}
@@ -0,0 +1,54 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestJSONStringWriter(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{name: "escapes", input: "say \"hi\"\n\tback\\slash \x01", expected: "say \"hi\"\n\tback\\slash \x01"},
+		{name: "multi-byte", input: "ünïcödé 日本 🐈", expected: "ünïcödé 日本 🐈"},
+		{name: "invalid bytes", input: "a\xffb\xc0\xafc", expected: "a�b��c"},
+		{name: "surrogate", input: "\xed\xa0\x80", expected: "���"},
+		{name: "truncated sequence", input: "\xe6\x97x", expected: "��x"},
+		{name: "trailing partial rune", input: "cat \xf0\x9f\x90", expected: "cat �"},
+	}
+
+	for _, tt := range tests {
+		// Writing one byte at a time splits every multi-byte sequence.
+		for _, chunk := range []int{len(tt.input), 1} {
+			var buf bytes.Buffer
+			buf.WriteByte('"')
+			w := &jsonStringWriter{w: &buf}
+			for input := []byte(tt.input); len(input) > 0; {
+				n := min(chunk, len(input))
+				if written, err := w.Write(input[:n]); err != nil || written != n {
+					t.Fatalf("%s: Write returned %d, %v", tt.name, written, err)
+				}
+				input = input[n:]
+			}
+			if err := w.Flush(); err != nil {
+				t.Fatalf("%s: Flush returned %v", tt.name, err)
+			}
+			buf.WriteByte('"')
+
+			var got string
+			if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+				t.Fatalf("%s: invalid JSON string %q: %v", tt.name, buf.String(), err)
+			}
+			if got != tt.expected {
+				t.Errorf("%s (chunks of %d): expected %q, got %q", tt.name, chunk, tt.expected, got)
+			}
+			if !utf8.Valid(buf.Bytes()) {
+				t.Errorf("%s: invalid UTF-8 copied through: %q", tt.name, buf.String())
+			}
+		}
+	}
+}
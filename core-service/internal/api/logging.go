@@ -0,0 +1,75 @@
+package api
+
+import (
+	"log/slog"
+	"net/http"
+	"time"
+)
+
+// sensitiveHeaders are redacted from request logs unless
+// WithLogSensitiveHeaders is set.
+var sensitiveHeaders = []string{"Authorization", "Proxy-Authorization", "Cookie"}
+
+// loggingTransport logs each request and its response or error.
+type loggingTransport struct {
+	next          http.RoundTripper
+	logger        *slog.Logger
+	showSensitive bool
+}
+
+func (t *loggingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	ctx := req.Context()
+	operation := slog.String("operation", operationName(req.Method, req.URL.String()))
+	requestID := slog.String("request_id", req.Header.Get(requestIDHeader))
+	t.logger.LogAttrs(ctx, slog.LevelDebug, "ml request",
+		operation,
+		requestID,
+		slog.String("method", req.Method),
+		slog.String("url", req.URL.String()),
+		slog.Int64("body_size", req.ContentLength),
+		slog.Any("headers", t.headers(req.Header)),
+	)
+
+	start := time.Now()
+	resp, err := t.next.RoundTrip(req)
+	duration := durationMillis(time.Since(start))
+	if err != nil {
+		t.logger.LogAttrs(ctx, slog.LevelError, "ml request failed",
+			operation,
+			requestID,
+			slog.String("method", req.Method),
+			slog.String("url", req.URL.String()),
+			duration,
+			slog.String("error", err.Error()),
+		)
+		return nil, err
+	}
+
+	t.logger.LogAttrs(ctx, slog.LevelDebug, "ml response",
+		operation,
+		requestID,
+		slog.String("method", req.Method),
+		slog.String("url", req.URL.String()),
+		slog.Int("status", resp.StatusCode),
+		slog.Int64("body_size", resp.ContentLength),
+		duration,
+	)
+	return resp, nil
+}
+
+func durationMillis(d time.Duration) slog.Attr {
+	return slog.Float64("duration_ms", float64(d.Microseconds())/1000)
+}
+
+func (t *loggingTransport) headers(header http.Header) http.Header {
+	if t.showSensitive {
+		return header
+	}
+	redacted := header.Clone()
+	for _, name := range sensitiveHeaders {
+		if redacted.Get(name) != "" {
+			redacted.Set(name, "REDACTED")
+		}
+	}
+	return redacted
+}
@@ -0,0 +1,107 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func logLines(t *testing.T, buf *bytes.Buffer) []map[string]any {
+	t.Helper()
+	var lines []map[string]any
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		var entry map[string]any
+		if err := json.Unmarshal([]byte(line), &entry); err != nil {
+			t.Fatalf("Error decoding log line %q: %v", line, err)
+		}
+		lines = append(lines, entry)
+	}
+	return lines
+}
+
+func TestWithRequestLogger(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"document_id": "doc1"}`))
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		name         string
+		opts         []Option
+		expectedAuth string
+	}{
+		{name: "authorization redacted", expectedAuth: "REDACTED"},
+		{name: "sensitive headers logged", opts: []Option{WithLogSensitiveHeaders()}, expectedAuth: "Bearer secret"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			logger := slog.New(slog.NewJSONHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+			opts := append([]Option{
+				WithAPIKey("secret"),
+				WithRequestLogger(logger),
+				WithRequestIDFunc(func() string { return "req-1" }),
+			}, tt.opts...)
+
+			client := newTestClient(t, server.URL, opts...)
+			if _, err := client.AddDocumentWithMetadata(context.Background(), "hello", nil); err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			lines := logLines(t, &buf)
+			if len(lines) != 2 {
+				t.Fatalf("Expected request and response log lines, got %d: %s", len(lines), buf.String())
+			}
+
+			req, resp := lines[0], lines[1]
+			if req["level"] != "DEBUG" || req["operation"] != "add" || req["method"] != "POST" || req["url"] != server.URL+"/documents" {
+				t.Errorf("Unexpected request log: %v", req)
+			}
+			if size, _ := req["body_size"].(float64); size <= 0 {
+				t.Errorf("Expected request body size, got %v", req["body_size"])
+			}
+			headers, _ := req["headers"].(map[string]any)
+			if auth, _ := headers["Authorization"].([]any); len(auth) != 1 || auth[0] != tt.expectedAuth {
+				t.Errorf("Expected Authorization %q in log, got %v", tt.expectedAuth, headers["Authorization"])
+			}
+			if req["request_id"] != "req-1" || resp["request_id"] != "req-1" {
+				t.Errorf("Expected request_id req-1 in both log lines, got %v and %v", req["request_id"], resp["request_id"])
+			}
+			if tt.expectedAuth == "REDACTED" && strings.Contains(buf.String(), "secret") {
+				t.Error("Expected the API key not to appear in the log")
+			}
+
+			if resp["level"] != "DEBUG" || resp["status"] != float64(http.StatusOK) {
+				t.Errorf("Unexpected response log: %v", resp)
+			}
+			for _, field := range []string{"body_size", "duration_ms"} {
+				if _, ok := resp[field]; !ok {
+					t.Errorf("Expected %s in response log, got %v", field, resp)
+				}
+			}
+		})
+	}
+}
+
+func TestWithRequestLoggerError(t *testing.T) {
+	server := httptest.NewServer(http.NotFoundHandler())
+	server.Close()
+
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&buf, &slog.HandlerOptions{Level: slog.LevelError}))
+	client := newTestClient(t, server.URL, WithRequestLogger(logger))
+	if _, err := client.Search(context.Background(), "hello", 5, 0.1); err == nil {
+		t.Fatal("Expected error but got none")
+	}
+
+	lines := logLines(t, &buf)
+	if len(lines) != 1 || lines[0]["level"] != "ERROR" || lines[0]["operation"] != "search" || lines[0]["error"] == nil {
+		t.Errorf("Expected one error log line with the error, got %s", buf.String())
+	}
+}
@@ -0,0 +1,97 @@
+package api
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// MetricsCollector receives one observation per request to the ML service.
+// Latency runs until the response headers arrive and includes any retries.
+// A request fails when it gets no response or a 4xx or 5xx status.
+type MetricsCollector interface {
+	ObserveRequest(operation string, latency time.Duration, failed bool)
+}
+
+// OperationStats are the counters kept for one operation.
+type OperationStats struct {
+	Requests     int64         `json:"requests"`
+	Errors       int64         `json:"errors"`
+	TotalLatency time.Duration `json:"total_latency"`
+	MaxLatency   time.Duration `json:"max_latency"`
+}
+
+// InMemoryMetrics is a MetricsCollector that keeps counters per operation.
+// It is safe for concurrent use.
+type InMemoryMetrics struct {
+	mu    sync.Mutex
+	stats map[string]OperationStats
+}
+
+func NewInMemoryMetrics() *InMemoryMetrics {
+	return &InMemoryMetrics{stats: make(map[string]OperationStats)}
+}
+
+func (m *InMemoryMetrics) ObserveRequest(operation string, latency time.Duration, failed bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	s := m.stats[operation]
+	s.Requests++
+	if failed {
+		s.Errors++
+	}
+	s.TotalLatency += latency
+	s.MaxLatency = max(s.MaxLatency, latency)
+	m.stats[operation] = s
+}
+
+// Snapshot returns a copy of the current counters keyed by operation.
+func (m *InMemoryMetrics) Snapshot() map[string]OperationStats {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	snapshot := make(map[string]OperationStats, len(m.stats))
+	for op, s := range m.stats {
+		snapshot[op] = s
+	}
+	return snapshot
+}
+
+// metricsClient reports every request made through next to collector.
+type metricsClient struct {
+	next      HTTPClient
+	collector MetricsCollector
+}
+
+func (c *metricsClient) Do(req *http.Request) (*http.Response, error) {
+	start := time.Now()
+	resp, err := c.next.Do(req)
+	c.observe(req.Method, req.URL.String(), start, resp, err)
+	return resp, err
+}
+
+func (c *metricsClient) observe(method, rawURL string, start time.Time, resp *http.Response, err error) {
+	failed := err != nil || resp.StatusCode >= http.StatusBadRequest
+	c.collector.ObserveRequest(operationName(method, rawURL), time.Since(start), failed)
+}
+
+// operationName groups requests by endpoint: "add" for new documents,
+// "document" for other document requests, "search", "image", and otherwise
+// the first path segment, e.g. "export".
+func operationName(method, rawURL string) string {
+	path := rawURL
+	if u, err := url.Parse(rawURL); err == nil {
+		path = u.Path
+	}
+	segment, _, _ := strings.Cut(strings.TrimPrefix(path, "/"), "/")
+	switch {
+	case segment == "documents" && method == http.MethodPost:
+		return "add"
+	case segment == "documents":
+		return "document"
+	case segment == "images":
+		return "image"
+	}
+	return segment
+}
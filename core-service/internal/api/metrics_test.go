@@ -0,0 +1,97 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWithMetrics(t *testing.T) {
+	status := http.StatusOK
+	var sendErr error
+	respond := func() (*http.Response, error) {
+		if sendErr != nil {
+			return nil, sendErr
+		}
+		return &http.Response{
+			StatusCode: status,
+			Body:       io.NopCloser(strings.NewReader(`{"id": "doc1", "query": "test", "results": []}`)),
+		}, nil
+	}
+	mock := &MockHTTPClient{
+		DoFunc: func(req *http.Request) (*http.Response, error) { return respond() },
+	}
+
+	metrics := NewInMemoryMetrics()
+	client := newTestClient(t, "http://test", WithHTTPClient(mock), WithMetrics(metrics))
+
+	client.AddDocument(context.Background(), "hello")
+	client.Search(context.Background(), "hello", 5, 0.1)
+	status = http.StatusInternalServerError
+	client.Search(context.Background(), "hello", 5, 0.1)
+	status = http.StatusOK
+	sendErr = errors.New("connection refused")
+	client.AddImage(context.Background(), []byte("png"), "cat.png", "image/png")
+
+	expected := map[string]struct{ requests, errors int64 }{
+		"add":    {1, 0},
+		"search": {2, 1},
+		"image":  {1, 1},
+	}
+	snapshot := metrics.Snapshot()
+	if len(snapshot) != len(expected) {
+		t.Errorf("Expected %d operations, got %v", len(expected), snapshot)
+	}
+	for op, want := range expected {
+		got := snapshot[op]
+		if got.Requests != want.requests || got.Errors != want.errors {
+			t.Errorf("%s: expected %d requests and %d errors, got %d and %d", op, want.requests, want.errors, got.Requests, got.Errors)
+		}
+		if got.MaxLatency > got.TotalLatency {
+			t.Errorf("%s: max latency %v exceeds total %v", op, got.MaxLatency, got.TotalLatency)
+		}
+	}
+}
+
+func TestInMemoryMetricsSnapshot(t *testing.T) {
+	metrics := NewInMemoryMetrics()
+	metrics.ObserveRequest("search", 2*time.Second, false)
+	metrics.ObserveRequest("search", time.Second, true)
+
+	snapshot := metrics.Snapshot()
+	expected := OperationStats{Requests: 2, Errors: 1, TotalLatency: 3 * time.Second, MaxLatency: 2 * time.Second}
+	if snapshot["search"] != expected {
+		t.Errorf("Expected %+v, got %+v", expected, snapshot["search"])
+	}
+
+	metrics.ObserveRequest("search", time.Second, false)
+	if snapshot["search"].Requests != 2 {
+		t.Errorf("Expected snapshot to be unaffected by later requests, got %d requests", snapshot["search"].Requests)
+	}
+}
+
+func TestOperationName(t *testing.T) {
+	tests := []struct {
+		method   string
+		url      string
+		expected string
+	}{
+		{http.MethodPost, "http://test/documents", "add"},
+		{http.MethodPost, "http://test/documents/batch", "add"},
+		{http.MethodPut, "http://test/documents/doc1", "document"},
+		{http.MethodGet, "http://test/documents?offset=0", "document"},
+		{http.MethodGet, "http://test/search?query=cats", "search"},
+		{http.MethodPost, "http://test/images/similar", "image"},
+		{http.MethodGet, "http://test/export", "export"},
+	}
+
+	for _, tt := range tests {
+		if got := operationName(tt.method, tt.url); got != tt.expected {
+			t.Errorf("operationName(%s, %s): expected %q, got %q", tt.method, tt.url, tt.expected, got)
+		}
+	}
+}
@@ -2,52 +2,264 @@ package api
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
 	"mime/multipart"
 	"net/http"
 	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode"
 )
 
+// HTTPClient sends the requests built by MLClient. *http.Client satisfies it.
 type HTTPClient interface {
-	Post(url string, contentType string, body io.Reader) (*http.Response, error)
-	Get(url string) (*http.Response, error)
+	Do(req *http.Request) (*http.Response, error)
+}
+
+const requestIDHeader = "X-Request-ID"
+
+// RequestContext records the X-Request-ID of the last request made with a
+// context from NewRequestContext. EchoedID is the ID the server sent back,
+// or empty if it did not echo one.
+type RequestContext struct {
+	RequestID string
+	EchoedID  string
+}
+
+type requestContextKey struct{}
+
+// NewRequestContext returns a context that fills in rc when passed to a
+// client method that takes one.
+func NewRequestContext(ctx context.Context) (context.Context, *RequestContext) {
+	rc := &RequestContext{}
+	return context.WithValue(ctx, requestContextKey{}, rc), rc
+}
+
+// headerClient sets extra headers on every request before passing it to next,
+// including a new X-Request-ID per request when requestID is set.
+type headerClient struct {
+	next      HTTPClient
+	header    http.Header
+	requestID func() string
+}
+
+func (c *headerClient) Do(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	if c.requestID != nil {
+		req.Header.Set(requestIDHeader, c.requestID())
+	}
+	for name, values := range c.header {
+		req.Header[name] = values
+	}
+	resp, err := c.next.Do(req)
+	if rc, ok := req.Context().Value(requestContextKey{}).(*RequestContext); ok {
+		rc.RequestID = req.Header.Get(requestIDHeader)
+		if resp != nil {
+			rc.EchoedID = resp.Header.Get(requestIDHeader)
+		}
+	}
+	return resp, err
 }
 
 type MLClient struct {
-	baseURL    string
-	httpClient HTTPClient
+	baseURL          string
+	httpClient       HTTPClient
+	streamClient     HTTPClient
+	maxResponseBytes int64
+	idempotencyKey   func() string
 }
 
-func NewMLClient(baseURL string) *MLClient {
+// NewMLClient returns a client for the ML service at baseURL, which must be
+// an http or https URL. Trailing slashes are dropped.
+func NewMLClient(baseURL string, opts ...Option) (*MLClient, error) {
+	baseURL = strings.TrimRight(baseURL, "/")
+	u, err := url.Parse(baseURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid ML service URL: %w", err)
+	}
+	if (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, fmt.Errorf("invalid ML service URL %q: must be an http or https URL", baseURL)
+	}
+
+	o := clientOptions{
+		maxResponseBytes:    DefaultMaxResponseBytes,
+		timeout:             DefaultTimeout,
+		maxRetries:          DefaultMaxRetries,
+		requestID:           newRequestID,
+		idempotencyKey:      newRequestID,
+		idleConnTimeout:     defaultIdleConnTimeout,
+		keepAlive:           defaultKeepAlive,
+		maxIdleConnsPerHost: defaultMaxIdleConnsPerHost,
+		tlsHandshakeTimeout: defaultTLSHandshakeTimeout,
+	}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
 	return &MLClient{
-		baseURL:    baseURL,
-		httpClient: &http.Client{},
+		baseURL:          baseURL,
+		httpClient:       o.httpClient(),
+		streamClient:     o.streamClient(),
+		maxResponseBytes: o.maxResponseBytes,
+		idempotencyKey:   o.idempotencyKey,
+	}, nil
+}
+
+func (c *MLClient) BaseURL() string {
+	return c.baseURL
+}
+
+// decodeResponse decodes a JSON response body, reading at most
+// c.maxResponseBytes of it. Each of required must be a top-level field of
+// the response that is neither null nor an empty string.
+func (c *MLClient) decodeResponse(body io.Reader, v any, required ...string) error {
+	data, err := c.readResponse(body)
+	if err != nil {
+		return err
+	}
+	return decodeJSON(data, v, required...)
+}
+
+func (c *MLClient) readResponse(body io.Reader) ([]byte, error) {
+	data, err := io.ReadAll(io.LimitReader(body, c.maxResponseBytes+1))
+	if err != nil {
+		return nil, fmt.Errorf("error reading response: %w", err)
+	}
+	if int64(len(data)) > c.maxResponseBytes {
+		return nil, fmt.Errorf("%w: exceeds %d bytes", ErrResponseTooLarge, c.maxResponseBytes)
+	}
+	return data, nil
+}
+
+// decodeJSON decodes data into v and checks the required fields, quoting
+// the start of data in errors so a changed response shape is easy to spot.
+func decodeJSON(data []byte, v any, required ...string) error {
+	if err := json.Unmarshal(data, v); err != nil {
+		return fmt.Errorf("error decoding response %s: %w", bodySnippet(data), err)
+	}
+	if len(required) == 0 {
+		return nil
+	}
+
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return fmt.Errorf("%w: expected a JSON object, got %s", ErrInvalidResponse, bodySnippet(data))
+	}
+	for _, name := range required {
+		if value := strings.TrimSpace(string(fields[name])); value == "" || value == "null" || value == `""` {
+			return missingField(name, data)
+		}
 	}
+	return nil
+}
+
+func missingField(name string, data []byte) error {
+	return fmt.Errorf("%w: missing field %q in %s", ErrInvalidResponse, name, bodySnippet(data))
+}
+
+// maxBodySnippet is how much of a response body errors quote.
+const maxBodySnippet = 200
+
+func bodySnippet(data []byte) string {
+	if len(data) > maxBodySnippet {
+		return strconv.Quote(string(data[:maxBodySnippet])) + "..."
+	}
+	return strconv.Quote(string(data))
+}
+
+// maxDrainBytes is how much of an unread response body closeResponse reads
+// so the connection can be reused. Error bodies are far shorter; past this,
+// e.g. in an abandoned stream, dropping the connection is cheaper.
+const maxDrainBytes = 4 << 20
+
+// closeResponse reads what is left of body before closing it, since the
+// transport only reuses a connection whose response was read to the end.
+func closeResponse(body io.ReadCloser) {
+	io.CopyN(io.Discard, body, maxDrainBytes)
+	body.Close()
 }
 
 type Document struct {
-	Text string `json:"text"`
+	Text     string            `json:"text"`
+	Metadata map[string]string `json:"metadata,omitempty"`
 }
 
 type ImageMetadata struct {
-	Filename    string `json:"filename"`
-	ContentType string `json:"content_type"`
-	Description string `json:"description,omitempty"`
+	Filename    string   `json:"filename"`
+	ContentType string   `json:"content_type"`
+	Description string   `json:"description,omitempty"`
+	Tags        []string `json:"tags,omitempty"`
 }
 
 type UnifiedSearchResult struct {
-	ID         string         `json:"id"`
-	Score      float64        `json:"score"`
-	SourceType string         `json:"source_type"`
-	Content    UnifiedContent `json:"content"`
+	ID          string             `json:"id"`
+	Score       float64            `json:"score"`
+	SourceType  string             `json:"source_type"`
+	Content     UnifiedContent     `json:"content"`
+	Explanation *SearchExplanation `json:"explanation,omitempty"`
+}
+
+// SearchExplanation is returned per result when a search asks for explain.
+// MatchedTerms lists the query words found in the result's text or image
+// description; older servers leave it out.
+type SearchExplanation struct {
+	EmbeddingDistance float64  `json:"embedding_distance"`
+	TokenOverlap      float64  `json:"token_overlap"`
+	ModelName         string   `json:"model_name"`
+	MatchedTerms      []string `json:"matched_terms,omitempty"`
 }
 
+// SearchParams holds the full set of search settings. Search covers the
+// common case of query, limit and threshold.
+type SearchParams struct {
+	Query          string
+	Limit          int
+	ScoreThreshold float64
+	Explain        bool
+	// Sort is one of SortFields; empty leaves ordering to the server.
+	Sort string
+	// Order is "asc" or "desc"; empty uses the server default.
+	Order string
+	// SourceType restricts results to "text" or "image"; empty returns both.
+	SourceType string
+	// Highlight asks the server to fill UnifiedContent.HighlightedText.
+	Highlight bool
+}
+
+// SortFields lists the result orderings the ML service supports.
+var SortFields = []string{"score", "date", "type"}
+
 type UnifiedContent struct {
-	Text      string        `json:"text,omitempty"`
-	Metadata  ImageMetadata `json:"metadata,omitempty"`
-	ImageData string        `json:"image_data,omitempty"`
+	Text             string            `json:"text,omitempty"`
+	DocumentMetadata map[string]string `json:"document_metadata,omitempty"`
+	Metadata         ImageMetadata     `json:"metadata,omitempty"`
+	ImageData        string            `json:"image_data,omitempty"`
+	// HighlightedText is Text with query terms wrapped in <mark> tags, set
+	// when SearchParams.Highlight is.
+	HighlightedText string `json:"highlighted_text,omitempty"`
+}
+
+// DocumentSearchResult is a text search result, as returned by
+// SearchDocuments.
+type DocumentSearchResult struct {
+	ID          string             `json:"id"`
+	Score       float64            `json:"score"`
+	Text        string             `json:"text"`
+	Metadata    map[string]string  `json:"metadata,omitempty"`
+	Explanation *SearchExplanation `json:"explanation,omitempty"`
+}
+
+// ImageSearchResult is an image search result, as returned by SearchImages.
+type ImageSearchResult struct {
+	ID          string             `json:"id"`
+	Score       float64            `json:"score"`
+	Metadata    ImageMetadata      `json:"metadata"`
+	ImageData   string             `json:"image_data,omitempty"`
+	Explanation *SearchExplanation `json:"explanation,omitempty"`
 }
 
 type UnifiedSearchResponse struct {
@@ -56,17 +268,50 @@ type UnifiedSearchResponse struct {
 	TimeTaken float64               `json:"time_taken"`
 }
 
+type DocumentSummary struct {
+	ID   string `json:"id"`
+	Text string `json:"text"`
+}
+
+type ListDocumentsResponse struct {
+	Documents []DocumentSummary `json:"documents"`
+	Total     int               `json:"total"`
+}
+
+type DuplicateGroup struct {
+	Score     float64           `json:"score"`
+	Documents []DocumentSummary `json:"documents"`
+}
+
 type AddImageResponse struct {
 	ImageID  string        `json:"image_id"`
 	Status   string        `json:"status"`
 	Metadata ImageMetadata `json:"metadata"`
 }
 
+// ImageDetail is a stored image with its base64-encoded data.
+type ImageDetail struct {
+	AddImageResponse
+	ImageData string `json:"image_data"`
+}
+
 type SimilarImagesResponse struct {
 	QueryImage string        `json:"query_image"`
 	Results    []ImageResult `json:"results"`
 }
 
+type ImageSummary struct {
+	ID       string        `json:"id"`
+	Metadata ImageMetadata `json:"metadata"`
+	// AddedAt is when the image was stored, in Unix seconds.
+	AddedAt float64 `json:"added_at,omitempty"`
+}
+
+type ListImagesResponse struct {
+	Images []ImageSummary `json:"images"`
+	Total  int            `json:"total"`
+}
+
 type ImageResult struct {
 	ID        string        `json:"id"`
 	Score     float64       `json:"score"`
@@ -74,132 +319,673 @@ type ImageResult struct {
 	ImageData string        `json:"image_data"`
 }
 
-func (c *MLClient) AddDocument(text string) (string, error) {
-	doc := Document{Text: text}
-	jsonData, err := json.Marshal(doc)
-	if err != nil {
-		return "", fmt.Errorf("error marshaling document: %w", err)
+func (c *MLClient) AddDocument(ctx context.Context, text string) (string, error) {
+	if strings.TrimSpace(text) == "" {
+		return "", fmt.Errorf("document text must not be empty")
+	}
+	return c.AddDocumentFromReader(ctx, strings.NewReader(text), nil)
+}
+
+// AddDocumentFromReader adds the UTF-8 text read from r as a document,
+// streaming it to the server with chunked transfer encoding instead of
+// holding it in memory. tags are stored with the document if given. The
+// request is only retried after a 429 response if r is an io.Seeker.
+func (c *MLClient) AddDocumentFromReader(ctx context.Context, r io.Reader, tags []string) (string, error) {
+	var tagsJSON []byte
+	if len(tags) > 0 {
+		var err error
+		if tagsJSON, err = json.Marshal(tags); err != nil {
+			return "", fmt.Errorf("error marshaling tags: %w", err)
+		}
 	}
 
-	resp, err := c.httpClient.Post(c.baseURL+"/documents", "application/json", bytes.NewBuffer(jsonData))
+	req, closeBody, err := newStreamRequest(ctx, http.MethodPost, c.baseURL+"/documents", r, func(w io.Writer) error {
+		return writeDocument(w, r, tagsJSON)
+	})
 	if err != nil {
-		return "", fmt.Errorf("error sending request: %w", err)
+		return "", fmt.Errorf("error creating request: %w", err)
 	}
-	defer resp.Body.Close()
+	defer closeBody()
+	req.Header.Set("Content-Type", "application/json")
+	c.setIdempotencyKey(req)
 
-	if resp.StatusCode != http.StatusOK {
-		return "", fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+	var result struct {
+		DocumentID string `json:"document_id"`
 	}
+	if err := c.do(req, &result, "document_id"); err != nil {
+		return "", err
+	}
+	return result.DocumentID, nil
+}
 
+func (c *MLClient) AddDocumentWithMetadata(ctx context.Context, text string, metadata map[string]string) (string, error) {
+	if strings.TrimSpace(text) == "" {
+		return "", fmt.Errorf("document text must not be empty")
+	}
 	var result struct {
 		DocumentID string `json:"document_id"`
 		Status     string `json:"status"`
 	}
-	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
-		return "", fmt.Errorf("error decoding response: %w", err)
+	req, err := c.newJSONRequest(ctx, http.MethodPost, "/documents", Document{Text: text, Metadata: metadata})
+	if err != nil {
+		return "", err
+	}
+	c.setIdempotencyKey(req)
+	if err := c.do(req, &result, "document_id"); err != nil {
+		return "", err
 	}
 
 	return result.DocumentID, nil
 }
 
-func (c *MLClient) Search(query string, limit int, scoreThreshold float64) (*UnifiedSearchResponse, error) {
-	baseURL := c.baseURL + "/search"
-	u, err := url.Parse(baseURL)
-	if err != nil {
-		return nil, fmt.Errorf("error parsing URL: %w", err)
+func (c *MLClient) AddDocuments(ctx context.Context, docs []Document) ([]string, error) {
+	if len(docs) == 0 {
+		return nil, fmt.Errorf("no documents to add")
 	}
 
-	q := u.Query()
-	q.Set("query", query)
+	body := struct {
+		Documents []Document `json:"documents"`
+	}{Documents: docs}
+	var result struct {
+		DocumentIDs []string `json:"document_ids"`
+		Status      string   `json:"status"`
+	}
+	if err := c.doJSON(ctx, http.MethodPost, "/documents/batch", body, &result, "document_ids"); err != nil {
+		return nil, err
+	}
+	if len(result.DocumentIDs) != len(docs) {
+		return nil, fmt.Errorf("expected %d document IDs, got %d", len(docs), len(result.DocumentIDs))
+	}
+
+	return result.DocumentIDs, nil
+}
+
+func (c *MLClient) UpdateDocument(ctx context.Context, id, text string) error {
+	if strings.TrimSpace(id) == "" {
+		return fmt.Errorf("document ID must not be empty")
+	}
+	if strings.TrimSpace(text) == "" {
+		return fmt.Errorf("document text must not be empty")
+	}
+
+	err := c.doJSON(ctx, http.MethodPut, "/documents/"+url.PathEscape(id), Document{Text: text}, nil)
+	return notFound(err, "document "+id)
+}
+
+func (c *MLClient) DeleteDocument(ctx context.Context, id string) error {
+	if strings.TrimSpace(id) == "" {
+		return fmt.Errorf("document ID must not be empty")
+	}
+
+	err := c.doJSON(ctx, http.MethodDelete, "/documents/"+url.PathEscape(id), nil, nil)
+	return notFound(err, "document "+id)
+}
+
+func (c *MLClient) FindDuplicates(ctx context.Context, threshold float64) ([]DuplicateGroup, error) {
+	q := url.Values{}
+	q.Set("threshold", fmt.Sprintf("%f", threshold))
+
+	var result struct {
+		Groups []DuplicateGroup `json:"groups"`
+	}
+	if err := c.doJSON(ctx, http.MethodGet, "/documents/duplicates?"+q.Encode(), nil, &result, "groups"); err != nil {
+		return nil, err
+	}
+
+	return result.Groups, nil
+}
+
+func (c *MLClient) ListDocuments(ctx context.Context, offset, limit int) (*ListDocumentsResponse, error) {
+	q := url.Values{}
+	q.Set("offset", fmt.Sprintf("%d", offset))
 	q.Set("limit", fmt.Sprintf("%d", limit))
-	q.Set("score_threshold", fmt.Sprintf("%f", scoreThreshold))
-	u.RawQuery = q.Encode()
 
-	resp, err := c.httpClient.Get(u.String())
+	var result ListDocumentsResponse
+	if err := c.doJSON(ctx, http.MethodGet, "/documents?"+q.Encode(), nil, &result, "documents"); err != nil {
+		return nil, err
+	}
+
+	return &result, nil
+}
+
+func (c *MLClient) Search(ctx context.Context, query string, limit int, scoreThreshold float64) (*UnifiedSearchResponse, error) {
+	return c.SearchWithParams(ctx, SearchParams{Query: query, Limit: limit, ScoreThreshold: scoreThreshold})
+}
+
+func (c *MLClient) SearchWithParams(ctx context.Context, params SearchParams) (*UnifiedSearchResponse, error) {
+	return c.search(ctx, params)
+}
+
+// SearchDocuments searches text documents only.
+func (c *MLClient) SearchDocuments(ctx context.Context, query string, limit int, threshold float64) ([]DocumentSearchResult, error) {
+	resp, err := c.search(ctx, SearchParams{Query: query, Limit: limit, ScoreThreshold: threshold, SourceType: "text"})
 	if err != nil {
-		return nil, fmt.Errorf("error sending request: %w", err)
+		return nil, err
 	}
-	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+	results := make([]DocumentSearchResult, 0, len(resp.Results))
+	for _, r := range resp.Results {
+		if r.SourceType != "text" {
+			continue
+		}
+		results = append(results, DocumentSearchResult{
+			ID:          r.ID,
+			Score:       r.Score,
+			Text:        r.Content.Text,
+			Metadata:    r.Content.DocumentMetadata,
+			Explanation: r.Explanation,
+		})
+	}
+	return results, nil
+}
+
+// SearchImages searches images by a text description.
+func (c *MLClient) SearchImages(ctx context.Context, query string, limit int, threshold float64) ([]ImageSearchResult, error) {
+	resp, err := c.search(ctx, SearchParams{Query: query, Limit: limit, ScoreThreshold: threshold, SourceType: "image"})
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]ImageSearchResult, 0, len(resp.Results))
+	for _, r := range resp.Results {
+		if r.SourceType != "image" {
+			continue
+		}
+		results = append(results, ImageSearchResult{
+			ID:          r.ID,
+			Score:       r.Score,
+			Metadata:    r.Content.Metadata,
+			ImageData:   r.Content.ImageData,
+			Explanation: r.Explanation,
+		})
+	}
+	return results, nil
+}
+
+func (c *MLClient) search(ctx context.Context, params SearchParams) (*UnifiedSearchResponse, error) {
+	searchURL, err := c.searchURL(params, false)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, searchURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("error creating request: %w", err)
 	}
 
 	var result UnifiedSearchResponse
-	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
-		return nil, fmt.Errorf("error decoding response: %w", err)
+	if err := c.do(req, &result, "results"); err != nil {
+		return nil, err
 	}
 
 	return &result, nil
 }
 
-func (c *MLClient) AddImage(imageData []byte, filename string) (*AddImageResponse, error) {
-	body := &bytes.Buffer{}
-	writer := multipart.NewWriter(body)
+// SearchCountLimit caps the results counted by SearchCount when the server
+// does not support counting and sends the results themselves.
+const SearchCountLimit = 1000
 
-	part, err := writer.CreateFormFile("image", filename)
+// SearchCount returns the number of text and image results scoring at least
+// threshold, asking the server for the count alone. Servers that ignore
+// count_only send the results instead, and those are counted.
+func (c *MLClient) SearchCount(ctx context.Context, query string, threshold float64) (int, error) {
+	searchURL, err := c.searchURL(SearchParams{Query: query, Limit: SearchCountLimit, ScoreThreshold: threshold}, false)
 	if err != nil {
-		return nil, fmt.Errorf("error creating form file: %w", err)
+		return 0, err
 	}
-	if _, err := part.Write(imageData); err != nil {
-		return nil, fmt.Errorf("error writing image data: %w", err)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, searchURL, nil)
+	if err != nil {
+		return 0, fmt.Errorf("error creating request: %w", err)
 	}
+	q := req.URL.Query()
+	q.Set("count_only", "true")
+	req.URL.RawQuery = q.Encode()
 
-	if err := writer.Close(); err != nil {
-		return nil, fmt.Errorf("error closing multipart writer: %w", err)
+	var data json.RawMessage
+	if err := c.do(req, &data); err != nil {
+		return 0, err
+	}
+	var result struct {
+		Count   *int                  `json:"count"`
+		Results []UnifiedSearchResult `json:"results"`
+	}
+	if err := decodeJSON(data, &result); err != nil {
+		return 0, err
+	}
+	if result.Count != nil {
+		return *result.Count, nil
+	}
+	if result.Results == nil {
+		return 0, missingField("count", data)
 	}
+	return len(result.Results), nil
+}
+
+// SearchStream asks the server for newline-delimited JSON results and calls
+// handler for each one as it arrives, instead of buffering the whole
+// response. It stops at the first error handler returns.
+func (c *MLClient) SearchStream(ctx context.Context, query string, limit int, threshold float64, handler func(UnifiedSearchResult) error) error {
+	searchURL, err := c.searchURL(SearchParams{Query: query, Limit: limit, ScoreThreshold: threshold}, true)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, searchURL, nil)
+	if err != nil {
+		return fmt.Errorf("error creating request: %w", err)
+	}
+
+	resp, err := c.send(req)
+	if err != nil {
+		return err
+	}
+	defer closeResponse(resp.Body)
+
+	decoder := json.NewDecoder(resp.Body)
+	for {
+		var result UnifiedSearchResult
+		if err := decoder.Decode(&result); err == io.EOF {
+			return nil
+		} else if err != nil {
+			return fmt.Errorf("error decoding response: %w", err)
+		}
+		if err := handler(result); err != nil {
+			return err
+		}
+	}
+}
 
-	resp, err := c.httpClient.Post(c.baseURL+"/images", writer.FormDataContentType(), body)
+func (c *MLClient) searchURL(params SearchParams, stream bool) (string, error) {
+	if params.Sort != "" && !slices.Contains(SortFields, params.Sort) {
+		return "", fmt.Errorf("invalid sort field %q (use %s)", params.Sort, strings.Join(SortFields, ", "))
+	}
+	if params.Order != "" && params.Order != "asc" && params.Order != "desc" {
+		return "", fmt.Errorf("invalid sort order %q (use asc or desc)", params.Order)
+	}
+
+	u, err := url.Parse(c.baseURL + "/search")
 	if err != nil {
-		return nil, fmt.Errorf("error sending request: %w", err)
+		return "", fmt.Errorf("error parsing URL: %w", err)
+	}
+
+	q := u.Query()
+	q.Set("query", params.Query)
+	q.Set("limit", fmt.Sprintf("%d", params.Limit))
+	q.Set("score_threshold", fmt.Sprintf("%f", params.ScoreThreshold))
+	if params.Explain {
+		q.Set("explain", "true")
 	}
-	defer resp.Body.Close()
+	if params.Sort != "" {
+		q.Set("sort", params.Sort)
+	}
+	if params.Order != "" {
+		q.Set("order", params.Order)
+	}
+	if params.SourceType != "" {
+		q.Set("source_type", params.SourceType)
+	}
+	if params.Highlight {
+		q.Set("highlight", "true")
+	}
+	if stream {
+		q.Set("stream", "true")
+	}
+	u.RawQuery = q.Encode()
+	return u.String(), nil
+}
+
+// AddImage uploads an image. contentType is sent as the content_type field
+// so the server does not have to guess it; it is omitted when empty.
+func (c *MLClient) AddImage(ctx context.Context, imageData []byte, filename, contentType string) (*AddImageResponse, error) {
+	return c.AddImageWithMetadata(ctx, imageData, filename, contentType, "", nil)
+}
 
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+// AddImageWithMetadata uploads an image like AddImage, storing description
+// and tags in its metadata when they are set.
+func (c *MLClient) AddImageWithMetadata(ctx context.Context, imageData []byte, filename, contentType, description string, tags []string) (*AddImageResponse, error) {
+	return c.addImage(ctx, bytes.NewReader(imageData), filename, contentType, description, tags)
+}
+
+// AddImageFromReader uploads the image read from r, copying it into the
+// multipart body as it is sent rather than reading it into memory first.
+// The server detects the content type.
+func (c *MLClient) AddImageFromReader(ctx context.Context, r io.Reader, filename, description string, tags []string) (*AddImageResponse, error) {
+	return c.addImage(ctx, r, filename, "", description, tags)
+}
+
+func (c *MLClient) addImage(ctx context.Context, r io.Reader, filename, contentType, description string, tags []string) (*AddImageResponse, error) {
+	boundary := multipart.NewWriter(io.Discard).Boundary()
+	req, closeBody, err := newStreamRequest(ctx, http.MethodPost, c.baseURL+"/images", r, func(w io.Writer) error {
+		return writeImageForm(w, boundary, r, sanitizeFilename(filename), contentType, description, tags)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error creating request: %w", err)
 	}
+	defer closeBody()
+	req.Header.Set("Content-Type", "multipart/form-data; boundary="+boundary)
 
 	var result AddImageResponse
-	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
-		return nil, fmt.Errorf("error decoding response: %w", err)
+	if err := c.do(req, &result, "image_id"); err != nil {
+		return nil, err
+	}
+
+	return &result, nil
+}
+
+// writeImageForm writes the multipart form of an image upload, with the
+// image read from r as its last part.
+func writeImageForm(w io.Writer, boundary string, r io.Reader, filename, contentType, description string, tags []string) error {
+	writer := multipart.NewWriter(w)
+	if err := writer.SetBoundary(boundary); err != nil {
+		return err
+	}
+
+	if contentType != "" {
+		if err := writer.WriteField("content_type", contentType); err != nil {
+			return fmt.Errorf("error writing content type: %w", err)
+		}
+	}
+	if description != "" {
+		if err := writer.WriteField("description", description); err != nil {
+			return fmt.Errorf("error writing description: %w", err)
+		}
+	}
+	for _, tag := range tags {
+		if err := writer.WriteField("tags[]", tag); err != nil {
+			return fmt.Errorf("error writing tags: %w", err)
+		}
+	}
+
+	part, err := writer.CreateFormFile("image", filename)
+	if err != nil {
+		return fmt.Errorf("error creating form file: %w", err)
+	}
+	if _, err := io.Copy(part, r); err != nil {
+		return fmt.Errorf("error writing image data: %w", err)
+	}
+	return writer.Close()
+}
+
+// defaultImageFilename is sent for an image whose filename is empty once
+// sanitized.
+const defaultImageFilename = "image"
+
+// sanitizeFilename makes filename safe to quote in a Content-Disposition
+// header: control characters, quotes and path separators are replaced with
+// underscores, since a newline or quote could otherwise end the header early.
+func sanitizeFilename(filename string) string {
+	name := strings.TrimSpace(strings.Map(func(r rune) rune {
+		if unicode.IsControl(r) || r == '"' || r == '\\' || r == '/' {
+			return '_'
+		}
+		return r
+	}, filename))
+	if name == "" || name == "." || name == ".." {
+		return defaultImageFilename
+	}
+	return name
+}
+
+func (c *MLClient) GetImage(ctx context.Context, id string) (*ImageDetail, error) {
+	if strings.TrimSpace(id) == "" {
+		return nil, fmt.Errorf("image ID must not be empty")
+	}
+
+	var result ImageDetail
+	if err := c.doJSON(ctx, http.MethodGet, "/images/"+url.PathEscape(id), nil, &result, "image_id"); err != nil {
+		return nil, notFound(err, "image "+id)
+	}
+
+	return &result, nil
+}
+
+func (c *MLClient) DeleteImage(ctx context.Context, id string) error {
+	if strings.TrimSpace(id) == "" {
+		return fmt.Errorf("image ID must not be empty")
+	}
+
+	err := c.doJSON(ctx, http.MethodDelete, "/images/"+url.PathEscape(id), nil, nil)
+	return notFound(err, "image "+id)
+}
+
+func (c *MLClient) ListImages(ctx context.Context, offset, limit int) (*ListImagesResponse, error) {
+	q := url.Values{}
+	q.Set("offset", fmt.Sprintf("%d", offset))
+	q.Set("limit", fmt.Sprintf("%d", limit))
+
+	var result ListImagesResponse
+	if err := c.doJSON(ctx, http.MethodGet, "/images?"+q.Encode(), nil, &result, "images"); err != nil {
+		return nil, err
+	}
+
+	return &result, nil
+}
+
+func (c *MLClient) FindSimilarImages(ctx context.Context, imageData []byte, limit int, scoreThreshold float64) (*SimilarImagesResponse, error) {
+	var result SimilarImagesResponse
+	err := c.doMultipart(ctx, "/images/similar", func(w *multipart.Writer) error {
+		return writeQueryImage(w, imageData, limit, scoreThreshold)
+	}, &result)
+	if err != nil {
+		return nil, err
+	}
+
+	return &result, nil
+}
+
+func (c *MLClient) TextToImageSearch(ctx context.Context, query string, limit int, scoreThreshold float64) (*SimilarImagesResponse, error) {
+	q := url.Values{}
+	q.Set("query", query)
+	q.Set("limit", fmt.Sprintf("%d", limit))
+	q.Set("score_threshold", fmt.Sprintf("%f", scoreThreshold))
+
+	var result SimilarImagesResponse
+	if err := c.doJSON(ctx, http.MethodGet, "/images/search?"+q.Encode(), nil, &result); err != nil {
+		return nil, err
 	}
 
 	return &result, nil
 }
 
-func (c *MLClient) FindSimilarImages(imageData []byte, limit int, scoreThreshold float64) (*SimilarImagesResponse, error) {
-	body := &bytes.Buffer{}
-	writer := multipart.NewWriter(body)
+func (c *MLClient) ImageToTextSearch(ctx context.Context, imageData []byte, limit int, scoreThreshold float64) (*UnifiedSearchResponse, error) {
+	var result UnifiedSearchResponse
+	err := c.doMultipart(ctx, "/images/search/text", func(w *multipart.Writer) error {
+		return writeQueryImage(w, imageData, limit, scoreThreshold)
+	}, &result)
+	if err != nil {
+		return nil, err
+	}
 
-	part, err := writer.CreateFormFile("image", "query_image")
+	return &result, nil
+}
+
+// writeQueryImage writes the form of an image used as a search query.
+func writeQueryImage(w *multipart.Writer, imageData []byte, limit int, scoreThreshold float64) error {
+	part, err := w.CreateFormFile("image", "query_image")
 	if err != nil {
-		return nil, fmt.Errorf("error creating form file: %w", err)
+		return fmt.Errorf("error creating form file: %w", err)
 	}
 	if _, err := part.Write(imageData); err != nil {
-		return nil, fmt.Errorf("error writing image data: %w", err)
+		return fmt.Errorf("error writing image data: %w", err)
 	}
 
-	_ = writer.WriteField("limit", fmt.Sprintf("%d", limit))
-	_ = writer.WriteField("score_threshold", fmt.Sprintf("%f", scoreThreshold))
+	_ = w.WriteField("limit", fmt.Sprintf("%d", limit))
+	_ = w.WriteField("score_threshold", fmt.Sprintf("%f", scoreThreshold))
+	return nil
+}
+
+// ExportAll streams every document and image as NDJSON, one record per line.
+// The caller must close the returned reader. The client's timeout only bounds
+// the wait for the response headers, not reading the stream.
+func (c *MLClient) ExportAll(ctx context.Context) (io.ReadCloser, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.baseURL+"/export", nil)
+	if err != nil {
+		return nil, fmt.Errorf("error creating request: %w", err)
+	}
 
-	if err := writer.Close(); err != nil {
-		return nil, fmt.Errorf("error closing multipart writer: %w", err)
+	resp, err := c.sendWith(c.streamClient, req)
+	if err != nil {
+		return nil, err
 	}
+	return resp.Body, nil
+}
 
-	resp, err := c.httpClient.Post(c.baseURL+"/images/similar", writer.FormDataContentType(), body)
+type ImportResponse struct {
+	Imported int `json:"imported"`
+}
+
+// Import sends NDJSON records in the format produced by ExportAll. As with
+// ExportAll, the client's timeout does not bound sending the records.
+func (c *MLClient) Import(ctx context.Context, records io.Reader) (*ImportResponse, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.baseURL+"/import", records)
 	if err != nil {
-		return nil, fmt.Errorf("error sending request: %w", err)
+		return nil, fmt.Errorf("error creating request: %w", err)
 	}
-	defer resp.Body.Close()
+	req.Header.Set("Content-Type", "application/x-ndjson")
 
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+	resp, err := c.sendWith(c.streamClient, req)
+	if err != nil {
+		return nil, err
 	}
+	defer closeResponse(resp.Body)
 
-	var result SimilarImagesResponse
-	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
-		return nil, fmt.Errorf("error decoding response: %w", err)
+	var result ImportResponse
+	if err := c.decodeResponse(resp.Body, &result); err != nil {
+		return nil, err
 	}
 
 	return &result, nil
 }
+
+// ClearAll deletes every document and image.
+func (c *MLClient) ClearAll(ctx context.Context) error {
+	return c.doJSON(ctx, http.MethodDelete, "/data", nil, nil)
+}
+
+// Reindex states reported by the server. A reindex has finished once its
+// status is ReindexCompleted or ReindexFailed.
+const (
+	ReindexIdle      = "idle"
+	ReindexRunning   = "running"
+	ReindexCompleted = "completed"
+	ReindexFailed    = "failed"
+)
+
+type ReindexResponse struct {
+	Status    string `json:"status"`
+	Total     int    `json:"total"`
+	Processed int    `json:"processed"`
+	Error     string `json:"error,omitempty"`
+}
+
+// Done reports whether the reindex has finished, successfully or not.
+func (r *ReindexResponse) Done() bool {
+	return r.Status == ReindexCompleted || r.Status == ReindexFailed
+}
+
+// Reindex starts recomputing the embeddings of every document and image
+// with the server's current models. It returns once the job has started;
+// use ReindexStatus to follow it.
+func (c *MLClient) Reindex(ctx context.Context) (*ReindexResponse, error) {
+	var result ReindexResponse
+	if err := c.doJSON(ctx, http.MethodPost, "/reindex", nil, &result, "status"); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+func (c *MLClient) ReindexStatus(ctx context.Context) (*ReindexResponse, error) {
+	var result ReindexResponse
+	if err := c.doJSON(ctx, http.MethodGet, "/reindex/status", nil, &result, "status"); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// Job states reported by the server. A job has finished once its state is
+// JobDone, JobFailed or JobCancelled.
+const (
+	JobPending   = "pending"
+	JobRunning   = "running"
+	JobDone      = "done"
+	JobFailed    = "failed"
+	JobCancelled = "cancelled"
+)
+
+// JobAll lists jobs in every state with ListJobs.
+const JobAll = "all"
+
+// JobStatus is the progress of a background job, such as a document added
+// with AddDocumentAsync. Progress runs from 0 to 1. DocumentID is set once
+// the document is stored.
+type JobStatus struct {
+	JobID      string  `json:"job_id"`
+	State      string  `json:"state"`
+	Progress   float64 `json:"progress"`
+	Error      string  `json:"error,omitempty"`
+	DocumentID string  `json:"document_id,omitempty"`
+}
+
+// Done reports whether the job has finished, successfully or not.
+func (s *JobStatus) Done() bool {
+	return s.State == JobDone || s.State == JobFailed || s.State == JobCancelled
+}
+
+type JobListResponse struct {
+	Jobs  []JobStatus `json:"jobs"`
+	Total int         `json:"total"`
+}
+
+// AddDocumentAsync queues doc to be embedded and stored in the background.
+// It returns as soon as the server has accepted the job; use GetJobStatus to
+// follow it.
+func (c *MLClient) AddDocumentAsync(ctx context.Context, doc Document) (*JobStatus, error) {
+	var result JobStatus
+	if err := c.doJSON(ctx, http.MethodPost, "/documents/async", doc, &result, "job_id", "state"); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+func (c *MLClient) GetJobStatus(ctx context.Context, jobID string) (*JobStatus, error) {
+	if strings.TrimSpace(jobID) == "" {
+		return nil, fmt.Errorf("job ID must not be empty")
+	}
+
+	var result JobStatus
+	if err := c.doJSON(ctx, http.MethodGet, "/jobs/"+url.PathEscape(jobID), nil, &result, "job_id", "state"); err != nil {
+		return nil, notFound(err, "job "+jobID)
+	}
+	return &result, nil
+}
+
+// ListJobs lists the jobs in state, or every job if state is JobAll or
+// empty.
+func (c *MLClient) ListJobs(ctx context.Context, state string) (*JobListResponse, error) {
+	switch state {
+	case "":
+		state = JobAll
+	case JobAll, JobPending, JobRunning, JobDone, JobFailed, JobCancelled:
+	default:
+		return nil, fmt.Errorf("job state must be pending, running, done, failed, cancelled or all, got %q", state)
+	}
+	q := url.Values{}
+	q.Set("state", state)
+
+	var result JobListResponse
+	if err := c.doJSON(ctx, http.MethodGet, "/jobs?"+q.Encode(), nil, &result, "jobs"); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// CancelJob stops a job that has not finished yet.
+func (c *MLClient) CancelJob(ctx context.Context, jobID string) error {
+	if strings.TrimSpace(jobID) == "" {
+		return fmt.Errorf("job ID must not be empty")
+	}
+
+	err := c.doJSON(ctx, http.MethodDelete, "/jobs/"+url.PathEscape(jobID), nil, nil)
+	return notFound(err, "job "+jobID)
+}
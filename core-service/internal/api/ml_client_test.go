@@ -2,47 +2,86 @@ package api
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"io"
+	"mime"
+	"mime/multipart"
 	"net/http"
+	"net/http/httptest"
 	"net/url"
+	"reflect"
 	"strings"
 	"testing"
+	"time"
 )
 
 type MockHTTPClient struct {
-	PostFunc func(url string, contentType string, body io.Reader) (*http.Response, error)
-	GetFunc  func(url string) (*http.Response, error)
+	DoFunc func(req *http.Request) (*http.Response, error)
 }
 
 func NewMLClientWithHTTPClient(baseURL string, httpClient HTTPClient) *MLClient {
 	return &MLClient{
-		baseURL:    baseURL,
-		httpClient: httpClient,
+		baseURL:          baseURL,
+		httpClient:       httpClient,
+		streamClient:     httpClient,
+		maxResponseBytes: DefaultMaxResponseBytes,
 	}
 }
 
-func (m *MockHTTPClient) Post(url string, contentType string, body io.Reader) (*http.Response, error) {
-	return m.PostFunc(url, contentType, body)
+func (m *MockHTTPClient) Do(req *http.Request) (*http.Response, error) {
+	return m.DoFunc(req)
 }
 
-func (m *MockHTTPClient) Get(url string) (*http.Response, error) {
-	return m.GetFunc(url)
+// newTestClient calls NewMLClient, failing the test on error.
+func newTestClient(t *testing.T, baseURL string, opts ...Option) *MLClient {
+	t.Helper()
+	client, err := NewMLClient(baseURL, opts...)
+	if err != nil {
+		t.Fatalf("Unexpected error creating client: %v", err)
+	}
+	return client
 }
 
 func TestNewMLClient(t *testing.T) {
-	baseURL := "http://test-ml-service"
-	client := NewMLClient(baseURL)
-
-	if client == nil {
-		t.Fatal("Expected non-nil client")
-	}
-	if client.baseURL != baseURL {
-		t.Errorf("Expected baseURL %s, got %s", baseURL, client.baseURL)
+	tests := []struct {
+		name            string
+		baseURL         string
+		expectError     bool
+		expectedBaseURL string
+	}{
+		{name: "plain", baseURL: "http://test-ml-service", expectedBaseURL: "http://test-ml-service"},
+		{name: "trailing slash", baseURL: "http://host:8000/", expectedBaseURL: "http://host:8000"},
+		{name: "trailing slashes after path", baseURL: "https://host/ml//", expectedBaseURL: "https://host/ml"},
+		{name: "unsupported scheme", baseURL: "ftp://host", expectError: true},
+		{name: "missing scheme", baseURL: "host:8000", expectError: true},
+		{name: "missing host", baseURL: "http://", expectError: true},
+		{name: "unparseable", baseURL: "http://host:port", expectError: true},
+		{name: "empty", baseURL: "", expectError: true},
 	}
-	if client.httpClient == nil {
-		t.Error("Expected non-nil HTTP client")
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client, err := NewMLClient(tt.baseURL)
+
+			if tt.expectError {
+				if err == nil {
+					t.Error("Expected error but got none")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if client.baseURL != tt.expectedBaseURL {
+				t.Errorf("Expected baseURL %s, got %s", tt.expectedBaseURL, client.baseURL)
+			}
+			if client.httpClient == nil {
+				t.Error("Expected non-nil HTTP client")
+			}
+		})
 	}
 }
 
@@ -77,25 +116,38 @@ func TestAddDocument(t *testing.T) {
 			mockErr:     errors.New("network error"),
 			expectError: true,
 		},
+		{
+			name:        "empty text",
+			text:        "",
+			expectError: true,
+		},
+		{
+			name:        "whitespace-only text",
+			text:        " \n\t",
+			expectError: true,
+		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			mockClient := &MockHTTPClient{
-				PostFunc: func(url string, contentType string, body io.Reader) (*http.Response, error) {
+				DoFunc: func(req *http.Request) (*http.Response, error) {
+					if strings.TrimSpace(tt.text) == "" {
+						t.Error("Expected no request for empty text")
+					}
 					if tt.mockErr != nil {
 						return nil, tt.mockErr
 					}
 
-					if !strings.HasSuffix(url, "/documents") {
-						t.Errorf("Expected /documents endpoint, got %s", url)
+					if !strings.HasSuffix(req.URL.Path, "/documents") {
+						t.Errorf("Expected /documents endpoint, got %s", req.URL)
 					}
-					if contentType != "application/json" {
+					if contentType := req.Header.Get("Content-Type"); contentType != "application/json" {
 						t.Errorf("Expected application/json content type, got %s", contentType)
 					}
 
 					var doc Document
-					if err := json.NewDecoder(body).Decode(&doc); err != nil {
+					if err := json.NewDecoder(req.Body).Decode(&doc); err != nil {
 						t.Errorf("Error decoding request body: %v", err)
 					}
 					if doc.Text != tt.text {
@@ -104,13 +156,13 @@ func TestAddDocument(t *testing.T) {
 
 					return &http.Response{
 						StatusCode: tt.mockStatus,
-						Body:       io.NopCloser(bytes.NewBufferString(tt.mockResp)),
+						Body:       io.NopCloser(strings.NewReader(tt.mockResp)),
 					}, nil
 				},
 			}
 
 			client := NewMLClientWithHTTPClient("http://test", mockClient)
-			id, err := client.AddDocument(tt.text)
+			id, err := client.AddDocument(context.Background(), tt.text)
 
 			if tt.expectError && err == nil {
 				t.Error("Expected error but got none")
@@ -125,104 +177,314 @@ func TestAddDocument(t *testing.T) {
 	}
 }
 
-func TestSearch(t *testing.T) {
+func TestAddDocumentWithMetadata(t *testing.T) {
+	metadata := map[string]string{"source_url": "https://example.com/post"}
+	mockClient := &MockHTTPClient{
+		DoFunc: func(req *http.Request) (*http.Response, error) {
+			var doc Document
+			if err := json.NewDecoder(req.Body).Decode(&doc); err != nil {
+				t.Errorf("Error decoding request body: %v", err)
+			}
+			if doc.Metadata["source_url"] != metadata["source_url"] {
+				t.Errorf("Expected source_url metadata %q, got %q", metadata["source_url"], doc.Metadata["source_url"])
+			}
+
+			return &http.Response{
+				StatusCode: http.StatusOK,
+				Body:       io.NopCloser(strings.NewReader(`{"document_id": "doc123", "status": "stored"}`)),
+			}, nil
+		},
+	}
+
+	client := NewMLClientWithHTTPClient("http://test", mockClient)
+	id, err := client.AddDocumentWithMetadata(context.Background(), "page text", metadata)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if id != "doc123" {
+		t.Errorf("Expected document ID %q, got %q", "doc123", id)
+	}
+}
+
+func TestAddDocuments(t *testing.T) {
+	tests := []struct {
+		name        string
+		docs        []Document
+		mockResp    string
+		mockStatus  int
+		expectedIDs []string
+		expectError bool
+	}{
+		{
+			name:        "successful batch",
+			docs:        []Document{{Text: "chunk one"}, {Text: "chunk two"}},
+			mockResp:    `{"document_ids": ["doc1", "doc2"], "status": "stored"}`,
+			mockStatus:  http.StatusOK,
+			expectedIDs: []string{"doc1", "doc2"},
+		},
+		{
+			name:        "mismatched ID count",
+			docs:        []Document{{Text: "chunk one"}, {Text: "chunk two"}},
+			mockResp:    `{"document_ids": ["doc1"], "status": "stored"}`,
+			mockStatus:  http.StatusOK,
+			expectError: true,
+		},
+		{
+			name:        "server error",
+			docs:        []Document{{Text: "chunk one"}},
+			mockResp:    `{"detail": "internal error"}`,
+			mockStatus:  http.StatusInternalServerError,
+			expectError: true,
+		},
+		{
+			name:        "empty batch",
+			expectError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockClient := &MockHTTPClient{
+				DoFunc: func(req *http.Request) (*http.Response, error) {
+					if !strings.HasSuffix(req.URL.Path, "/documents/batch") {
+						t.Errorf("Expected /documents/batch endpoint, got %s", req.URL)
+					}
+
+					var payload struct {
+						Documents []Document `json:"documents"`
+					}
+					if err := json.NewDecoder(req.Body).Decode(&payload); err != nil {
+						t.Errorf("Error decoding request body: %v", err)
+					}
+					if len(payload.Documents) != len(tt.docs) {
+						t.Errorf("Expected %d documents in body, got %d", len(tt.docs), len(payload.Documents))
+					}
+
+					return &http.Response{
+						StatusCode: tt.mockStatus,
+						Body:       io.NopCloser(strings.NewReader(tt.mockResp)),
+					}, nil
+				},
+			}
+
+			client := NewMLClientWithHTTPClient("http://test", mockClient)
+			ids, err := client.AddDocuments(context.Background(), tt.docs)
+
+			if tt.expectError && err == nil {
+				t.Error("Expected error but got none")
+			}
+			if !tt.expectError && err != nil {
+				t.Errorf("Unexpected error: %v", err)
+			}
+			if !tt.expectError && strings.Join(ids, ",") != strings.Join(tt.expectedIDs, ",") {
+				t.Errorf("Expected IDs %v, got %v", tt.expectedIDs, ids)
+			}
+		})
+	}
+}
+
+func TestUpdateDocument(t *testing.T) {
 	tests := []struct {
 		name           string
-		query          string
-		limit          int
-		scoreThreshold float64
-		mockResp       string
+		id             string
+		text           string
 		mockStatus     int
-		mockErr        error
 		expectError    bool
-		expectedCount  int
+		expectNotFound bool
+		expectRequest  bool
 	}{
 		{
-			name:           "successful search",
-			query:          "test query",
-			limit:          5,
-			scoreThreshold: 0.5,
-			mockStatus:     http.StatusOK,
-			mockResp: `{
-				"query": "test query",
-				"results": [
-					{
-						"id": "doc1",
-						"score": 0.8,
-						"source_type": "text",
-						"content": {"text": "result 1"}
-					},
-					{
-						"id": "doc2",
-						"score": 0.7,
-						"source_type": "text",
-						"content": {"text": "result 2"}
-					}
-				]
-			}`,
-			expectedCount: 2,
+			name:          "successful update",
+			id:            "doc123",
+			text:          "updated text",
+			mockStatus:    http.StatusOK,
+			expectRequest: true,
 		},
 		{
-			name:           "no results",
-			query:          "nonexistent",
-			limit:          5,
-			scoreThreshold: 0.5,
-			mockStatus:     http.StatusOK,
-			mockResp:       `{"query": "nonexistent", "results": []}`,
-			expectedCount:  0,
+			name:           "document not found",
+			id:             "missing",
+			text:           "updated text",
+			mockStatus:     http.StatusNotFound,
+			expectError:    true,
+			expectNotFound: true,
+			expectRequest:  true,
 		},
 		{
-			name:          "network error",
-			query:         "test",
-			mockErr:       errors.New("network error"),
-			expectError:   true,
-			expectedCount: 0,
+			name:        "empty text",
+			id:          "doc123",
+			text:        "   ",
+			expectError: true,
+		},
+		{
+			name:        "empty id",
+			id:          "",
+			text:        "updated text",
+			expectError: true,
 		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
+			called := false
 			mockClient := &MockHTTPClient{
-				GetFunc: func(urlStr string) (*http.Response, error) {
-					if tt.mockErr != nil {
-						return nil, tt.mockErr
+				DoFunc: func(req *http.Request) (*http.Response, error) {
+					called = true
+
+					if req.Method != http.MethodPut {
+						t.Errorf("Expected PUT, got %s", req.Method)
+					}
+					if !strings.HasSuffix(req.URL.Path, "/documents/"+tt.id) {
+						t.Errorf("Expected /documents/%s endpoint, got %s", tt.id, req.URL)
+					}
+					if contentType := req.Header.Get("Content-Type"); contentType != "application/json" {
+						t.Errorf("Expected application/json content type, got %s", contentType)
 					}
 
-					// Parse and verify URL
-					parsedURL, err := url.Parse(urlStr)
-					if err != nil {
-						t.Errorf("Failed to parse URL: %v", err)
-						return nil, err
+					var doc Document
+					if err := json.NewDecoder(req.Body).Decode(&doc); err != nil {
+						t.Errorf("Error decoding request body: %v", err)
+					}
+					if doc.Text != tt.text {
+						t.Errorf("Expected text %q, got %q", tt.text, doc.Text)
 					}
 
-					// Verify endpoint
-					if !strings.Contains(parsedURL.Path, "/search") {
-						t.Errorf("Expected /search endpoint in URL: %s", urlStr)
+					return &http.Response{
+						StatusCode: tt.mockStatus,
+						Body:       io.NopCloser(strings.NewReader(`{}`)),
+					}, nil
+				},
+			}
+
+			client := NewMLClientWithHTTPClient("http://test", mockClient)
+			err := client.UpdateDocument(context.Background(), tt.id, tt.text)
+
+			if tt.expectError && err == nil {
+				t.Error("Expected error but got none")
+			}
+			if !tt.expectError && err != nil {
+				t.Errorf("Unexpected error: %v", err)
+			}
+			if tt.expectNotFound && !errors.Is(err, ErrNotFound) {
+				t.Errorf("Expected ErrNotFound, got %v", err)
+			}
+			if called != tt.expectRequest {
+				t.Errorf("Expected request sent to be %v, got %v", tt.expectRequest, called)
+			}
+		})
+	}
+}
+
+func TestDeleteDocument(t *testing.T) {
+	tests := []struct {
+		name           string
+		id             string
+		mockStatus     int
+		mockErr        error
+		expectError    bool
+		expectNotFound bool
+	}{
+		{name: "successful delete", id: "doc123", mockStatus: http.StatusOK},
+		{name: "no content", id: "doc123", mockStatus: http.StatusNoContent},
+		{name: "not found", id: "missing", mockStatus: http.StatusNotFound, expectError: true, expectNotFound: true},
+		{name: "network error", id: "doc123", mockErr: errors.New("network error"), expectError: true},
+		{name: "empty id", id: " ", expectError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockClient := &MockHTTPClient{
+				DoFunc: func(req *http.Request) (*http.Response, error) {
+					if tt.mockErr != nil {
+						return nil, tt.mockErr
+					}
+					if req.Method != http.MethodDelete {
+						t.Errorf("Expected DELETE method, got %s", req.Method)
+					}
+					if req.URL.Path != "/documents/"+tt.id {
+						t.Errorf("Expected /documents/%s endpoint, got %s", tt.id, req.URL.Path)
 					}
 
-					// Get query parameters
-					query := parsedURL.Query()
+					return &http.Response{
+						StatusCode: tt.mockStatus,
+						Body:       io.NopCloser(strings.NewReader("")),
+					}, nil
+				},
+			}
 
-					// Verify query parameters
-					if q := query.Get("query"); q != tt.query {
-						t.Errorf("Expected query parameter %q, got %q", tt.query, q)
+			client := NewMLClientWithHTTPClient("http://test", mockClient)
+			err := client.DeleteDocument(context.Background(), tt.id)
+
+			if tt.expectError && err == nil {
+				t.Error("Expected error but got none")
+			}
+			if !tt.expectError && err != nil {
+				t.Errorf("Unexpected error: %v", err)
+			}
+			if tt.expectNotFound && !errors.Is(err, ErrNotFound) {
+				t.Errorf("Expected ErrNotFound, got %v", err)
+			}
+		})
+	}
+}
+
+func TestFindDuplicates(t *testing.T) {
+	tests := []struct {
+		name           string
+		threshold      float64
+		mockResp       string
+		mockStatus     int
+		expectError    bool
+		expectedGroups int
+	}{
+		{
+			name:       "duplicates found",
+			threshold:  0.95,
+			mockStatus: http.StatusOK,
+			mockResp: `{"groups": [
+				{"score": 0.98, "documents": [{"id": "a", "text": "note"}, {"id": "b", "text": "note!"}]},
+				{"score": 0.96, "documents": [{"id": "c", "text": "todo"}, {"id": "d", "text": "todo list"}]}
+			]}`,
+			expectedGroups: 2,
+		},
+		{
+			name:           "no duplicates",
+			threshold:      0.99,
+			mockStatus:     http.StatusOK,
+			mockResp:       `{"groups": []}`,
+			expectedGroups: 0,
+		},
+		{
+			name:        "server error",
+			threshold:   0.95,
+			mockStatus:  http.StatusInternalServerError,
+			mockResp:    `{"detail": "internal error"}`,
+			expectError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockClient := &MockHTTPClient{
+				DoFunc: func(req *http.Request) (*http.Response, error) {
+					if req.Method != http.MethodGet {
+						t.Errorf("Expected GET method, got %s", req.Method)
 					}
-					if !query.Has("limit") {
-						t.Errorf("Expected limit parameter in URL: %s", urlStr)
+					if req.URL.Path != "/documents/duplicates" {
+						t.Errorf("Expected /documents/duplicates endpoint, got %s", req.URL.Path)
 					}
-					if !query.Has("score_threshold") {
-						t.Errorf("Expected score_threshold parameter in URL: %s", urlStr)
+					if th := req.URL.Query().Get("threshold"); th != fmt.Sprintf("%f", tt.threshold) {
+						t.Errorf("Expected threshold parameter %f, got %s", tt.threshold, th)
 					}
 
 					return &http.Response{
 						StatusCode: tt.mockStatus,
-						Body:       io.NopCloser(bytes.NewBufferString(tt.mockResp)),
+						Body:       io.NopCloser(strings.NewReader(tt.mockResp)),
 					}, nil
 				},
 			}
 
 			client := NewMLClientWithHTTPClient("http://test", mockClient)
-			resp, err := client.Search(tt.query, tt.limit, tt.scoreThreshold)
+			groups, err := client.FindDuplicates(context.Background(), tt.threshold)
 
 			if tt.expectError && err == nil {
 				t.Error("Expected error but got none")
@@ -230,14 +492,1751 @@ func TestSearch(t *testing.T) {
 			if !tt.expectError && err != nil {
 				t.Errorf("Unexpected error: %v", err)
 			}
-			if err == nil {
-				if len(resp.Results) != tt.expectedCount {
-					t.Errorf("Expected %d results, got %d", tt.expectedCount, len(resp.Results))
-				}
-				if resp.Query != tt.query {
-					t.Errorf("Expected query %q in response, got %q", tt.query, resp.Query)
-				}
+			if len(groups) != tt.expectedGroups {
+				t.Errorf("Expected %d groups, got %d", tt.expectedGroups, len(groups))
 			}
 		})
 	}
 }
+
+func TestListDocuments(t *testing.T) {
+	tests := []struct {
+		name          string
+		offset        int
+		limit         int
+		mockResp      string
+		mockStatus    int
+		mockErr       error
+		expectError   bool
+		expectedCount int
+	}{
+		{
+			name:       "successful list",
+			offset:     10,
+			limit:      2,
+			mockStatus: http.StatusOK,
+			mockResp: `{
+				"documents": [
+					{"id": "doc1", "text": "first"},
+					{"id": "doc2", "text": "second"}
+				],
+				"total": 12
+			}`,
+			expectedCount: 2,
+		},
+		{
+			name:        "server error",
+			limit:       2,
+			mockStatus:  http.StatusInternalServerError,
+			mockResp:    `{"detail": "internal error"}`,
+			expectError: true,
+		},
+		{
+			name:        "network error",
+			mockErr:     errors.New("network error"),
+			expectError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockClient := &MockHTTPClient{
+				DoFunc: func(req *http.Request) (*http.Response, error) {
+					if tt.mockErr != nil {
+						return nil, tt.mockErr
+					}
+
+					parsedURL := req.URL
+					if parsedURL.Path != "/documents" {
+						t.Errorf("Expected /documents endpoint, got %s", parsedURL.Path)
+					}
+					query := parsedURL.Query()
+					if o := query.Get("offset"); o != fmt.Sprintf("%d", tt.offset) {
+						t.Errorf("Expected offset parameter %d, got %s", tt.offset, o)
+					}
+					if l := query.Get("limit"); l != fmt.Sprintf("%d", tt.limit) {
+						t.Errorf("Expected limit parameter %d, got %s", tt.limit, l)
+					}
+
+					return &http.Response{
+						StatusCode: tt.mockStatus,
+						Body:       io.NopCloser(strings.NewReader(tt.mockResp)),
+					}, nil
+				},
+			}
+
+			client := NewMLClientWithHTTPClient("http://test", mockClient)
+			resp, err := client.ListDocuments(context.Background(), tt.offset, tt.limit)
+
+			if tt.expectError && err == nil {
+				t.Error("Expected error but got none")
+			}
+			if !tt.expectError && err != nil {
+				t.Errorf("Unexpected error: %v", err)
+			}
+			if err == nil && len(resp.Documents) != tt.expectedCount {
+				t.Errorf("Expected %d documents, got %d", tt.expectedCount, len(resp.Documents))
+			}
+		})
+	}
+}
+
+func TestSearch(t *testing.T) {
+	tests := []struct {
+		name           string
+		query          string
+		limit          int
+		scoreThreshold float64
+		mockResp       string
+		mockStatus     int
+		mockErr        error
+		expectError    bool
+		expectedCount  int
+	}{
+		{
+			name:           "successful search",
+			query:          "test query",
+			limit:          5,
+			scoreThreshold: 0.5,
+			mockStatus:     http.StatusOK,
+			mockResp: `{
+				"query": "test query",
+				"results": [
+					{
+						"id": "doc1",
+						"score": 0.8,
+						"source_type": "text",
+						"content": {"text": "result 1"}
+					},
+					{
+						"id": "doc2",
+						"score": 0.7,
+						"source_type": "text",
+						"content": {"text": "result 2"}
+					}
+				]
+			}`,
+			expectedCount: 2,
+		},
+		{
+			name:           "no results",
+			query:          "nonexistent",
+			limit:          5,
+			scoreThreshold: 0.5,
+			mockStatus:     http.StatusOK,
+			mockResp:       `{"query": "nonexistent", "results": []}`,
+			expectedCount:  0,
+		},
+		{
+			name:          "network error",
+			query:         "test",
+			mockErr:       errors.New("network error"),
+			expectError:   true,
+			expectedCount: 0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockClient := &MockHTTPClient{
+				DoFunc: func(req *http.Request) (*http.Response, error) {
+					urlStr := req.URL.String()
+					if tt.mockErr != nil {
+						return nil, tt.mockErr
+					}
+
+					// Parse and verify URL
+					parsedURL, err := url.Parse(urlStr)
+					if err != nil {
+						t.Errorf("Failed to parse URL: %v", err)
+						return nil, err
+					}
+
+					// Verify endpoint
+					if !strings.Contains(parsedURL.Path, "/search") {
+						t.Errorf("Expected /search endpoint in URL: %s", urlStr)
+					}
+
+					// Get query parameters
+					query := parsedURL.Query()
+
+					// Verify query parameters
+					if q := query.Get("query"); q != tt.query {
+						t.Errorf("Expected query parameter %q, got %q", tt.query, q)
+					}
+					if !query.Has("limit") {
+						t.Errorf("Expected limit parameter in URL: %s", urlStr)
+					}
+					if !query.Has("score_threshold") {
+						t.Errorf("Expected score_threshold parameter in URL: %s", urlStr)
+					}
+
+					return &http.Response{
+						StatusCode: tt.mockStatus,
+						Body:       io.NopCloser(strings.NewReader(tt.mockResp)),
+					}, nil
+				},
+			}
+
+			client := NewMLClientWithHTTPClient("http://test", mockClient)
+			resp, err := client.Search(context.Background(), tt.query, tt.limit, tt.scoreThreshold)
+
+			if tt.expectError && err == nil {
+				t.Error("Expected error but got none")
+			}
+			if !tt.expectError && err != nil {
+				t.Errorf("Unexpected error: %v", err)
+			}
+			if err == nil {
+				if len(resp.Results) != tt.expectedCount {
+					t.Errorf("Expected %d results, got %d", tt.expectedCount, len(resp.Results))
+				}
+				if resp.Query != tt.query {
+					t.Errorf("Expected query %q in response, got %q", tt.query, resp.Query)
+				}
+			}
+		})
+	}
+}
+
+func TestTextToImageSearch(t *testing.T) {
+	tests := []struct {
+		name           string
+		query          string
+		limit          int
+		scoreThreshold float64
+		mockResp       string
+		mockStatus     int
+		mockErr        error
+		expectError    bool
+		expectedCount  int
+	}{
+		{
+			name:           "successful search",
+			query:          "cat driving a car",
+			limit:          3,
+			scoreThreshold: 0.2,
+			mockStatus:     http.StatusOK,
+			mockResp: `{
+				"query_image": "",
+				"results": [
+					{
+						"id": "img1",
+						"score": 0.31,
+						"metadata": {"filename": "cat.jpg", "content_type": "image/jpeg"}
+					}
+				]
+			}`,
+			expectedCount: 1,
+		},
+		{
+			name:           "server error",
+			query:          "test",
+			limit:          5,
+			scoreThreshold: 0.1,
+			mockStatus:     http.StatusInternalServerError,
+			mockResp:       `{"detail": "internal error"}`,
+			expectError:    true,
+		},
+		{
+			name:        "network error",
+			query:       "test",
+			mockErr:     errors.New("network error"),
+			expectError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockClient := &MockHTTPClient{
+				DoFunc: func(req *http.Request) (*http.Response, error) {
+					if tt.mockErr != nil {
+						return nil, tt.mockErr
+					}
+
+					if req.Method != http.MethodGet {
+						t.Errorf("Expected GET method, got %s", req.Method)
+					}
+					if req.URL.Path != "/images/search" {
+						t.Errorf("Expected /images/search endpoint, got %s", req.URL.Path)
+					}
+
+					query := req.URL.Query()
+					if q := query.Get("query"); q != tt.query {
+						t.Errorf("Expected query parameter %q, got %q", tt.query, q)
+					}
+					if l := query.Get("limit"); l != fmt.Sprintf("%d", tt.limit) {
+						t.Errorf("Expected limit parameter %d, got %s", tt.limit, l)
+					}
+					if s := query.Get("score_threshold"); s != fmt.Sprintf("%f", tt.scoreThreshold) {
+						t.Errorf("Expected score_threshold parameter %f, got %s", tt.scoreThreshold, s)
+					}
+
+					return &http.Response{
+						StatusCode: tt.mockStatus,
+						Body:       io.NopCloser(strings.NewReader(tt.mockResp)),
+					}, nil
+				},
+			}
+
+			client := NewMLClientWithHTTPClient("http://test", mockClient)
+			resp, err := client.TextToImageSearch(context.Background(), tt.query, tt.limit, tt.scoreThreshold)
+
+			if tt.expectError && err == nil {
+				t.Error("Expected error but got none")
+			}
+			if !tt.expectError && err != nil {
+				t.Errorf("Unexpected error: %v", err)
+			}
+			if err == nil && len(resp.Results) != tt.expectedCount {
+				t.Errorf("Expected %d results, got %d", tt.expectedCount, len(resp.Results))
+			}
+		})
+	}
+}
+
+func TestImageToTextSearch(t *testing.T) {
+	tests := []struct {
+		name           string
+		imageData      []byte
+		limit          int
+		scoreThreshold float64
+		mockResp       string
+		mockStatus     int
+		mockErr        error
+		expectError    bool
+		expectedCount  int
+	}{
+		{
+			name:           "successful search",
+			imageData:      []byte("fake image bytes"),
+			limit:          5,
+			scoreThreshold: 0.2,
+			mockStatus:     http.StatusOK,
+			mockResp: `{
+				"query": "query_image",
+				"results": [
+					{
+						"id": "doc1",
+						"score": 0.42,
+						"source_type": "text",
+						"content": {"text": "a note about cats"}
+					}
+				]
+			}`,
+			expectedCount: 1,
+		},
+		{
+			name:        "server error",
+			imageData:   []byte("fake image bytes"),
+			mockStatus:  http.StatusInternalServerError,
+			mockResp:    `{"detail": "internal error"}`,
+			expectError: true,
+		},
+		{
+			name:        "network error",
+			imageData:   []byte("fake image bytes"),
+			mockErr:     errors.New("network error"),
+			expectError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockClient := &MockHTTPClient{
+				DoFunc: func(req *http.Request) (*http.Response, error) {
+					if tt.mockErr != nil {
+						return nil, tt.mockErr
+					}
+
+					if req.Method != http.MethodPost {
+						t.Errorf("Expected POST method, got %s", req.Method)
+					}
+					if req.URL.Path != "/images/search/text" {
+						t.Errorf("Expected /images/search/text endpoint, got %s", req.URL.Path)
+					}
+
+					if err := req.ParseMultipartForm(1 << 20); err != nil {
+						t.Fatalf("Error parsing multipart body: %v", err)
+					}
+					if l := req.FormValue("limit"); l != fmt.Sprintf("%d", tt.limit) {
+						t.Errorf("Expected limit field %d, got %s", tt.limit, l)
+					}
+					if s := req.FormValue("score_threshold"); s != fmt.Sprintf("%f", tt.scoreThreshold) {
+						t.Errorf("Expected score_threshold field %f, got %s", tt.scoreThreshold, s)
+					}
+
+					file, _, err := req.FormFile("image")
+					if err != nil {
+						t.Fatalf("Expected image form file: %v", err)
+					}
+					defer file.Close()
+					data, _ := io.ReadAll(file)
+					if !bytes.Equal(data, tt.imageData) {
+						t.Errorf("Expected image data %q, got %q", tt.imageData, data)
+					}
+
+					return &http.Response{
+						StatusCode: tt.mockStatus,
+						Body:       io.NopCloser(strings.NewReader(tt.mockResp)),
+					}, nil
+				},
+			}
+
+			client := NewMLClientWithHTTPClient("http://test", mockClient)
+			resp, err := client.ImageToTextSearch(context.Background(), tt.imageData, tt.limit, tt.scoreThreshold)
+
+			if tt.expectError && err == nil {
+				t.Error("Expected error but got none")
+			}
+			if !tt.expectError && err != nil {
+				t.Errorf("Unexpected error: %v", err)
+			}
+			if err == nil && len(resp.Results) != tt.expectedCount {
+				t.Errorf("Expected %d results, got %d", tt.expectedCount, len(resp.Results))
+			}
+		})
+	}
+}
+
+func TestFindSimilarImages(t *testing.T) {
+	tests := []struct {
+		name           string
+		limit          int
+		scoreThreshold float64
+		mockResp       string
+		mockStatus     int
+		expectError    bool
+		expectedCount  int
+	}{
+		{
+			name:           "default values",
+			limit:          5,
+			scoreThreshold: 0.3,
+			mockStatus:     http.StatusOK,
+			mockResp:       `{"query_image": "query_image", "results": [{"id": "img1", "score": 0.9, "metadata": {"filename": "a.jpg"}}]}`,
+			expectedCount:  1,
+		},
+		{
+			name:           "custom values",
+			limit:          50,
+			scoreThreshold: 0.75,
+			mockStatus:     http.StatusOK,
+			mockResp:       `{"query_image": "query_image", "results": []}`,
+		},
+		{
+			name:           "server error",
+			limit:          5,
+			scoreThreshold: 0.3,
+			mockStatus:     http.StatusInternalServerError,
+			mockResp:       `{"detail": "internal error"}`,
+			expectError:    true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockClient := &MockHTTPClient{
+				DoFunc: func(req *http.Request) (*http.Response, error) {
+					if !strings.HasSuffix(req.URL.Path, "/images/similar") {
+						t.Errorf("Expected /images/similar endpoint, got %s", req.URL)
+					}
+
+					if err := req.ParseMultipartForm(1 << 20); err != nil {
+						t.Fatalf("Error parsing multipart body: %v", err)
+					}
+					if l := req.FormValue("limit"); l != fmt.Sprintf("%d", tt.limit) {
+						t.Errorf("Expected limit field %d, got %s", tt.limit, l)
+					}
+					if s := req.FormValue("score_threshold"); s != fmt.Sprintf("%f", tt.scoreThreshold) {
+						t.Errorf("Expected score_threshold field %f, got %s", tt.scoreThreshold, s)
+					}
+
+					return &http.Response{
+						StatusCode: tt.mockStatus,
+						Body:       io.NopCloser(strings.NewReader(tt.mockResp)),
+					}, nil
+				},
+			}
+
+			client := NewMLClientWithHTTPClient("http://test", mockClient)
+			resp, err := client.FindSimilarImages(context.Background(), []byte("image"), tt.limit, tt.scoreThreshold)
+
+			if tt.expectError && err == nil {
+				t.Error("Expected error but got none")
+			}
+			if !tt.expectError && err != nil {
+				t.Errorf("Unexpected error: %v", err)
+			}
+			if err == nil && len(resp.Results) != tt.expectedCount {
+				t.Errorf("Expected %d results, got %d", tt.expectedCount, len(resp.Results))
+			}
+		})
+	}
+}
+
+func TestAddDocumentMetadataBody(t *testing.T) {
+	tests := []struct {
+		name     string
+		metadata map[string]string
+		expected string
+	}{
+		{name: "no metadata", expected: `{"text":"note"}`},
+		{name: "empty metadata", metadata: map[string]string{}, expected: `{"text":"note"}`},
+		{name: "with metadata", metadata: map[string]string{"project": "apollo", "tag": "draft"}, expected: `{"text":"note","metadata":{"project":"apollo","tag":"draft"}}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockClient := &MockHTTPClient{
+				DoFunc: func(req *http.Request) (*http.Response, error) {
+					data, _ := io.ReadAll(req.Body)
+					if string(data) != tt.expected {
+						t.Errorf("Expected body %s, got %s", tt.expected, data)
+					}
+					return &http.Response{
+						StatusCode: http.StatusOK,
+						Body:       io.NopCloser(strings.NewReader(`{"document_id": "doc123", "status": "stored"}`)),
+					}, nil
+				},
+			}
+
+			client := NewMLClientWithHTTPClient("http://test", mockClient)
+			if _, err := client.AddDocumentWithMetadata(context.Background(), "note", tt.metadata); err != nil {
+				t.Errorf("Unexpected error: %v", err)
+			}
+		})
+	}
+}
+
+func TestAddDocumentFromReader(t *testing.T) {
+	text := "line one\nsaid \"hi\"\tback\\slash ünïcödé \x01"
+
+	tests := []struct {
+		name         string
+		reader       io.Reader
+		tags         []string
+		status       int
+		expectedTags []string
+		expectError  bool
+	}{
+		{name: "text only", reader: strings.NewReader(text), status: http.StatusOK},
+		{name: "with tags", reader: strings.NewReader(text), tags: []string{"a", "b"}, status: http.StatusOK, expectedTags: []string{"a", "b"}},
+		{name: "non-seekable reader", reader: io.MultiReader(strings.NewReader(text)), status: http.StatusOK},
+		{name: "not retried without seeking", reader: io.MultiReader(strings.NewReader(text)), status: http.StatusTooManyRequests, expectError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var requests int
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				requests++
+				if len(r.TransferEncoding) == 0 || r.TransferEncoding[0] != "chunked" {
+					t.Errorf("Expected chunked transfer encoding, got %v", r.TransferEncoding)
+				}
+				var doc struct {
+					Text string   `json:"text"`
+					Tags []string `json:"tags"`
+				}
+				if err := json.NewDecoder(r.Body).Decode(&doc); err != nil {
+					t.Errorf("Error decoding request body: %v", err)
+				}
+				if doc.Text != text {
+					t.Errorf("Expected text %q, got %q", text, doc.Text)
+				}
+				if !reflect.DeepEqual(doc.Tags, tt.expectedTags) {
+					t.Errorf("Expected tags %v, got %v", tt.expectedTags, doc.Tags)
+				}
+				w.WriteHeader(tt.status)
+				w.Write([]byte(`{"document_id": "doc1"}`))
+			}))
+			defer server.Close()
+
+			client := newTestClient(t, server.URL)
+			id, err := client.AddDocumentFromReader(context.Background(), tt.reader, tt.tags)
+
+			if tt.expectError {
+				if err == nil {
+					t.Error("Expected error but got none")
+				}
+				if requests != 1 {
+					t.Errorf("Expected 1 request, got %d", requests)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if id != "doc1" {
+				t.Errorf("Expected doc1, got %s", id)
+			}
+		})
+	}
+}
+
+func TestAddImageFromReader(t *testing.T) {
+	image := bytes.Repeat([]byte("\x89PNG image bytes "), 64<<10)
+
+	tests := []struct {
+		name             string
+		reader           io.Reader
+		filename         string
+		description      string
+		tags             []string
+		failFirst        bool
+		expectedFilename string
+		expectedRequests int
+		expectError      bool
+	}{
+		{name: "seekable reader", reader: bytes.NewReader(image), filename: "cat.png", expectedFilename: "cat.png", expectedRequests: 1},
+		{name: "with description", reader: bytes.NewReader(image), filename: "cat.png", description: "a cat", expectedFilename: "cat.png", expectedRequests: 1},
+		{name: "with tags", reader: bytes.NewReader(image), filename: "cat.png", tags: []string{"pet", "cat"}, expectedFilename: "cat.png", expectedRequests: 1},
+		{name: "non-seekable reader", reader: io.MultiReader(bytes.NewReader(image)), filename: "cat.png", expectedFilename: "cat.png", expectedRequests: 1},
+		{name: "hostile filename", reader: bytes.NewReader(image), filename: "a\"b\r\n.png", expectedFilename: "a_b__.png", expectedRequests: 1},
+		{name: "retried after rewinding", reader: bytes.NewReader(image), filename: "cat.png", failFirst: true, expectedFilename: "cat.png", expectedRequests: 2},
+		{name: "not retried without seeking", reader: io.MultiReader(bytes.NewReader(image)), filename: "cat.png", failFirst: true, expectedFilename: "cat.png", expectedRequests: 1, expectError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var requests int
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				requests++
+				if len(r.TransferEncoding) == 0 || r.TransferEncoding[0] != "chunked" {
+					t.Errorf("Expected chunked transfer encoding, got %v", r.TransferEncoding)
+				}
+				if err := r.ParseMultipartForm(1 << 20); err != nil {
+					t.Fatalf("Error parsing multipart body: %v", err)
+				}
+				if description := r.FormValue("description"); description != tt.description {
+					t.Errorf("Expected description %q, got %q", tt.description, description)
+				}
+				if tags := r.MultipartForm.Value["tags[]"]; !reflect.DeepEqual(tags, tt.tags) {
+					t.Errorf("Expected tags %v, got %v", tt.tags, tags)
+				}
+				files := r.MultipartForm.File["image"]
+				if len(files) != 1 {
+					t.Fatalf("Expected one image part, got %d", len(files))
+				}
+				_, params, err := mime.ParseMediaType(files[0].Header.Get("Content-Disposition"))
+				if err != nil {
+					t.Errorf("Malformed Content-Disposition: %v", err)
+				}
+				if params["filename"] != tt.expectedFilename {
+					t.Errorf("Expected filename %q, got %q", tt.expectedFilename, params["filename"])
+				}
+				f, err := files[0].Open()
+				if err != nil {
+					t.Fatalf("Error opening image part: %v", err)
+				}
+				defer f.Close()
+				if data, _ := io.ReadAll(f); !bytes.Equal(data, image) {
+					t.Errorf("Expected %d image bytes, got %d", len(image), len(data))
+				}
+
+				if tt.failFirst && requests == 1 {
+					w.WriteHeader(http.StatusTooManyRequests)
+					return
+				}
+				w.Write([]byte(`{"image_id": "img1", "status": "stored"}`))
+			}))
+			defer server.Close()
+
+			client := newTestClient(t, server.URL)
+			resp, err := client.AddImageFromReader(context.Background(), tt.reader, tt.filename, tt.description, tt.tags)
+
+			if requests != tt.expectedRequests {
+				t.Errorf("Expected %d requests, got %d", tt.expectedRequests, requests)
+			}
+			if tt.expectError {
+				if err == nil {
+					t.Error("Expected error but got none")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if resp.ImageID != "img1" {
+				t.Errorf("Expected img1, got %s", resp.ImageID)
+			}
+		})
+	}
+}
+
+func TestGetImage(t *testing.T) {
+	tests := []struct {
+		name           string
+		id             string
+		mockResp       string
+		mockStatus     int
+		expectError    bool
+		expectNotFound bool
+	}{
+		{
+			name:       "successful get",
+			id:         "img/1",
+			mockStatus: http.StatusOK,
+			mockResp:   `{"image_id": "img/1", "metadata": {"filename": "cat.png", "content_type": "image/png"}, "image_data": "aGVsbG8="}`,
+		},
+		{name: "not found", id: "missing", mockStatus: http.StatusNotFound, mockResp: `{"detail": "not found"}`, expectError: true, expectNotFound: true},
+		{name: "server error", id: "img1", mockStatus: http.StatusInternalServerError, mockResp: `{}`, expectError: true},
+		{name: "empty id", id: "", expectError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockClient := &MockHTTPClient{
+				DoFunc: func(req *http.Request) (*http.Response, error) {
+					if req.Method != http.MethodGet {
+						t.Errorf("Expected GET, got %s", req.Method)
+					}
+					if expected := "http://test/images/" + url.PathEscape(tt.id); req.URL.String() != expected {
+						t.Errorf("Expected URL %s, got %s", expected, req.URL)
+					}
+					return &http.Response{
+						StatusCode: tt.mockStatus,
+						Body:       io.NopCloser(strings.NewReader(tt.mockResp)),
+					}, nil
+				},
+			}
+
+			client := NewMLClientWithHTTPClient("http://test", mockClient)
+			image, err := client.GetImage(context.Background(), tt.id)
+
+			if tt.expectError && err == nil {
+				t.Error("Expected error but got none")
+			}
+			if !tt.expectError && err != nil {
+				t.Errorf("Unexpected error: %v", err)
+			}
+			if tt.expectNotFound && !errors.Is(err, ErrNotFound) {
+				t.Errorf("Expected ErrNotFound, got %v", err)
+			}
+			if err == nil && (image.ImageID != tt.id || image.Metadata.ContentType != "image/png" || image.ImageData != "aGVsbG8=") {
+				t.Errorf("Unexpected image: %+v", image)
+			}
+		})
+	}
+}
+
+func TestListImages(t *testing.T) {
+	tests := []struct {
+		name          string
+		offset        int
+		limit         int
+		mockResp      string
+		mockStatus    int
+		expectError   bool
+		expectedCount int
+	}{
+		{
+			name:       "successful list",
+			offset:     20,
+			limit:      2,
+			mockStatus: http.StatusOK,
+			mockResp: `{
+				"images": [
+					{"id": "img1", "metadata": {"filename": "cat.jpg", "content_type": "image/jpeg", "description": "a cat"}, "added_at": 1738324800.5},
+					{"id": "img2", "metadata": {"filename": "dog.png", "content_type": "image/png"}}
+				],
+				"total": 22
+			}`,
+			expectedCount: 2,
+		},
+		{
+			name:        "server error",
+			limit:       2,
+			mockStatus:  http.StatusInternalServerError,
+			mockResp:    `{"detail": "internal error"}`,
+			expectError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockClient := &MockHTTPClient{
+				DoFunc: func(req *http.Request) (*http.Response, error) {
+					if req.Method != http.MethodGet {
+						t.Errorf("Expected GET method, got %s", req.Method)
+					}
+					parsedURL := req.URL
+					if parsedURL.Path != "/images" {
+						t.Errorf("Expected /images endpoint, got %s", parsedURL.Path)
+					}
+					query := parsedURL.Query()
+					if o := query.Get("offset"); o != fmt.Sprintf("%d", tt.offset) {
+						t.Errorf("Expected offset parameter %d, got %s", tt.offset, o)
+					}
+					if l := query.Get("limit"); l != fmt.Sprintf("%d", tt.limit) {
+						t.Errorf("Expected limit parameter %d, got %s", tt.limit, l)
+					}
+
+					return &http.Response{
+						StatusCode: tt.mockStatus,
+						Body:       io.NopCloser(strings.NewReader(tt.mockResp)),
+					}, nil
+				},
+			}
+
+			client := NewMLClientWithHTTPClient("http://test", mockClient)
+			resp, err := client.ListImages(context.Background(), tt.offset, tt.limit)
+
+			if tt.expectError && err == nil {
+				t.Error("Expected error but got none")
+			}
+			if !tt.expectError && err != nil {
+				t.Errorf("Unexpected error: %v", err)
+			}
+			if err != nil {
+				return
+			}
+			if len(resp.Images) != tt.expectedCount {
+				t.Errorf("Expected %d images, got %d", tt.expectedCount, len(resp.Images))
+			}
+			if first := resp.Images[0]; first.Metadata.Filename != "cat.jpg" || first.Metadata.Description != "a cat" || first.AddedAt != 1738324800.5 {
+				t.Errorf("Unexpected first image: %+v", resp.Images[0])
+			}
+		})
+	}
+}
+
+func TestSearchWithParamsExplain(t *testing.T) {
+	tests := []struct {
+		name        string
+		explain     bool
+		mockResp    string
+		expected    *SearchExplanation
+		expectParam string
+	}{
+		{
+			name:        "explain off",
+			mockResp:    `{"query": "q", "results": [{"id": "doc1", "score": 0.73, "source_type": "text", "content": {"text": "t"}}]}`,
+			expectParam: "",
+		},
+		{
+			name:        "explain on",
+			explain:     true,
+			mockResp:    `{"query": "q", "results": [{"id": "doc1", "score": 0.73, "source_type": "text", "content": {"text": "t"}, "explanation": {"embedding_distance": 0.27, "token_overlap": 0.5, "model_name": "all-mpnet-base-v2"}}]}`,
+			expected:    &SearchExplanation{EmbeddingDistance: 0.27, TokenOverlap: 0.5, ModelName: "all-mpnet-base-v2"},
+			expectParam: "true",
+		},
+		{
+			name:        "matched terms",
+			explain:     true,
+			mockResp:    `{"query": "q", "results": [{"id": "doc1", "score": 0.73, "source_type": "text", "content": {"text": "t"}, "explanation": {"embedding_distance": 0.27, "token_overlap": 0.5, "model_name": "all-mpnet-base-v2", "matched_terms": ["cats", "sleep"]}}]}`,
+			expected:    &SearchExplanation{EmbeddingDistance: 0.27, TokenOverlap: 0.5, ModelName: "all-mpnet-base-v2", MatchedTerms: []string{"cats", "sleep"}},
+			expectParam: "true",
+		},
+		{
+			name:        "explanation omitted by server",
+			explain:     true,
+			mockResp:    `{"query": "q", "results": [{"id": "doc1", "score": 0.73, "source_type": "text", "content": {"text": "t"}}]}`,
+			expectParam: "true",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockClient := &MockHTTPClient{
+				DoFunc: func(req *http.Request) (*http.Response, error) {
+					urlStr := req.URL.String()
+					parsedURL, err := url.Parse(urlStr)
+					if err != nil {
+						t.Fatalf("Failed to parse URL: %v", err)
+					}
+					if e := parsedURL.Query().Get("explain"); e != tt.expectParam {
+						t.Errorf("Expected explain parameter %q, got %q", tt.expectParam, e)
+					}
+					return &http.Response{
+						StatusCode: http.StatusOK,
+						Body:       io.NopCloser(strings.NewReader(tt.mockResp)),
+					}, nil
+				},
+			}
+
+			client := NewMLClientWithHTTPClient("http://test", mockClient)
+			resp, err := client.SearchWithParams(context.Background(), SearchParams{Query: "q", Limit: 5, ScoreThreshold: 0.1, Explain: tt.explain})
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(resp.Results[0].Explanation, tt.expected) {
+				t.Errorf("Expected explanation %+v, got %+v", tt.expected, resp.Results[0].Explanation)
+			}
+		})
+	}
+}
+
+func TestSearchExplanationJSON(t *testing.T) {
+	result := UnifiedSearchResult{ID: "doc1", Score: 0.73, SourceType: "text"}
+	data, err := json.Marshal(result)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if strings.Contains(string(data), "explanation") {
+		t.Errorf("Expected explanation to be omitted when nil, got %s", data)
+	}
+
+	result.Explanation = &SearchExplanation{EmbeddingDistance: 0.27, TokenOverlap: 0.5, ModelName: "clip"}
+	data, err = json.Marshal(result)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	expected := `"explanation":{"embedding_distance":0.27,"token_overlap":0.5,"model_name":"clip"}`
+	if !strings.Contains(string(data), expected) {
+		t.Errorf("Expected %s in %s", expected, data)
+	}
+}
+
+func TestDeleteImage(t *testing.T) {
+	tests := []struct {
+		name           string
+		id             string
+		mockStatus     int
+		mockErr        error
+		expectError    bool
+		expectNotFound bool
+	}{
+		{name: "successful delete", id: "img1", mockStatus: http.StatusOK},
+		{name: "network error", id: "img1", mockErr: errors.New("connection refused"), expectError: true},
+		{name: "no content", id: "img/1", mockStatus: http.StatusNoContent},
+		{name: "not found", id: "missing", mockStatus: http.StatusNotFound, expectError: true, expectNotFound: true},
+		{name: "server error", id: "img1", mockStatus: http.StatusInternalServerError, expectError: true},
+		{name: "empty id", id: "", expectError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockClient := &MockHTTPClient{
+				DoFunc: func(req *http.Request) (*http.Response, error) {
+					if req.Method != http.MethodDelete {
+						t.Errorf("Expected DELETE method, got %s", req.Method)
+					}
+					if expected := "/images/" + url.PathEscape(tt.id); req.URL.EscapedPath() != expected {
+						t.Errorf("Expected %s endpoint, got %s", expected, req.URL.EscapedPath())
+					}
+					if tt.mockErr != nil {
+						return nil, tt.mockErr
+					}
+
+					return &http.Response{
+						StatusCode: tt.mockStatus,
+						Body:       io.NopCloser(strings.NewReader("")),
+					}, nil
+				},
+			}
+
+			client := NewMLClientWithHTTPClient("http://test", mockClient)
+			err := client.DeleteImage(context.Background(), tt.id)
+
+			if tt.expectError && err == nil {
+				t.Error("Expected error but got none")
+			}
+			if !tt.expectError && err != nil {
+				t.Errorf("Unexpected error: %v", err)
+			}
+			if tt.expectNotFound && !errors.Is(err, ErrNotFound) {
+				t.Errorf("Expected ErrNotFound, got %v", err)
+			}
+			if !tt.expectNotFound && errors.Is(err, ErrNotFound) {
+				t.Errorf("Expected an error other than ErrNotFound, got %v", err)
+			}
+		})
+	}
+}
+
+func TestSearchWithParamsSort(t *testing.T) {
+	tests := []struct {
+		name          string
+		sort          string
+		order         string
+		expectError   bool
+		expectedQuery map[string]string
+	}{
+		{name: "no sort", expectedQuery: map[string]string{"sort": "", "order": ""}},
+		{name: "date ascending", sort: "date", order: "asc", expectedQuery: map[string]string{"sort": "date", "order": "asc"}},
+		{name: "type only", sort: "type", expectedQuery: map[string]string{"sort": "type", "order": ""}},
+		{name: "unknown field", sort: "size", expectError: true},
+		{name: "unknown order", sort: "score", order: "up", expectError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockClient := &MockHTTPClient{
+				DoFunc: func(req *http.Request) (*http.Response, error) {
+					urlStr := req.URL.String()
+					if tt.expectError {
+						t.Error("Expected no request for invalid sort settings")
+					}
+					parsedURL, err := url.Parse(urlStr)
+					if err != nil {
+						t.Fatalf("Failed to parse URL: %v", err)
+					}
+					for key, expected := range tt.expectedQuery {
+						if got := parsedURL.Query().Get(key); got != expected {
+							t.Errorf("Expected %s parameter %q, got %q", key, expected, got)
+						}
+					}
+					return &http.Response{
+						StatusCode: http.StatusOK,
+						Body:       io.NopCloser(strings.NewReader(`{"query": "q", "results": []}`)),
+					}, nil
+				},
+			}
+
+			client := NewMLClientWithHTTPClient("http://test", mockClient)
+			_, err := client.SearchWithParams(context.Background(), SearchParams{Query: "q", Limit: 5, ScoreThreshold: 0.1, Sort: tt.sort, Order: tt.order})
+
+			if tt.expectError && err == nil {
+				t.Error("Expected error but got none")
+			}
+			if !tt.expectError && err != nil {
+				t.Errorf("Unexpected error: %v", err)
+			}
+		})
+	}
+}
+
+func TestAddImageContentType(t *testing.T) {
+	tests := []struct {
+		name        string
+		contentType string
+	}{
+		{name: "content type sent", contentType: "image/png"},
+		{name: "content type omitted", contentType: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockClient := &MockHTTPClient{
+				DoFunc: func(req *http.Request) (*http.Response, error) {
+					if err := req.ParseMultipartForm(1 << 20); err != nil {
+						t.Fatalf("Error parsing multipart body: %v", err)
+					}
+					values, ok := req.MultipartForm.Value["content_type"]
+					if tt.contentType == "" && ok {
+						t.Errorf("Expected no content_type field, got %v", values)
+					}
+					if tt.contentType != "" && (len(values) != 1 || values[0] != tt.contentType) {
+						t.Errorf("Expected content_type field %s, got %v", tt.contentType, values)
+					}
+					if files := req.MultipartForm.File["image"]; len(files) != 1 || files[0].Filename != "cat.png" {
+						t.Errorf("Expected image file cat.png, got %v", files)
+					}
+
+					return &http.Response{
+						StatusCode: http.StatusOK,
+						Body: io.NopCloser(strings.NewReader(fmt.Sprintf(
+							`{"image_id": "img1", "status": "stored", "metadata": {"filename": "cat.png", "content_type": %q}}`, tt.contentType))),
+					}, nil
+				},
+			}
+
+			client := NewMLClientWithHTTPClient("http://test", mockClient)
+			resp, err := client.AddImage(context.Background(), []byte("image"), "cat.png", tt.contentType)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if resp.Metadata.ContentType != tt.contentType {
+				t.Errorf("Expected echoed content type %q, got %q", tt.contentType, resp.Metadata.ContentType)
+			}
+		})
+	}
+}
+
+// mixedSearchResponse has one result of each source type, as returned by a
+// server that ignores source_type.
+const mixedSearchResponse = `{
+	"query": "cat",
+	"results": [
+		{"id": "doc1", "score": 0.9, "source_type": "text", "content": {"text": "a cat", "document_metadata": {"source": "notes.md"}}},
+		{"id": "img1", "score": 0.8, "source_type": "image", "content": {"metadata": {"filename": "cat.png", "content_type": "image/png"}, "image_data": "aGVsbG8="}}
+	],
+	"time_taken": 0.1
+}`
+
+func typedSearchClient(t *testing.T, expectedSourceType string, status int) *MLClient {
+	t.Helper()
+	return NewMLClientWithHTTPClient("http://test", &MockHTTPClient{
+		DoFunc: func(req *http.Request) (*http.Response, error) {
+			query := req.URL.Query()
+			if got := query.Get("source_type"); got != expectedSourceType {
+				t.Errorf("Expected source_type %q, got %q", expectedSourceType, got)
+			}
+			if got := query.Get("query"); got != "cat" {
+				t.Errorf("Expected query %q, got %q", "cat", got)
+			}
+			return &http.Response{
+				StatusCode: status,
+				Body:       io.NopCloser(strings.NewReader(mixedSearchResponse)),
+			}, nil
+		},
+	})
+}
+
+func TestSearchDocuments(t *testing.T) {
+	client := typedSearchClient(t, "text", http.StatusOK)
+	results, err := client.SearchDocuments(context.Background(), "cat", 5, 0.5)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	expected := []DocumentSearchResult{{ID: "doc1", Score: 0.9, Text: "a cat", Metadata: map[string]string{"source": "notes.md"}}}
+	if !reflect.DeepEqual(results, expected) {
+		t.Errorf("Expected %+v, got %+v", expected, results)
+	}
+
+	client = typedSearchClient(t, "text", http.StatusInternalServerError)
+	if _, err := client.SearchDocuments(context.Background(), "cat", 5, 0.5); err == nil {
+		t.Error("Expected error but got none")
+	}
+}
+
+func TestSearchImages(t *testing.T) {
+	client := typedSearchClient(t, "image", http.StatusOK)
+	results, err := client.SearchImages(context.Background(), "cat", 5, 0.5)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	expected := []ImageSearchResult{{
+		ID:        "img1",
+		Score:     0.8,
+		Metadata:  ImageMetadata{Filename: "cat.png", ContentType: "image/png"},
+		ImageData: "aGVsbG8=",
+	}}
+	if !reflect.DeepEqual(results, expected) {
+		t.Errorf("Expected %+v, got %+v", expected, results)
+	}
+
+	client = typedSearchClient(t, "image", http.StatusInternalServerError)
+	if _, err := client.SearchImages(context.Background(), "cat", 5, 0.5); err == nil {
+		t.Error("Expected error but got none")
+	}
+}
+
+func TestSearchStream(t *testing.T) {
+	received := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("stream") != "true" {
+			t.Errorf("Expected stream=true, got %q", r.URL.RawQuery)
+		}
+		w.Header().Set("Content-Type", "application/x-ndjson")
+		w.Write([]byte(`{"id": "doc1", "score": 0.9, "source_type": "text", "content": {"text": "first"}}` + "\n"))
+		w.(http.Flusher).Flush()
+		// Hold the connection open until the client has handled the first result.
+		select {
+		case <-received:
+		case <-time.After(5 * time.Second):
+			t.Error("Expected first result to be handled before the response ended")
+		}
+		w.Write([]byte(`{"id": "img1", "score": 0.4, "source_type": "image", "content": {"metadata": {"filename": "cat.png"}}}` + "\n"))
+	}))
+	defer server.Close()
+
+	client := newTestClient(t, server.URL)
+	var ids []string
+	err := client.SearchStream(context.Background(), "cats", 10, 0.1, func(result UnifiedSearchResult) error {
+		if len(ids) == 0 {
+			close(received)
+		}
+		ids = append(ids, result.ID)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(ids, []string{"doc1", "img1"}) {
+		t.Errorf("Expected [doc1 img1], got %v", ids)
+	}
+
+	stop := errors.New("stop")
+	calls := 0
+	server2 := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"id": "a"}` + "\n" + `{"id": "b"}` + "\n"))
+	}))
+	defer server2.Close()
+	err = newTestClient(t, server2.URL).SearchStream(context.Background(), "cats", 10, 0.1, func(UnifiedSearchResult) error {
+		calls++
+		return stop
+	})
+	if !errors.Is(err, stop) || calls != 1 {
+		t.Errorf("Expected handler error after 1 call, got %v after %d", err, calls)
+	}
+
+	failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer failing.Close()
+	var apiErr *APIError
+	err = newTestClient(t, failing.URL, WithMaxRetries(0)).SearchStream(context.Background(), "cats", 10, 0.1, func(UnifiedSearchResult) error { return nil })
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusInternalServerError {
+		t.Errorf("Expected APIError with status 500, got %v", err)
+	}
+}
+
+func TestSearchCount(t *testing.T) {
+	tests := []struct {
+		name          string
+		status        int
+		body          string
+		expectedCount int
+		expectError   bool
+	}{
+		{name: "count from server", status: http.StatusOK, body: `{"count": 42}`, expectedCount: 42},
+		{name: "zero count", status: http.StatusOK, body: `{"count": 0, "results": [{"id": "doc1"}]}`, expectedCount: 0},
+		{name: "counted from results", status: http.StatusOK, body: `{"query": "cats", "results": [{"id": "doc1"}, {"id": "img1"}], "time_taken": 0.1}`, expectedCount: 2},
+		{name: "no results", status: http.StatusOK, body: `{"query": "cats", "results": [], "time_taken": 0.1}`, expectedCount: 0},
+		{name: "server error", status: http.StatusInternalServerError, body: `{"detail": "boom"}`, expectError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				q := r.URL.Query()
+				if q.Get("count_only") != "true" || q.Get("query") != "cats" || q.Get("score_threshold") != "0.500000" {
+					t.Errorf("Unexpected query: %s", r.URL.RawQuery)
+				}
+				if q.Get("limit") != fmt.Sprint(SearchCountLimit) {
+					t.Errorf("Expected limit %d, got %s", SearchCountLimit, q.Get("limit"))
+				}
+				w.WriteHeader(tt.status)
+				w.Write([]byte(tt.body))
+			}))
+			defer server.Close()
+
+			client := newTestClient(t, server.URL)
+			count, err := client.SearchCount(context.Background(), "cats", 0.5)
+
+			if tt.expectError {
+				if err == nil {
+					t.Error("Expected error but got none")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if count != tt.expectedCount {
+				t.Errorf("Expected count %d, got %d", tt.expectedCount, count)
+			}
+		})
+	}
+}
+
+func TestReindex(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodPost && r.URL.Path == "/reindex":
+			w.WriteHeader(http.StatusAccepted)
+			w.Write([]byte(`{"status": "running", "total": 10, "processed": 0}`))
+		case r.Method == http.MethodGet && r.URL.Path == "/reindex/status":
+			w.Write([]byte(`{"status": "failed", "total": 10, "processed": 4, "error": "model not loaded"}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	client := newTestClient(t, server.URL)
+	started, err := client.Reindex(context.Background())
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if *started != (ReindexResponse{Status: ReindexRunning, Total: 10}) || started.Done() {
+		t.Errorf("Expected a running reindex of 10 items, got %+v", started)
+	}
+
+	status, err := client.ReindexStatus(context.Background())
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if !status.Done() || status.Error != "model not loaded" || status.Processed != 4 {
+		t.Errorf("Expected a failed reindex after 4 items, got %+v", status)
+	}
+
+	failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusConflict)
+	}))
+	defer failing.Close()
+	var apiErr *APIError
+	if _, err := newTestClient(t, failing.URL).Reindex(context.Background()); !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusConflict {
+		t.Errorf("Expected APIError with status 409, got %v", err)
+	}
+}
+
+func TestAddDocumentAsync(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodPost && r.URL.Path == "/documents/async":
+			var doc Document
+			if err := json.NewDecoder(r.Body).Decode(&doc); err != nil || doc.Text != "hello" || doc.Metadata["project"] != "apollo" {
+				t.Errorf("Unexpected document %+v (%v)", doc, err)
+			}
+			w.WriteHeader(http.StatusAccepted)
+			w.Write([]byte(`{"job_id": "job-1", "state": "pending", "progress": 0}`))
+		case r.URL.Path == "/jobs/job-1":
+			w.Write([]byte(`{"job_id": "job-1", "state": "done", "progress": 1, "document_id": "doc-1"}`))
+		case r.URL.Path == "/jobs/job-2":
+			w.Write([]byte(`{"job_id": "job-2", "state": "failed", "progress": 0.5, "error": "model not loaded"}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	client := newTestClient(t, server.URL)
+	job, err := client.AddDocumentAsync(context.Background(), Document{Text: "hello", Metadata: map[string]string{"project": "apollo"}})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if *job != (JobStatus{JobID: "job-1", State: JobPending}) || job.Done() {
+		t.Errorf("Expected a pending job, got %+v", job)
+	}
+
+	tests := []struct {
+		id             string
+		expected       JobStatus
+		expectNotFound bool
+		expectError    bool
+	}{
+		{id: "job-1", expected: JobStatus{JobID: "job-1", State: JobDone, Progress: 1, DocumentID: "doc-1"}},
+		{id: "job-2", expected: JobStatus{JobID: "job-2", State: JobFailed, Progress: 0.5, Error: "model not loaded"}},
+		{id: "missing", expectNotFound: true},
+		{id: " ", expectError: true},
+	}
+	for _, tt := range tests {
+		status, err := client.GetJobStatus(context.Background(), tt.id)
+		switch {
+		case tt.expectNotFound:
+			if !errors.Is(err, ErrNotFound) || !strings.Contains(err.Error(), "job missing") {
+				t.Errorf("Expected ErrNotFound for job missing, got %v", err)
+			}
+		case tt.expectError:
+			if err == nil {
+				t.Errorf("Expected an error for job ID %q", tt.id)
+			}
+		case err != nil:
+			t.Errorf("Unexpected error for %s: %v", tt.id, err)
+		case *status != tt.expected || !status.Done():
+			t.Errorf("Expected %+v, got %+v", tt.expected, status)
+		}
+	}
+}
+
+func TestListJobs(t *testing.T) {
+	tests := []struct {
+		filter      string
+		expected    string
+		expectError bool
+	}{
+		{filter: "", expected: "all"},
+		{filter: JobAll, expected: "all"},
+		{filter: JobPending, expected: "pending"},
+		{filter: JobRunning, expected: "running"},
+		{filter: JobDone, expected: "done"},
+		{filter: JobFailed, expected: "failed"},
+		{filter: JobCancelled, expected: "cancelled"},
+		{filter: "finished", expectError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.filter, func(t *testing.T) {
+			var state string
+			requests := 0
+			mockClient := &MockHTTPClient{
+				DoFunc: func(req *http.Request) (*http.Response, error) {
+					requests++
+					if req.Method != http.MethodGet || req.URL.Path != "/jobs" {
+						t.Errorf("Unexpected request %s %s", req.Method, req.URL)
+					}
+					state = req.URL.Query().Get("state")
+					return &http.Response{
+						StatusCode: http.StatusOK,
+						Body:       io.NopCloser(strings.NewReader(`{"jobs": [{"job_id": "job-1", "state": "running", "progress": 0.5}], "total": 1}`)),
+					}, nil
+				},
+			}
+
+			client := NewMLClientWithHTTPClient("http://test", mockClient)
+			resp, err := client.ListJobs(context.Background(), tt.filter)
+			if tt.expectError {
+				if err == nil || requests != 0 {
+					t.Errorf("Expected an error without a request, got %v after %d requests", err, requests)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if state != tt.expected {
+				t.Errorf("Expected state=%s, got %q", tt.expected, state)
+			}
+			if resp.Total != 1 || len(resp.Jobs) != 1 || resp.Jobs[0].JobID != "job-1" {
+				t.Errorf("Unexpected response %+v", resp)
+			}
+		})
+	}
+}
+
+func TestCancelJob(t *testing.T) {
+	tests := []struct {
+		name           string
+		id             string
+		status         int
+		expectError    bool
+		expectNotFound bool
+		expectConflict bool
+	}{
+		{name: "cancelled", id: "job/1", status: http.StatusNoContent},
+		{name: "not found", id: "missing", status: http.StatusNotFound, expectError: true, expectNotFound: true},
+		{name: "already finished", id: "job-1", status: http.StatusConflict, expectError: true, expectConflict: true},
+		{name: "empty id", id: "", expectError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockClient := &MockHTTPClient{
+				DoFunc: func(req *http.Request) (*http.Response, error) {
+					if req.Method != http.MethodDelete {
+						t.Errorf("Expected DELETE, got %s", req.Method)
+					}
+					if expected := "http://test/jobs/" + url.PathEscape(tt.id); req.URL.String() != expected {
+						t.Errorf("Expected URL %s, got %s", expected, req.URL)
+					}
+					return &http.Response{StatusCode: tt.status, Body: io.NopCloser(strings.NewReader(""))}, nil
+				},
+			}
+
+			client := NewMLClientWithHTTPClient("http://test", mockClient)
+			err := client.CancelJob(context.Background(), tt.id)
+			if (err != nil) != tt.expectError {
+				t.Fatalf("Expected error %v, got %v", tt.expectError, err)
+			}
+			if errors.Is(err, ErrNotFound) != tt.expectNotFound {
+				t.Errorf("Expected ErrNotFound %v, got %v", tt.expectNotFound, err)
+			}
+			var apiErr *APIError
+			if tt.expectConflict && (!errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusConflict) {
+				t.Errorf("Expected APIError with status 409, got %v", err)
+			}
+		})
+	}
+}
+
+func TestResponseValidation(t *testing.T) {
+	longText := strings.Repeat("x", 300)
+
+	tests := []struct {
+		name          string
+		body          string
+		call          func(c *MLClient) error
+		expectInvalid bool
+		expected      []string
+	}{
+		{
+			name: "add without document_id",
+			body: `{"id": "doc1", "status": "stored"}`,
+			call: func(c *MLClient) error {
+				_, err := c.AddDocumentWithMetadata(context.Background(), "note", nil)
+				return err
+			},
+			expectInvalid: true,
+			expected:      []string{`missing field "document_id"`, `{\"id\": \"doc1\", \"status\": \"stored\"}`},
+		},
+		{
+			name: "add with empty document_id",
+			body: `{"document_id": "", "status": "stored"}`,
+			call: func(c *MLClient) error {
+				_, err := c.AddDocumentWithMetadata(context.Background(), "note", nil)
+				return err
+			},
+			expectInvalid: true,
+			expected:      []string{`missing field "document_id"`},
+		},
+		{
+			name: "search without results",
+			body: `{"query": "cats", "hits": []}`,
+			call: func(c *MLClient) error {
+				_, err := c.Search(context.Background(), "cats", 10, 0.5)
+				return err
+			},
+			expectInvalid: true,
+			expected:      []string{`missing field "results"`},
+		},
+		{
+			name: "search with null results",
+			body: `{"query": "cats", "results": null}`,
+			call: func(c *MLClient) error {
+				_, err := c.Search(context.Background(), "cats", 10, 0.5)
+				return err
+			},
+			expectInvalid: true,
+			expected:      []string{`missing field "results"`},
+		},
+		{
+			name: "search with empty results",
+			body: `{"query": "cats", "results": []}`,
+			call: func(c *MLClient) error {
+				_, err := c.Search(context.Background(), "cats", 10, 0.5)
+				return err
+			},
+		},
+		{
+			name: "image without image_id",
+			body: `{"id": "img1", "status": "stored"}`,
+			call: func(c *MLClient) error {
+				_, err := c.AddImage(context.Background(), []byte("data"), "cat.png", "image/png")
+				return err
+			},
+			expectInvalid: true,
+			expected:      []string{`missing field "image_id"`},
+		},
+		{
+			name: "count without count or results",
+			body: `{"total": 3}`,
+			call: func(c *MLClient) error {
+				_, err := c.SearchCount(context.Background(), "cats", 0.5)
+				return err
+			},
+			expectInvalid: true,
+			expected:      []string{`missing field "count"`},
+		},
+		{
+			name: "not an object",
+			body: `["doc1"]`,
+			call: func(c *MLClient) error {
+				_, err := c.AddDocumentWithMetadata(context.Background(), "note", nil)
+				return err
+			},
+			expected: []string{"error decoding response", `"[\"doc1\"]"`},
+		},
+		{
+			name: "long body cut short",
+			body: `{"text": "` + longText + `"}`,
+			call: func(c *MLClient) error {
+				_, err := c.AddDocumentWithMetadata(context.Background(), "note", nil)
+				return err
+			},
+			expectInvalid: true,
+			expected:      []string{`missing field "document_id"`, strings.Repeat("x", maxBodySnippet-len(`{"text": "`)) + `"...`},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Write([]byte(tt.body))
+			}))
+			defer server.Close()
+
+			err := tt.call(newTestClient(t, server.URL))
+			if tt.expected == nil {
+				if err != nil {
+					t.Errorf("Unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil {
+				t.Fatal("Expected error but got none")
+			}
+			if errors.Is(err, ErrInvalidResponse) != tt.expectInvalid {
+				t.Errorf("Expected errors.Is(err, ErrInvalidResponse) to be %v, got %v", tt.expectInvalid, err)
+			}
+			for _, expected := range tt.expected {
+				if !strings.Contains(err.Error(), expected) {
+					t.Errorf("Expected error to contain %q, got %v", expected, err)
+				}
+			}
+		})
+	}
+}
+
+func TestDecodeErrorWrapped(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"document_id": `))
+	}))
+	defer server.Close()
+
+	_, err := newTestClient(t, server.URL).AddDocumentWithMetadata(context.Background(), "note", nil)
+	var syntaxErr *json.SyntaxError
+	if !errors.As(err, &syntaxErr) {
+		t.Errorf("Expected a wrapped *json.SyntaxError, got %v", err)
+	}
+	if err != nil && !strings.Contains(err.Error(), `"{\"document_id\": "`) {
+		t.Errorf("Expected the body in the error, got %v", err)
+	}
+}
+
+func TestAddImageSanitizesFilename(t *testing.T) {
+	tests := []struct {
+		name     string
+		filename string
+		expected string
+	}{
+		{name: "plain", filename: "cat.png", expected: "cat.png"},
+		{name: "quotes", filename: `say "cheese".png`, expected: "say _cheese_.png"},
+		{name: "header injection", filename: "cat.png\"\r\nContent-Type: text/html\r\n\r\nx", expected: "cat.png___Content-Type: text_html____x"},
+		{name: "path separators", filename: `..\..\etc/passwd`, expected: ".._.._etc_passwd"},
+		{name: "tab and null", filename: "a\tb\x00.png", expected: "a_b_.png"},
+		{name: "empty", filename: "", expected: defaultImageFilename},
+		{name: "only spaces", filename: "   ", expected: defaultImageFilename},
+		{name: "dot dot", filename: "..", expected: defaultImageFilename},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockClient := &MockHTTPClient{
+				DoFunc: func(req *http.Request) (*http.Response, error) {
+					_, params, err := mime.ParseMediaType(req.Header.Get("Content-Type"))
+					if err != nil {
+						t.Fatalf("Error parsing content type: %v", err)
+					}
+					reader := multipart.NewReader(req.Body, params["boundary"])
+					var parts int
+					for {
+						part, err := reader.NextPart()
+						if err == io.EOF {
+							break
+						}
+						if err != nil {
+							t.Fatalf("Malformed multipart body: %v", err)
+						}
+						parts++
+						if part.FormName() != "image" {
+							t.Errorf("Expected only the image part, got %q", part.FormName())
+						}
+						disposition, dparams, err := mime.ParseMediaType(part.Header.Get("Content-Disposition"))
+						if err != nil || disposition != "form-data" {
+							t.Errorf("Malformed Content-Disposition %q: %v", part.Header.Get("Content-Disposition"), err)
+						}
+						if dparams["filename"] != tt.expected {
+							t.Errorf("Expected filename %q, got %q", tt.expected, dparams["filename"])
+						}
+						if len(part.Header) != 2 {
+							t.Errorf("Expected only Content-Disposition and Content-Type headers, got %v", part.Header)
+						}
+					}
+					if parts != 1 {
+						t.Errorf("Expected 1 part, got %d", parts)
+					}
+					return &http.Response{
+						StatusCode: http.StatusOK,
+						Body:       io.NopCloser(strings.NewReader(`{"image_id": "img1", "status": "stored"}`)),
+					}, nil
+				},
+			}
+
+			client := NewMLClientWithHTTPClient("http://test", mockClient)
+			if _, err := client.AddImage(context.Background(), []byte("image"), tt.filename, ""); err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+		})
+	}
+}
+
+func TestRequestsCancelledWithContext(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// The server only notices the client going away once the body is read.
+		io.Copy(io.Discard, r.Body)
+		<-r.Context().Done()
+	}))
+	defer server.Close()
+	client := newTestClient(t, server.URL)
+
+	tests := []struct {
+		name string
+		call func(ctx context.Context) error
+	}{
+		{name: "AddDocumentWithMetadata", call: func(ctx context.Context) error {
+			_, err := client.AddDocumentWithMetadata(ctx, "note", nil)
+			return err
+		}},
+		{name: "AddDocuments", call: func(ctx context.Context) error {
+			_, err := client.AddDocuments(ctx, []Document{{Text: "note"}})
+			return err
+		}},
+		{name: "UpdateDocument", call: func(ctx context.Context) error { return client.UpdateDocument(ctx, "doc1", "note") }},
+		{name: "DeleteDocument", call: func(ctx context.Context) error { return client.DeleteDocument(ctx, "doc1") }},
+		{name: "ListDocuments", call: func(ctx context.Context) error {
+			_, err := client.ListDocuments(ctx, 0, 10)
+			return err
+		}},
+		{name: "SearchWithParams", call: func(ctx context.Context) error {
+			_, err := client.SearchWithParams(ctx, SearchParams{Query: "cats", Limit: 10, ScoreThreshold: 0.5})
+			return err
+		}},
+		{name: "AddImageWithMetadata", call: func(ctx context.Context) error {
+			_, err := client.AddImageWithMetadata(ctx, []byte("image"), "cat.png", "image/png", "", nil)
+			return err
+		}},
+		{name: "FindSimilarImages", call: func(ctx context.Context) error {
+			_, err := client.FindSimilarImages(ctx, []byte("image"), 10, 0.5)
+			return err
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+			defer cancel()
+			if err := tt.call(ctx); !errors.Is(err, context.DeadlineExceeded) {
+				t.Errorf("Expected the request to end with the context, got %v", err)
+			}
+		})
+	}
+}
+
+func TestExportImportOutlastTimeout(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/export":
+			w.Write([]byte(`{"type":"document","id":"doc1"}` + "\n"))
+			w.(http.Flusher).Flush()
+			time.Sleep(150 * time.Millisecond)
+			w.Write([]byte(`{"type":"document","id":"doc2"}` + "\n"))
+		case "/import":
+			io.Copy(io.Discard, r.Body)
+			w.Write([]byte(`{"imported": 2}`))
+		case "/stalled":
+			time.Sleep(150 * time.Millisecond)
+		}
+	}))
+	defer server.Close()
+
+	client := newTestClient(t, server.URL, WithTimeout(50*time.Millisecond))
+
+	stream, err := client.ExportAll(context.Background())
+	if err != nil {
+		t.Fatalf("Unexpected export error: %v", err)
+	}
+	data, err := io.ReadAll(stream)
+	stream.Close()
+	if err != nil {
+		t.Fatalf("Expected the export to outlast the timeout, got %v", err)
+	}
+	if lines := strings.Count(string(data), "\n"); lines != 2 {
+		t.Errorf("Expected 2 exported records, got %d", lines)
+	}
+
+	pr, pw := io.Pipe()
+	go func() {
+		pw.Write([]byte(`{"type":"document","id":"doc1"}` + "\n"))
+		time.Sleep(150 * time.Millisecond)
+		pw.Write([]byte(`{"type":"document","id":"doc2"}` + "\n"))
+		pw.Close()
+	}()
+	resp, err := client.Import(context.Background(), pr)
+	if err != nil {
+		t.Fatalf("Expected the import to outlast the timeout, got %v", err)
+	}
+	if resp.Imported != 2 {
+		t.Errorf("Expected 2 imported records, got %d", resp.Imported)
+	}
+
+	// The timeout still bounds the wait for response headers.
+	req, _ := http.NewRequest(http.MethodGet, server.URL+"/stalled", nil)
+	if _, err := client.sendWith(client.streamClient, req); err == nil || !strings.Contains(err.Error(), "timeout") {
+		t.Errorf("Expected a response header timeout, got %v", err)
+	}
+}
@@ -0,0 +1,343 @@
+package api
+
+import (
+	"crypto/rand"
+	"crypto/tls"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// DefaultMaxResponseBytes caps how much of an ML service response is read.
+const DefaultMaxResponseBytes = 32 << 20
+
+// DefaultTimeout bounds a whole request to the ML service, including reading
+// the response. Embedding large batches or images can take a while.
+const DefaultTimeout = 60 * time.Second
+
+const (
+	defaultMaxIdleConns        = 100
+	defaultMaxIdleConnsPerHost = 10
+	defaultIdleConnTimeout     = 90 * time.Second
+	defaultTLSHandshakeTimeout = 10 * time.Second
+	defaultDialTimeout         = 30 * time.Second
+	defaultKeepAlive           = 30 * time.Second
+)
+
+type Option func(*clientOptions)
+
+type clientOptions struct {
+	tlsConfig        *tls.Config
+	proxyURL         *url.URL
+	maxResponseBytes int64
+	timeout          time.Duration
+	client           HTTPClient
+	roundTripper     http.RoundTripper
+	apiKey           string
+	headers          map[string]string
+	maxRetries       int
+	logger           *slog.Logger
+	logSensitive     bool
+	metrics          MetricsCollector
+	requestID        func() string
+	idempotencyKey   func() string
+	gzipRequests     bool
+	noRedirects      bool
+
+	idleConnTimeout       time.Duration
+	tlsHandshakeTimeout   time.Duration
+	responseHeaderTimeout time.Duration
+	keepAlive             time.Duration
+	maxIdleConnsPerHost   int
+}
+
+// WithTLSConfig sets the TLS configuration used when connecting to an HTTPS
+// ML service, e.g. to trust a private CA.
+func WithTLSConfig(config *tls.Config) Option {
+	return func(o *clientOptions) {
+		o.tlsConfig = config
+	}
+}
+
+// WithProxy routes requests through the given HTTP(S) or SOCKS5 proxy,
+// overriding HTTP_PROXY, HTTPS_PROXY and NO_PROXY.
+func WithProxy(proxyURL *url.URL) Option {
+	return func(o *clientOptions) {
+		o.proxyURL = proxyURL
+	}
+}
+
+// WithMaxResponseBytes sets the largest response body the client will decode.
+// Larger responses fail with ErrResponseTooLarge.
+func WithMaxResponseBytes(n int64) Option {
+	return func(o *clientOptions) {
+		o.maxResponseBytes = n
+	}
+}
+
+// WithTimeout overrides DefaultTimeout. Zero disables the timeout.
+func WithTimeout(timeout time.Duration) Option {
+	return func(o *clientOptions) {
+		o.timeout = timeout
+	}
+}
+
+// WithIdleConnTimeout sets how long an unused connection is kept for reuse,
+// overriding the default of 90 seconds. Zero keeps idle connections open
+// indefinitely.
+func WithIdleConnTimeout(d time.Duration) Option {
+	return func(o *clientOptions) {
+		o.idleConnTimeout = d
+	}
+}
+
+// WithTLSHandshakeTimeout bounds the TLS handshake with an HTTPS ML service,
+// overriding the default of 10 seconds. Zero disables the limit.
+func WithTLSHandshakeTimeout(d time.Duration) Option {
+	return func(o *clientOptions) {
+		o.tlsHandshakeTimeout = d
+	}
+}
+
+// WithResponseHeaderTimeout bounds the wait for response headers once a
+// request has been sent, so a stalled connection fails before the overall
+// timeout. Zero, the default, disables the limit.
+func WithResponseHeaderTimeout(d time.Duration) Option {
+	return func(o *clientOptions) {
+		o.responseHeaderTimeout = d
+	}
+}
+
+// WithKeepAlive sets the interval between TCP keep-alive probes on
+// connections to the ML service, overriding the default of 30 seconds, so
+// pooled connections dropped by a firewall or NAT are noticed. A negative
+// duration disables the probes.
+func WithKeepAlive(d time.Duration) Option {
+	return func(o *clientOptions) {
+		o.keepAlive = d
+	}
+}
+
+// WithMaxIdleConnsPerHost sets how many idle connections to the ML service
+// are kept for reuse, overriding the default of 10. Raise it when many
+// requests are sent concurrently, as during large imports.
+func WithMaxIdleConnsPerHost(n int) Option {
+	return func(o *clientOptions) {
+		o.maxIdleConnsPerHost = n
+	}
+}
+
+// WithMaxRetries sets how many times a request rejected with 429 Too Many
+// Requests is retried, overriding DefaultMaxRetries. Zero disables retries.
+func WithMaxRetries(n int) Option {
+	return func(o *clientOptions) {
+		o.maxRetries = n
+	}
+}
+
+// WithRequestLogger logs every request and response at debug level, and
+// failed requests at error level. Authorization and similar headers are
+// redacted unless WithLogSensitiveHeaders is also given.
+func WithRequestLogger(logger *slog.Logger) Option {
+	return func(o *clientOptions) {
+		o.logger = logger
+	}
+}
+
+// WithLogSensitiveHeaders includes credential headers in request logs as-is.
+func WithLogSensitiveHeaders() Option {
+	return func(o *clientOptions) {
+		o.logSensitive = true
+	}
+}
+
+// WithGzipRequests compresses request bodies with gzip when enabled, which
+// saves bandwidth for large documents and batches.
+func WithGzipRequests(enabled bool) Option {
+	return func(o *clientOptions) {
+		o.gzipRequests = enabled
+	}
+}
+
+// WithFollowRedirects controls whether redirects from the ML service are
+// followed, as they are by default. When disabled, a redirect is returned as
+// an *APIError with its 3xx status, which catches a misconfigured proxy that
+// sends requests to a login page.
+func WithFollowRedirects(follow bool) Option {
+	return func(o *clientOptions) {
+		o.noRedirects = !follow
+	}
+}
+
+// WithRoundTripper sends requests through rt instead of the default
+// transport, e.g. to add tracing headers or record metrics. rt sees every
+// attempt, with the client's headers already set; WithTLSConfig and WithProxy
+// have no effect when it is set.
+func WithRoundTripper(rt http.RoundTripper) Option {
+	return func(o *clientOptions) {
+		o.roundTripper = rt
+	}
+}
+
+// WithMetrics reports request counts, latencies and errors per operation to
+// collector. It also applies to a client given with WithHTTPClient.
+func WithMetrics(collector MetricsCollector) Option {
+	return func(o *clientOptions) {
+		o.metrics = collector
+	}
+}
+
+// WithRequestIDFunc replaces the random UUIDs sent as X-Request-ID with the
+// result of fn, called once per request. nil stops the header from being sent.
+func WithRequestIDFunc(fn func() string) Option {
+	return func(o *clientOptions) {
+		o.requestID = fn
+	}
+}
+
+// WithIdempotencyKeyFunc replaces the random UUIDs sent as Idempotency-Key
+// when adding a document with the result of fn, called once per document and
+// reused by its retries. nil stops the header from being sent.
+func WithIdempotencyKeyFunc(fn func() string) Option {
+	return func(o *clientOptions) {
+		o.idempotencyKey = fn
+	}
+}
+
+// WithHTTPClient replaces the default HTTP client entirely. The TLS, proxy,
+// timeout, transport timeout, keep-alive, retry, logging, gzip, redirect and
+// round tripper options have no effect when it is set.
+func WithHTTPClient(client HTTPClient) Option {
+	return func(o *clientOptions) {
+		o.client = client
+	}
+}
+
+// WithAPIKey sends key as a bearer token in the Authorization header.
+func WithAPIKey(key string) Option {
+	return func(o *clientOptions) {
+		o.apiKey = key
+	}
+}
+
+// WithHeaders adds headers to every request. They are applied last, so they
+// override the client's own headers, including an Authorization header set
+// by WithAPIKey. Repeated calls add to the set.
+func WithHeaders(headers map[string]string) Option {
+	return func(o *clientOptions) {
+		if o.headers == nil {
+			o.headers = make(map[string]string, len(headers))
+		}
+		for name, value := range headers {
+			o.headers[name] = value
+		}
+	}
+}
+
+// defaultHTTPClient returns a client with bounded connection reuse and an
+// overall request timeout, rather than the zero-value http.Client.
+func defaultHTTPClient() *http.Client {
+	return &http.Client{Transport: defaultTransport(), Timeout: DefaultTimeout}
+}
+
+func defaultTransport() *http.Transport {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = http.ProxyFromEnvironment
+	transport.MaxIdleConns = defaultMaxIdleConns
+	transport.MaxIdleConnsPerHost = defaultMaxIdleConnsPerHost
+	transport.IdleConnTimeout = defaultIdleConnTimeout
+	transport.TLSHandshakeTimeout = defaultTLSHandshakeTimeout
+	return transport
+}
+
+func (o *clientOptions) transport() *http.Transport {
+	transport := defaultTransport()
+	if o.proxyURL != nil {
+		transport.Proxy = http.ProxyURL(o.proxyURL)
+	}
+	if o.tlsConfig != nil {
+		transport.TLSClientConfig = o.tlsConfig
+	}
+	transport.IdleConnTimeout = o.idleConnTimeout
+	transport.TLSHandshakeTimeout = o.tlsHandshakeTimeout
+	transport.ResponseHeaderTimeout = o.responseHeaderTimeout
+	transport.DialContext = (&net.Dialer{Timeout: defaultDialTimeout, KeepAlive: o.keepAlive}).DialContext
+	if o.maxIdleConnsPerHost > 0 {
+		transport.MaxIdleConnsPerHost = o.maxIdleConnsPerHost
+		transport.MaxIdleConns = max(defaultMaxIdleConns, o.maxIdleConnsPerHost)
+	}
+	return transport
+}
+
+func (o *clientOptions) httpClient() HTTPClient {
+	var client HTTPClient
+	if o.client != nil {
+		client = o.client
+	} else {
+		c := defaultHTTPClient()
+		c.Transport = o.transport()
+		if o.roundTripper != nil {
+			c.Transport = o.roundTripper
+		}
+		if o.gzipRequests {
+			c.Transport = &gzipTransport{next: c.Transport}
+		}
+		if o.logger != nil {
+			c.Transport = &loggingTransport{next: c.Transport, logger: o.logger, showSensitive: o.logSensitive}
+		}
+		if o.maxRetries > 0 {
+			c.Transport = &retryTransport{next: c.Transport, maxRetries: o.maxRetries}
+		}
+		c.Timeout = o.timeout
+		if o.noRedirects {
+			c.CheckRedirect = func(*http.Request, []*http.Request) error {
+				return http.ErrUseLastResponse
+			}
+		}
+		client = c
+	}
+
+	if header := o.header(); len(header) > 0 || o.requestID != nil {
+		client = &headerClient{next: client, header: header, requestID: o.requestID}
+	}
+	if o.metrics != nil {
+		client = &metricsClient{next: client, collector: o.metrics}
+	}
+	return client
+}
+
+// streamClient returns a client like httpClient for requests that stream a
+// body of any size, such as exports and imports. The overall timeout would
+// cut those off part way, so it only bounds the wait for response headers,
+// unless WithResponseHeaderTimeout sets that wait explicitly.
+func (o *clientOptions) streamClient() HTTPClient {
+	s := *o
+	if s.responseHeaderTimeout == 0 {
+		s.responseHeaderTimeout = o.timeout
+	}
+	s.timeout = 0
+	return s.httpClient()
+}
+
+// newRequestID returns a random version 4 UUID.
+func newRequestID() string {
+	var b [16]byte
+	rand.Read(b[:])
+	b[6] = b[6]&0x0f | 0x40
+	b[8] = b[8]&0x3f | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:])
+}
+
+func (o *clientOptions) header() http.Header {
+	header := http.Header{}
+	if o.apiKey != "" {
+		header.Set("Authorization", "Bearer "+o.apiKey)
+	}
+	for name, value := range o.headers {
+		header.Set(name, value)
+	}
+	return header
+}
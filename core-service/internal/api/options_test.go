@@ -0,0 +1,676 @@
+package api
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"regexp"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestWithTLSConfig(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"query": "test", "results": []}`))
+	}))
+	defer server.Close()
+
+	pool := x509.NewCertPool()
+	pool.AddCert(server.Certificate())
+
+	tests := []struct {
+		name        string
+		opts        []Option
+		expectError bool
+	}{
+		{name: "default roots reject self-signed server", expectError: true},
+		{name: "custom CA pool", opts: []Option{WithTLSConfig(&tls.Config{RootCAs: pool})}},
+		{name: "skip verification", opts: []Option{WithTLSConfig(&tls.Config{InsecureSkipVerify: true})}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := newTestClient(t, server.URL, tt.opts...)
+			_, err := client.Search(context.Background(), "test", 1, 0.1)
+
+			if tt.expectError && err == nil {
+				t.Error("Expected error but got none")
+			}
+			if !tt.expectError && err != nil {
+				t.Errorf("Unexpected error: %v", err)
+			}
+		})
+	}
+}
+
+func TestProxy(t *testing.T) {
+	req, _ := http.NewRequest(http.MethodGet, "http://ml-service:8000/search", nil)
+
+	// The standard library reads the proxy environment once per process, so
+	// the default is checked against http.ProxyFromEnvironment itself.
+	envProxy, err := http.ProxyFromEnvironment(req)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	explicit, _ := url.Parse("socks5://flag-proxy:1080")
+
+	tests := []struct {
+		name     string
+		opts     []Option
+		expected *url.URL
+	}{
+		{name: "environment by default", expected: envProxy},
+		{name: "explicit proxy overrides environment", opts: []Option{WithProxy(explicit)}, expected: explicit},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			o := &clientOptions{}
+			for _, opt := range tt.opts {
+				opt(o)
+			}
+
+			proxy, err := o.transport().Proxy(req)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if fmt.Sprint(proxy) != fmt.Sprint(tt.expected) {
+				t.Errorf("Expected proxy %v, got %v", tt.expected, proxy)
+			}
+		})
+	}
+}
+
+func TestWithMaxResponseBytes(t *testing.T) {
+	results := strings.Repeat(`{"id": "doc1", "score": 0.5, "source_type": "text", "content": {"text": "padding"}},`, 1000)
+	body := `{"query": "test", "results": [` + strings.TrimSuffix(results, ",") + `]}`
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(body))
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		name        string
+		opts        []Option
+		expectError bool
+	}{
+		{name: "default limit"},
+		{name: "limit above body size", opts: []Option{WithMaxResponseBytes(int64(len(body)))}},
+		{name: "limit below body size", opts: []Option{WithMaxResponseBytes(1024)}, expectError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := newTestClient(t, server.URL, tt.opts...)
+			_, err := client.Search(context.Background(), "test", 1, 0.1)
+
+			if tt.expectError {
+				if !errors.Is(err, ErrResponseTooLarge) {
+					t.Errorf("Expected ErrResponseTooLarge, got %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Errorf("Unexpected error: %v", err)
+			}
+		})
+	}
+}
+
+func TestDefaultHTTPClient(t *testing.T) {
+	client := defaultHTTPClient()
+	if client.Timeout != DefaultTimeout {
+		t.Errorf("Expected timeout %v, got %v", DefaultTimeout, client.Timeout)
+	}
+
+	transport, ok := client.Transport.(*http.Transport)
+	if !ok {
+		t.Fatalf("Expected *http.Transport, got %T", client.Transport)
+	}
+	if transport.MaxIdleConns == 0 || transport.MaxIdleConnsPerHost == 0 || transport.IdleConnTimeout == 0 {
+		t.Errorf("Expected non-zero idle connection settings, got MaxIdleConns=%d MaxIdleConnsPerHost=%d IdleConnTimeout=%v",
+			transport.MaxIdleConns, transport.MaxIdleConnsPerHost, transport.IdleConnTimeout)
+	}
+	if transport.MaxIdleConnsPerHost <= http.DefaultMaxIdleConnsPerHost {
+		t.Errorf("Expected more than %d idle connections per host, got %d", http.DefaultMaxIdleConnsPerHost, transport.MaxIdleConnsPerHost)
+	}
+}
+
+func TestClientOptionsHTTPClient(t *testing.T) {
+	custom := &MockHTTPClient{}
+
+	tests := []struct {
+		name            string
+		opts            []Option
+		expectedTimeout time.Duration
+	}{
+		{name: "default timeout", expectedTimeout: DefaultTimeout},
+		{name: "custom timeout", opts: []Option{WithTimeout(5 * time.Second)}, expectedTimeout: 5 * time.Second},
+		{name: "timeout disabled", opts: []Option{WithTimeout(0)}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := newTestClient(t, "http://test", tt.opts...)
+			std, ok := unwrapHeaderClient(t, client.httpClient).(*http.Client)
+			if !ok {
+				t.Fatalf("Expected *http.Client, got %T", client.httpClient)
+			}
+			if std.Timeout != tt.expectedTimeout {
+				t.Errorf("Expected timeout %v, got %v", tt.expectedTimeout, std.Timeout)
+			}
+		})
+	}
+
+	if client := newTestClient(t, "http://test", WithHTTPClient(custom)); unwrapHeaderClient(t, client.httpClient) != custom {
+		t.Errorf("Expected WithHTTPClient to replace the default client, got %T", client.httpClient)
+	}
+}
+
+// unwrapHeaderClient returns the client behind the headerClient that sets
+// request IDs.
+func unwrapHeaderClient(t *testing.T, client HTTPClient) HTTPClient {
+	t.Helper()
+	hc, ok := client.(*headerClient)
+	if !ok {
+		t.Fatalf("Expected *headerClient, got %T", client)
+	}
+	return hc.next
+}
+
+func TestWithHeaders(t *testing.T) {
+	var received []*http.Request
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received = append(received, r)
+		switch r.URL.Path {
+		case "/documents":
+			w.Write([]byte(`{"document_id": "doc1"}`))
+		case "/search":
+			w.Write([]byte(`{"query": "test", "results": []}`))
+		case "/images":
+			w.Write([]byte(`{"image_id": "img1"}`))
+		default:
+			w.Write([]byte(`{}`))
+		}
+	}))
+	defer server.Close()
+
+	client := newTestClient(t, server.URL, WithHeaders(map[string]string{"X-Tenant-ID": "acme"}))
+	if _, err := client.AddDocument(context.Background(), "hello"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if _, err := client.Search(context.Background(), "hello", 5, 0.1); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if _, err := client.AddImage(context.Background(), []byte("image"), "cat.png", "image/png"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if err := client.UpdateDocument(context.Background(), "doc1", "updated"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if len(received) != 4 {
+		t.Fatalf("Expected 4 requests, got %d", len(received))
+	}
+	for _, r := range received {
+		if got := r.Header.Get("X-Tenant-ID"); got != "acme" {
+			t.Errorf("%s %s: expected X-Tenant-ID acme, got %q", r.Method, r.URL.Path, got)
+		}
+	}
+	if ct := received[2].Header.Get("Content-Type"); !strings.HasPrefix(ct, "multipart/form-data") {
+		t.Errorf("Expected the multipart content type to be kept, got %q", ct)
+	}
+}
+
+func TestAuthorizationPrecedence(t *testing.T) {
+	tests := []struct {
+		name     string
+		opts     []Option
+		expected string
+	}{
+		{name: "no auth"},
+		{name: "api key", opts: []Option{WithAPIKey("secret")}, expected: "Bearer secret"},
+		{
+			name:     "headers override api key",
+			opts:     []Option{WithAPIKey("secret"), WithHeaders(map[string]string{"Authorization": "Basic dXNlcg=="})},
+			expected: "Basic dXNlcg==",
+		},
+		{
+			name:     "headers override api key regardless of order",
+			opts:     []Option{WithHeaders(map[string]string{"Authorization": "Basic dXNlcg=="}), WithAPIKey("secret")},
+			expected: "Basic dXNlcg==",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got string
+			mock := &MockHTTPClient{
+				DoFunc: func(req *http.Request) (*http.Response, error) {
+					got = req.Header.Get("Authorization")
+					return &http.Response{
+						StatusCode: http.StatusOK,
+						Body:       io.NopCloser(strings.NewReader(`{"query": "test", "results": []}`)),
+					}, nil
+				},
+			}
+
+			client := newTestClient(t, "http://test", append(tt.opts, WithHTTPClient(mock))...)
+			if _, err := client.Search(context.Background(), "hello", 5, 0.1); err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if got != tt.expected {
+				t.Errorf("Expected Authorization %q, got %q", tt.expected, got)
+			}
+		})
+	}
+}
+
+// countingTransport records requests before passing them to http.DefaultTransport.
+type countingTransport struct {
+	count     int
+	authHeads []string
+}
+
+func (t *countingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	t.count++
+	t.authHeads = append(t.authHeads, req.Header.Get("Authorization"))
+	req = req.Clone(req.Context())
+	req.Header.Set("X-Trace-Id", fmt.Sprintf("trace-%d", t.count))
+	return http.DefaultTransport.RoundTrip(req)
+}
+
+func TestWithRoundTripper(t *testing.T) {
+	var traceIDs []string
+	rateLimited := true
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		traceIDs = append(traceIDs, r.Header.Get("X-Trace-Id"))
+		if r.URL.Path == "/search" && rateLimited {
+			rateLimited = false
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		w.Write([]byte(`{"query": "test", "results": [], "document_id": "doc1"}`))
+	}))
+	defer server.Close()
+
+	rt := &countingTransport{}
+	client := newTestClient(t, server.URL, WithRoundTripper(rt), WithAPIKey("secret"))
+
+	if _, err := client.AddDocument(context.Background(), "hello"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if _, err := client.Search(context.Background(), "hello", 5, 0.1); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	// The rate-limited search is retried, and each attempt goes through rt.
+	if rt.count != 3 {
+		t.Errorf("Expected 3 requests through the round tripper, got %d", rt.count)
+	}
+	for i, auth := range rt.authHeads {
+		if auth != "Bearer secret" {
+			t.Errorf("Request %d: expected Authorization %q, got %q", i, "Bearer secret", auth)
+		}
+	}
+	expected := []string{"trace-1", "trace-2", "trace-3"}
+	if fmt.Sprint(traceIDs) != fmt.Sprint(expected) {
+		t.Errorf("Expected trace IDs %v, got %v", expected, traceIDs)
+	}
+}
+
+func TestWithRoundTripperIgnoredWithHTTPClient(t *testing.T) {
+	rt := &countingTransport{}
+	mock := &MockHTTPClient{
+		DoFunc: func(req *http.Request) (*http.Response, error) {
+			return &http.Response{
+				StatusCode: http.StatusOK,
+				Body:       io.NopCloser(strings.NewReader(`{"query": "test", "results": []}`)),
+			}, nil
+		},
+	}
+
+	client := newTestClient(t, "http://test", WithRoundTripper(rt), WithHTTPClient(mock))
+	if _, err := client.Search(context.Background(), "hello", 5, 0.1); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if rt.count != 0 {
+		t.Errorf("Expected the mock client to bypass the round tripper, got %d requests", rt.count)
+	}
+}
+
+func TestRequestID(t *testing.T) {
+	var received []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received = append(received, r.Header.Get("X-Request-ID"))
+		if r.URL.Path == "/search" {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.Write([]byte(`{"document_id": "doc1"}`))
+	}))
+	defer server.Close()
+
+	n := 0
+	client := newTestClient(t, server.URL, WithRequestIDFunc(func() string {
+		n++
+		return fmt.Sprintf("req-%d", n)
+	}))
+
+	if _, err := client.AddDocument(context.Background(), "hello"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	_, err := client.Search(context.Background(), "hello", 5, 0.1)
+
+	if fmt.Sprint(received) != "[req-1 req-2]" {
+		t.Errorf("Expected request IDs [req-1 req-2], got %v", received)
+	}
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) {
+		t.Fatalf("Expected *APIError, got %v", err)
+	}
+	if apiErr.StatusCode != http.StatusInternalServerError || apiErr.RequestID != "req-2" {
+		t.Errorf("Expected status 500 and request ID req-2, got %+v", apiErr)
+	}
+	if !strings.Contains(err.Error(), "request ID req-2") {
+		t.Errorf("Expected request ID in error message, got %q", err.Error())
+	}
+}
+
+func TestIdempotencyKey(t *testing.T) {
+	var keys []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		keys = append(keys, r.Header.Get("Idempotency-Key"))
+		if len(keys) == 1 {
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		w.Write([]byte(`{"document_id": "doc1"}`))
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		name string
+		add  func(c *MLClient) error
+	}{
+		{name: "AddDocument", add: func(c *MLClient) error { _, err := c.AddDocument(context.Background(), "hello"); return err }},
+		{name: "AddDocumentFromReader", add: func(c *MLClient) error {
+			_, err := c.AddDocumentFromReader(context.Background(), strings.NewReader("hello"), []string{"greeting"})
+			return err
+		}},
+		{name: "AddDocumentWithMetadata", add: func(c *MLClient) error {
+			_, err := c.AddDocumentWithMetadata(context.Background(), "hello", map[string]string{"project": "apollo"})
+			return err
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			keys = nil
+			n := 0
+			client := newTestClient(t, server.URL, WithIdempotencyKeyFunc(func() string {
+				n++
+				return fmt.Sprintf("key-%d", n)
+			}))
+
+			if err := tt.add(client); err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if err := tt.add(client); err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if fmt.Sprint(keys) != "[key-1 key-1 key-2]" {
+				t.Errorf("Expected the retry to reuse key-1 and the next document to get key-2, got %v", keys)
+			}
+		})
+	}
+
+	t.Run("random by default", func(t *testing.T) {
+		keys = []string{"skip the 429"}
+		client := newTestClient(t, server.URL)
+		client.AddDocument(context.Background(), "hello")
+		client.AddDocument(context.Background(), "hello")
+		if len(keys[1]) != 36 || keys[1] == keys[2] {
+			t.Errorf("Expected two different UUIDs, got %q and %q", keys[1], keys[2])
+		}
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+		keys = []string{"skip the 429"}
+		client := newTestClient(t, server.URL, WithIdempotencyKeyFunc(nil))
+		client.AddDocument(context.Background(), "hello")
+		if keys[1] != "" {
+			t.Errorf("Expected no Idempotency-Key, got %q", keys[1])
+		}
+	})
+}
+
+func TestRequestContext(t *testing.T) {
+	echo := true
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if echo {
+			w.Header().Set("X-Request-ID", r.Header.Get("X-Request-ID"))
+		}
+		w.Write([]byte(`{"image_id": "img1", "metadata": {"filename": "cat.png"}, "image_data": ""}`))
+	}))
+	defer server.Close()
+
+	client := newTestClient(t, server.URL, WithRequestIDFunc(func() string { return "req-1" }))
+
+	ctx, rc := NewRequestContext(context.Background())
+	if _, err := client.GetImage(ctx, "img1"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if rc.RequestID != "req-1" || rc.EchoedID != "req-1" {
+		t.Errorf("Expected sent and echoed ID req-1, got %+v", rc)
+	}
+
+	echo = false
+	ctx, rc = NewRequestContext(context.Background())
+	if _, err := client.GetImage(ctx, "img1"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if rc.RequestID != "req-1" || rc.EchoedID != "" {
+		t.Errorf("Expected sent ID req-1 and no echoed ID, got %+v", rc)
+	}
+}
+
+func TestDefaultRequestID(t *testing.T) {
+	var ids []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ids = append(ids, r.Header.Get("X-Request-ID"))
+		w.Write([]byte(`{"query": "test", "results": []}`))
+	}))
+	defer server.Close()
+
+	for _, opts := range [][]Option{nil, {WithRequestIDFunc(nil)}} {
+		client := newTestClient(t, server.URL, opts...)
+		if _, err := client.Search(context.Background(), "hello", 5, 0.1); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+	}
+
+	uuid := regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`)
+	if !uuid.MatchString(ids[0]) {
+		t.Errorf("Expected a UUID request ID by default, got %q", ids[0])
+	}
+	if ids[1] != "" {
+		t.Errorf("Expected no request ID with a nil generator, got %q", ids[1])
+	}
+}
+
+func TestTransportTimeouts(t *testing.T) {
+	tests := []struct {
+		name                  string
+		opts                  []Option
+		idleConnTimeout       time.Duration
+		tlsHandshakeTimeout   time.Duration
+		responseHeaderTimeout time.Duration
+	}{
+		{name: "defaults", idleConnTimeout: defaultIdleConnTimeout, tlsHandshakeTimeout: defaultTLSHandshakeTimeout},
+		{
+			name: "all set",
+			opts: []Option{
+				WithIdleConnTimeout(30 * time.Second),
+				WithTLSHandshakeTimeout(5 * time.Second),
+				WithResponseHeaderTimeout(20 * time.Second),
+			},
+			idleConnTimeout:       30 * time.Second,
+			tlsHandshakeTimeout:   5 * time.Second,
+			responseHeaderTimeout: 20 * time.Second,
+		},
+		{
+			name:                "combined with proxy and TLS options",
+			opts:                []Option{WithProxy(&url.URL{Scheme: "http", Host: "proxy:8080"}), WithTLSConfig(&tls.Config{}), WithIdleConnTimeout(0)},
+			tlsHandshakeTimeout: defaultTLSHandshakeTimeout,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := newTestClient(t, "http://test", append(tt.opts, WithMaxRetries(0))...)
+			std, ok := unwrapHeaderClient(t, client.httpClient).(*http.Client)
+			if !ok {
+				t.Fatalf("Expected *http.Client, got %T", client.httpClient)
+			}
+			transport, ok := std.Transport.(*http.Transport)
+			if !ok {
+				t.Fatalf("Expected *http.Transport, got %T", std.Transport)
+			}
+			if transport.IdleConnTimeout != tt.idleConnTimeout {
+				t.Errorf("Expected IdleConnTimeout %v, got %v", tt.idleConnTimeout, transport.IdleConnTimeout)
+			}
+			if transport.TLSHandshakeTimeout != tt.tlsHandshakeTimeout {
+				t.Errorf("Expected TLSHandshakeTimeout %v, got %v", tt.tlsHandshakeTimeout, transport.TLSHandshakeTimeout)
+			}
+			if transport.ResponseHeaderTimeout != tt.responseHeaderTimeout {
+				t.Errorf("Expected ResponseHeaderTimeout %v, got %v", tt.responseHeaderTimeout, transport.ResponseHeaderTimeout)
+			}
+		})
+	}
+}
+
+func TestConnectionReuse(t *testing.T) {
+	var connections atomic.Int32
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Query().Get("query") {
+		case "fail":
+			w.WriteHeader(http.StatusInternalServerError)
+			w.Write([]byte(`{"detail": "` + strings.Repeat("x", 1<<20) + `"}`))
+		case "invalid":
+			w.Write([]byte(`{"query": "invalid"}`))
+		default:
+			w.Write([]byte(`{"query": "cats", "results": []}`))
+		}
+	}))
+	server.Config.ConnState = func(_ net.Conn, state http.ConnState) {
+		if state == http.StateNew {
+			connections.Add(1)
+		}
+	}
+	server.Start()
+	defer server.Close()
+
+	client := newTestClient(t, server.URL)
+	for range 20 {
+		for _, query := range []string{"cats", "fail", "invalid"} {
+			client.Search(context.Background(), query, 10, 0.1)
+		}
+	}
+
+	if n := connections.Load(); n != 1 {
+		t.Errorf("Expected 60 sequential requests to share 1 connection, got %d", n)
+	}
+}
+
+func TestWithMaxIdleConnsPerHost(t *testing.T) {
+	tests := []struct {
+		name            string
+		opts            []Option
+		expectedPerHost int
+		expectedTotal   int
+	}{
+		{name: "default", expectedPerHost: defaultMaxIdleConnsPerHost, expectedTotal: defaultMaxIdleConns},
+		{name: "raised", opts: []Option{WithMaxIdleConnsPerHost(50)}, expectedPerHost: 50, expectedTotal: defaultMaxIdleConns},
+		{name: "above total", opts: []Option{WithMaxIdleConnsPerHost(200)}, expectedPerHost: 200, expectedTotal: 200},
+		{name: "with keep-alive", opts: []Option{WithKeepAlive(-1), WithMaxIdleConnsPerHost(5)}, expectedPerHost: 5, expectedTotal: defaultMaxIdleConns},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := newTestClient(t, "http://test", append(tt.opts, WithMaxRetries(0))...)
+			std, ok := unwrapHeaderClient(t, client.httpClient).(*http.Client)
+			if !ok {
+				t.Fatalf("Expected *http.Client, got %T", client.httpClient)
+			}
+			transport, ok := std.Transport.(*http.Transport)
+			if !ok {
+				t.Fatalf("Expected *http.Transport, got %T", std.Transport)
+			}
+			if transport.MaxIdleConnsPerHost != tt.expectedPerHost {
+				t.Errorf("Expected MaxIdleConnsPerHost %d, got %d", tt.expectedPerHost, transport.MaxIdleConnsPerHost)
+			}
+			if transport.MaxIdleConns != tt.expectedTotal {
+				t.Errorf("Expected MaxIdleConns %d, got %d", tt.expectedTotal, transport.MaxIdleConns)
+			}
+			if transport.DialContext == nil {
+				t.Error("Expected a dialer with the keep-alive setting")
+			}
+		})
+	}
+}
+
+func TestWithFollowRedirects(t *testing.T) {
+	var loginHits atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/login" {
+			loginHits.Add(1)
+			w.Write([]byte(`{"query": "cats", "results": []}`))
+			return
+		}
+		http.Redirect(w, r, "/login", http.StatusFound)
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		name           string
+		opts           []Option
+		expectStatus   int
+		expectLoginHit int32
+	}{
+		{name: "followed by default", expectLoginHit: 1},
+		{name: "followed when enabled", opts: []Option{WithFollowRedirects(true)}, expectLoginHit: 1},
+		{name: "not followed when disabled", opts: []Option{WithFollowRedirects(false)}, expectStatus: http.StatusFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			loginHits.Store(0)
+			client := newTestClient(t, server.URL, tt.opts...)
+			_, err := client.Search(context.Background(), "cats", 10, 0.1)
+
+			if tt.expectStatus != 0 {
+				var apiErr *APIError
+				if !errors.As(err, &apiErr) || apiErr.StatusCode != tt.expectStatus {
+					t.Errorf("Expected APIError with status %d, got %v", tt.expectStatus, err)
+				}
+			} else if err != nil {
+				t.Errorf("Unexpected error: %v", err)
+			}
+			if hits := loginHits.Load(); hits != tt.expectLoginHit {
+				t.Errorf("Expected %d requests to the redirect target, got %d", tt.expectLoginHit, hits)
+			}
+		})
+	}
+}
@@ -0,0 +1,123 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+)
+
+// doJSON sends a request to path, with body encoded as JSON unless it is
+// nil, and decodes the response into out unless out is nil. Each of required
+// must be set in the response, as for decodeResponse.
+func (c *MLClient) doJSON(ctx context.Context, method, path string, body, out any, required ...string) error {
+	req, err := c.newJSONRequest(ctx, method, path, body)
+	if err != nil {
+		return err
+	}
+	return c.do(req, out, required...)
+}
+
+// newJSONRequest returns a request to path with body encoded as JSON unless
+// it is nil.
+func (c *MLClient) newJSONRequest(ctx context.Context, method, path string, body any) (*http.Request, error) {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return nil, fmt.Errorf("error marshaling request: %w", err)
+		}
+		reader = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+path, reader)
+	if err != nil {
+		return nil, fmt.Errorf("error creating request: %w", err)
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	return req, nil
+}
+
+// setIdempotencyKey gives req a new Idempotency-Key header. Retries of req
+// send the same key, so the ML service can tell that a document it already
+// stored was submitted again rather than store it twice.
+func (c *MLClient) setIdempotencyKey(req *http.Request) {
+	if c.idempotencyKey != nil {
+		req.Header.Set("Idempotency-Key", c.idempotencyKey())
+	}
+}
+
+// doMultipart posts the multipart form written by write to path and decodes
+// the response like doJSON. The form is buffered, so the request can be
+// retried.
+func (c *MLClient) doMultipart(ctx context.Context, path string, write func(*multipart.Writer) error, out any, required ...string) error {
+	body := &bytes.Buffer{}
+	writer := multipart.NewWriter(body)
+	if err := write(writer); err != nil {
+		return err
+	}
+	if err := writer.Close(); err != nil {
+		return fmt.Errorf("error closing multipart writer: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.baseURL+path, body)
+	if err != nil {
+		return fmt.Errorf("error creating request: %w", err)
+	}
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+	return c.do(req, out, required...)
+}
+
+// do sends req and decodes the response into out unless out is nil.
+func (c *MLClient) do(req *http.Request, out any, required ...string) error {
+	resp, err := c.send(req)
+	if err != nil {
+		return err
+	}
+	defer closeResponse(resp.Body)
+
+	if out == nil {
+		return nil
+	}
+	return c.decodeResponse(resp.Body, out, required...)
+}
+
+// send sends req and returns the response if it succeeded with 200 OK,
+// 202 Accepted or 204 No Content. Any other status is returned as an
+// *APIError, or as a *RateLimitError or *BadRequestError wrapping one. The
+// caller must close the response with closeResponse.
+func (c *MLClient) send(req *http.Request) (*http.Response, error) {
+	return c.sendWith(c.httpClient, req)
+}
+
+// sendWith is send using client, e.g. c.streamClient for long streams.
+func (c *MLClient) sendWith(client HTTPClient, req *http.Request) (*http.Response, error) {
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("error sending request: %w", err)
+	}
+
+	switch resp.StatusCode {
+	case http.StatusOK, http.StatusAccepted, http.StatusNoContent:
+		return resp, nil
+	}
+	err = newAPIError(resp)
+	closeResponse(resp.Body)
+	return nil, err
+}
+
+// notFound turns a 404 *APIError into ErrNotFound for what, e.g.
+// "document abc". Other errors are returned unchanged.
+func notFound(err error, what string) error {
+	var apiErr *APIError
+	if errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusNotFound {
+		return fmt.Errorf("%s: %w", what, ErrNotFound)
+	}
+	return err
+}
@@ -0,0 +1,189 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestDoJSON(t *testing.T) {
+	type payload struct {
+		Name string `json:"name"`
+	}
+
+	tests := []struct {
+		name           string
+		status         int
+		response       string
+		body           any
+		out            bool
+		required       []string
+		expected       string
+		expectAPIError int
+		expectInvalid  bool
+		expectTooLarge bool
+		expectError    string
+	}{
+		{name: "success", status: http.StatusOK, response: `{"name": "cat"}`, out: true, expected: "cat"},
+		{name: "request body sent", status: http.StatusOK, response: `{"name": "dog"}`, body: payload{Name: "dog"}, out: true, expected: "dog"},
+		{name: "accepted", status: http.StatusAccepted, response: `{"name": "cat"}`, out: true, expected: "cat"},
+		{name: "no content without output", status: http.StatusNoContent},
+		{name: "response ignored without output", status: http.StatusOK, response: `not json`},
+		{name: "server error", status: http.StatusInternalServerError, response: `{"detail": "boom"}`, out: true, expectAPIError: http.StatusInternalServerError},
+		{name: "not found", status: http.StatusNotFound, response: `{"detail": "missing"}`, expectAPIError: http.StatusNotFound},
+		{name: "created is not accepted", status: http.StatusCreated, response: `{"name": "cat"}`, out: true, expectAPIError: http.StatusCreated},
+		{name: "malformed JSON", status: http.StatusOK, response: `{"name": `, out: true, expectError: `error decoding response "{\"name\": "`},
+		{name: "missing required field", status: http.StatusOK, response: `{"other": 1}`, out: true, required: []string{"name"}, expectInvalid: true},
+		{name: "too large", status: http.StatusOK, response: `{"name": "` + strings.Repeat("x", 2048) + `"}`, out: true, expectTooLarge: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var received payload
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.Method != http.MethodPost || r.URL.Path != "/things" || r.URL.Query().Get("q") != "1" {
+					t.Errorf("Unexpected request %s %s", r.Method, r.URL)
+				}
+				if tt.body != nil {
+					if ct := r.Header.Get("Content-Type"); ct != "application/json" {
+						t.Errorf("Expected application/json, got %q", ct)
+					}
+					data, _ := io.ReadAll(r.Body)
+					if string(data) != `{"name":"dog"}` {
+						t.Errorf("Unexpected request body %s", data)
+					}
+				} else if r.Header.Get("Content-Type") != "" {
+					t.Errorf("Expected no content type without a body, got %q", r.Header.Get("Content-Type"))
+				}
+				w.WriteHeader(tt.status)
+				w.Write([]byte(tt.response))
+			}))
+			defer server.Close()
+
+			client := newTestClient(t, server.URL, WithMaxResponseBytes(1024), WithMaxRetries(0))
+			var out any
+			if tt.out {
+				out = &received
+			}
+			err := client.doJSON(context.Background(), http.MethodPost, "/things?q=1", tt.body, out, tt.required...)
+
+			var apiErr *APIError
+			switch {
+			case tt.expectAPIError != 0:
+				if !errors.As(err, &apiErr) || apiErr.StatusCode != tt.expectAPIError {
+					t.Errorf("Expected APIError with status %d, got %v", tt.expectAPIError, err)
+				}
+			case tt.expectInvalid:
+				if !errors.Is(err, ErrInvalidResponse) {
+					t.Errorf("Expected ErrInvalidResponse, got %v", err)
+				}
+			case tt.expectTooLarge:
+				if !errors.Is(err, ErrResponseTooLarge) {
+					t.Errorf("Expected ErrResponseTooLarge, got %v", err)
+				}
+			case tt.expectError != "":
+				if err == nil || !strings.Contains(err.Error(), tt.expectError) {
+					t.Errorf("Expected error containing %q, got %v", tt.expectError, err)
+				}
+			default:
+				if err != nil {
+					t.Fatalf("Unexpected error: %v", err)
+				}
+				if received.Name != tt.expected {
+					t.Errorf("Expected name %q, got %q", tt.expected, received.Name)
+				}
+			}
+		})
+	}
+}
+
+func TestDoJSONSendError(t *testing.T) {
+	server := httptest.NewServer(http.NotFoundHandler())
+	server.Close()
+
+	client := newTestClient(t, server.URL, WithMaxRetries(0))
+	err := client.doJSON(context.Background(), http.MethodGet, "/things", nil, nil)
+	if err == nil || !strings.HasPrefix(err.Error(), "error sending request: ") {
+		t.Errorf("Expected a send error, got %v", err)
+	}
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		t.Errorf("Expected no APIError for a failed connection, got %v", apiErr)
+	}
+}
+
+func TestDoMultipart(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseMultipartForm(1 << 20); err != nil {
+			t.Fatalf("Error parsing multipart body: %v", err)
+		}
+		if v := r.FormValue("limit"); v != "5" {
+			t.Errorf("Expected limit 5, got %q", v)
+		}
+		if r.URL.Path == "/fail" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		w.Write([]byte(`{"status": "ok"}`))
+	}))
+	defer server.Close()
+
+	client := newTestClient(t, server.URL, WithMaxRetries(0))
+	write := func(w *multipart.Writer) error { return w.WriteField("limit", "5") }
+
+	var result struct {
+		Status string `json:"status"`
+	}
+	if err := client.doMultipart(context.Background(), "/upload", write, &result, "status"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if result.Status != "ok" {
+		t.Errorf("Expected status ok, got %q", result.Status)
+	}
+
+	var apiErr *APIError
+	if err := client.doMultipart(context.Background(), "/fail", write, nil); !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusBadRequest {
+		t.Errorf("Expected APIError with status 400, got %v", err)
+	}
+
+	writeErr := errors.New("cannot read image")
+	err := client.doMultipart(context.Background(), "/upload", func(*multipart.Writer) error { return writeErr }, nil)
+	if !errors.Is(err, writeErr) {
+		t.Errorf("Expected the write error, got %v", err)
+	}
+}
+
+func TestNotFound(t *testing.T) {
+	other := errors.New("connection refused")
+	tests := []struct {
+		name           string
+		err            error
+		expectNotFound bool
+		expected       string
+	}{
+		{name: "404", err: &APIError{StatusCode: http.StatusNotFound}, expectNotFound: true, expected: "document abc: not found"},
+		{name: "other status", err: &APIError{StatusCode: http.StatusInternalServerError}},
+		{name: "other error", err: other},
+		{name: "nil", err: nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := notFound(tt.err, "document abc")
+			if tt.expectNotFound {
+				if !errors.Is(err, ErrNotFound) || err.Error() != tt.expected {
+					t.Errorf("Expected ErrNotFound as %q, got %v", tt.expected, err)
+				}
+				return
+			}
+			if err != tt.err {
+				t.Errorf("Expected the error unchanged, got %v", err)
+			}
+		})
+	}
+}
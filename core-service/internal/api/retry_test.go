@@ -0,0 +1,110 @@
+package api
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestRetryOnTooManyRequests(t *testing.T) {
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if !strings.Contains(string(body), "hello") {
+			t.Errorf("Expected the request body on every attempt, got %q", body)
+		}
+		if requests.Add(1) == 1 {
+			w.Header().Set("Retry-After", "1")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		w.Write([]byte(`{"document_id": "doc1"}`))
+	}))
+	defer server.Close()
+
+	client := newTestClient(t, server.URL)
+	start := time.Now()
+	id, err := client.AddDocument(context.Background(), "hello")
+	elapsed := time.Since(start)
+
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if id != "doc1" {
+		t.Errorf("Expected doc1, got %s", id)
+	}
+	if n := requests.Load(); n != 2 {
+		t.Errorf("Expected 2 requests, got %d", n)
+	}
+	if elapsed < time.Second {
+		t.Errorf("Expected to wait for Retry-After, returned after %v", elapsed)
+	}
+}
+
+func TestRetryLimits(t *testing.T) {
+	tests := []struct {
+		name             string
+		opts             []Option
+		retryAfter       string
+		expectedRequests int32
+		maxElapsed       time.Duration
+	}{
+		{name: "retries disabled", opts: []Option{WithMaxRetries(0)}, retryAfter: "1", expectedRequests: 1, maxElapsed: 500 * time.Millisecond},
+		{name: "retry after deadline", opts: []Option{WithTimeout(500 * time.Millisecond)}, retryAfter: "5", expectedRequests: 1, maxElapsed: 500 * time.Millisecond},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var requests atomic.Int32
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				requests.Add(1)
+				w.Header().Set("Retry-After", tt.retryAfter)
+				w.WriteHeader(http.StatusTooManyRequests)
+			}))
+			defer server.Close()
+
+			client := newTestClient(t, server.URL, tt.opts...)
+			start := time.Now()
+			_, err := client.Search(context.Background(), "hello", 5, 0.1)
+			elapsed := time.Since(start)
+
+			if err == nil || !strings.Contains(err.Error(), "429") {
+				t.Errorf("Expected status 429 error, got %v", err)
+			}
+			if n := requests.Load(); n != tt.expectedRequests {
+				t.Errorf("Expected %d requests, got %d", tt.expectedRequests, n)
+			}
+			if elapsed > tt.maxElapsed {
+				t.Errorf("Expected to give up within %v, took %v", tt.maxElapsed, elapsed)
+			}
+		})
+	}
+}
+
+func TestRetryAfter(t *testing.T) {
+	now := time.Date(2025, 1, 31, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		value    string
+		expected time.Duration
+	}{
+		{name: "seconds", value: "3", expected: 3 * time.Second},
+		{name: "http date", value: "Fri, 31 Jan 2025 12:00:10 GMT", expected: 10 * time.Second},
+		{name: "missing", value: "", expected: 0},
+		{name: "invalid", value: "soon", expected: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := retryAfter(tt.value, now); got != tt.expected {
+				t.Errorf("Expected %v, got %v", tt.expected, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,87 @@
+package extract
+
+import (
+	"strings"
+	"unicode"
+)
+
+// ChunkText splits text into chunks of at most chunkSize runes, breaking on
+// sentence boundaries where possible. Consecutive chunks share the last
+// overlap runes of the previous chunk. Sentences longer than chunkSize are
+// split mid-sentence. A non-positive chunkSize returns the text as one chunk.
+func ChunkText(text string, chunkSize, overlap int) []string {
+	if strings.TrimSpace(text) == "" {
+		return nil
+	}
+	if chunkSize <= 0 {
+		return []string{strings.TrimSpace(text)}
+	}
+	if overlap < 0 {
+		overlap = 0
+	}
+	if overlap >= chunkSize {
+		overlap = chunkSize - 1
+	}
+
+	var (
+		chunks  []string
+		current []rune
+		carried int
+	)
+	emit := func() {
+		if chunk := strings.TrimSpace(string(current)); chunk != "" {
+			chunks = append(chunks, chunk)
+		}
+		if len(current) > overlap {
+			current = append([]rune(nil), current[len(current)-overlap:]...)
+		}
+		carried = len(current)
+	}
+
+	for _, sentence := range splitSentences([]rune(text)) {
+		for len(sentence) > 0 {
+			if len(current)+len(sentence) <= chunkSize {
+				current = append(current, sentence...)
+				break
+			}
+			if len(current) > carried {
+				emit()
+				continue
+			}
+			n := chunkSize - len(current)
+			current = append(current, sentence[:n]...)
+			sentence = sentence[n:]
+			emit()
+		}
+	}
+	if len(current) > carried {
+		emit()
+	}
+
+	return chunks
+}
+
+// splitSentences cuts text after sentence terminators and newlines, keeping the
+// trailing whitespace with the sentence so the pieces concatenate back to text.
+func splitSentences(text []rune) [][]rune {
+	var sentences [][]rune
+	start := 0
+	for i := 0; i < len(text); i++ {
+		r := text[i]
+		if r != '.' && r != '!' && r != '?' && r != '\n' {
+			continue
+		}
+		if r != '\n' && i+1 < len(text) && !unicode.IsSpace(text[i+1]) {
+			continue
+		}
+		for i+1 < len(text) && unicode.IsSpace(text[i+1]) {
+			i++
+		}
+		sentences = append(sentences, text[start:i+1])
+		start = i + 1
+	}
+	if start < len(text) {
+		sentences = append(sentences, text[start:])
+	}
+	return sentences
+}
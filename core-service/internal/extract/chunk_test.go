@@ -0,0 +1,98 @@
+package extract
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestChunkText(t *testing.T) {
+	tests := []struct {
+		name      string
+		text      string
+		chunkSize int
+		overlap   int
+		expected  []string
+	}{
+		{
+			name:      "empty text",
+			text:      "",
+			chunkSize: 10,
+			expected:  nil,
+		},
+		{
+			name:      "whitespace only",
+			text:      "  \n\t ",
+			chunkSize: 10,
+			expected:  nil,
+		},
+		{
+			name:      "shorter than chunk size",
+			text:      "One sentence.",
+			chunkSize: 100,
+			expected:  []string{"One sentence."},
+		},
+		{
+			name:      "chunking disabled",
+			text:      "First. Second.",
+			chunkSize: 0,
+			expected:  []string{"First. Second."},
+		},
+		{
+			name:      "splits on sentence boundaries",
+			text:      "First one. Second one. Third one.",
+			chunkSize: 23,
+			expected:  []string{"First one. Second one.", "Third one."},
+		},
+		{
+			name:      "exact boundary",
+			text:      "Aaaa. Bbbb.",
+			chunkSize: 6,
+			expected:  []string{"Aaaa.", "Bbbb."},
+		},
+		{
+			name:      "long sentence split mid-sentence",
+			text:      "abcdefghij",
+			chunkSize: 4,
+			expected:  []string{"abcd", "efgh", "ij"},
+		},
+		{
+			name:      "overlap carries previous tail",
+			text:      "abcdefghij",
+			chunkSize: 4,
+			overlap:   2,
+			expected:  []string{"abcd", "cdef", "efgh", "ghij"},
+		},
+		{
+			name:      "newlines are boundaries",
+			text:      "line one\nline two\nline three",
+			chunkSize: 18,
+			expected:  []string{"line one\nline two", "line three"},
+		},
+		{
+			name:      "multibyte runes",
+			text:      "çöğüşı. ğüşıçö.",
+			chunkSize: 9,
+			expected:  []string{"çöğüşı.", "ğüşıçö."},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := ChunkText(tt.text, tt.chunkSize, tt.overlap)
+			if !reflect.DeepEqual(got, tt.expected) {
+				t.Errorf("Expected %q, got %q", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestChunkTextRespectsSize(t *testing.T) {
+	text := strings.Repeat("The quick brown fox jumps over the lazy dog. ", 50)
+	for _, chunk := range ChunkText(text, 120, 30) {
+		if n := utf8.RuneCountInString(chunk); n > 120 {
+			t.Errorf("Expected chunk of at most 120 runes, got %d", n)
+		}
+	}
+}
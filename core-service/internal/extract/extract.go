@@ -0,0 +1,49 @@
+package extract
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"mime"
+	"strings"
+
+	"github.com/ledongthuc/pdf"
+)
+
+// ExtractText returns the plain text content of data based on its MIME type.
+func ExtractText(data []byte, mimeType string) (string, error) {
+	mediaType, _, err := mime.ParseMediaType(mimeType)
+	if err != nil {
+		mediaType = mimeType
+	}
+
+	switch {
+	case mediaType == "application/pdf":
+		return extractPDF(data)
+	case mediaType == "text/html" || mediaType == "application/xhtml+xml":
+		return StripHTML(data)
+	case mediaType == "" || strings.HasPrefix(mediaType, "text/"):
+		return string(data), nil
+	default:
+		return "", fmt.Errorf("unsupported content type: %s", mimeType)
+	}
+}
+
+func extractPDF(data []byte) (string, error) {
+	reader, err := pdf.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return "", fmt.Errorf("error opening PDF: %w", err)
+	}
+
+	textReader, err := reader.GetPlainText()
+	if err != nil {
+		return "", fmt.Errorf("error extracting PDF text: %w", err)
+	}
+
+	text, err := io.ReadAll(textReader)
+	if err != nil {
+		return "", fmt.Errorf("error reading PDF text: %w", err)
+	}
+
+	return strings.TrimSpace(string(text)), nil
+}
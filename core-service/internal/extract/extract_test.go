@@ -0,0 +1,56 @@
+package extract
+
+import (
+	"os"
+	"testing"
+)
+
+func TestExtractTextPDF(t *testing.T) {
+	data, err := os.ReadFile("testdata/hello.pdf")
+	if err != nil {
+		t.Fatalf("Error reading fixture: %v", err)
+	}
+
+	text, err := ExtractText(data, "application/pdf")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if text != "Hello from TidyData" {
+		t.Errorf("Expected %q, got %q", "Hello from TidyData", text)
+	}
+}
+
+func TestExtractTextInvalidPDF(t *testing.T) {
+	if _, err := ExtractText([]byte("not a pdf"), "application/pdf"); err == nil {
+		t.Error("Expected error for invalid PDF data")
+	}
+}
+
+func TestExtractTextPlain(t *testing.T) {
+	tests := []struct {
+		name     string
+		mimeType string
+	}{
+		{name: "text/plain", mimeType: "text/plain"},
+		{name: "text/plain with charset", mimeType: "text/plain; charset=utf-8"},
+		{name: "unknown type", mimeType: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			text, err := ExtractText([]byte("plain content"), tt.mimeType)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if text != "plain content" {
+				t.Errorf("Expected %q, got %q", "plain content", text)
+			}
+		})
+	}
+}
+
+func TestExtractTextUnsupported(t *testing.T) {
+	if _, err := ExtractText([]byte{0x89, 'P', 'N', 'G'}, "image/png"); err == nil {
+		t.Error("Expected error for unsupported content type")
+	}
+}
@@ -0,0 +1,130 @@
+package extract
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// skippedHTMLTags hold markup or navigation rather than document content.
+var skippedHTMLTags = map[string]bool{
+	"script": true, "style": true, "nav": true, "noscript": true, "template": true, "svg": true,
+}
+
+var inlineHTMLTags = map[string]bool{
+	"a": true, "abbr": true, "b": true, "code": true, "em": true, "i": true, "mark": true,
+	"s": true, "small": true, "span": true, "strong": true, "sub": true, "sup": true, "u": true,
+}
+
+// StripHTML extracts the readable text from an HTML document. The title and
+// meta description come first, followed by the contents of <article> when the
+// page has one, or the whole body otherwise.
+func StripHTML(data []byte) (string, error) {
+	var (
+		title, article, body strings.Builder
+		description          string
+		skipDepth            int
+		articleDepth         int
+		inTitle              bool
+	)
+
+	write := func(s string) {
+		if articleDepth > 0 {
+			article.WriteString(s)
+		}
+		body.WriteString(s)
+	}
+
+	z := html.NewTokenizer(bytes.NewReader(data))
+	for {
+		tt := z.Next()
+		switch tt {
+		case html.ErrorToken:
+			if err := z.Err(); err != io.EOF {
+				return "", fmt.Errorf("error parsing HTML: %w", err)
+			}
+			return joinHTMLParts(title.String(), description, article.String(), body.String()), nil
+
+		case html.StartTagToken, html.SelfClosingTagToken:
+			token := z.Token()
+			name := token.Data
+			if tt == html.StartTagToken && skippedHTMLTags[name] {
+				skipDepth++
+				continue
+			}
+			switch name {
+			case "title":
+				inTitle = tt == html.StartTagToken
+			case "meta":
+				if description == "" && strings.EqualFold(htmlAttr(token, "name"), "description") {
+					description = htmlAttr(token, "content")
+				}
+			case "article":
+				if tt == html.StartTagToken {
+					articleDepth++
+				}
+			}
+			if !inlineHTMLTags[name] {
+				write(" ")
+			}
+
+		case html.EndTagToken:
+			name := z.Token().Data
+			if skippedHTMLTags[name] {
+				if skipDepth > 0 {
+					skipDepth--
+				}
+				continue
+			}
+			switch name {
+			case "title":
+				inTitle = false
+			case "article":
+				if articleDepth > 0 {
+					articleDepth--
+				}
+			}
+			if !inlineHTMLTags[name] {
+				write(" ")
+			}
+
+		case html.TextToken:
+			if skipDepth > 0 {
+				continue
+			}
+			text := string(z.Text())
+			if inTitle {
+				title.WriteString(text)
+				continue
+			}
+			write(text)
+		}
+	}
+}
+
+func joinHTMLParts(title, description, article, body string) string {
+	content := article
+	if strings.TrimSpace(content) == "" {
+		content = body
+	}
+
+	var parts []string
+	for _, part := range []string{title, description, content} {
+		if part = strings.Join(strings.Fields(part), " "); part != "" {
+			parts = append(parts, part)
+		}
+	}
+	return strings.Join(parts, "\n\n")
+}
+
+func htmlAttr(token html.Token, key string) string {
+	for _, attr := range token.Attr {
+		if attr.Key == key {
+			return attr.Val
+		}
+	}
+	return ""
+}
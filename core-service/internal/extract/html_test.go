@@ -0,0 +1,65 @@
+package extract
+
+import (
+	"os"
+	"testing"
+)
+
+func TestStripHTML(t *testing.T) {
+	tests := []struct {
+		name     string
+		fixture  string
+		expected string
+	}{
+		{
+			name:    "article page",
+			fixture: "testdata/article.html",
+			expected: "Semantic Search Explained | Notes\n\n" +
+				"How vector search finds notes by meaning.\n\n" +
+				"Semantic Search Explained Embeddings map text to vectors so that similar meanings end up close together. " +
+				"Tom & Jerry would match \"cat and mouse\" even without shared words.",
+		},
+		{
+			name:     "page without article",
+			fixture:  "testdata/page.html",
+			expected: "Grocery List\n\nThis week Apples Oat milk",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := os.ReadFile(tt.fixture)
+			if err != nil {
+				t.Fatalf("Error reading fixture: %v", err)
+			}
+
+			text, err := StripHTML(data)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if text != tt.expected {
+				t.Errorf("Expected %q, got %q", tt.expected, text)
+			}
+		})
+	}
+}
+
+func TestStripHTMLFragment(t *testing.T) {
+	text, err := StripHTML([]byte("<p>un<b>believ</b>able</p><p>next</p>"))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if text != "unbelievable next" {
+		t.Errorf("Expected %q, got %q", "unbelievable next", text)
+	}
+}
+
+func TestExtractTextHTML(t *testing.T) {
+	text, err := ExtractText([]byte("<title>T</title><p>body</p>"), "text/html; charset=utf-8")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if text != "T\n\nbody" {
+		t.Errorf("Expected %q, got %q", "T\n\nbody", text)
+	}
+}
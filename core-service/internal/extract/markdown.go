@@ -0,0 +1,65 @@
+package extract
+
+import (
+	"regexp"
+	"strings"
+)
+
+var (
+	mdFence          = regexp.MustCompile("^\\s*(```|~~~)")
+	mdHorizontalRule = regexp.MustCompile(`^\s{0,3}([-*_]\s*){3,}$`)
+	mdHeading        = regexp.MustCompile(`^\s{0,3}#{1,6}\s+`)
+	mdHeadingClose   = regexp.MustCompile(`\s+#+\s*$`)
+	mdBlockquote     = regexp.MustCompile(`^\s{0,3}>\s?`)
+	mdListMarker     = regexp.MustCompile(`^\s*([-*+]|\d+[.)])\s+(\[[ xX]\]\s+)?`)
+	mdImage          = regexp.MustCompile(`!\[([^\]]*)\]\([^)]*\)`)
+	mdLink           = regexp.MustCompile(`\[([^\]]+)\]\([^)]*\)`)
+	mdRefLink        = regexp.MustCompile(`\[([^\]]+)\]\[[^\]]*\]`)
+	mdLinkDef        = regexp.MustCompile(`^\s{0,3}\[[^\]]+\]:\s+\S+.*$`)
+	mdInlineCode     = regexp.MustCompile("`([^`]+)`")
+	mdBold           = regexp.MustCompile(`\*\*(.+?)\*\*|__(.+?)__`)
+	mdItalic         = regexp.MustCompile(`\*(\S(?:.*?\S)?)\*|\b_(\S(?:.*?\S)?)_\b`)
+	mdStrikethrough  = regexp.MustCompile(`~~(.+?)~~`)
+)
+
+// StripMarkdown removes common Markdown syntax from text, keeping the readable
+// content. Code inside fenced blocks is kept verbatim.
+func StripMarkdown(text string) string {
+	lines := strings.Split(text, "\n")
+	out := make([]string, 0, len(lines))
+	inFence := false
+
+	for _, line := range lines {
+		if mdFence.MatchString(line) {
+			inFence = !inFence
+			continue
+		}
+		if inFence {
+			out = append(out, line)
+			continue
+		}
+		if mdHorizontalRule.MatchString(line) || mdLinkDef.MatchString(line) {
+			continue
+		}
+		out = append(out, stripMarkdownLine(line))
+	}
+
+	return strings.TrimSpace(strings.Join(out, "\n"))
+}
+
+func stripMarkdownLine(line string) string {
+	if mdHeading.MatchString(line) {
+		line = mdHeading.ReplaceAllString(line, "")
+		line = mdHeadingClose.ReplaceAllString(line, "")
+	}
+	line = mdBlockquote.ReplaceAllString(line, "")
+	line = mdListMarker.ReplaceAllString(line, "")
+	line = mdImage.ReplaceAllString(line, "$1")
+	line = mdLink.ReplaceAllString(line, "$1")
+	line = mdRefLink.ReplaceAllString(line, "$1")
+	line = mdInlineCode.ReplaceAllString(line, "$1")
+	line = mdBold.ReplaceAllString(line, "$1$2")
+	line = mdItalic.ReplaceAllString(line, "$1$2")
+	line = mdStrikethrough.ReplaceAllString(line, "$1")
+	return line
+}
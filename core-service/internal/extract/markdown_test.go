@@ -0,0 +1,65 @@
+package extract
+
+import "testing"
+
+func TestStripMarkdown(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{
+			name:     "headings",
+			input:    "# Title\n## Section ##\n###### Deep",
+			expected: "Title\nSection\nDeep",
+		},
+		{
+			name:     "hashtag without space is kept",
+			input:    "#notes for later",
+			expected: "#notes for later",
+		},
+		{
+			name:     "unordered and ordered lists",
+			input:    "- apples\n* pears\n+ plums\n1. first\n2) second\n- [x] done",
+			expected: "apples\npears\nplums\nfirst\nsecond\ndone",
+		},
+		{
+			name:     "links and images",
+			input:    "See [the docs](https://example.com/docs) and ![diagram](img/arch.png) or [ref][1].\n[1]: https://example.com",
+			expected: "See the docs and diagram or ref.",
+		},
+		{
+			name:     "fenced code block kept verbatim",
+			input:    "Run this:\n```bash\necho **not bold**\n```\nDone",
+			expected: "Run this:\necho **not bold**\nDone",
+		},
+		{
+			name:     "inline code",
+			input:    "Use `go test ./...` often",
+			expected: "Use go test ./... often",
+		},
+		{
+			name:     "bold and italic",
+			input:    "**bold** __also bold__ *italic* _also italic_ ~~gone~~",
+			expected: "bold also bold italic also italic gone",
+		},
+		{
+			name:     "snake_case words untouched",
+			input:    "call my_helper_func now",
+			expected: "call my_helper_func now",
+		},
+		{
+			name:     "blockquotes and rules",
+			input:    "> quoted text\n\n---\nafter",
+			expected: "quoted text\n\nafter",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := StripMarkdown(tt.input); got != tt.expected {
+				t.Errorf("Expected %q, got %q", tt.expected, got)
+			}
+		})
+	}
+}
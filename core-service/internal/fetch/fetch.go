@@ -0,0 +1,77 @@
+package fetch
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/berkayuckac/tidydata/internal/extract"
+)
+
+// DefaultMaxBytes is the largest response body FetchText accepts by default.
+const DefaultMaxBytes = 10 << 20
+
+// FetchText downloads rawURL and returns its text content. HTML pages have
+// their markup removed, plain text is returned unchanged, and bodies larger
+// than maxBytes are rejected.
+func FetchText(ctx context.Context, client *http.Client, rawURL string, maxBytes int64) (string, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return "", fmt.Errorf("error parsing URL: %w", err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return "", fmt.Errorf("unsupported URL scheme %q (only http and https are allowed)", u.Scheme)
+	}
+	if u.Host == "" {
+		return "", fmt.Errorf("URL is missing a host: %s", rawURL)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return "", fmt.Errorf("error creating request: %w", err)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("error fetching URL: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+	}
+
+	if resp.ContentLength > maxBytes {
+		return "", fmt.Errorf("content size %d bytes exceeds limit of %d bytes", resp.ContentLength, maxBytes)
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxBytes+1))
+	if err != nil {
+		return "", fmt.Errorf("error reading response: %w", err)
+	}
+	if int64(len(body)) > maxBytes {
+		return "", fmt.Errorf("content exceeds limit of %d bytes", maxBytes)
+	}
+
+	contentType := resp.Header.Get("Content-Type")
+	if contentType == "" {
+		contentType = http.DetectContentType(body)
+	}
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return "", fmt.Errorf("error parsing content type %q: %w", contentType, err)
+	}
+
+	switch {
+	case mediaType == "text/html" || mediaType == "application/xhtml+xml":
+		return extract.StripHTML(body)
+	case strings.HasPrefix(mediaType, "text/"):
+		return string(body), nil
+	default:
+		return "", fmt.Errorf("unsupported content type: %s", mediaType)
+	}
+}
@@ -0,0 +1,133 @@
+package fetch
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestFetchText(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		w.Write([]byte(`<!DOCTYPE html>
+<html>
+<head><title>Notes</title><style>h1 { font-size: 2em; }</style></head>
+<body>
+	<h1>Semantic search</h1>
+	<p>Find content by <em>meaning</em>, not keywords.</p>
+	<script>console.log("ignored")</script>
+</body>
+</html>`))
+	}))
+	defer server.Close()
+
+	text, err := FetchText(context.Background(), server.Client(), server.URL, DefaultMaxBytes)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	expected := "Notes\n\nSemantic search Find content by meaning, not keywords."
+	if text != expected {
+		t.Errorf("Expected %q, got %q", expected, text)
+	}
+}
+
+func TestFetchTextInvalidScheme(t *testing.T) {
+	for _, rawURL := range []string{"ftp://example.com/file.txt", "file:///etc/passwd", "example.com"} {
+		if _, err := FetchText(context.Background(), http.DefaultClient, rawURL, DefaultMaxBytes); err == nil {
+			t.Errorf("Expected error for URL %q", rawURL)
+		}
+	}
+}
+
+func TestFetchTextServerError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer server.Close()
+
+	if _, err := FetchText(context.Background(), server.Client(), server.URL, DefaultMaxBytes); err == nil {
+		t.Error("Expected error for non-200 response")
+	}
+}
+
+func TestFetchTextPlain(t *testing.T) {
+	const body = "<not a tag> plain text\n  keeps   its formatting"
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		w.Write([]byte(body))
+	}))
+	defer server.Close()
+
+	text, err := FetchText(context.Background(), server.Client(), server.URL, DefaultMaxBytes)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if text != body {
+		t.Errorf("Expected %q, got %q", body, text)
+	}
+}
+
+func TestFetchTextUnsupportedContentType(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "image/png")
+		w.Write([]byte{0x89, 'P', 'N', 'G'})
+	}))
+	defer server.Close()
+
+	if _, err := FetchText(context.Background(), server.Client(), server.URL, DefaultMaxBytes); err == nil {
+		t.Error("Expected error for unsupported content type")
+	}
+}
+
+func TestFetchTextMaxBytes(t *testing.T) {
+	body := strings.Repeat("a", 100)
+	tests := []struct {
+		name        string
+		chunked     bool
+		maxBytes    int64
+		expectError bool
+	}{
+		{name: "within limit", maxBytes: 100},
+		{name: "content length over limit", maxBytes: 99, expectError: true},
+		{name: "streamed body over limit", chunked: true, maxBytes: 99, expectError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "text/plain")
+				if tt.chunked {
+					// Flushing before writing forces chunked encoding, hiding the size.
+					w.(http.Flusher).Flush()
+				}
+				w.Write([]byte(body))
+			}))
+			defer server.Close()
+
+			_, err := FetchText(context.Background(), server.Client(), server.URL, tt.maxBytes)
+			if tt.expectError && err == nil {
+				t.Error("Expected error but got none")
+			}
+			if !tt.expectError && err != nil {
+				t.Errorf("Unexpected error: %v", err)
+			}
+		})
+	}
+}
+
+func TestFetchTextTimeout(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(200 * time.Millisecond)
+		w.Write([]byte("too late"))
+	}))
+	defer server.Close()
+
+	client := &http.Client{Timeout: 20 * time.Millisecond}
+	if _, err := FetchText(context.Background(), client, server.URL, DefaultMaxBytes); err == nil {
+		t.Error("Expected timeout error")
+	}
+}
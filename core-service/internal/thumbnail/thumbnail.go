@@ -0,0 +1,74 @@
+package thumbnail
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	_ "image/gif"
+	"image/jpeg"
+	"image/png"
+	"strconv"
+	"strings"
+
+	"golang.org/x/image/draw"
+)
+
+// jpegQuality is used when the original image is a JPEG.
+const jpegQuality = 85
+
+// ParseSize parses a size given as WxH, e.g. "200x150".
+func ParseSize(s string) (width, height int, err error) {
+	w, h, ok := strings.Cut(strings.ToLower(s), "x")
+	if ok {
+		width, err = strconv.Atoi(w)
+		if err == nil {
+			height, err = strconv.Atoi(h)
+		}
+	}
+	if !ok || err != nil || width <= 0 || height <= 0 {
+		return 0, 0, fmt.Errorf("invalid size %q: expected WxH with positive width and height, e.g. 200x150", s)
+	}
+	return width, height, nil
+}
+
+// Generate decodes a JPEG, PNG or GIF image and scales it to fit within
+// width by height with Catmull-Rom resampling, keeping its aspect ratio.
+// Images that already fit keep their size. The thumbnail is encoded as a
+// JPEG if the original was one and as a PNG otherwise; ext is the matching
+// file extension.
+func Generate(data []byte, width, height int) (thumb []byte, ext string, err error) {
+	src, format, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, "", fmt.Errorf("error decoding image: %w", err)
+	}
+
+	w, h := fit(src.Bounds().Dx(), src.Bounds().Dy(), width, height)
+	dst := image.NewRGBA(image.Rect(0, 0, w, h))
+	draw.CatmullRom.Scale(dst, dst.Bounds(), src, src.Bounds(), draw.Src, nil)
+
+	var buf bytes.Buffer
+	ext = ".png"
+	if format == "jpeg" {
+		ext = ".jpg"
+		err = jpeg.Encode(&buf, dst, &jpeg.Options{Quality: jpegQuality})
+	} else {
+		err = png.Encode(&buf, dst)
+	}
+	if err != nil {
+		return nil, "", fmt.Errorf("error encoding thumbnail: %w", err)
+	}
+	return buf.Bytes(), ext, nil
+}
+
+// fit returns the largest size with the aspect ratio of w by h that fits
+// within maxW by maxH, without enlarging. Neither side drops below 1.
+func fit(w, h, maxW, maxH int) (int, int) {
+	if w <= maxW && h <= maxH {
+		return w, h
+	}
+	// Compare w/maxW with h/maxH without floating point.
+	if w*maxH >= h*maxW {
+		return maxW, max(1, h*maxW/w)
+	}
+	return max(1, w*maxH/h), maxH
+}
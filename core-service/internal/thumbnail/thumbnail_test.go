@@ -0,0 +1,145 @@
+package thumbnail
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"testing"
+)
+
+func encodeImage(t *testing.T, format string, w, h int) []byte {
+	t.Helper()
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	for y := range h {
+		for x := range w {
+			img.Set(x, y, color.RGBA{R: uint8(x), G: uint8(y), B: 200, A: 255})
+		}
+	}
+
+	var buf bytes.Buffer
+	var err error
+	switch format {
+	case "jpeg":
+		err = jpeg.Encode(&buf, img, nil)
+	case "gif":
+		err = gif.Encode(&buf, img, nil)
+	default:
+		err = png.Encode(&buf, img)
+	}
+	if err != nil {
+		t.Fatalf("Error encoding fixture: %v", err)
+	}
+	return buf.Bytes()
+}
+
+func TestGenerate(t *testing.T) {
+	tests := []struct {
+		name         string
+		format       string
+		width        int
+		height       int
+		maxW         int
+		maxH         int
+		expectW      int
+		expectH      int
+		expectExt    string
+		expectFormat string
+	}{
+		{name: "landscape png", format: "png", width: 400, height: 200, maxW: 100, maxH: 100, expectW: 100, expectH: 50, expectExt: ".png", expectFormat: "png"},
+		{name: "portrait jpeg", format: "jpeg", width: 120, height: 300, maxW: 100, maxH: 100, expectW: 40, expectH: 100, expectExt: ".jpg", expectFormat: "jpeg"},
+		{name: "gif becomes png", format: "gif", width: 64, height: 64, maxW: 32, maxH: 16, expectW: 16, expectH: 16, expectExt: ".png", expectFormat: "png"},
+		{name: "small image keeps its size", format: "png", width: 30, height: 20, maxW: 100, maxH: 100, expectW: 30, expectH: 20, expectExt: ".png", expectFormat: "png"},
+		{name: "thin image keeps one pixel", format: "png", width: 500, height: 2, maxW: 50, maxH: 50, expectW: 50, expectH: 1, expectExt: ".png", expectFormat: "png"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			thumb, ext, err := Generate(encodeImage(t, tt.format, tt.width, tt.height), tt.maxW, tt.maxH)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if ext != tt.expectExt {
+				t.Errorf("Expected extension %s, got %s", tt.expectExt, ext)
+			}
+
+			img, format, err := image.Decode(bytes.NewReader(thumb))
+			if err != nil {
+				t.Fatalf("Error decoding thumbnail: %v", err)
+			}
+			if format != tt.expectFormat {
+				t.Errorf("Expected format %s, got %s", tt.expectFormat, format)
+			}
+			if bounds := img.Bounds(); bounds.Dx() != tt.expectW || bounds.Dy() != tt.expectH {
+				t.Errorf("Expected %dx%d, got %dx%d", tt.expectW, tt.expectH, bounds.Dx(), bounds.Dy())
+			}
+		})
+	}
+}
+
+func TestGenerateAveragesPixels(t *testing.T) {
+	img := image.NewRGBA(image.Rect(0, 0, 2, 2))
+	img.Set(0, 0, color.RGBA{R: 255, A: 255})
+	img.Set(1, 0, color.RGBA{R: 255, A: 255})
+	img.Set(0, 1, color.RGBA{B: 255, A: 255})
+	img.Set(1, 1, color.RGBA{B: 255, A: 255})
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatalf("Error encoding fixture: %v", err)
+	}
+
+	thumb, _, err := Generate(buf.Bytes(), 1, 1)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	decoded, err := png.Decode(bytes.NewReader(thumb))
+	if err != nil {
+		t.Fatalf("Error decoding thumbnail: %v", err)
+	}
+	if c := color.RGBAModel.Convert(decoded.At(0, 0)).(color.RGBA); c != (color.RGBA{R: 127, B: 127, A: 255}) {
+		t.Errorf("Expected the average of red and blue, got %v", c)
+	}
+}
+
+func TestGenerateInvalidImage(t *testing.T) {
+	if _, _, err := Generate([]byte("not an image"), 100, 100); err == nil {
+		t.Error("Expected an error for data that is not an image")
+	}
+}
+
+func TestParseSize(t *testing.T) {
+	tests := []struct {
+		size        string
+		expectW     int
+		expectH     int
+		expectError bool
+	}{
+		{size: "200x150", expectW: 200, expectH: 150},
+		{size: "64X64", expectW: 64, expectH: 64},
+		{size: "200", expectError: true},
+		{size: "0x100", expectError: true},
+		{size: "-5x10", expectError: true},
+		{size: "axb", expectError: true},
+		{size: "", expectError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.size, func(t *testing.T) {
+			w, h, err := ParseSize(tt.size)
+			if tt.expectError {
+				if err == nil {
+					t.Errorf("Expected an error for %q", tt.size)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if w != tt.expectW || h != tt.expectH {
+				t.Errorf("Expected %dx%d, got %dx%d", tt.expectW, tt.expectH, w, h)
+			}
+		})
+	}
+}
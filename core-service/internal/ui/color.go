@@ -0,0 +1,57 @@
+package ui
+
+import (
+	"fmt"
+	"io"
+	"os"
+)
+
+const (
+	colorReset  = "\033[0m"
+	colorRed    = "\033[31m"
+	colorGreen  = "\033[32m"
+	colorYellow = "\033[33m"
+)
+
+// Score thresholds used to pick a color band.
+const (
+	HighScore = 0.6
+	MidScore  = 0.3
+)
+
+// ForceColor enables colors for writers that are not terminals, such as the
+// pipe to a pager that shows them. --no-color and NO_COLOR still win.
+var ForceColor bool
+
+// ColorEnabled reports whether ANSI colors should be written to w. Colors are
+// disabled by the --no-color flag, a non-empty NO_COLOR environment variable,
+// or when w is not a terminal and ForceColor is unset.
+func ColorEnabled(noColor bool, w io.Writer) bool {
+	if noColor || os.Getenv("NO_COLOR") != "" {
+		return false
+	}
+	return ForceColor || IsTerminal(w)
+}
+
+// IsTerminal reports whether w is a terminal.
+func IsTerminal(w io.Writer) bool {
+	f, ok := w.(*os.File)
+	if !ok {
+		return false
+	}
+	info, err := f.Stat()
+	if err != nil {
+		return false
+	}
+	return info.Mode()&os.ModeCharDevice != 0
+}
+
+// Score formats a similarity score, colored green, yellow or red by band when
+// color is enabled.
+func Score(score float64, color bool) string {
+	s := fmt.Sprintf("%.2f", score)
+	if !color {
+		return s
+	}
+	return scoreColor(score) + s + colorReset
+}
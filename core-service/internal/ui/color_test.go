@@ -0,0 +1,55 @@
+package ui
+
+import (
+	"bytes"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestScore(t *testing.T) {
+	tests := []struct {
+		name     string
+		score    float64
+		color    bool
+		expected string
+	}{
+		{name: "high", score: 0.85, color: true, expected: colorGreen + "0.85" + colorReset},
+		{name: "mid", score: 0.45, color: true, expected: colorYellow + "0.45" + colorReset},
+		{name: "low", score: 0.12, color: true, expected: colorRed + "0.12" + colorReset},
+		{name: "boundary high", score: HighScore, color: true, expected: colorGreen + "0.60" + colorReset},
+		{name: "disabled", score: 0.85, color: false, expected: "0.85"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Score(tt.score, tt.color); got != tt.expected {
+				t.Errorf("Expected %q, got %q", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestScoreNoEscapeCodesWhenDisabled(t *testing.T) {
+	for _, score := range []float64{0, 0.3, 0.6, 1} {
+		if got := Score(score, false); strings.Contains(got, "\033") {
+			t.Errorf("Expected no escape codes for score %.2f, got %q", score, got)
+		}
+	}
+}
+
+func TestColorEnabled(t *testing.T) {
+	t.Setenv("NO_COLOR", "")
+
+	if ColorEnabled(false, &bytes.Buffer{}) {
+		t.Error("Expected color to be disabled for a non-terminal writer")
+	}
+	if ColorEnabled(true, os.Stdout) {
+		t.Error("Expected color to be disabled by the no-color flag")
+	}
+
+	t.Setenv("NO_COLOR", "1")
+	if ColorEnabled(false, os.Stdout) {
+		t.Error("Expected color to be disabled by NO_COLOR")
+	}
+}
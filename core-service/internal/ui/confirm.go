@@ -0,0 +1,54 @@
+package ui
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// Where Confirm reads answers and writes prompts; replaced in tests.
+var (
+	confirmIn  io.Reader = os.Stdin
+	confirmOut io.Writer = os.Stdout
+	confirmErr io.Writer = os.Stderr
+)
+
+// Confirm asks prompt as a yes/no question and reports whether the answer was
+// y or yes. It returns true without asking when force is set. The prompt goes
+// to stdout when that is a terminal and to stderr otherwise, so piped output
+// stays clean; an empty answer or end of input counts as no.
+func Confirm(prompt string, force bool) bool {
+	if force {
+		return true
+	}
+
+	out := confirmErr
+	if IsTerminal(confirmOut) {
+		out = confirmOut
+	}
+	fmt.Fprint(out, prompt+" [y/N]: ")
+
+	answer := strings.ToLower(strings.TrimSpace(readLine(confirmIn)))
+	return answer == "y" || answer == "yes"
+}
+
+// readLine reads up to the next newline one byte at a time, so input after
+// the answer is left for whoever reads r next.
+func readLine(r io.Reader) string {
+	var line []byte
+	buf := make([]byte, 1)
+	for {
+		n, err := r.Read(buf)
+		if n > 0 {
+			if buf[0] == '\n' {
+				break
+			}
+			line = append(line, buf[0])
+		}
+		if err != nil {
+			break
+		}
+	}
+	return string(line)
+}
@@ -0,0 +1,63 @@
+package ui
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+// useConfirmIO replaces Confirm's input and stderr for the rest of the test.
+func useConfirmIO(t *testing.T, input string) (*strings.Reader, *bytes.Buffer) {
+	t.Helper()
+	in, stderr := strings.NewReader(input), &bytes.Buffer{}
+	origIn, origErr := confirmIn, confirmErr
+	confirmIn, confirmErr = in, stderr
+	t.Cleanup(func() { confirmIn, confirmErr = origIn, origErr })
+	return in, stderr
+}
+
+func TestConfirm(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		force    bool
+		expected bool
+	}{
+		{name: "no", input: "n\n", expected: false},
+		{name: "y", input: "y\n", expected: true},
+		{name: "yes with spaces", input: "  YES \n", expected: true},
+		{name: "empty", input: "\n", expected: false},
+		{name: "end of input", input: "", expected: false},
+		{name: "other answer", input: "sure\n", expected: false},
+		{name: "force skips prompt", input: "n\n", force: true, expected: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, stderr := useConfirmIO(t, tt.input)
+
+			if got := Confirm("Delete document doc1?", tt.force); got != tt.expected {
+				t.Errorf("Expected %v, got %v", tt.expected, got)
+			}
+
+			prompt := "Delete document doc1? [y/N]: "
+			if tt.force && stderr.Len() != 0 {
+				t.Errorf("Expected no prompt with force, got %q", stderr.String())
+			}
+			if !tt.force && stderr.String() != prompt {
+				t.Errorf("Expected prompt %q on stderr when stdout is not a terminal, got %q", prompt, stderr.String())
+			}
+		})
+	}
+}
+
+func TestConfirmLeavesRemainingInput(t *testing.T) {
+	in, _ := useConfirmIO(t, "y\nnext line\n")
+
+	if !Confirm("Continue?", false) {
+		t.Fatal("Expected confirmation")
+	}
+	if rest := readLine(in); rest != "next line" {
+		t.Errorf("Expected the next line to be unread, got %q", rest)
+	}
+}
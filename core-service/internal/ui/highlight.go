@@ -0,0 +1,162 @@
+package ui
+
+import (
+	"regexp"
+	"slices"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+const colorHighlight = "\033[1;33m"
+
+// highlightMarker surrounds highlighted text when color is disabled.
+const highlightMarker = "**"
+
+// markTag matches opening and closing <mark> tags, with or without
+// attributes, in any case.
+var markTag = regexp.MustCompile(`(?i)</?mark(\s[^>]*)?>`)
+
+// Highlight renders text containing <mark> spans, as sent by the server for
+// matching terms, in bold yellow when color is enabled. Without color the
+// spans are surrounded by ** instead. A width above zero cuts the visible
+// text like Truncate, without counting the tags or splitting an escape
+// sequence.
+func Highlight(text string, color bool, width int) string {
+	s, visible := renderMarks(text, color, -1)
+	if width <= 0 || visible <= width {
+		return s
+	}
+	limit, ellipsis := width, ""
+	if width > 3 {
+		limit, ellipsis = width-3, "..."
+	}
+	s, _ = renderMarks(text, color, limit)
+	return s + ellipsis
+}
+
+// renderMarks renders the <mark> spans of text as Highlight does, writing
+// at most limit visible runes when limit is not negative. It also returns
+// the number of visible runes written.
+func renderMarks(text string, color bool, limit int) (string, int) {
+	var b strings.Builder
+	written, open := 0, false
+	writeText := func(s string) {
+		for _, r := range s {
+			if limit >= 0 && written >= limit {
+				return
+			}
+			b.WriteRune(r)
+			written++
+		}
+	}
+	toggle := func(code string) {
+		if color {
+			b.WriteString(code)
+		} else {
+			writeText(highlightMarker)
+		}
+	}
+
+	pos := 0
+	for _, loc := range markTag.FindAllStringIndex(text, -1) {
+		writeText(text[pos:loc[0]])
+		pos = loc[1]
+		closing := strings.HasPrefix(text[loc[0]:loc[1]], "</")
+		if !closing && !open {
+			toggle(colorHighlight)
+			open = true
+		} else if closing && open {
+			toggle(colorReset)
+			open = false
+		}
+	}
+	writeText(text[pos:])
+	if open && (color || limit < 0) {
+		toggle(colorReset)
+	}
+	return b.String(), written
+}
+
+// queryOperators are the words between search terms that join them rather
+// than being searched for.
+var queryOperators = []string{"AND", "OR"}
+
+// QueryTerms splits the search arguments into the words MarkTerms looks for,
+// leaving out the AND and OR operators.
+func QueryTerms(args ...string) []string {
+	var terms []string
+	for _, arg := range args {
+		for _, word := range strings.FieldsFunc(arg, func(r rune) bool { return !isWordRune(r) }) {
+			if !slices.Contains(queryOperators, word) {
+				terms = append(terms, word)
+			}
+		}
+	}
+	return terms
+}
+
+// isWordRune reports whether r can be part of a word, like \w in the ML
+// service's highlighting but including letters and digits outside ASCII.
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_'
+}
+
+// MarkTerms wraps every whole-word occurrence of terms in text in <mark>
+// tags, ignoring case, so that Highlight can render them. It marks the same
+// words as the ML service does: "or" is not marked inside "for", nor "cat"
+// inside "concatenate". Text without any of the terms is returned unchanged.
+func MarkTerms(text string, terms []string) string {
+	type span struct{ start, end int }
+	var spans []span
+	for _, term := range terms {
+		if term == "" {
+			continue
+		}
+		re := regexp.MustCompile(`(?i)` + regexp.QuoteMeta(term))
+		// Search again one rune after each match so that a match inside a
+		// longer word does not hide a whole word that starts within it.
+		for pos := 0; pos < len(text); {
+			loc := re.FindStringIndex(text[pos:])
+			if loc == nil {
+				break
+			}
+			start, end := pos+loc[0], pos+loc[1]
+			before, _ := utf8.DecodeLastRuneInString(text[:start])
+			after, _ := utf8.DecodeRuneInString(text[end:])
+			// Go's \b only knows ASCII word characters, so the boundaries
+			// are checked here instead.
+			if !isWordRune(before) && !isWordRune(after) {
+				spans = append(spans, span{start, end})
+			}
+			_, size := utf8.DecodeRuneInString(text[start:])
+			pos = start + size
+		}
+	}
+	if len(spans) == 0 {
+		return text
+	}
+
+	slices.SortFunc(spans, func(a, b span) int { return a.start - b.start })
+	merged := spans[:1]
+	for _, s := range spans[1:] {
+		last := &merged[len(merged)-1]
+		if s.start <= last.end {
+			last.end = max(last.end, s.end)
+		} else {
+			merged = append(merged, s)
+		}
+	}
+
+	var b strings.Builder
+	pos := 0
+	for _, s := range merged {
+		b.WriteString(text[pos:s.start])
+		b.WriteString("<mark>")
+		b.WriteString(text[s.start:s.end])
+		b.WriteString("</mark>")
+		pos = s.end
+	}
+	b.WriteString(text[pos:])
+	return b.String()
+}
@@ -0,0 +1,69 @@
+package ui
+
+import "testing"
+
+func TestHighlight(t *testing.T) {
+	const on, off = colorHighlight, colorReset
+
+	tests := []struct {
+		name     string
+		text     string
+		color    bool
+		width    int
+		expected string
+	}{
+		{name: "no marks", text: "plain text", color: true, expected: "plain text"},
+		{name: "one mark", text: "the <mark>cat</mark> sat", color: true, expected: "the " + on + "cat" + off + " sat"},
+		{name: "several marks", text: "<mark>cats</mark> and <mark>dogs</mark>", color: true, expected: on + "cats" + off + " and " + on + "dogs" + off},
+		{name: "upper case and attributes", text: `a <MARK class="hit">cat</MARK>`, color: true, expected: "a " + on + "cat" + off},
+		{name: "nested", text: "<mark><mark>cat</mark></mark>s", color: true, expected: on + "cat" + off + "s"},
+		{name: "unclosed", text: "a <mark>cat", color: true, expected: "a " + on + "cat" + off},
+		{name: "stray closing tag", text: "a</mark> cat", color: true, expected: "a cat"},
+		{name: "other tags kept", text: "<b>bold</b> <marker>", color: true, expected: "<b>bold</b> <marker>"},
+		{name: "no color uses markers", text: "the <mark>cat</mark> sat", expected: "the **cat** sat"},
+		{name: "no color unclosed", text: "a <mark>cat", expected: "a **cat**"},
+		{name: "cut inside mark", text: "a <mark>catalogue</mark> here", color: true, width: 8, expected: "a " + on + "cat" + off + "..."},
+		{name: "cut ignores tag width", text: "<mark>cat</mark> sat", color: true, width: 7, expected: on + "cat" + off + " sat"},
+		{name: "cut counts markers", text: "<mark>cat</mark> sat", width: 11, expected: "**cat** sat"},
+		{name: "cut inside marker span", text: "<mark>catalogue</mark>", width: 8, expected: "**cat..."},
+		{name: "cut multibyte", text: "<mark>ünïcödé</mark> text", color: true, width: 6, expected: on + "ünï" + off + "..."},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Highlight(tt.text, tt.color, tt.width); got != tt.expected {
+				t.Errorf("Expected %q, got %q", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestMarkTerms(t *testing.T) {
+	tests := []struct {
+		name     string
+		text     string
+		query    string
+		expected string
+	}{
+		{name: "several terms", text: "A cat chases a dog", query: "cat dog", expected: "A <mark>cat</mark> chases a <mark>dog</mark>"},
+		{name: "ignores case", text: "GoLang and golang", query: "golang", expected: "<mark>GoLang</mark> and <mark>golang</mark>"},
+		{name: "whole words only", text: "Cats like catnip", query: "cat", expected: "Cats like catnip"},
+		{name: "not inside longer words", text: "work for the door or window", query: "or", expected: "work for the door <mark>or</mark> window"},
+		{name: "word after a partial match", text: "concatenate the cat", query: "cat", expected: "concatenate the <mark>cat</mark>"},
+		{name: "operators dropped", text: "cats OR dogs", query: "cats OR dogs", expected: "<mark>cats</mark> OR <mark>dogs</mark>"},
+		{name: "underscore joins words", text: "snake_case snake", query: "snake", expected: "snake_case <mark>snake</mark>"},
+		{name: "punctuation ignored", text: "what is go?", query: "go?", expected: "what is <mark>go</mark>?"},
+		{name: "multibyte", text: "Über café", query: "über CAFÉ", expected: "<mark>Über</mark> <mark>café</mark>"},
+		{name: "multibyte boundary", text: "naïve", query: "na", expected: "naïve"},
+		{name: "terms absent", text: "plain text", query: "cat dog", expected: "plain text"},
+		{name: "empty query", text: "plain text", query: "", expected: "plain text"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := MarkTerms(tt.text, QueryTerms(tt.query)); got != tt.expected {
+				t.Errorf("Expected %q, got %q", tt.expected, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,152 @@
+package ui
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"golang.org/x/term"
+)
+
+const (
+	colorMagenta = "\033[35m"
+	colorCyan    = "\033[36m"
+	// colorDefault has the same length as the other colors, so colored and
+	// uncolored cells in a column take the same number of bytes and
+	// tabwriter keeps them aligned.
+	colorDefault = "\033[39m"
+)
+
+// DefaultTerminalWidth is used when the output is not a terminal.
+const DefaultTerminalWidth = 100
+
+const (
+	tablePadding     = 2
+	minContentWidth  = 10
+	tableColumnCount = 4
+)
+
+// TableRow is one search result in a table.
+type TableRow struct {
+	Score   float64
+	Type    string
+	ID      string
+	Content string
+}
+
+// TableFormatter writes search results as aligned Score, Type, ID and
+// Content columns.
+type TableFormatter struct {
+	Color bool
+	// Maximum widths of each column; longer values are cut short with "...".
+	// Zero means no limit, except for Content, which then fills the rest of
+	// TerminalWidth.
+	ScoreWidth, TypeWidth, IDWidth, ContentWidth int
+	TerminalWidth                                int
+	// Highlight renders <mark> spans in Content, as Highlight does.
+	Highlight bool
+}
+
+// NewTableFormatter returns a formatter for w, with colors and width taken
+// from the terminal.
+func NewTableFormatter(w io.Writer, noColor bool) *TableFormatter {
+	return &TableFormatter{
+		Color:         ColorEnabled(noColor, w),
+		TerminalWidth: TerminalWidth(w),
+	}
+}
+
+// TerminalWidth returns the width of w in columns, or DefaultTerminalWidth
+// when w is not a terminal.
+func TerminalWidth(w io.Writer) int {
+	if f, ok := w.(*os.File); ok {
+		if width, _, err := term.GetSize(int(f.Fd())); err == nil && width > 0 {
+			return width
+		}
+	}
+	return DefaultTerminalWidth
+}
+
+// Format writes a header line followed by one line per row.
+func (f *TableFormatter) Format(w io.Writer, rows []TableRow) error {
+	cells := make([][tableColumnCount]string, len(rows))
+	widths := [tableColumnCount]int{len("SCORE"), len("TYPE"), len("ID")}
+	for i, row := range rows {
+		cells[i] = [tableColumnCount]string{
+			Truncate(fmt.Sprintf("%.2f", row.Score), f.ScoreWidth),
+			Truncate(row.Type, f.TypeWidth),
+			Truncate(row.ID, f.IDWidth),
+			strings.Join(strings.Fields(row.Content), " "),
+		}
+		for col := range tableColumnCount - 1 {
+			widths[col] = max(widths[col], len([]rune(cells[i][col])))
+		}
+	}
+
+	contentWidth := f.ContentWidth
+	if contentWidth == 0 {
+		used := widths[0] + widths[1] + widths[2] + tablePadding*(tableColumnCount-1)
+		contentWidth = max(f.TerminalWidth-used, minContentWidth)
+	}
+
+	content := Truncate
+	if f.Highlight {
+		content = func(s string, width int) string { return Highlight(s, f.Color, width) }
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, tablePadding, ' ', 0)
+	fmt.Fprintf(tw, "%s\t%s\tID\tCONTENT\n", f.colored("SCORE", colorDefault), f.colored("TYPE", colorDefault))
+	for i, row := range rows {
+		c := cells[i]
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n",
+			f.colored(c[0], scoreColor(row.Score)),
+			f.colored(c[1], typeColor(row.Type)),
+			c[2],
+			content(c[3], contentWidth))
+	}
+	return tw.Flush()
+}
+
+func (f *TableFormatter) colored(s, color string) string {
+	if !f.Color {
+		return s
+	}
+	return color + s + colorReset
+}
+
+func scoreColor(score float64) string {
+	switch {
+	case score >= HighScore:
+		return colorGreen
+	case score >= MidScore:
+		return colorYellow
+	default:
+		return colorRed
+	}
+}
+
+func typeColor(sourceType string) string {
+	switch sourceType {
+	case "text":
+		return colorCyan
+	case "image":
+		return colorMagenta
+	default:
+		return colorDefault
+	}
+}
+
+// Truncate shortens s to at most width runes, ending in "..." when cut.
+// A width of zero leaves s unchanged.
+func Truncate(s string, width int) string {
+	runes := []rune(s)
+	if width <= 0 || len(runes) <= width {
+		return s
+	}
+	if width <= 3 {
+		return string(runes[:width])
+	}
+	return string(runes[:width-3]) + "..."
+}
@@ -0,0 +1,82 @@
+package ui
+
+import (
+	"bytes"
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "rewrite golden files in testdata")
+
+var tableRows = []TableRow{
+	{Score: 0.82, Type: "text", ID: "3f2b9c1e-8a4d-4e6f-9b7a-1c2d3e4f5a6b", Content: "Car travel guides for road trips\nacross the country."},
+	{Score: 0.41, Type: "image", ID: "img-7", Content: "cat_driving.jpg"},
+	{Score: 0.12, Type: "text", ID: "doc-9", Content: "Notes about café opening hours"},
+}
+
+func TestTableFormatter(t *testing.T) {
+	tests := []struct {
+		name      string
+		formatter TableFormatter
+	}{
+		{name: "plain", formatter: TableFormatter{TerminalWidth: 80}},
+		{name: "color", formatter: TableFormatter{Color: true, TerminalWidth: 80}},
+		{name: "narrow", formatter: TableFormatter{TerminalWidth: 40}},
+		{name: "widths", formatter: TableFormatter{IDWidth: 8, ContentWidth: 12, TerminalWidth: 80}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := tt.formatter.Format(&buf, tableRows); err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			golden := filepath.Join("testdata", "table_"+tt.name+".golden")
+			if *update {
+				if err := os.WriteFile(golden, buf.Bytes(), 0o644); err != nil {
+					t.Fatalf("Error writing golden file: %v", err)
+				}
+			}
+			expected, err := os.ReadFile(golden)
+			if err != nil {
+				t.Fatalf("Error reading golden file: %v", err)
+			}
+			if buf.String() != string(expected) {
+				t.Errorf("Output does not match %s.\nExpected:\n%s\nGot:\n%s", golden, expected, buf.String())
+			}
+		})
+	}
+}
+
+func TestTableFormatterNoEscapeCodesWhenDisabled(t *testing.T) {
+	var buf bytes.Buffer
+	NewTableFormatter(&buf, true).Format(&buf, tableRows)
+	if strings.Contains(buf.String(), "\033") {
+		t.Errorf("Expected no escape codes with color disabled, got %q", buf.String())
+	}
+}
+
+func TestTruncate(t *testing.T) {
+	tests := []struct {
+		s        string
+		width    int
+		expected string
+	}{
+		{s: "hello world", width: 0, expected: "hello world"},
+		{s: "hello", width: 5, expected: "hello"},
+		{s: "hello world", width: 8, expected: "hello..."},
+		{s: "café au lait", width: 7, expected: "café..."},
+		{s: "日本語のテキストです", width: 6, expected: "日本語..."},
+		{s: "hello", width: 2, expected: "he"},
+	}
+
+	for _, tt := range tests {
+		if got := Truncate(tt.s, tt.width); got != tt.expected {
+			t.Errorf("Truncate(%q, %d): expected %q, got %q", tt.s, tt.width, tt.expected, got)
+		}
+	}
+}
@@ -1,16 +1,21 @@
-from fastapi import FastAPI, HTTPException, UploadFile, File
+from fastapi import FastAPI, HTTPException, UploadFile, File, Form, Header, Request
 from fastapi.middleware.cors import CORSMiddleware
+from fastapi.responses import JSONResponse, StreamingResponse
 from pydantic import BaseModel, Field, ConfigDict
 from typing import List, Optional, Dict, Any, Union
+from collections import OrderedDict
 from ..embeddings.model import EmbeddingModel
 from ..embeddings.image_model import ImageModel
 from ..storage.qdrant_client import QdrantClient
 import numpy as np
 import uuid
+import re
 import logging
 import io
 import asyncio
 import time
+import gzip
+import json
 
 # Configure logging
 logging.basicConfig(level=logging.INFO)
@@ -18,6 +23,76 @@ logger = logging.getLogger(__name__)
 
 app = FastAPI(title="TidyData ML Service")
 
+
+class GzipRequestMiddleware:
+    """Decompresses request bodies sent with Content-Encoding: gzip."""
+
+    def __init__(self, app):
+        self.app = app
+
+    async def __call__(self, scope, receive, send):
+        if scope["type"] != "http":
+            return await self.app(scope, receive, send)
+        headers = dict(scope["headers"])
+        if headers.get(b"content-encoding", b"").lower() != b"gzip":
+            return await self.app(scope, receive, send)
+
+        body = b""
+        more_body = True
+        while more_body:
+            message = await receive()
+            body += message.get("body", b"")
+            more_body = message.get("more_body", False)
+        try:
+            body = gzip.decompress(body)
+        except (OSError, EOFError):
+            response = JSONResponse({"detail": "invalid gzip request body"}, status_code=400)
+            return await response(scope, receive, send)
+
+        scope = dict(scope)
+        scope["headers"] = [
+            (name, value) for name, value in scope["headers"]
+            if name not in (b"content-encoding", b"content-length")
+        ] + [(b"content-length", str(len(body)).encode())]
+
+        delivered = False
+
+        async def receive_decompressed():
+            nonlocal delivered
+            if delivered:
+                return await receive()
+            delivered = True
+            return {"type": "http.request", "body": body, "more_body": False}
+
+        await self.app(scope, receive_decompressed, send)
+
+
+class RequestIDMiddleware:
+    """Echoes a request's X-Request-ID header in the response and logs it."""
+
+    def __init__(self, app):
+        self.app = app
+
+    async def __call__(self, scope, receive, send):
+        if scope["type"] != "http":
+            return await self.app(scope, receive, send)
+        request_id = dict(scope["headers"]).get(b"x-request-id")
+        if not request_id:
+            return await self.app(scope, receive, send)
+        logger.info("request %s %s id=%s", scope["method"], scope["path"], request_id.decode("latin-1"))
+
+        async def send_with_id(message):
+            if message["type"] == "http.response.start":
+                message = dict(message)
+                message["headers"] = list(message.get("headers", [])) + [(b"x-request-id", request_id)]
+            await send(message)
+
+        await self.app(scope, receive, send_with_id)
+
+
+app.add_middleware(GzipRequestMiddleware)
+app.add_middleware(RequestIDMiddleware)
+
 # Add CORS middleware
 app.add_middleware(
     CORSMiddleware,
@@ -79,8 +154,19 @@ class SimilarityInput(BaseModel):
 
 class DocumentInput(BaseModel):
     text: str = Field(..., min_length=1, description="Document text to store")
+    metadata: Optional[Dict[str, str]] = Field(default=None, description="Optional key/value metadata")
+    tags: Optional[List[str]] = Field(default=None, description="Optional tags")
+    model_config = ConfigDict(json_schema_extra={
+        "example": {
+            "text": "Document text to be stored and indexed",
+            "metadata": {"project": "apollo"}
+        }
+    })
+
+class DocumentsInput(BaseModel):
+    documents: List[DocumentInput] = Field(..., min_items=1, description="Documents to store")
     model_config = ConfigDict(json_schema_extra={
-        "example": {"text": "Document text to be stored and indexed"}
+        "example": {"documents": [{"text": "First document"}, {"text": "Second document"}]}
     })
 
 class SearchInput(BaseModel):
@@ -110,11 +196,18 @@ class ImageInput(BaseModel):
         }
     })
 
+class SearchExplanation(BaseModel):
+    embedding_distance: float
+    token_overlap: float
+    model_name: str
+    matched_terms: List[str] = []
+
 class UnifiedSearchResult(BaseModel):
     id: str
     score: float
     source_type: str  # "text" or "image"
     content: Dict[str, Any]  # Contains either text content or image metadata/data
+    explanation: Optional[SearchExplanation] = None
 
 class UnifiedSearchResponse(BaseModel):
     query: str
@@ -155,53 +248,359 @@ async def calculate_similarity(input_data: SimilarityInput):
     except Exception as e:
         raise HTTPException(status_code=500, detail=str(e))
 
+async def store_document(input_data: DocumentInput) -> str:
+    """Embeds and stores a document, returning its ID."""
+    embedding = text_model.get_embeddings(input_data.text)
+
+    doc_id = str(uuid.uuid4())
+
+    payload = {"added_at": time.time()}
+    if input_data.metadata:
+        payload["metadata"] = input_data.metadata
+    if input_data.tags:
+        payload["tags"] = input_data.tags
+
+    success = await qdrant.add_document(
+        document_id=doc_id,
+        embedding=embedding,
+        text=input_data.text,
+        payload=payload
+    )
+
+    if not success:
+        raise HTTPException(status_code=500, detail="Failed to store document")
+    return doc_id
+
+# Document IDs by the Idempotency-Key they were added with, so that a retried
+# request returns the stored document instead of adding it again. Only the
+# most recent keys are kept.
+idempotent_documents: "OrderedDict[str, str]" = OrderedDict()
+MAX_IDEMPOTENCY_KEYS = 10000
+
 @app.post("/documents", response_model=dict)
-async def add_document(input_data: DocumentInput):
-    """Add a document to the vector store."""
+async def add_document(input_data: DocumentInput, idempotency_key: Optional[str] = Header(None)):
+    """Add a document to the vector store. A request with the Idempotency-Key
+    of an earlier one returns that document without storing it again."""
+    if idempotency_key and idempotency_key in idempotent_documents:
+        return {
+            "document_id": idempotent_documents[idempotency_key],
+            "status": "stored"
+        }
+    try:
+        doc_id = await store_document(input_data)
+        if idempotency_key:
+            idempotent_documents[idempotency_key] = doc_id
+            while len(idempotent_documents) > MAX_IDEMPOTENCY_KEYS:
+                idempotent_documents.popitem(last=False)
+        return {
+            "document_id": doc_id,
+            "status": "stored"
+        }
+    except Exception as e:
+        logger.error(f"Error adding document: {str(e)}", exc_info=True)
+        raise HTTPException(status_code=500, detail=str(e))
+
+@app.post("/documents/batch", response_model=dict)
+async def add_documents(input_data: DocumentsInput):
+    """Add several documents, embedding them in one batch. The IDs are
+    returned in the order of the documents."""
+    try:
+        embeddings = text_model.get_embeddings([document.text for document in input_data.documents])
+
+        doc_ids = []
+        for document, embedding in zip(input_data.documents, embeddings):
+            doc_id = str(uuid.uuid4())
+            payload = {"added_at": time.time()}
+            if document.metadata:
+                payload["metadata"] = document.metadata
+            if document.tags:
+                payload["tags"] = document.tags
+
+            success = await qdrant.add_document(
+                document_id=doc_id,
+                embedding=embedding,
+                text=document.text,
+                payload=payload
+            )
+            if not success:
+                raise HTTPException(status_code=500, detail=f"Failed to store document {len(doc_ids) + 1}")
+            doc_ids.append(doc_id)
+
+        return {
+            "document_ids": doc_ids,
+            "status": "stored"
+        }
+    except HTTPException:
+        raise
+    except Exception as e:
+        logger.error(f"Error adding documents: {str(e)}", exc_info=True)
+        raise HTTPException(status_code=500, detail=str(e))
+
+@app.get("/documents/duplicates", response_model=dict)
+async def find_duplicate_documents(threshold: float = 0.95):
+    """Group documents whose embeddings are at least threshold similar. A
+    document joins a group when it is that similar to any member, and a
+    group's score is the highest similarity within it."""
+    if not 0 < threshold <= 1:
+        raise HTTPException(status_code=400, detail="threshold must be between 0 and 1")
+    try:
+        points = []
+        offset = None
+        while True:
+            page, offset = await qdrant.scroll_points("documents", limit=256, offset=offset, with_vector=True)
+            points.extend(point for point in page if point.get("vector") is not None)
+            if offset is None:
+                break
+        if len(points) < 2:
+            return {"groups": []}
+
+        # Vectors are stored normalized, so the dot product is the cosine.
+        vectors = np.array([point["vector"] for point in points])
+        similarities = vectors @ vectors.T
+
+        parent = list(range(len(points)))
+        def find(i):
+            while parent[i] != i:
+                parent[i] = parent[parent[i]]
+                i = parent[i]
+            return i
+
+        rows, cols = np.where(np.triu(similarities, k=1) >= threshold)
+        for i, j in zip(rows, cols):
+            parent[find(i)] = find(j)
+
+        members: Dict[int, List[int]] = {}
+        for i in range(len(points)):
+            members.setdefault(find(i), []).append(i)
+
+        groups = []
+        for indexes in members.values():
+            if len(indexes) < 2:
+                continue
+            block = similarities[np.ix_(indexes, indexes)]
+            np.fill_diagonal(block, -1)
+            groups.append({
+                "score": float(block.max()),
+                "documents": [
+                    {"id": str(points[i]["id"]), "text": points[i]["payload"].get("text", "")}
+                    for i in indexes
+                ]
+            })
+        groups.sort(key=lambda group: group["score"], reverse=True)
+        return {"groups": groups}
+    except Exception as e:
+        logger.error(f"Error finding duplicate documents: {str(e)}", exc_info=True)
+        raise HTTPException(status_code=500, detail=str(e))
+
+@app.put("/documents/{document_id}", response_model=dict)
+async def update_document(document_id: str, input_data: DocumentInput):
+    """Replace the text of a document, keeping its ID. Metadata and tags are
+    kept unless the request sets them."""
+    point = await qdrant.get_point("documents", document_id)
+    if point is None:
+        raise HTTPException(status_code=404, detail="document not found")
     try:
         embedding = text_model.get_embeddings(input_data.text)
-        
-        doc_id = str(uuid.uuid4())
-        
+
+        payload = dict(point.get("payload") or {})
+        payload.pop("text", None)
+        payload["updated_at"] = time.time()
+        if input_data.metadata is not None:
+            payload["metadata"] = input_data.metadata
+        if input_data.tags is not None:
+            payload["tags"] = input_data.tags
+
         success = await qdrant.add_document(
-            document_id=doc_id,
+            document_id=document_id,
             embedding=embedding,
-            text=input_data.text
+            text=input_data.text,
+            payload=payload
         )
-        
         if not success:
             raise HTTPException(status_code=500, detail="Failed to store document")
-            
         return {
-            "document_id": doc_id,
-            "status": "stored"
+            "document_id": document_id,
+            "status": "updated"
         }
+    except HTTPException:
+        raise
     except Exception as e:
-        logger.error(f"Error adding document: {str(e)}", exc_info=True)
+        logger.error(f"Error updating document: {str(e)}", exc_info=True)
+        raise HTTPException(status_code=500, detail=str(e))
+
+@app.delete("/documents/{document_id}", status_code=204)
+async def delete_document(document_id: str):
+    """Delete a document."""
+    if await qdrant.get_point("documents", document_id) is None:
+        raise HTTPException(status_code=404, detail="document not found")
+    try:
+        await qdrant.delete_points("documents", [document_id])
+    except Exception as e:
+        logger.error(f"Error deleting document: {str(e)}", exc_info=True)
         raise HTTPException(status_code=500, detail=str(e))
 
+async def page_points(collection_name: str, offset: int, limit: int) -> tuple:
+    """Returns the points of a collection from offset up to limit, in storage
+    order, and the number of points in the collection."""
+    if offset < 0 or limit < 1:
+        raise HTTPException(status_code=400, detail="offset must not be negative and limit must be positive")
+    total = await qdrant.count_points(collection_name)
+    points = []
+    next_offset = None
+    while len(points) < offset + limit:
+        page, next_offset = await qdrant.scroll_points(collection_name, limit=256, offset=next_offset)
+        points.extend(page)
+        if next_offset is None:
+            break
+    return points[offset:offset + limit], total
+
+@app.get("/documents", response_model=dict)
+async def list_documents(offset: int = 0, limit: int = 100):
+    """List documents in storage order, skipping the first offset."""
+    try:
+        points, total = await page_points("documents", offset, limit)
+        return {
+            "documents": [
+                {"id": str(point["id"]), "text": point["payload"].get("text", "")}
+                for point in points
+            ],
+            "total": total
+        }
+    except HTTPException:
+        raise
+    except Exception as e:
+        logger.error(f"Error listing documents: {str(e)}", exc_info=True)
+        raise HTTPException(status_code=500, detail=str(e))
+
+# Background jobs by ID. Jobs are kept in memory until the service restarts.
+jobs: Dict[str, Dict[str, Any]] = {}
+job_tasks: Dict[str, asyncio.Task] = {}
+JOB_STATES = ("pending", "running", "done", "failed", "cancelled")
+
+async def run_document_job(job: Dict[str, Any], input_data: DocumentInput):
+    """Stores a document queued with /documents/async, recording the outcome in job."""
+    job["state"] = "running"
+    try:
+        job["document_id"] = await store_document(input_data)
+        job["progress"] = 1.0
+        job["state"] = "done"
+    except Exception as e:
+        error = e.detail if isinstance(e, HTTPException) else str(e)
+        logger.error(f"Error in job {job['job_id']}: {error}", exc_info=True)
+        job["state"] = "failed"
+        job["error"] = error
+    finally:
+        job_tasks.pop(job["job_id"], None)
+
+@app.post("/documents/async", status_code=202)
+async def add_document_async(input_data: DocumentInput):
+    """Queues a document to be stored in the background and returns its job."""
+    job = {"job_id": str(uuid.uuid4()), "state": "pending", "progress": 0.0}
+    jobs[job["job_id"]] = job
+    job_tasks[job["job_id"]] = asyncio.create_task(run_document_job(job, input_data))
+    return job
+
+@app.get("/jobs")
+async def list_jobs(state: str = "all"):
+    """Lists background jobs, optionally only those in one state."""
+    if state != "all" and state not in JOB_STATES:
+        raise HTTPException(status_code=400, detail=f"state must be all or one of {', '.join(JOB_STATES)}")
+    matching = [job for job in jobs.values() if state == "all" or job["state"] == state]
+    return {"jobs": matching, "total": len(matching)}
+
+@app.get("/jobs/{job_id}")
+async def get_job(job_id: str):
+    """Reports the state of a background job."""
+    if job_id not in jobs:
+        raise HTTPException(status_code=404, detail="job not found")
+    return jobs[job_id]
+
+@app.delete("/jobs/{job_id}", status_code=204)
+async def cancel_job(job_id: str):
+    """Cancels a background job that has not finished."""
+    if job_id not in jobs:
+        raise HTTPException(status_code=404, detail="job not found")
+    job = jobs[job_id]
+    if job["state"] not in ("pending", "running"):
+        raise HTTPException(status_code=409, detail=f"job is already {job['state']}")
+    task = job_tasks.pop(job_id, None)
+    if task:
+        task.cancel()
+    job["state"] = "cancelled"
+
+# Documents stored before added_at existed sort as the oldest.
+SORT_KEYS = {
+    "score": lambda result: result["score"],
+    "date": lambda result: result["payload"].get("added_at", 0),
+    "type": lambda result: result["source_type"],
+}
+
+def token_overlap(query: str, text: str) -> float:
+    """Fraction of query words that also appear in text."""
+    query_tokens = set(re.findall(r"\w+", query.lower()))
+    if not query_tokens:
+        return 0.0
+    text_tokens = set(re.findall(r"\w+", (text or "").lower()))
+    return len(query_tokens & text_tokens) / len(query_tokens)
+
+def matched_terms(query: str, text: str) -> List[str]:
+    """Distinct query words that also appear in text, in query order."""
+    text_tokens = set(re.findall(r"\w+", (text or "").lower()))
+    matched = []
+    for token in re.findall(r"\w+", query.lower()):
+        if token in text_tokens and token not in matched:
+            matched.append(token)
+    return matched
+
+def highlight_terms(query: str, text: str) -> str:
+    """Wraps words of text that appear in query in <mark> tags."""
+    query_tokens = sorted(set(re.findall(r"\w+", query.lower())), key=len, reverse=True)
+    if not query_tokens:
+        return text
+    pattern = re.compile(r"\b(" + "|".join(re.escape(t) for t in query_tokens) + r")\b", re.IGNORECASE)
+    return pattern.sub(r"<mark>\1</mark>", text or "")
+
 @app.get("/search", response_model=UnifiedSearchResponse)
-async def unified_search(query: str, limit: int = 10, score_threshold: float = 0.5):
-    """Search across both text and images using a single query."""
+async def unified_search(query: str, limit: int = 10, score_threshold: float = 0.5, explain: bool = False,
+                         sort: str = "score", order: str = "desc", source_type: Optional[str] = None,
+                         stream: bool = False, highlight: bool = False, count_only: bool = False):
+    """Search across both text and images using a single query, or only one
+    of them when source_type is "text" or "image". With stream=true results
+    are sent as newline-delimited JSON, one per line. With highlight=true text
+    results also carry highlighted_text, with matching words in <mark> tags.
+    With count_only=true only {"count": N} is returned, counting every match
+    regardless of limit."""
+    if sort not in SORT_KEYS:
+        raise HTTPException(status_code=400, detail=f"sort must be one of {', '.join(SORT_KEYS)}")
+    if order not in ("asc", "desc"):
+        raise HTTPException(status_code=400, detail="order must be asc or desc")
+    if source_type not in (None, "text", "image"):
+        raise HTTPException(status_code=400, detail="source_type must be text or image")
     try:
         start_time = time.perf_counter()
         
-        text_embedding = text_model.get_embeddings(query)
-        image_embedding = image_model.get_text_embedding(query)
+        embeddings = {}
+        if source_type in (None, "text"):
+            embeddings["documents"] = text_model.get_embeddings(query)
+        if source_type in (None, "image"):
+            embeddings["images"] = image_model.get_text_embedding(query)
+
+        if count_only:
+            limit = max(1, sum([await qdrant.count_points(name) for name in embeddings]))
         
         results = await qdrant.search_multiple_collections(
-            embeddings={
-                "documents": text_embedding,
-                "images": image_embedding
-            },
+            embeddings=embeddings,
             limit=limit,
             score_threshold=score_threshold
         )
+        if count_only:
+            return JSONResponse({"count": len(results)})
         
+        results = sorted(results, key=SORT_KEYS[sort], reverse=(order == "desc"))
+
         time_taken = time.perf_counter() - start_time
         
-        processed_results = []
-        for result in results:
+        def process(result):
             processed_result = {
                 "id": result["id"],
                 "score": result["score"],
@@ -212,13 +611,37 @@ async def unified_search(query: str, limit: int = 10, score_threshold: float = 0
                 processed_result["content"] = {
                     "text": result["payload"]["text"]
                 }
+                if result["payload"].get("metadata"):
+                    processed_result["content"]["document_metadata"] = result["payload"]["metadata"]
+                if highlight:
+                    processed_result["content"]["highlighted_text"] = highlight_terms(query, result["payload"]["text"])
+                compared_text = result["payload"]["text"]
+                model_name = text_model.model_name
             else:  # image
                 processed_result["content"] = {
                     "metadata": result["payload"]["metadata"],
                     "image_data": result["payload"]["image_data"]
                 }
+                compared_text = (result["payload"]["metadata"] or {}).get("description")
+                model_name = image_model.model_name
+
+            if explain:
+                processed_result["explanation"] = {
+                    "embedding_distance": 1.0 - result["score"],
+                    "token_overlap": token_overlap(query, compared_text),
+                    "model_name": model_name,
+                    "matched_terms": matched_terms(query, compared_text)
+                }
             
-            processed_results.append(processed_result)
+            return processed_result
+
+        if stream:
+            return StreamingResponse(
+                (json.dumps(process(result)) + "\n" for result in results),
+                media_type="application/x-ndjson"
+            )
+
+        processed_results = [process(result) for result in results]
         
         return {
             "query": query,
@@ -229,6 +652,54 @@ async def unified_search(query: str, limit: int = 10, score_threshold: float = 0
         logger.error(f"Error in unified search: {str(e)}", exc_info=True)
         raise HTTPException(status_code=500, detail=str(e))
 
+reindex_state = {"status": "idle", "total": 0, "processed": 0}
+
+async def run_reindex():
+    """Recomputes the embedding of every document and image in place."""
+    try:
+        for collection_name in ("documents", "images"):
+            offset = None
+            while True:
+                points, offset = await qdrant.scroll_points(collection_name, offset=offset)
+                for point in points:
+                    payload = point["payload"]
+                    if collection_name == "documents":
+                        embedding = text_model.get_embeddings(payload["text"])
+                    else:
+                        embedding = image_model.get_image_embedding(
+                            image_model.decode_image_base64(payload["image_data"]))
+                    if not await qdrant.add_document(document_id=point["id"], embedding=embedding,
+                                                     collection_name=collection_name, payload=payload):
+                        raise RuntimeError(f"failed to store {point['id']}")
+                    reindex_state["processed"] += 1
+                if offset is None:
+                    break
+        reindex_state["status"] = "completed"
+    except Exception as e:
+        logger.error(f"Error reindexing: {str(e)}", exc_info=True)
+        reindex_state["status"] = "failed"
+        reindex_state["error"] = str(e)
+
+@app.post("/reindex", status_code=202)
+async def start_reindex():
+    """Starts recomputing all embeddings in the background."""
+    if reindex_state["status"] == "running":
+        raise HTTPException(status_code=409, detail="a reindex is already running")
+    try:
+        total = await qdrant.count_points("documents") + await qdrant.count_points("images")
+    except Exception as e:
+        logger.error(f"Error counting points: {str(e)}", exc_info=True)
+        raise HTTPException(status_code=500, detail=str(e))
+    reindex_state.clear()
+    reindex_state.update({"status": "running", "total": total, "processed": 0})
+    asyncio.create_task(run_reindex())
+    return reindex_state
+
+@app.get("/reindex/status")
+async def reindex_status():
+    """Reports the progress of the last reindex."""
+    return reindex_state
+
 @app.get("/health")
 async def health_check():
     """Health check endpoint."""
@@ -243,8 +714,12 @@ async def health_check():
     }
 
 @app.post("/images", response_model=dict)
-async def add_image(image: UploadFile = File(...), description: Optional[str] = None):
-    """Add an image to the vector store."""
+async def add_image(image: UploadFile = File(...), description: Optional[str] = Form(None),
+                    content_type: Optional[str] = Form(None),
+                    tags: Optional[List[str]] = Form(None, alias="tags[]"),
+                    legacy_tags: Optional[List[str]] = Form(None, alias="tags")):
+    """Add an image to the vector store. Tags are sent as repeated tags[]
+    fields; plain tags fields from older clients are accepted too."""
     try:
         image_data = await image.read()
         
@@ -254,9 +729,12 @@ async def add_image(image: UploadFile = File(...), description: Optional[str] =
         
         metadata = {
             "filename": image.filename,
-            "content_type": image.content_type,
+            "content_type": content_type or image.content_type,
             "description": description
         }
+        tags = (tags or []) + (legacy_tags or [])
+        if tags:
+            metadata["tags"] = tags
         
         image_base64 = image_model.encode_image_base64(image_data)
         
@@ -266,7 +744,8 @@ async def add_image(image: UploadFile = File(...), description: Optional[str] =
             embedding=embedding,
             payload={
                 "image_data": image_base64,
-                "metadata": metadata
+                "metadata": metadata,
+                "added_at": time.time()
             }
         )
         
@@ -282,6 +761,29 @@ async def add_image(image: UploadFile = File(...), description: Optional[str] =
         logger.error(f"Error adding image: {str(e)}", exc_info=True)
         raise HTTPException(status_code=500, detail=str(e))
 
+@app.get("/images", response_model=dict)
+async def list_images(offset: int = 0, limit: int = 100):
+    """List images in storage order, skipping the first offset. Image data is
+    left out; fetch an image by ID for it."""
+    try:
+        points, total = await page_points("images", offset, limit)
+        return {
+            "images": [
+                {
+                    "id": str(point["id"]),
+                    "metadata": point["payload"]["metadata"],
+                    "added_at": point["payload"].get("added_at")
+                }
+                for point in points
+            ],
+            "total": total
+        }
+    except HTTPException:
+        raise
+    except Exception as e:
+        logger.error(f"Error listing images: {str(e)}", exc_info=True)
+        raise HTTPException(status_code=500, detail=str(e))
+
 @app.post("/images/similar", response_model=dict)
 async def find_similar_images(image: UploadFile = File(...), limit: int = 10, score_threshold: float = 0.5):
     """Find similar images to the uploaded image."""
@@ -312,4 +814,190 @@ async def find_similar_images(image: UploadFile = File(...), limit: int = 10, sc
         }
     except Exception as e:
         logger.error(f"Error finding similar images: {str(e)}", exc_info=True)
-        raise HTTPException(status_code=500, detail=str(e)) 
\ No newline at end of file
+        raise HTTPException(status_code=500, detail=str(e)) 
+
+@app.get("/images/search", response_model=dict)
+async def search_images(query: str, limit: int = 10, score_threshold: float = 0.5):
+    """Find images matching a text description, without text documents."""
+    try:
+        query_embedding = image_model.get_text_embedding(query)
+
+        results = await qdrant.search_documents(
+            collection_name="images",
+            query_embedding=query_embedding,
+            limit=limit,
+            score_threshold=score_threshold
+        )
+
+        processed_results = []
+        for result in results:
+            processed_results.append({
+                "id": result["id"],
+                "score": result["score"],
+                "metadata": result["payload"]["metadata"],
+                "image_data": result["payload"]["image_data"]
+            })
+
+        return {
+            "query": query,
+            "results": processed_results
+        }
+    except Exception as e:
+        logger.error(f"Error searching images: {str(e)}", exc_info=True)
+        raise HTTPException(status_code=500, detail=str(e))
+
+@app.post("/images/search/text", response_model=UnifiedSearchResponse)
+async def search_text_by_image(image: UploadFile = File(...), limit: int = Form(10),
+                               score_threshold: float = Form(0.5)):
+    """Find text documents related to the uploaded image. Stored documents are
+    embedded with MPNet, which cannot be compared with an image, so each one
+    is embedded again with CLIP and ranked against the image."""
+    start_time = time.perf_counter()
+    try:
+        image_data = await image.read()
+        query_embedding = image_model.get_image_embedding(image_data).flatten()
+
+        results = []
+        offset = None
+        while True:
+            points, offset = await qdrant.scroll_points("documents", limit=256, offset=offset)
+            points = [point for point in points if point["payload"].get("text")]
+            if points:
+                text_embeddings = image_model.get_text_embedding([point["payload"]["text"] for point in points])
+                for point, score in zip(points, text_embeddings @ query_embedding):
+                    if score < score_threshold:
+                        continue
+                    content = {"text": point["payload"]["text"]}
+                    if point["payload"].get("metadata"):
+                        content["document_metadata"] = point["payload"]["metadata"]
+                    results.append(UnifiedSearchResult(
+                        id=str(point["id"]),
+                        score=float(score),
+                        source_type="text",
+                        content=content
+                    ))
+            if offset is None:
+                break
+
+        results.sort(key=lambda result: result.score, reverse=True)
+        return UnifiedSearchResponse(
+            query=image.filename or "image",
+            results=results[:limit],
+            time_taken=time.perf_counter() - start_time
+        )
+    except Exception as e:
+        logger.error(f"Error searching text by image: {str(e)}", exc_info=True)
+        raise HTTPException(status_code=500, detail=str(e))
+
+@app.get("/images/{image_id}", response_model=dict)
+async def get_image(image_id: str):
+    """Return a stored image with its metadata."""
+    point = await qdrant.get_point("images", image_id)
+    if point is None:
+        raise HTTPException(status_code=404, detail="image not found")
+    return {
+        "image_id": str(point["id"]),
+        "status": "stored",
+        "metadata": point["payload"]["metadata"],
+        "image_data": point["payload"]["image_data"]
+    }
+
+@app.delete("/images/{image_id}", status_code=204)
+async def delete_image(image_id: str):
+    """Delete an image."""
+    if await qdrant.get_point("images", image_id) is None:
+        raise HTTPException(status_code=404, detail="image not found")
+    try:
+        await qdrant.delete_points("images", [image_id])
+    except Exception as e:
+        logger.error(f"Error deleting image: {str(e)}", exc_info=True)
+        raise HTTPException(status_code=500, detail=str(e))
+
+# Records sent to /import are stored in batches of this many points.
+IMPORT_BATCH_SIZE = 256
+
+# The record type of the points in each collection in /export and /import.
+RECORD_TYPES = {"documents": "document", "images": "image"}
+
+@app.get("/export")
+async def export_data():
+    """Stream every document and image as NDJSON, one record per line: its
+    type, ID and vector followed by its payload fields. Vectors are included
+    so that /import does not have to embed the records again."""
+    async def records():
+        for collection_name in qdrant.collections:
+            offset = None
+            while True:
+                points, offset = await qdrant.scroll_points(collection_name, limit=256, offset=offset, with_vector=True)
+                for point in points:
+                    yield json.dumps({
+                        "type": RECORD_TYPES[collection_name],
+                        "id": point["id"],
+                        "vector": point["vector"],
+                        **point["payload"]
+                    }) + "\n"
+                if offset is None:
+                    break
+
+    return StreamingResponse(records(), media_type="application/x-ndjson")
+
+@app.post("/import", response_model=dict)
+async def import_data(request: Request):
+    """Store NDJSON records in the format produced by /export. Records with
+    the ID of an existing document or image replace it."""
+    collections = {record_type: name for name, record_type in RECORD_TYPES.items()}
+    batches: Dict[str, List[Dict[str, Any]]] = {name: [] for name in RECORD_TYPES}
+    imported = 0
+
+    async def flush(collection_name):
+        nonlocal imported
+        if batches[collection_name]:
+            await qdrant.upsert_points(collection_name, batches[collection_name])
+            imported += len(batches[collection_name])
+            batches[collection_name] = []
+
+    async def lines():
+        pending = b""
+        async for chunk in request.stream():
+            pending += chunk
+            *complete, pending = pending.split(b"\n")
+            for line in complete:
+                yield line
+        yield pending
+
+    line_number = 0
+    try:
+        async for line in lines():
+            line_number += 1
+            if not line.strip():
+                continue
+            try:
+                record = json.loads(line)
+                record_type = record.pop("type")
+                point = {"id": record.pop("id"), "vector": record.pop("vector"), "payload": record}
+            except (ValueError, KeyError, TypeError, AttributeError) as e:
+                raise HTTPException(status_code=400, detail=f"line {line_number}: invalid record: {e}")
+            if record_type not in collections:
+                raise HTTPException(status_code=400, detail=f"line {line_number}: unknown record type {record_type}")
+            collection_name = collections[record_type]
+            batches[collection_name].append(point)
+            if len(batches[collection_name]) >= IMPORT_BATCH_SIZE:
+                await flush(collection_name)
+        for collection_name in batches:
+            await flush(collection_name)
+        return {"imported": imported}
+    except HTTPException:
+        raise
+    except Exception as e:
+        logger.error(f"Error importing data: {str(e)}", exc_info=True)
+        raise HTTPException(status_code=500, detail=str(e))
+
+@app.delete("/data", status_code=204)
+async def clear_data():
+    """Delete every document and image."""
+    try:
+        await qdrant.clear_collections()
+        idempotent_documents.clear()
+    except Exception as e:
+        logger.error(f"Error clearing data: {str(e)}", exc_info=True)
+        raise HTTPException(status_code=500, detail=str(e))
@@ -18,6 +18,7 @@ class ImageModel:
             model_name: The name of the CLIP model to use
         """
         logger.info(f"Loading CLIP model {model_name}")
+        self.model_name = model_name
         
         self.processor = CLIPProcessor.from_pretrained(model_name)
         self.model = CLIPModel.from_pretrained(model_name)
@@ -94,7 +95,7 @@ class ImageModel:
         """Generate embedding for text query.
         
         Args:
-            text: Text to embed
+            text: Text to embed, or a list of texts to embed as one batch
             benchmark: If True, return timing information
             
         Returns:
@@ -103,7 +104,8 @@ class ImageModel:
         """
         start_time = time.time() if benchmark else None
         
-        inputs = self.processor(text=text, return_tensors="pt", padding=True).to(self.device)
+        # CLIP reads at most 77 tokens; longer text is cut rather than rejected.
+        inputs = self.processor(text=text, return_tensors="pt", padding=True, truncation=True).to(self.device)
         
         with torch.no_grad():
             text_features = self.model.get_text_features(**inputs)
@@ -16,6 +16,7 @@ class EmbeddingModel:
                 Default: all-mpnet-base-v2
         """
         logger.info(f"Loading model {model_name}")
+        self.model_name = model_name
         
         # Load model directly
         self.model = SentenceTransformer(model_name)
@@ -244,4 +244,91 @@ class QdrantClient:
             return combined_results
         except Exception as e:
             logger.error(f"Error searching multiple collections: {str(e)}", exc_info=True)
-            return [] 
\ No newline at end of file
+            return [] 
+
+    async def count_points(self, collection_name: str) -> int:
+        """Return the exact number of points in a collection."""
+        await self.ensure_collections()
+        async with httpx.AsyncClient() as client:
+            response = await client.post(
+                f"{self.base_url}/collections/{collection_name}/points/count",
+                json={"exact": True}
+            )
+        response.raise_for_status()
+        return response.json()["result"]["count"]
+
+    async def scroll_points(self,
+                            collection_name: str,
+                            limit: int = 100,
+                            offset: Optional[Any] = None,
+                            with_vector: bool = False) -> tuple:
+        """Fetch a page of points with their payloads, and their vectors if
+        with_vector is set.
+
+        Returns:
+            The points and the offset of the next page, or None after the last.
+        """
+        await self.ensure_collections()
+        scroll_data = {"limit": limit, "with_payload": True, "with_vector": with_vector}
+        if offset is not None:
+            scroll_data["offset"] = offset
+        async with httpx.AsyncClient() as client:
+            response = await client.post(
+                f"{self.base_url}/collections/{collection_name}/points/scroll",
+                json=scroll_data
+            )
+        response.raise_for_status()
+        result = response.json()["result"]
+        return result["points"], result.get("next_page_offset")
+
+    async def get_point(self, collection_name: str, point_id: str,
+                        with_vector: bool = False) -> Optional[Dict[str, Any]]:
+        """Fetch a single point with its payload.
+
+        Returns:
+            The point, or None if the collection has no point with that ID.
+        """
+        await self.ensure_collections()
+        async with httpx.AsyncClient() as client:
+            response = await client.post(
+                f"{self.base_url}/collections/{collection_name}/points",
+                json={"ids": [point_id], "with_payload": True, "with_vector": with_vector}
+            )
+        # Qdrant rejects IDs that are not UUIDs or integers with a 400.
+        if response.status_code in (400, 404):
+            return None
+        response.raise_for_status()
+        points = response.json()["result"]
+        return points[0] if points else None
+
+    async def delete_points(self, collection_name: str, point_ids: List[str]) -> None:
+        """Delete points by ID. IDs that do not exist are ignored."""
+        await self.ensure_collections()
+        async with httpx.AsyncClient() as client:
+            response = await client.post(
+                f"{self.base_url}/collections/{collection_name}/points/delete",
+                params={"wait": "true"},
+                json={"points": point_ids}
+            )
+        response.raise_for_status()
+
+    async def upsert_points(self, collection_name: str, points: List[Dict[str, Any]]) -> None:
+        """Store points that already have an id, vector and payload."""
+        await self.ensure_collections()
+        async with httpx.AsyncClient() as client:
+            response = await client.put(
+                f"{self.base_url}/collections/{collection_name}/points",
+                params={"wait": "true"},
+                json={"points": points}
+            )
+        response.raise_for_status()
+
+    async def clear_collections(self) -> None:
+        """Delete every point by dropping and recreating the collections."""
+        async with httpx.AsyncClient() as client:
+            for name in self.collections:
+                response = await client.delete(f"{self.base_url}/collections/{name}")
+                if response.status_code != 404:
+                    response.raise_for_status()
+        self._collections_initialized = False
+        await self.ensure_collections()